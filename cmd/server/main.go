@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"todo-app/internal/buildinfo"
 	"todo-app/internal/handler"
+	"todo-app/internal/logging"
+	"todo-app/internal/metrics"
+	"todo-app/internal/model"
 	"todo-app/internal/repository"
+	"todo-app/internal/server"
 	"todo-app/internal/service"
+	"todo-app/internal/webhook"
 )
 
 func main() {
@@ -16,7 +26,19 @@ func main() {
 	env := getEnv("ENV", "development")
 	port := getEnv("PORT", "8080")
 
-	// Set database path based on environment
+	// Logs are JSON lines for the log pipeline in production, text otherwise
+	defaultLogFormat := logging.FormatText
+	if env == "production" {
+		defaultLogFormat = logging.FormatJSON
+	}
+	logger, err := logging.New(os.Stderr, getEnv("LOG_FORMAT", defaultLogFormat), getEnv("LOG_LEVEL", "info"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid logging configuration: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	// Set the default SQLite database path based on environment
 	var defaultDBPath string
 	switch env {
 	case "production":
@@ -27,37 +49,215 @@ func main() {
 		defaultDBPath = "todos_dev.db"
 	}
 
-	dbPath := getEnv("DB_PATH", defaultDBPath)
+	storage, err := loadStorageConfig(defaultDBPath)
+	if err != nil {
+		fatal("Invalid storage configuration", err)
+	}
+
+	// The subcommands log what they did through the same logger as the server
+	ctx := logging.WithLogger(context.Background(), logger)
+
+	// `server migrate status|up|down [N]` manages the schema, and `server
+	// migrate assign-owner <username>` gives the data made before accounts
+	// existed an owner; both exit
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, storage, os.Args[2:]); err != nil {
+			fatal("Migration failed", err)
+		}
+		return
+	}
+
+	// `server search rebuild` refills the full-text search index and exits
+	if len(os.Args) > 1 && os.Args[1] == "search" {
+		if err := runSearch(ctx, storage, os.Args[2:]); err != nil {
+			fatal("Search index command failed", err)
+		}
+		return
+	}
+
+	// `server workspace create|list|settings` manages workspaces and exits
+	if len(os.Args) > 1 && os.Args[1] == "workspace" {
+		if err := runWorkspace(ctx, storage, os.Args[2:]); err != nil {
+			fatal("Workspace command failed", err)
+		}
+		return
+	}
+
+	if err := serve(env, port, storage, logger); err != nil {
+		fatal("Server failed", err)
+	}
+}
+
+// fatal logs err as the reason the program stops, and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// serve runs the API and frontend until SIGINT or SIGTERM, then drains the
+// requests in flight and closes the repository
+func serve(env, port string, storage storageConfig, logger *slog.Logger) error {
+	// The /api/test routes are only served with ENV=test, behind a shared secret
+	testSecret := os.Getenv("TEST_SECRET")
+	if env == "test" && testSecret == "" {
+		return fmt.Errorf("TEST_SECRET is required with ENV=test: the /api/test routes check it in the X-Test-Secret header")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	// Create dependencies
-	repo, err := repository.NewSQLiteTodoRepository(dbPath)
+	store, err := openRepository(storage)
 	if err != nil {
-		log.Fatalf("Failed to create SQLite repository: %v", err)
+		return fmt.Errorf("open %s repository: %w", storage.driver, err)
 	}
-	defer repo.Close() // ← Program bitince database'i kapat
+	defer store.Close() // ← Program bitince database'i kapat
+
+	// The services time every repository call, in every workspace, for
+	// /metrics, and give up on those taking longer than DB_QUERY_TIMEOUT
+	m := metrics.New()
+	repo := repository.WithCallOptions(store, repository.CallOptions{Timeout: storage.queryTimeout, Observe: m.ObserveQuery})
+	workspaces, err := openWorkspaces(storage, repo)
+	if err != nil {
+		return fmt.Errorf("open workspaces: %w", err)
+	}
+	defer workspaces.Close()
+	if pool, ok := store.(repository.PoolReporter); ok {
+		m.WatchPool(pool.PoolStats)
+	}
+	workspaceSvc := service.NewWorkspaceService(repo, workspaces)
+	m.WatchTodos(workspaceSvc.CountTodos)
 
 	svc := service.NewTodoService(repo)
 	h := handler.NewTodoHandler(svc)
+	tags := handler.NewTagHandler(svc)
+	listSvc := service.NewListService(repo)
+	lists := handler.NewListHandler(listSvc, svc)
+	events := handler.NewEventHandler(svc)
+	ws := handler.NewWebSocketHandler(listSvc, svc)
+	webhooks := handler.NewWebhookHandler(service.NewWebhookService(repo))
+	authSvc := service.NewAuthService(repo, workspaceSvc)
+	auth := handler.NewAuthHandler(authSvc, workspaceSvc, env == "production")
+	tokens := handler.NewAPITokenHandler(service.NewAPITokenService(repo))
+	workspace := handler.NewWorkspaceHandler(workspaceSvc)
+	healthSvc := service.NewHealthService(store)
+	health := handler.NewHealthHandler(healthSvc)
+	metricsHandler := handler.NewMetricsHandler(m)
+
+	// Send the webhook deliveries queued by todo changes, in every workspace, in the background,
+	// to public addresses and those of WEBHOOK_ALLOW_PRIVATE
+	allowed, err := webhook.ParseAllowedNetworks(getEnv("WEBHOOK_ALLOW_PRIVATE", ""))
+	if err != nil {
+		return fmt.Errorf("WEBHOOK_ALLOW_PRIVATE must list addresses or CIDR prefixes such as 10.0.0.0/8: %w", err)
+	}
+	dispatcher := webhook.NewDispatcher(repo)
+	dispatcher.Client = webhook.NewClient(allowed)
+	dispatcher.Stores = func(ctx context.Context) ([]webhook.Store, error) {
+		repos, err := workspaceSvc.OpenAll(ctx)
+		stores := make([]webhook.Store, len(repos))
+		for i, repo := range repos {
+			stores[i] = repo
+		}
+		return stores, err
+	}
+	dispatched := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx)
+		close(dispatched)
+	}()
 
 	// Setup routes
 	mux := http.NewServeMux()
 
-	// API routes
-	mux.HandleFunc("POST /api/todos", h.CreateTodo)
-	mux.HandleFunc("GET /api/todos", h.GetAllTodos)
-	mux.HandleFunc("POST /api/test/truncate", h.TruncateTodos) // Test database cleanup endpoint
+	// Probes, build details and metrics, outside /api so they need no account
+	mux.HandleFunc("GET /healthz", health.Healthz)
+	mux.HandleFunc("GET /readyz", health.Readyz)
+	mux.HandleFunc("GET /version", health.Version)
+	mux.HandleFunc("GET /metrics", metricsHandler.Metrics)
+
+	// API routes; API tokens may only call the Scoped ones, with that scope
+	mux.HandleFunc("POST /api/auth/register", auth.Register)
+	mux.HandleFunc("POST /api/auth/login", auth.Login)
+	mux.HandleFunc("POST /api/auth/logout", auth.Logout)
+	mux.HandleFunc("GET /api/auth/me", auth.Me)
+	mux.HandleFunc("POST /api/tokens", tokens.CreateAPIToken)
+	mux.HandleFunc("GET /api/tokens", tokens.GetAPITokens)
+	mux.HandleFunc("DELETE /api/tokens/{id}", tokens.RevokeAPIToken)
+	mux.Handle("GET /api/workspace", handler.Scoped(model.ScopeTodosRead, workspace.GetWorkspace))
+	mux.Handle("POST /api/todos", handler.Scoped(model.ScopeTodosWrite, h.CreateTodo))
+	mux.Handle("GET /api/todos", handler.Scoped(model.ScopeTodosRead, h.GetAllTodos))
+	mux.Handle("GET /api/todos/search", handler.Scoped(model.ScopeTodosRead, h.SearchTodos))
+	mux.Handle("GET /api/todos/events", handler.Scoped(model.ScopeTodosRead, events.StreamEvents))
+	mux.Handle("GET /api/todos/{id}", handler.Scoped(model.ScopeTodosRead, h.GetTodo))
+	mux.Handle("PUT /api/todos/{id}", handler.Scoped(model.ScopeTodosWrite, h.UpdateTodo))
+	mux.Handle("PATCH /api/todos/{id}", handler.Scoped(model.ScopeTodosWrite, h.PatchTodo))
+	mux.Handle("DELETE /api/todos/{id}", handler.Scoped(model.ScopeTodosWrite, h.DeleteTodo))
+	mux.Handle("POST /api/todos/{id}/complete", handler.Scoped(model.ScopeTodosWrite, h.CompleteTodo))
+	mux.Handle("POST /api/todos/{id}/reopen", handler.Scoped(model.ScopeTodosWrite, h.ReopenTodo))
+	mux.Handle("GET /api/tags", handler.Scoped(model.ScopeTodosRead, tags.GetTags))
+	mux.Handle("PATCH /api/tags/{id}", handler.Scoped(model.ScopeTodosWrite, tags.RenameTag))
+	mux.Handle("POST /api/tags/{id}/merge", handler.Scoped(model.ScopeTodosWrite, tags.MergeTag))
+	mux.Handle("POST /api/lists", handler.Scoped(model.ScopeTodosWrite, lists.CreateList))
+	mux.Handle("GET /api/lists", handler.Scoped(model.ScopeTodosRead, lists.GetLists))
+	mux.Handle("GET /api/lists/{id}", handler.Scoped(model.ScopeTodosRead, lists.GetList))
+	mux.Handle("PUT /api/lists/{id}", handler.Scoped(model.ScopeTodosWrite, lists.UpdateList))
+	mux.Handle("DELETE /api/lists/{id}", handler.Scoped(model.ScopeTodosWrite, lists.DeleteList))
+	mux.Handle("GET /api/lists/{id}/todos", handler.Scoped(model.ScopeTodosRead, lists.GetListTodos))
+	mux.Handle("GET /api/lists/{id}/members", handler.Scoped(model.ScopeTodosRead, lists.GetMembers))
+	mux.Handle("POST /api/lists/{id}/members", handler.Scoped(model.ScopeTodosWrite, lists.InviteMember))
+	mux.Handle("PATCH /api/lists/{id}/members/{userID}", handler.Scoped(model.ScopeTodosWrite, lists.UpdateMember))
+	mux.Handle("DELETE /api/lists/{id}/members/{userID}", handler.Scoped(model.ScopeTodosWrite, lists.RemoveMember))
+	mux.Handle("GET /api/invitations", handler.Scoped(model.ScopeTodosRead, lists.GetInvitations))
+	mux.Handle("POST /api/invitations/{id}/accept", handler.Scoped(model.ScopeTodosWrite, lists.AcceptInvitation))
+	mux.Handle("POST /api/invitations/{id}/decline", handler.Scoped(model.ScopeTodosWrite, lists.DeclineInvitation))
+	mux.Handle("GET /api/ws", handler.Scoped(model.ScopeTodosRead, ws.Serve))
+	mux.Handle("POST /api/webhooks", handler.Scoped(model.ScopeWebhooksWrite, webhooks.CreateWebhook))
+	mux.Handle("GET /api/webhooks", handler.Scoped(model.ScopeWebhooksRead, webhooks.GetWebhooks))
+	mux.Handle("GET /api/webhooks/{id}", handler.Scoped(model.ScopeWebhooksRead, webhooks.GetWebhook))
+	mux.Handle("PUT /api/webhooks/{id}", handler.Scoped(model.ScopeWebhooksWrite, webhooks.UpdateWebhook))
+	mux.Handle("DELETE /api/webhooks/{id}", handler.Scoped(model.ScopeWebhooksWrite, webhooks.DeleteWebhook))
+	mux.Handle("GET /api/webhooks/{id}/deliveries", handler.Scoped(model.ScopeWebhooksRead, webhooks.GetDeliveries))
+
+	// Routes for the end-to-end tests to reset and seed the database and
+	// freeze the clock; never served outside ENV=test
+	if env == "test" {
+		testSupport := handler.NewTestSupportHandler(service.NewTestSupportService(repo, authSvc, workspaceSvc, listSvc, svc), testSecret)
+		mux.HandleFunc("POST /api/test/truncate", testSupport.Truncate)
+		mux.HandleFunc("POST /api/test/seed", testSupport.Seed)
+		mux.HandleFunc("GET /api/test/clock", testSupport.GetClock)
+		mux.HandleFunc("PUT /api/test/clock", testSupport.FreezeClock)
+		mux.HandleFunc("DELETE /api/test/clock", testSupport.UnfreezeClock)
+	}
 
 	// Serve static files (frontend)
 	mux.Handle("/", http.FileServer(http.Dir("web/")))
 
-	// Start server
+	// Start server; on shutdown /readyz fails for SHUTDOWN_READINESS_DELAY
+	// while connections are still accepted, then the event streams and
+	// WebSockets end, and the webhook dispatcher stops before the repository
+	// is closed
 	serverPort := ":" + port
-	fmt.Printf("🚀 Server starting on http://localhost%s\n", serverPort)
-	fmt.Printf("📝 API: http://localhost%s/api/todos\n", serverPort)
-	fmt.Printf("🌐 Frontend: http://localhost%s\n", serverPort)
-	fmt.Printf("💾 Database: %s\n", dbPath)
+	cfg := server.DefaultConfig(serverPort)
+	cfg.ReadinessDelay, err = time.ParseDuration(getEnv("SHUTDOWN_READINESS_DELAY", cfg.ReadinessDelay.String()))
+	if err != nil || cfg.ReadinessDelay < 0 {
+		return fmt.Errorf("SHUTDOWN_READINESS_DELAY must be a duration such as 2s, not %q", getEnv("SHUTDOWN_READINESS_DELAY", ""))
+	}
+	srv := server.New(cfg, logging.Middleware(logger, m.InstrumentHTTP(mux, auth.RequireAuth(mux))))
+	srv.OnStop(healthSvc.Drain)
+	srv.OnDrain(svc.CloseSubscriptions)
+	srv.OnShutdown(func() error {
+		stop() // Also when the server failed to start rather than being signalled
+		<-dispatched
+		return nil
+	})
 
-	log.Fatal(http.ListenAndServe(serverPort, mux))
+	logger.Info("Server starting", "addr", serverPort, "env", env, "database", storage.String(), "commit", buildinfo.Get().Commit)
+
+	if err := srv.Run(ctx); err != nil {
+		return err
+	}
+	logger.Info("Server stopped")
+	return nil
 }
 
 // getEnv gets environment variable with default fallback
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+
+	"todo-app/internal/logging"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+)
+
+// runMigrate handles the `migrate` subcommand
+func runMigrate(ctx context.Context, storage storageConfig, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: server migrate status|up|down [steps] | assign-owner <username>")
+	}
+	if args[0] == "assign-owner" {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: server migrate assign-owner <username>")
+		}
+		return assignOwner(ctx, storage, args[1])
+	}
+
+	var steps int
+	switch args[0] {
+	case "status", "up":
+	case "down":
+		steps = 1
+		if len(args) > 1 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive integer, got %q", args[1])
+			}
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q (want status, up, down or assign-owner)", args[0])
+	}
+
+	// With WORKSPACE_DIR every workspace file is migrated like the main database
+	databases, err := migrationDatabases(storage)
+	if err != nil {
+		return err
+	}
+	for _, database := range databases {
+		if len(databases) > 1 && args[0] == "status" {
+			fmt.Printf("%s:\n", database)
+		}
+		if err := migrateDatabase(ctx, database, args[0], steps); err != nil {
+			if len(databases) > 1 {
+				return fmt.Errorf("%s: %w", database, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// migrationDatabases returns the storage of the main database and, with
+// WORKSPACE_DIR, of every workspace file in it
+func migrationDatabases(storage storageConfig) ([]storageConfig, error) {
+	databases := []storageConfig{{driver: storage.driver, dsn: storage.dsn}}
+	if storage.workspaceDir == "" {
+		return databases, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(storage.workspaceDir, "workspace-*.db"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		databases = append(databases, storageConfig{driver: "sqlite", dsn: file})
+	}
+	return databases, nil
+}
+
+// migrateDatabase runs the migrate command on one database, logging what
+// it changed to the logger of ctx
+func migrateDatabase(ctx context.Context, storage storageConfig, command string, steps int) error {
+	migrator, db, err := openMigrator(storage)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	logger := logging.FromContext(ctx).With("database", storage.String())
+
+	switch command {
+	case "status":
+		return printMigrationStatus(migrator)
+
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			logger.Info("Migration applied", "version", m.Version, "name", m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			logger.Info("Database is up to date")
+		}
+		return nil
+	}
+
+	reverted, err := migrator.Down(steps)
+	for _, m := range reverted {
+		logger.Info("Migration reverted", "version", m.Version, "name", m.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(reverted) == 0 {
+		logger.Info("No migration to revert")
+	}
+	return nil
+}
+
+// assignOwner gives the todos, tags, lists, events and webhooks made before
+// accounts existed to the user with username, in their workspace
+func assignOwner(ctx context.Context, storage storageConfig, username string) error {
+	repo, err := openRepository(storage)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	workspaces, err := openWorkspaces(storage, repo)
+	if err != nil {
+		return err
+	}
+	defer workspaces.Close()
+
+	user, err := service.NewWorkspaceService(repo, workspaces).AssignOwner(ctx, username)
+	if err != nil {
+		return fmt.Errorf("assign the data made before accounts existed to %q: %w", username, err)
+	}
+	logging.FromContext(ctx).Info("Assigned the data made before accounts existed", "username", user.Username, "workspace_id", user.WorkspaceID)
+	return nil
+}
+
+// printMigrationStatus prints one line per known migration
+func printMigrationStatus(migrator *repository.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state, appliedAt := "pending", "-"
+		if s.Applied {
+			state, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return migrator.Verify()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"todo-app/internal/logging"
+	"todo-app/internal/repository"
+)
+
+// searchIndexRebuilder is implemented by backends with a separate search index
+type searchIndexRebuilder interface {
+	RebuildSearchIndex(ctx context.Context) error
+}
+
+// runSearch handles the `search` subcommand
+func runSearch(ctx context.Context, storage storageConfig, args []string) error {
+	if len(args) != 1 || args[0] != "rebuild" {
+		return fmt.Errorf("usage: server search rebuild")
+	}
+
+	repo, err := openRepository(storage)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	rebuilder, ok := repo.(searchIndexRebuilder)
+	if !ok {
+		logging.FromContext(ctx).Info("Nothing to rebuild: the storage keeps no separate search index", "database", storage.String())
+		return nil
+	}
+
+	if err := rebuilder.RebuildSearchIndex(ctx); err != nil {
+		if errors.Is(err, repository.ErrSearchUnavailable) {
+			return fmt.Errorf("%w: build the server with -tags sqlite_fts5", err)
+		}
+		return err
+	}
+	logging.FromContext(ctx).Info("Search index rebuilt", "database", storage.String())
+	return nil
+}
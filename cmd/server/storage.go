@@ -0,0 +1,133 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"todo-app/internal/repository"
+)
+
+// storageConfig selects the repository backend
+type storageConfig struct {
+	driver       string // sqlite, postgres or memory
+	dsn          string // file path for sqlite, connection URL for postgres
+	workspaceDir string // with sqlite, keeps each workspace but the default in its own file here
+	queryTimeout time.Duration
+}
+
+// loadStorageConfig reads DB_DRIVER and DB_URL, falling back to SQLite at DB_PATH,
+// WORKSPACE_DIR and DB_QUERY_TIMEOUT. Without DB_DRIVER the driver is inferred
+// from the DB_URL scheme.
+func loadStorageConfig(defaultDBPath string) (storageConfig, error) {
+	driver := getEnv("DB_DRIVER", "")
+	dbURL := getEnv("DB_URL", "")
+
+	queryTimeout, err := time.ParseDuration(getEnv("DB_QUERY_TIMEOUT", repository.DefaultQueryTimeout.String()))
+	if err != nil || queryTimeout <= 0 {
+		return storageConfig{}, fmt.Errorf("DB_QUERY_TIMEOUT must be a positive duration such as 5s, not %q", getEnv("DB_QUERY_TIMEOUT", ""))
+	}
+
+	if driver == "" {
+		switch {
+		case strings.HasPrefix(dbURL, "postgres://"), strings.HasPrefix(dbURL, "postgresql://"):
+			driver = "postgres"
+		default:
+			driver = "sqlite"
+		}
+	}
+
+	workspaceDir := getEnv("WORKSPACE_DIR", "")
+	if workspaceDir != "" && driver != "sqlite" {
+		return storageConfig{}, fmt.Errorf("WORKSPACE_DIR needs DB_DRIVER=sqlite, not %s", driver)
+	}
+
+	switch driver {
+	case "sqlite":
+		if dbURL == "" {
+			dbURL = getEnv("DB_PATH", defaultDBPath)
+		}
+		return storageConfig{driver: driver, dsn: dbURL, workspaceDir: workspaceDir, queryTimeout: queryTimeout}, nil
+	case "postgres":
+		if dbURL == "" {
+			return storageConfig{}, fmt.Errorf("DB_URL is required for DB_DRIVER=postgres")
+		}
+		return storageConfig{driver: driver, dsn: dbURL, queryTimeout: queryTimeout}, nil
+	case "memory":
+		return storageConfig{driver: driver, queryTimeout: queryTimeout}, nil
+	}
+
+	return storageConfig{}, fmt.Errorf("unknown DB_DRIVER %q (want sqlite, postgres or memory)", driver)
+}
+
+// String describes the storage without leaking credentials
+func (c storageConfig) String() string {
+	switch c.driver {
+	case "postgres":
+		if u, err := url.Parse(c.dsn); err == nil {
+			return "postgres " + u.Redacted()
+		}
+		return "postgres"
+	case "memory":
+		return "memory (not persisted)"
+	}
+	if c.workspaceDir != "" {
+		return fmt.Sprintf("sqlite %s, workspaces in %s", c.dsn, c.workspaceDir)
+	}
+	return "sqlite " + c.dsn
+}
+
+// openRepository opens the configured backend and applies pending migrations
+func openRepository(cfg storageConfig) (repository.TodoRepository, error) {
+	switch cfg.driver {
+	case "postgres":
+		return repository.NewPostgresTodoRepository(cfg.dsn)
+	case "memory":
+		return repository.NewInMemoryTodoRepository(), nil
+	}
+	return repository.NewSQLiteTodoRepository(cfg.dsn)
+}
+
+// openWorkspaces finds the storage of each workspace: in repo itself, or
+// in files of their own when WORKSPACE_DIR is set
+func openWorkspaces(cfg storageConfig, repo repository.TodoRepository) (repository.Workspaces, error) {
+	if cfg.workspaceDir == "" {
+		return repository.SharedWorkspaces(repo), nil
+	}
+	return repository.NewSQLiteWorkspaceFiles(repo, cfg.workspaceDir)
+}
+
+// openMigrator opens the configured database for the migrate subcommand
+func openMigrator(cfg storageConfig) (*repository.Migrator, *sql.DB, error) {
+	var (
+		db  *sql.DB
+		err error
+	)
+
+	switch cfg.driver {
+	case "postgres":
+		db, err = repository.OpenPostgres(cfg.dsn)
+	case "sqlite":
+		db, err = repository.OpenSQLite(cfg.dsn)
+	default:
+		return nil, nil, fmt.Errorf("DB_DRIVER=%s has no migrations", cfg.driver)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var migrator *repository.Migrator
+	if cfg.driver == "postgres" {
+		migrator, err = repository.NewPostgresMigrator(db)
+	} else {
+		migrator, err = repository.NewSQLiteMigrator(db)
+	}
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	return migrator, db, nil
+}
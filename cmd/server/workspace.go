@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"todo-app/internal/logging"
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+)
+
+// runWorkspace handles the `workspace` subcommand
+func runWorkspace(ctx context.Context, storage storageConfig, args []string) error {
+	usage := fmt.Errorf("usage: server workspace create <slug> <name> | list | settings <slug> [sharing=on|off] [webhooks=on|off] [registration=on|off]")
+	if len(args) == 0 {
+		return usage
+	}
+
+	repo, err := openRepository(storage)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	workspaces, err := openWorkspaces(storage, repo)
+	if err != nil {
+		return err
+	}
+	defer workspaces.Close()
+	svc := service.NewWorkspaceService(repo, workspaces)
+
+	switch {
+	case args[0] == "create" && len(args) >= 3:
+		workspace, err := svc.CreateWorkspace(ctx, model.WorkspaceInput{Slug: args[1], Name: strings.Join(args[2:], " ")})
+		if err != nil {
+			return err
+		}
+		logging.FromContext(ctx).Info("Workspace created, closed to registration",
+			"id", workspace.ID, "slug", workspace.Slug, "name", workspace.Name,
+			"open_with", fmt.Sprintf("server workspace settings %s registration=on", workspace.Slug))
+		return nil
+
+	case args[0] == "list" && len(args) == 1:
+		all, err := svc.GetWorkspaces(ctx)
+		if err != nil {
+			return err
+		}
+		return printWorkspaces(all)
+
+	case args[0] == "settings" && len(args) >= 2:
+		workspace, err := svc.GetWorkspaceBySlug(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		if len(args) > 2 {
+			patch, err := parseWorkspaceSettings(args[2:])
+			if err != nil {
+				return err
+			}
+			if workspace, err = svc.UpdateSettings(ctx, workspace.ID, patch); err != nil {
+				return err
+			}
+		}
+		return printWorkspaces([]*model.Workspace{workspace})
+	}
+
+	return usage
+}
+
+// parseWorkspaceSettings reads settings given as sharing=on, webhooks=off and registration=on
+func parseWorkspaceSettings(args []string) (model.WorkspaceSettingsPatch, error) {
+	var patch model.WorkspaceSettingsPatch
+	for _, arg := range args {
+		key, value, _ := strings.Cut(arg, "=")
+		var on bool
+		switch value {
+		case "on":
+			on = true
+		case "off":
+		default:
+			return patch, fmt.Errorf("%s must be on or off, got %q", key, value)
+		}
+
+		switch key {
+		case "sharing":
+			patch.Sharing = &on
+		case "webhooks":
+			patch.Webhooks = &on
+		case "registration":
+			patch.Registration = &on
+		default:
+			return patch, fmt.Errorf("unknown setting %q (want sharing, webhooks or registration)", key)
+		}
+	}
+	return patch, nil
+}
+
+// printWorkspaces prints one line per workspace with its settings
+func printWorkspaces(workspaces []*model.Workspace) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSLUG\tNAME\tSHARING\tWEBHOOKS\tREGISTRATION")
+	for _, workspace := range workspaces {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", workspace.ID, workspace.Slug, workspace.Name,
+			onOff(workspace.Settings.Sharing), onOff(workspace.Settings.Webhooks), onOff(workspace.Settings.Registration))
+	}
+	return w.Flush()
+}
+
+// onOff spells a setting the way it is given on the command line
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,47 @@
+// Package buildinfo tells what the binary was built from. The backend
+// Dockerfile sets it at link time:
+//
+//	go build -ldflags "-X todo-app/internal/buildinfo.Commit=$(git rev-parse HEAD) -X todo-app/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/server
+//
+// Without them, a go build in a git checkout still reports the commit the
+// Go toolchain stamps into the binary.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags "-X todo-app/internal/buildinfo.<Name>=<value>"
+var (
+	Commit    = "" // git commit the binary was built from
+	BuildTime = "" // when it was built, RFC 3339 in UTC
+)
+
+// Info describes the running binary
+type Info struct {
+	Commit    string // "unknown" when neither ldflags nor VCS stamping set it
+	BuildTime string // "unknown" without ldflags
+	GoVersion string
+}
+
+// Get returns the build details, from ldflags or else from the VCS stamp
+func Get() Info {
+	info := Info{Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" && info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
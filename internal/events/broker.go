@@ -0,0 +1,112 @@
+// Package events fans todo change events out to the subscribers in this process
+package events
+
+import (
+	"sync"
+
+	"todo-app/internal/model"
+)
+
+// Broker delivers every published event to every current subscriber.
+// Publishing never blocks: a subscriber whose buffer is full is dropped and
+// its channel closed, so it can catch up from the change log instead.
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	closed      bool // set by Close; later subscriptions start out closed
+}
+
+// NewBroker creates a broker without subscribers
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives the events published after it was made
+type Subscription struct {
+	broker *Broker
+	events chan *model.TodoEvent
+	match  func(*model.TodoEvent) bool // nil matches every event
+}
+
+// Subscribe starts receiving events, holding up to buffer of them until they are read
+func (b *Broker) Subscribe(buffer int) *Subscription {
+	return b.SubscribeFiltered(buffer, nil)
+}
+
+// SubscribeFiltered is Subscribe for only the events match returns true for.
+// match is called while publishing and must not block.
+func (b *Broker) SubscribeFiltered(buffer int, match func(*model.TodoEvent) bool) *Subscription {
+	sub := &Subscription{broker: b, events: make(chan *model.TodoEvent, buffer), match: match}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(sub.events)
+		return sub
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Close ends every subscription, closing their channels, and those made
+// from now on, so that the streams reading them finish
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subscribers {
+		b.drop(sub)
+	}
+}
+
+// Closed reports whether Close was called
+func (b *Broker) Closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// Publish sends event to every subscriber in the order Publish is called
+func (b *Broker) Publish(event *model.TodoEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		if sub.match != nil && !sub.match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			b.drop(sub)
+		}
+	}
+}
+
+// Subscribers returns the number of current subscribers
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// drop removes sub and closes its channel; b.mu must be held
+func (b *Broker) drop(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Events returns the channel events arrive on; it is closed when the
+// subscription is cancelled, falls behind or the broker is closed
+func (s *Subscription) Events() <-chan *model.TodoEvent {
+	return s.events
+}
+
+// Cancel stops the subscription; it is safe to call more than once
+func (s *Subscription) Cancel() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.drop(s)
+}
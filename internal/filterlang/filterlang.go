@@ -0,0 +1,429 @@
+// Package filterlang parses the filter expressions accepted by ?filter= on
+// todo listings, e.g.
+//
+//	tag:work AND priority>=high AND due<2026-11-01 AND NOT completed
+//
+// An expression combines comparisons with AND, OR, NOT and parentheses; NOT
+// binds tighter than AND, which binds tighter than OR. Keywords are case
+// insensitive. A comparison is a field, an operator and a value:
+//
+//	tag       : = !=            a tag name
+//	text      : = !=            a substring of the text, ignoring case
+//	list      : = !=            a list ID
+//	priority  : = != < <= > >=  none, low, medium, high or urgent
+//	due       : = != < <= > >=  an RFC3339 timestamp, a YYYY-MM-DD date or none
+//	created   : = != < <= > >=  an RFC3339 timestamp or a YYYY-MM-DD date
+//	updated   : = != < <= > >=  an RFC3339 timestamp or a YYYY-MM-DD date
+//	completed : = !=            true or false; a bare completed means completed:true
+//	overdue   : = !=            true or false; a bare overdue means overdue:true
+//
+// ":" is the same as "=". Values containing spaces or parentheses are written
+// in double quotes. A date stands for its whole day (in UTC), so due:2026-11-01
+// matches any time that day and due<=2026-11-01 includes it.
+//
+// An expression holds at most MaxComparisons comparisons and nests
+// parentheses and NOT at most MaxDepth deep.
+package filterlang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"todo-app/internal/model"
+)
+
+// SyntaxError reports what is wrong with an expression and where
+type SyntaxError struct {
+	Pos int // 1-based character position of the offending input
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos)
+}
+
+// fieldKind groups the fields by the values and operators they accept
+type fieldKind int
+
+const (
+	kindTag fieldKind = iota
+	kindText
+	kindList
+	kindPriority
+	kindTime
+	kindBool
+)
+
+var fields = map[string]fieldKind{
+	model.FieldTag:       kindTag,
+	model.FieldText:      kindText,
+	model.FieldList:      kindList,
+	model.FieldPriority:  kindPriority,
+	model.FieldDue:       kindTime,
+	model.FieldCreated:   kindTime,
+	model.FieldUpdated:   kindTime,
+	model.FieldCompleted: kindBool,
+	model.FieldOverdue:   kindBool,
+}
+
+// fieldNames lists the fields for error messages
+var fieldNames = []string{
+	model.FieldTag, model.FieldText, model.FieldList, model.FieldPriority, model.FieldDue,
+	model.FieldCreated, model.FieldUpdated, model.FieldCompleted, model.FieldOverdue,
+}
+
+// Limits of an expression, so that the SQL it compiles to stays within what
+// the databases accept: SQLite refuses expressions nested 1000 deep
+const (
+	MaxComparisons = 100 // comparisons in an expression
+	MaxDepth       = 20  // parentheses and NOTs inside each other
+)
+
+// operators are tried in order, so two-character operators come first
+var operators = []string{"!=", "<=", ">=", ":", "=", "<", ">"}
+
+// Parse parses a filter expression
+func Parse(input string) (*model.FilterExpr, error) {
+	p := &parser{input: []rune(input)}
+
+	p.skipSpace()
+	if p.atEnd() {
+		return nil, p.errorf(p.pos, "filter is empty")
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.atEnd() {
+		return nil, p.errorf(p.pos, "expected AND, OR or the end of the filter, got %s", p.describeNext())
+	}
+	return expr, nil
+}
+
+// parser is a recursive descent parser reading input one rune at a time
+type parser struct {
+	input       []rune
+	pos         int // index of the next rune
+	comparisons int // parsed so far
+	depth       int // parentheses and NOTs open at pos
+}
+
+func (p *parser) atEnd() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) skipSpace() {
+	for !p.atEnd() && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) errorf(pos int, format string, args ...any) error {
+	return &SyntaxError{Pos: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// word returns the identifier starting at the current position without consuming it
+func (p *parser) word() string {
+	end := p.pos
+	for end < len(p.input) && (unicode.IsLetter(p.input[end]) || p.input[end] == '_') {
+		end++
+	}
+	return string(p.input[p.pos:end])
+}
+
+// keyword consumes the keyword kw (in any case) followed by a word boundary
+func (p *parser) keyword(kw string) bool {
+	word := p.word()
+	if !strings.EqualFold(word, kw) {
+		return false
+	}
+	p.pos += len([]rune(word))
+	p.skipSpace()
+	return true
+}
+
+// describeNext names the input at the current position for error messages
+func (p *parser) describeNext() string {
+	if p.atEnd() {
+		return "the end of the filter"
+	}
+	if word := p.word(); word != "" {
+		return fmt.Sprintf("%q", word)
+	}
+	return fmt.Sprintf("%q", string(p.input[p.pos]))
+}
+
+// parseOr parses and ("OR" and)*
+func (p *parser) parseOr() (*model.FilterExpr, error) {
+	operand, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*model.FilterExpr{operand}
+	for p.keyword("OR") {
+		if operand, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	return balanced(model.FilterOr, operands), nil
+}
+
+// parseAnd parses unary ("AND" unary)*
+func (p *parser) parseAnd() (*model.FilterExpr, error) {
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	operands := []*model.FilterExpr{operand}
+	for p.keyword("AND") {
+		if operand, err = p.parseUnary(); err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	return balanced(model.FilterAnd, operands), nil
+}
+
+// balanced joins operands with op into a tree as shallow as it can be, so a
+// long chain of ANDs or ORs does not nest its SQL as deep as it is long
+func balanced(op model.FilterOp, operands []*model.FilterExpr) *model.FilterExpr {
+	if len(operands) == 1 {
+		return operands[0]
+	}
+	half := len(operands) / 2
+	return &model.FilterExpr{Op: op, Left: balanced(op, operands[:half]), Right: balanced(op, operands[half:])}
+}
+
+// nest enters a parenthesis or NOT at pos, failing beyond MaxDepth; the
+// caller leaves it by decrementing p.depth
+func (p *parser) nest(pos int) error {
+	p.depth++
+	if p.depth > MaxDepth {
+		return p.errorf(pos, "filter nests parentheses and NOT more than %d deep", MaxDepth)
+	}
+	return nil
+}
+
+// parseUnary parses "NOT" unary | primary
+func (p *parser) parseUnary() (*model.FilterExpr, error) {
+	notPos := p.pos
+	if p.keyword("NOT") {
+		if err := p.nest(notPos); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		p.depth--
+		return &model.FilterExpr{Op: model.FilterNot, Left: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses a parenthesized expression or a comparison
+func (p *parser) parsePrimary() (*model.FilterExpr, error) {
+	if !p.atEnd() && p.input[p.pos] == '(' {
+		open := p.pos
+		if err := p.nest(open); err != nil {
+			return nil, err
+		}
+		p.pos++
+		p.skipSpace()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.atEnd() {
+			return nil, p.errorf(open, "unclosed (")
+		}
+		if p.input[p.pos] != ')' {
+			return nil, p.errorf(p.pos, "expected AND, OR or ), got %s", p.describeNext())
+		}
+		p.pos++
+		p.skipSpace()
+		p.depth--
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses field [operator value]
+func (p *parser) parseComparison() (*model.FilterExpr, error) {
+	fieldPos := p.pos
+	field := p.word()
+	if field == "" {
+		return nil, p.errorf(p.pos, "expected a field, NOT or (, got %s", p.describeNext())
+	}
+	kind, ok := fields[field]
+	if !ok {
+		return nil, p.errorf(fieldPos, "unknown field %q (want one of %s)", field, strings.Join(fieldNames, ", "))
+	}
+	if p.comparisons++; p.comparisons > MaxComparisons {
+		return nil, p.errorf(fieldPos, "filter has more than %d comparisons", MaxComparisons)
+	}
+	p.pos += len([]rune(field))
+	p.skipSpace()
+
+	opPos := p.pos
+	op := p.operator()
+	if op == "" {
+		if kind != kindBool {
+			return nil, p.errorf(p.pos, "expected an operator after %s, got %s", field, p.describeNext())
+		}
+		return &model.FilterExpr{Op: model.FilterCompare, Field: field, Cmp: model.CmpEq, Bool: true}, nil
+	}
+	cmp := model.FilterCmp(op)
+	if op == ":" {
+		cmp = model.CmpEq
+	}
+	if kind != kindPriority && kind != kindTime && cmp != model.CmpEq && cmp != model.CmpNe {
+		return nil, p.errorf(opPos, "%s only supports :, = and !=", field)
+	}
+	p.skipSpace()
+
+	valuePos := p.pos
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return nil, p.errorf(valuePos, "expected a value after %s%s, got %s", field, op, p.describeNext())
+	}
+	p.skipSpace()
+
+	// != is NOT of =, so it also matches todos without a due date
+	negate := cmp == model.CmpNe
+	if negate {
+		cmp = model.CmpEq
+	}
+	expr, err := comparison(field, kind, cmp, value)
+	if err != nil {
+		return nil, p.errorf(valuePos, "%v", err)
+	}
+	if negate {
+		expr = &model.FilterExpr{Op: model.FilterNot, Left: expr}
+	}
+	return expr, nil
+}
+
+// operator consumes and returns the operator at the current position, or ""
+func (p *parser) operator() string {
+	rest := string(p.input[p.pos:min(p.pos+2, len(p.input))])
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			p.pos += len(op)
+			return op
+		}
+	}
+	return ""
+}
+
+// value consumes a quoted string or a run of characters up to a space or parenthesis
+func (p *parser) value() (string, error) {
+	if p.atEnd() || p.input[p.pos] != '"' {
+		start := p.pos
+		for !p.atEnd() && !unicode.IsSpace(p.input[p.pos]) && p.input[p.pos] != '(' && p.input[p.pos] != ')' {
+			p.pos++
+		}
+		return string(p.input[start:p.pos]), nil
+	}
+
+	open := p.pos
+	var b strings.Builder
+	for p.pos++; !p.atEnd(); p.pos++ {
+		switch r := p.input[p.pos]; {
+		case r == '"':
+			p.pos++
+			return b.String(), nil
+		case r == '\\' && p.pos+1 < len(p.input):
+			p.pos++
+			b.WriteRune(p.input[p.pos])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return "", p.errorf(open, "unterminated string")
+}
+
+// comparison converts value for field and builds the comparison node
+func comparison(field string, kind fieldKind, cmp model.FilterCmp, value string) (*model.FilterExpr, error) {
+	expr := &model.FilterExpr{Op: model.FilterCompare, Field: field, Cmp: cmp}
+
+	switch kind {
+	case kindTag:
+		tag, err := model.NormalizeTag(value)
+		if err != nil {
+			return nil, err
+		}
+		expr.Text = tag
+
+	case kindText:
+		expr.Text = value
+
+	case kindList:
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("list must be a positive integer, got %q", value)
+		}
+		expr.Int = id
+
+	case kindPriority:
+		priority, err := model.ParsePriority(value)
+		if err != nil {
+			return nil, err
+		}
+		expr.Int = int(priority)
+
+	case kindBool:
+		switch value {
+		case "true":
+			expr.Bool = true
+		case "false":
+		default:
+			return nil, fmt.Errorf("%s must be true or false, got %q", field, value)
+		}
+
+	case kindTime:
+		if field == model.FieldDue && value == "none" {
+			if cmp != model.CmpEq {
+				return nil, fmt.Errorf("due:none only supports :, = and !=")
+			}
+			return expr, nil
+		}
+		t, allDay, err := model.ParseDue(value)
+		if err != nil {
+			return nil, err
+		}
+		if allDay {
+			return wholeDay(field, cmp, t), nil
+		}
+		expr.Time = &t
+	}
+
+	return expr, nil
+}
+
+// wholeDay compares field to the day starting at midnight
+func wholeDay(field string, cmp model.FilterCmp, midnight time.Time) *model.FilterExpr {
+	nextDay := midnight.AddDate(0, 0, 1)
+	at := func(cmp model.FilterCmp, t time.Time) *model.FilterExpr {
+		return &model.FilterExpr{Op: model.FilterCompare, Field: field, Cmp: cmp, Time: &t}
+	}
+
+	switch cmp {
+	case model.CmpLe:
+		return at(model.CmpLt, nextDay)
+	case model.CmpGt:
+		return at(model.CmpGe, nextDay)
+	case model.CmpLt, model.CmpGe:
+		return at(cmp, midnight)
+	}
+
+	return &model.FilterExpr{Op: model.FilterAnd, Left: at(model.CmpGe, midnight), Right: at(model.CmpLt, nextDay)}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+)
+
+// APITokenHandler handles HTTP requests for API tokens. Its routes take a
+// session only, so a token cannot create or revoke tokens.
+type APITokenHandler struct {
+	service *service.APITokenService
+}
+
+// NewAPITokenHandler creates a new API token handler
+func NewAPITokenHandler(svc *service.APITokenService) *APITokenHandler {
+	return &APITokenHandler{
+		service: svc,
+	}
+}
+
+// forUser returns the service scoped to the signed-in user of r
+func (h *APITokenHandler) forUser(r *http.Request) *service.APITokenService {
+	return h.service.ForUser(userID(r))
+}
+
+// CreateAPIToken handles POST /api/tokens
+func (h *APITokenHandler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	if !requireJSON(w, r) {
+		return
+	}
+
+	var request model.APITokenInput
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.forUser(r).CreateAPIToken(r.Context(), request)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to create API token")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, token)
+}
+
+// GetAPITokens handles GET /api/tokens
+func (h *APITokenHandler) GetAPITokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := h.forUser(r).GetAPITokens(r.Context())
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to get API tokens")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+// RevokeAPIToken handles DELETE /api/tokens/{id}
+func (h *APITokenHandler) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid API token ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.forUser(r).RevokeAPIToken(r.Context(), id); err != nil {
+		writeServiceError(w, r, err, "Failed to revoke API token")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+)
+
+// SessionCookie is the name of the cookie holding the session token
+const SessionCookie = "session"
+
+// publicPaths are the API routes that work without signing in
+var publicPaths = map[string]bool{
+	"/api/auth/register": true,
+	"/api/auth/login":    true,
+}
+
+// testPathPrefix starts the routes of the end-to-end tests, which check a
+// shared secret instead of signing in
+const testPathPrefix = "/api/test/"
+
+// AuthHandler handles registration, signing in and out, and the session
+// cookie or API token every other API route requires
+type AuthHandler struct {
+	service    *service.AuthService
+	workspaces *service.WorkspaceService
+	secure     bool // mark cookies Secure, for deployments served over HTTPS
+}
+
+// NewAuthHandler creates a new auth handler that opens the workspace of each
+// signed-in user with workspaces; secureCookies sends the session cookie over
+// HTTPS only
+func NewAuthHandler(svc *service.AuthService, workspaces *service.WorkspaceService, secureCookies bool) *AuthHandler {
+	return &AuthHandler{
+		service:    svc,
+		workspaces: workspaces,
+		secure:     secureCookies,
+	}
+}
+
+// Register handles POST /api/auth/register
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	credentials, ok := decodeCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	user, token, err := h.service.Register(r.Context(), credentials)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to register")
+		return
+	}
+
+	h.setSession(w, token)
+	writeJSON(w, http.StatusCreated, user)
+}
+
+// Login handles POST /api/auth/login
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	credentials, ok := decodeCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	user, token, err := h.service.Login(r.Context(), credentials)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to sign in")
+		return
+	}
+
+	h.setSession(w, token)
+	writeJSON(w, http.StatusOK, user)
+}
+
+// Logout handles POST /api/auth/logout
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		if err := h.service.Logout(r.Context(), cookie.Value); err != nil && !errors.Is(err, service.ErrSessionNotFound) {
+			writeInternalError(w, r, err, "Failed to sign out")
+			return
+		}
+	}
+
+	http.SetCookie(w, h.cookie("", -1))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Me handles GET /api/auth/me
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, UserFromContext(r.Context()))
+}
+
+// RequireAuth puts the user signed in with the session cookie, or calling
+// with an API token in the Authorization header, and the storage of their
+// workspace in the request context and answers 401 to API requests without
+// either, except for registering, signing in and the test routes. API tokens
+// may only call the routes mux has registered with Scoped, and only with that
+// route's scope.
+func (h *AuthHandler) RequireAuth(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if header := r.Header.Get("Authorization"); header != "" {
+			h.serveAPIToken(mux, w, r, header)
+			return
+		}
+
+		if cookie, err := r.Cookie(SessionCookie); err == nil {
+			user, err := h.service.Authenticate(r.Context(), cookie.Value)
+			switch {
+			case err == nil:
+				ctx, err := h.withWorkspace(WithUser(r.Context(), user), user)
+				if err != nil {
+					writeInternalError(w, r, err, "Failed to open workspace")
+					return
+				}
+				r = r.WithContext(ctx)
+			case !errors.Is(err, service.ErrSessionNotFound):
+				writeInternalError(w, r, err, "Failed to check session")
+				return
+			}
+		}
+
+		if UserFromContext(r.Context()) == nil && strings.HasPrefix(r.URL.Path, "/api/") && !publicPaths[r.URL.Path] && !strings.HasPrefix(r.URL.Path, testPathPrefix) {
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// serveAPIToken serves a request authenticated by the Authorization header,
+// which must be "Bearer <token>"; the session cookie is not looked at
+func (h *AuthHandler) serveAPIToken(mux *http.ServeMux, w http.ResponseWriter, r *http.Request, header string) {
+	scheme, value, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		http.Error(w, "Authorization header must be: Bearer <token>", http.StatusUnauthorized)
+		return
+	}
+
+	user, token, err := h.service.AuthenticateAPIToken(r.Context(), strings.TrimSpace(value))
+	if errors.Is(err, service.ErrAPITokenNotFound) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, "Invalid API token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to check API token")
+		return
+	}
+
+	route, _ := mux.Handler(r)
+	scoped, ok := route.(scopedRoute)
+	if !ok {
+		http.Error(w, "API tokens cannot use this endpoint", http.StatusForbidden)
+		return
+	}
+	if !token.HasScope(scoped.scope) {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scoped.scope))
+		http.Error(w, fmt.Sprintf("API token lacks the %s scope", scoped.scope), http.StatusForbidden)
+		return
+	}
+
+	ctx, err := h.withWorkspace(withAPIToken(WithUser(r.Context(), user), token), user)
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to open workspace")
+		return
+	}
+	mux.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// withWorkspace returns a context carrying the storage of the workspace of user
+func (h *AuthHandler) withWorkspace(ctx context.Context, user *model.User) (context.Context, error) {
+	repo, err := h.workspaces.Open(ctx, user.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return WithWorkspace(ctx, repo), nil
+}
+
+// scopedRoute is a route API tokens may call when they have its scope
+type scopedRoute struct {
+	scope string
+	http.HandlerFunc
+}
+
+// Scoped registers h as callable with an API token that has scope, as well
+// as with a session. Routes registered without it only take a session.
+func Scoped(scope string, h http.HandlerFunc) http.Handler {
+	return scopedRoute{scope: scope, HandlerFunc: h}
+}
+
+// setSession sends the session cookie for token
+func (h *AuthHandler) setSession(w http.ResponseWriter, token string) {
+	http.SetCookie(w, h.cookie(token, int(service.SessionLifetime/time.Second)))
+}
+
+// cookie returns the session cookie; a negative maxAge deletes it
+func (h *AuthHandler) cookie(token string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     SessionCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true, // Out of reach of scripts
+		Secure:   h.secure,
+		SameSite: http.SameSiteLaxMode, // Not sent with cross-site POSTs
+	}
+}
+
+// decodeCredentials reads the JSON body of register and login
+func decodeCredentials(w http.ResponseWriter, r *http.Request) (model.Credentials, bool) {
+	var credentials model.Credentials
+	if !requireJSON(w, r) {
+		return credentials, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return credentials, false
+	}
+	return credentials, true
+}
+
+// userKey is the context key of the signed-in user
+type userKey struct{}
+
+// WithUser returns a context carrying the signed-in user
+func WithUser(ctx context.Context, user *model.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the signed-in user, or nil
+func UserFromContext(ctx context.Context) *model.User {
+	user, _ := ctx.Value(userKey{}).(*model.User)
+	return user
+}
+
+// apiTokenKey is the context key of the API token a request is made with
+type apiTokenKey struct{}
+
+// withAPIToken returns a context carrying the API token of the request
+func withAPIToken(ctx context.Context, token *model.APIToken) context.Context {
+	return context.WithValue(ctx, apiTokenKey{}, token)
+}
+
+// allows reports whether r may use scope: always when signed in with a
+// session, only when its API token has scope otherwise
+func allows(r *http.Request, scope string) bool {
+	token, ok := r.Context().Value(apiTokenKey{}).(*model.APIToken)
+	return !ok || token.HasScope(scope)
+}
+
+// userID returns the ID of the signed-in user of r, or 0 without one
+func userID(r *http.Request) int {
+	if user := UserFromContext(r.Context()); user != nil {
+		return user.ID
+	}
+	return 0
+}
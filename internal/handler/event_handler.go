@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+)
+
+const (
+	// eventBuffer is how many live events a slow client may fall behind by
+	// before it is disconnected and has to resume from the change log
+	eventBuffer = 64
+
+	// replayBatch is how many change log events are read at a time when resuming
+	replayBatch = 100
+
+	// heartbeatInterval keeps idle connections from being closed by proxies
+	heartbeatInterval = 15 * time.Second
+
+	// reconnectDelay is the retry hint sent to EventSource clients, in milliseconds
+	reconnectDelay = 3000
+)
+
+// EventHandler streams todo changes as Server-Sent Events
+type EventHandler struct {
+	service *service.TodoService
+}
+
+// NewEventHandler creates a new event handler
+func NewEventHandler(service *service.TodoService) *EventHandler {
+	return &EventHandler{
+		service: service,
+	}
+}
+
+// forUser returns the service scoped to the signed-in user of r
+func (h *EventHandler) forUser(r *http.Request) *service.TodoService {
+	return inWorkspace(r, h.service).ForUser(userID(r))
+}
+
+// StreamEvents handles GET /api/todos/events. A client that sends the ID of
+// the last event it saw (the Last-Event-ID header EventSource sets when it
+// reconnects, or ?last_event_id=) first gets every later event from the change
+// log; then each created, updated and deleted event as it happens.
+func (h *EventHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	lastID, resume, ok := parseLastEventID(w, r)
+	if !ok {
+		return
+	}
+
+	svc := h.forUser(r)
+
+	// Subscribe before reading the change log so no event falls in between;
+	// live events the replay already sent are skipped by ID
+	sub := svc.Subscribe(r.Context(), eventBuffer)
+	defer sub.Cancel()
+
+	var replay []*model.TodoEvent
+	if resume {
+		var err error
+		if replay, err = svc.GetEvents(r.Context(), lastID, replayBatch); err != nil {
+			writeInternalError(w, r, err, "Failed to read events")
+			return
+		}
+	}
+
+	// The stream outlives the server's write timeout; heartbeats find clients that went away
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Tell nginx not to buffer the stream
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay)
+
+	for len(replay) > 0 {
+		for _, event := range replay {
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			lastID = event.ID
+		}
+		if len(replay) < replayBatch {
+			break
+		}
+		var err error
+		if replay, err = svc.GetEvents(r.Context(), lastID, replayBatch); err != nil {
+			return // The client reconnects and resumes from lastID
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case event, ok := <-sub.Events():
+			if !ok {
+				return // Fell behind; the client reconnects and resumes from the change log
+			}
+			if event.ID <= lastID {
+				continue
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			lastID = event.ID
+
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// parseLastEventID reads the event ID to resume after, answering 400 when it
+// is not a non-negative integer. resume is false when the client sent none.
+func parseLastEventID(w http.ResponseWriter, r *http.Request) (id int64, resume bool, ok bool) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, false, true
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		http.Error(w, "Last-Event-ID must be a non-negative integer", http.StatusBadRequest)
+		return 0, false, false
+	}
+	return id, true, true
+}
+
+// writeEvent writes one event in the text/event-stream format
+func writeEvent(w io.Writer, event *model.TodoEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+)
+
+// HealthHandler handles the probes of container runtimes and load
+// balancers, and the build details. They work without signing in.
+type HealthHandler struct {
+	service *service.HealthService
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(svc *service.HealthService) *HealthHandler {
+	return &HealthHandler{
+		service: svc,
+	}
+}
+
+// Healthz handles GET /healthz: the process is up and serving, whatever
+// the state of the database
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readyz handles GET /readyz, answering 503 while any check fails
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	readiness := h.service.Readiness(r.Context())
+
+	status := http.StatusOK
+	if readiness.Status != model.ReadinessReady {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, status, readiness)
+}
+
+// Version handles GET /version
+func (h *HealthHandler) Version(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.service.BuildInfo())
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+)
+
+// ListHandler handles HTTP requests for lists
+type ListHandler struct {
+	lists *service.ListService
+	todos *service.TodoService
+}
+
+// NewListHandler creates a new list handler
+func NewListHandler(lists *service.ListService, todos *service.TodoService) *ListHandler {
+	return &ListHandler{
+		lists: lists,
+		todos: todos,
+	}
+}
+
+// forUser returns the list service acting as the signed-in user of r
+func (h *ListHandler) forUser(r *http.Request) *service.ListService {
+	return inWorkspace(r, h.lists).ForUser(userID(r))
+}
+
+// CreateList handles POST /api/lists
+func (h *ListHandler) CreateList(w http.ResponseWriter, r *http.Request) {
+	if !requireJSON(w, r) {
+		return
+	}
+
+	var request model.ListInput
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.forUser(r).CreateList(r.Context(), request)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to create list")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, list)
+}
+
+// GetLists handles GET /api/lists
+func (h *ListHandler) GetLists(w http.ResponseWriter, r *http.Request) {
+	lists, err := h.forUser(r).GetLists(r.Context())
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to get lists")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, lists)
+}
+
+// GetList handles GET /api/lists/{id}
+func (h *ListHandler) GetList(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.forUser(r).GetList(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to get list")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// UpdateList handles PUT /api/lists/{id}
+func (h *ListHandler) UpdateList(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+	if !requireJSON(w, r) {
+		return
+	}
+
+	var request model.ListInput
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.forUser(r).RenameList(r.Context(), id, request)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to update list")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// DeleteList handles DELETE /api/lists/{id}
+func (h *ListHandler) DeleteList(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.forUser(r).DeleteList(r.Context(), id); err != nil {
+		writeServiceError(w, r, err, "Failed to delete list")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetListTodos handles GET /api/lists/{id}/todos with the query parameters and paging of GET /api/todos
+func (h *ListHandler) GetListTodos(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	filter, err := parseTodoFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.forUser(r).GetList(r.Context(), id); err != nil {
+		writeServiceError(w, r, err, "Failed to get list")
+		return
+	}
+
+	filter.ListID = id
+	writeTodoListing(w, r, inWorkspace(r, h.todos).ForUser(userID(r)), filter)
+}
+
+// parseListID reads the {id} path value, answering 400 when it is not a positive integer
+func parseListID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid list ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
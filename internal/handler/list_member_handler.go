@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"todo-app/internal/model"
+)
+
+// GetMembers handles GET /api/lists/{id}/members
+func (h *ListHandler) GetMembers(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := h.forUser(r).GetMembers(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to get members")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, members)
+}
+
+// InviteMember handles POST /api/lists/{id}/members
+func (h *ListHandler) InviteMember(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+	if !requireJSON(w, r) {
+		return
+	}
+
+	var request model.ListMemberInput
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.forUser(r).InviteMember(r.Context(), id, request)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to invite member")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, member)
+}
+
+// UpdateMember handles PATCH /api/lists/{id}/members/{userID}
+func (h *ListHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	id, memberID, ok := parseMemberID(w, r)
+	if !ok {
+		return
+	}
+	if !requireJSON(w, r) {
+		return
+	}
+
+	var request model.ListMemberInput
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	member, err := h.forUser(r).UpdateMember(r.Context(), id, memberID, request)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to update member")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, member)
+}
+
+// RemoveMember handles DELETE /api/lists/{id}/members/{userID}
+func (h *ListHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	id, memberID, ok := parseMemberID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.forUser(r).RemoveMember(r.Context(), id, memberID); err != nil {
+		writeServiceError(w, r, err, "Failed to remove member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetInvitations handles GET /api/invitations
+func (h *ListHandler) GetInvitations(w http.ResponseWriter, r *http.Request) {
+	invitations, err := h.forUser(r).GetInvitations(r.Context())
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to get invitations")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, invitations)
+}
+
+// AcceptInvitation handles POST /api/invitations/{id}/accept, answering with the joined list
+func (h *ListHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.forUser(r).AcceptInvitation(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to accept invitation")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// DeclineInvitation handles POST /api/invitations/{id}/decline
+func (h *ListHandler) DeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseListID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.forUser(r).DeclineInvitation(r.Context(), id); err != nil {
+		writeServiceError(w, r, err, "Failed to decline invitation")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseMemberID reads the {id} and {userID} path values, answering 400 when
+// either is not a positive integer
+func parseMemberID(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	id, ok := parseListID(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+	memberID, err := strconv.Atoi(r.PathValue("userID"))
+	if err != nil || memberID <= 0 {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return id, memberID, true
+}
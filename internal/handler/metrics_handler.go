@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"todo-app/internal/metrics"
+)
+
+// MetricsHandler serves the metrics to Prometheus. Like the probes it is
+// served outside /api and works without signing in.
+type MetricsHandler struct {
+	metrics *metrics.Metrics
+}
+
+// NewMetricsHandler creates a new metrics handler
+func NewMetricsHandler(m *metrics.Metrics) *MetricsHandler {
+	return &MetricsHandler{
+		metrics: m,
+	}
+}
+
+// Metrics handles GET /metrics in the Prometheus text format
+func (h *MetricsHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metrics.ContentType)
+	w.Header().Set("Cache-Control", "no-store")
+	h.metrics.WriteTo(w)
+}
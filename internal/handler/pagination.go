@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+)
+
+// writeTodoListing answers a todo listing with one page in an envelope and a
+// Link header, or with every todo as a bare array when the request sets the
+// legacy=true compatibility flag used by the React client
+func writeTodoListing(w http.ResponseWriter, r *http.Request, svc *service.TodoService, filter model.TodoFilter) {
+	legacy, limit, err := parsePagination(r, &filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if legacy {
+		todos, err := svc.GetAllTodos(r.Context(), filter)
+		if err != nil {
+			writeInternalError(w, r, err, "Failed to get todos")
+			return
+		}
+		writeJSON(w, http.StatusOK, todos)
+		return
+	}
+
+	page, err := svc.ListTodos(r.Context(), filter, limit)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to get todos")
+		return
+	}
+
+	var links []string
+	if page.NextCursor != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(r, page.NextCursor, limit)))
+	}
+	if page.PrevCursor != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(r, page.PrevCursor, limit)))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// parsePagination reads the legacy, limit and cursor query parameters,
+// storing the cursor in filter
+func parsePagination(r *http.Request, filter *model.TodoFilter) (legacy bool, limit int, err error) {
+	query := r.URL.Query()
+
+	if value := query.Get("legacy"); value != "" {
+		legacy, err = parseBoolParam(value)
+		if err != nil {
+			return false, 0, fmt.Errorf("legacy must be true or false")
+		}
+	}
+	if legacy {
+		if query.Has("limit") || query.Has("cursor") {
+			return false, 0, fmt.Errorf("legacy=true cannot be combined with limit or cursor")
+		}
+		return true, 0, nil
+	}
+
+	limit, err = parseLimit(query)
+	if err != nil {
+		return false, 0, err
+	}
+
+	if query.Has("cursor") {
+		cursor, err := model.DecodeCursor(query.Get("cursor"))
+		if err != nil {
+			return false, 0, err
+		}
+		filter.Cursor = cursor
+	}
+
+	return false, limit, nil
+}
+
+// parseLimit reads the limit query parameter, DefaultPageSize when absent
+func parseLimit(query url.Values) (int, error) {
+	if !query.Has("limit") {
+		return model.DefaultPageSize, nil
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 || limit > model.MaxPageSize {
+		return 0, fmt.Errorf("limit must be an integer from 1 to %d", model.MaxPageSize)
+	}
+	return limit, nil
+}
+
+// pageURL returns the request URL pointing at the page of cursor
+func pageURL(r *http.Request, cursor string, limit int) string {
+	query := r.URL.Query()
+	query.Set("cursor", cursor)
+	query.Set("limit", strconv.Itoa(limit))
+	return r.URL.Path + "?" + query.Encode()
+}
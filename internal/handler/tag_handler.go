@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"todo-app/internal/service"
+)
+
+// TagHandler handles HTTP requests for tags
+type TagHandler struct {
+	service *service.TodoService
+}
+
+// NewTagHandler creates a new tag handler
+func NewTagHandler(service *service.TodoService) *TagHandler {
+	return &TagHandler{
+		service: service,
+	}
+}
+
+// forUser returns the service scoped to the signed-in user of r
+func (h *TagHandler) forUser(r *http.Request) *service.TodoService {
+	return inWorkspace(r, h.service).ForUser(userID(r))
+}
+
+// renameTagRequest is the body of PATCH /api/tags/{id}
+type renameTagRequest struct {
+	Name string `json:"name"`
+}
+
+// mergeTagRequest is the body of POST /api/tags/{id}/merge
+type mergeTagRequest struct {
+	Into int `json:"into"` // ID of the tag that is kept
+}
+
+// GetTags handles GET /api/tags
+func (h *TagHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := h.forUser(r).GetTags(r.Context())
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to get tags")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tags)
+}
+
+// RenameTag handles PATCH /api/tags/{id}
+func (h *TagHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTagID(w, r)
+	if !ok {
+		return
+	}
+	if !requireJSON(w, r) {
+		return
+	}
+
+	var request renameTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.forUser(r).RenameTag(r.Context(), id, request.Name)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to rename tag")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tag)
+}
+
+// MergeTag handles POST /api/tags/{id}/merge
+func (h *TagHandler) MergeTag(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTagID(w, r)
+	if !ok {
+		return
+	}
+	if !requireJSON(w, r) {
+		return
+	}
+
+	var request mergeTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if request.Into <= 0 {
+		http.Error(w, "into must be a tag ID", http.StatusBadRequest)
+		return
+	}
+
+	tag, err := h.forUser(r).MergeTags(r.Context(), id, request.Into)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to merge tags")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tag)
+}
+
+// parseTagID reads the {id} path value, answering 400 when it is not a positive integer
+func parseTagID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
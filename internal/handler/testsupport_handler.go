@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+)
+
+// TestSecretHeader carries the shared secret every /api/test route requires
+const TestSecretHeader = "X-Test-Secret"
+
+// TestSupportHandler handles the /api/test routes the end-to-end tests use
+// to reset the storage, seed fixtures and freeze the clock. They are only
+// registered with ENV=test and answer 403 without the shared secret.
+type TestSupportHandler struct {
+	service *service.TestSupportService
+	secret  string
+}
+
+// NewTestSupportHandler creates a new test support handler; an empty
+// secret refuses every request
+func NewTestSupportHandler(svc *service.TestSupportService, secret string) *TestSupportHandler {
+	return &TestSupportHandler{
+		service: svc,
+		secret:  secret,
+	}
+}
+
+// clockResponse is the body of the /api/test/clock responses
+type clockResponse struct {
+	Now    time.Time `json:"now"`
+	Frozen bool      `json:"frozen"`
+}
+
+// freezeClockRequest is the body of PUT /api/test/clock
+type freezeClockRequest struct {
+	Now time.Time `json:"now"`
+}
+
+// Truncate handles POST /api/test/truncate
+func (h *TestSupportHandler) Truncate(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	if err := h.service.Truncate(r.Context()); err != nil {
+		writeInternalError(w, r, err, "Failed to truncate")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Seed handles POST /api/test/seed
+func (h *TestSupportHandler) Seed(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) || !requireJSON(w, r) {
+		return
+	}
+
+	var fixtures model.Fixtures
+	if err := json.NewDecoder(r.Body).Decode(&fixtures); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	seeded, err := h.service.Seed(r.Context(), fixtures)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to seed fixtures")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, seeded)
+}
+
+// GetClock handles GET /api/test/clock
+func (h *TestSupportHandler) GetClock(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	now, frozen := h.service.Clock()
+	writeJSON(w, http.StatusOK, clockResponse{Now: now, Frozen: frozen})
+}
+
+// FreezeClock handles PUT /api/test/clock
+func (h *TestSupportHandler) FreezeClock(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) || !requireJSON(w, r) {
+		return
+	}
+
+	var request freezeClockRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if request.Now.IsZero() {
+		http.Error(w, "now must be an RFC3339 timestamp", http.StatusBadRequest)
+		return
+	}
+
+	h.service.FreezeClock(request.Now)
+	writeJSON(w, http.StatusOK, clockResponse{Now: request.Now, Frozen: true})
+}
+
+// UnfreezeClock handles DELETE /api/test/clock
+func (h *TestSupportHandler) UnfreezeClock(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	h.service.UnfreezeClock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorize answers 403 unless r carries the shared secret
+func (h *TestSupportHandler) authorize(w http.ResponseWriter, r *http.Request) bool {
+	given := r.Header.Get(TestSecretHeader)
+	if h.secret == "" || subtle.ConstantTimeCompare([]byte(given), []byte(h.secret)) != 1 {
+		http.Error(w, "Missing or wrong "+TestSecretHeader+" header", http.StatusForbidden)
+		return false
+	}
+	return true
+}
@@ -1,10 +1,18 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"todo-app/internal/filterlang"
+	"todo-app/internal/logging"
+	"todo-app/internal/model"
 	"todo-app/internal/service"
 )
 
@@ -20,19 +28,19 @@ func NewTodoHandler(service *service.TodoService) *TodoHandler {
 	}
 }
 
+// forUser returns the service scoped to the signed-in user of r
+func (h *TodoHandler) forUser(r *http.Request) *service.TodoService {
+	return inWorkspace(r, h.service).ForUser(userID(r))
+}
+
 // CreateTodo handles POST /api/todos
 func (h *TodoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
-	// Validate Content-Type
-	contentType := r.Header.Get("Content-Type")
-	if contentType != "application/json" {
-		http.Error(w, "Content-Type must be application/json", http.StatusBadRequest)
+	if !requireJSON(w, r) {
 		return
 	}
 
 	// json'u struct yapısına çevir
-	var request struct {
-		Text string `json:"text"`
-	}
+	var request model.TodoInput
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
@@ -45,38 +53,393 @@ func (h *TodoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	todo, err := h.service.CreateTodo(request.Text)
+	todo, err := h.forUser(r).CreateTodo(r.Context(), request)
 	if err != nil {
-		if strings.Contains(err.Error(), "empty") {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		http.Error(w, "Failed to create todo", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Failed to create todo")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated) // 201
-	json.NewEncoder(w).Encode(todo)   // struct'ı json'a çevir
+	writeJSON(w, http.StatusCreated, todo) // 201
 }
 
 // GetAllTodos handles GET /api/todos
 func (h *TodoHandler) GetAllTodos(w http.ResponseWriter, r *http.Request) {
-	todos, err := h.service.GetAllTodos()
+	filter, err := parseTodoFilter(r)
 	if err != nil {
-		http.Error(w, "Failed to get todos", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(todos)
+	writeTodoListing(w, r, h.forUser(r), filter)
+}
+
+// SearchTodos handles GET /api/todos/search
+func (h *TodoHandler) SearchTodos(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if strings.TrimSpace(query.Get("q")) == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	search, err := model.ParseSearch(query.Get("q"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := parseLimit(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.forUser(r).SearchTodos(r.Context(), search, limit)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to search todos")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"data": results})
+}
+
+// GetTodo handles GET /api/todos/{id}
+func (h *TodoHandler) GetTodo(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTodoID(w, r)
+	if !ok {
+		return
+	}
+
+	todo, err := h.forUser(r).GetTodo(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to get todo")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, todo)
+}
+
+// UpdateTodo handles PUT /api/todos/{id}
+func (h *TodoHandler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTodoID(w, r)
+	if !ok {
+		return
+	}
+	if !requireJSON(w, r) {
+		return
+	}
+
+	var request model.TodoInput
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	todo, err := h.forUser(r).UpdateTodo(r.Context(), id, request)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to update todo")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, todo)
+}
+
+// PatchTodo handles PATCH /api/todos/{id}
+func (h *TodoHandler) PatchTodo(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTodoID(w, r)
+	if !ok {
+		return
+	}
+	if !requireJSON(w, r) {
+		return
+	}
+
+	var patch model.TodoPatch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if patch.IsEmpty() {
+		http.Error(w, "No fields to update", http.StatusBadRequest)
+		return
+	}
+
+	todo, err := h.forUser(r).PatchTodo(r.Context(), id, patch)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to update todo")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, todo)
+}
+
+// CompleteTodo handles POST /api/todos/{id}/complete
+func (h *TodoHandler) CompleteTodo(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTodoID(w, r)
+	if !ok {
+		return
+	}
+
+	todo, err := h.forUser(r).CompleteTodo(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to complete todo")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, todo)
+}
+
+// ReopenTodo handles POST /api/todos/{id}/reopen
+func (h *TodoHandler) ReopenTodo(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTodoID(w, r)
+	if !ok {
+		return
+	}
+
+	todo, err := h.forUser(r).ReopenTodo(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to reopen todo")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, todo)
 }
 
-// TruncateTodos handles removing all todos (for testing only)
-func (h *TodoHandler) TruncateTodos(w http.ResponseWriter, r *http.Request) {
-	if err := h.service.TruncateTodos(); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+// DeleteTodo handles DELETE /api/todos/{id}
+func (h *TodoHandler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseTodoID(w, r)
+	if !ok {
 		return
 	}
+
+	if err := h.forUser(r).DeleteTodo(r.Context(), id); err != nil {
+		writeServiceError(w, r, err, "Failed to delete todo")
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// requireJSON rejects requests whose Content-Type is not application/json
+func requireJSON(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// parseTodoID reads the {id} path value, answering 400 when it is not a positive integer
+func parseTodoID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid todo ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// parseTodoFilter reads the listing query parameters of GET /api/todos
+func parseTodoFilter(r *http.Request) (model.TodoFilter, error) {
+	var filter model.TodoFilter
+	query := r.URL.Query()
+
+	if value := query.Get("list_id"); value != "" {
+		listID, err := strconv.Atoi(value)
+		if err != nil || listID <= 0 {
+			return filter, fmt.Errorf("list_id must be a positive integer")
+		}
+		filter.ListID = listID
+	}
+
+	if value := query.Get("completed"); value != "" {
+		completed, err := parseBoolParam(value)
+		if err != nil {
+			return filter, fmt.Errorf("completed must be true or false")
+		}
+		filter.Completed = &completed
+	}
+
+	for _, param := range []struct {
+		name   string
+		target **time.Time
+	}{
+		{"due_before", &filter.DueBefore},
+		{"due_after", &filter.DueAfter},
+	} {
+		if value := query.Get(param.name); value != "" {
+			t, _, err := model.ParseDue(value)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be an RFC3339 timestamp or a YYYY-MM-DD date", param.name)
+			}
+			*param.target = &t
+		}
+	}
+
+	if value := query.Get("overdue"); value != "" {
+		overdue, err := parseBoolParam(value)
+		if err != nil {
+			return filter, fmt.Errorf("overdue must be true or false")
+		}
+		filter.Overdue = overdue
+	}
+
+	if tags := query["tag"]; len(tags) > 0 {
+		normalized, err := model.NormalizeTags(tags)
+		if err != nil {
+			return filter, fmt.Errorf("invalid tag: %w", err)
+		}
+		filter.Tags = normalized
+	}
+
+	switch query.Get("tag_mode") {
+	case "", "all":
+	case "any":
+		filter.AnyTag = true
+	default:
+		return filter, fmt.Errorf("tag_mode must be all or any")
+	}
+
+	if query.Has("filter") {
+		where, err := filterlang.Parse(query.Get("filter"))
+		if err != nil {
+			return filter, fmt.Errorf("invalid filter: %w", err)
+		}
+		filter.Where = where
+	}
+
+	if query.Has("sort") {
+		sort, err := model.ParseSort(query.Get("sort"))
+		if err != nil {
+			return filter, fmt.Errorf("invalid sort: %w", err)
+		}
+		filter.Sort = sort
+	}
+
+	return filter, nil
+}
+
+// parseBoolParam accepts only the literal query values "true" and "false"
+func parseBoolParam(value string) (bool, error) {
+	switch value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", value)
+}
+
+// writeServiceError maps service errors to HTTP status codes
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	status, message := serviceErrorStatus(err, fallback)
+	if status == http.StatusInternalServerError {
+		writeInternalError(w, r, err, message)
+		return
+	}
+	http.Error(w, message, status)
+}
+
+// writeInternalError answers 500 with message, and logs err, which the
+// client is not told, with the ID of the request. Requests that failed for
+// being canceled or running out of time are answered as contextErrorStatus
+// says instead, and not logged as errors.
+func writeInternalError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	logger := logging.FromContext(r.Context())
+	if status, contextMessage, ok := contextErrorStatus(err); ok {
+		if status == statusClientClosedRequest {
+			logger.Info("Request canceled by the client", "error", err)
+		} else {
+			logger.Warn("Request ran out of time", "error", err)
+		}
+		http.Error(w, contextMessage, status)
+		return
+	}
+	logger.Error(message, "error", err)
+	http.Error(w, message, http.StatusInternalServerError)
+}
+
+// statusClientClosedRequest is nginx's status for requests the client gave
+// up on before the answer
+const statusClientClosedRequest = 499
+
+// contextErrorStatus returns 499 for errors of a request whose client went
+// away, canceling its context, and 503 for those of a repository call that
+// took longer than DB_QUERY_TIMEOUT, with their message, and whether err is
+// either
+func contextErrorStatus(err error) (int, string, bool) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest, "Request canceled", true
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable, "The database did not answer in time; try again later", true
+	}
+	return 0, "", false
+}
+
+// serviceErrorStatus returns the HTTP status and message for a service error,
+// or 500 with fallback for unexpected ones
+func serviceErrorStatus(err error, fallback string) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrTodoNotFound):
+		return http.StatusNotFound, "Todo not found"
+	case errors.Is(err, service.ErrEmptyText):
+		return http.StatusBadRequest, "Text cannot be empty"
+	case errors.Is(err, service.ErrListNotFound):
+		return http.StatusNotFound, "List not found"
+	case errors.Is(err, service.ErrDeleteInbox):
+		return http.StatusConflict, "The inbox list cannot be deleted"
+	case errors.Is(err, service.ErrNotListOwner):
+		return http.StatusForbidden, "Only the owner of the list can do this"
+	case errors.Is(err, service.ErrReadOnlyList):
+		return http.StatusForbidden, "The list is shared with you read-only"
+	case errors.Is(err, service.ErrMemberNotFound):
+		return http.StatusNotFound, "Member not found"
+	case errors.Is(err, service.ErrMemberExists):
+		return http.StatusConflict, "User is already a member of the list"
+	case errors.Is(err, service.ErrInvitationNotFound):
+		return http.StatusNotFound, "Invitation not found"
+	case errors.Is(err, service.ErrUserNotFound):
+		return http.StatusNotFound, "User not found"
+	case errors.Is(err, service.ErrTagNotFound):
+		return http.StatusNotFound, "Tag not found"
+	case errors.Is(err, service.ErrTagExists):
+		return http.StatusConflict, "Tag already exists; merge the tags instead"
+	case errors.Is(err, service.ErrWebhookNotFound):
+		return http.StatusNotFound, "Webhook not found"
+	case errors.Is(err, service.ErrAPITokenNotFound):
+		return http.StatusNotFound, "API token not found"
+	case errors.Is(err, service.ErrUsernameTaken):
+		return http.StatusConflict, "Username is already taken"
+	case errors.Is(err, service.ErrInvalidCredentials):
+		return http.StatusUnauthorized, "Invalid username or password"
+	case errors.Is(err, service.ErrSharingDisabled), errors.Is(err, service.ErrWebhooksDisabled),
+		errors.Is(err, service.ErrRegistrationClosed):
+		return http.StatusForbidden, err.Error()
+	case errors.Is(err, service.ErrWorkspaceNotFound):
+		return http.StatusNotFound, "Workspace not found"
+	case errors.Is(err, service.ErrWorkspaceExists):
+		return http.StatusConflict, "Workspace slug is already taken"
+	case errors.Is(err, service.ErrSearchUnavailable):
+		return http.StatusNotImplemented, "Full-text search is not available in this build"
+	case errors.Is(err, service.ErrInvalidDueDate), errors.Is(err, service.ErrInvalidPriority),
+		errors.Is(err, service.ErrInvalidTag), errors.Is(err, service.ErrMergeSameTag),
+		errors.Is(err, service.ErrUnknownList), errors.Is(err, service.ErrInvalidListName),
+		errors.Is(err, service.ErrCursorMismatch), errors.Is(err, service.ErrInvalidWebhookURL),
+		errors.Is(err, service.ErrInvalidEventType), errors.Is(err, service.ErrInvalidUsername),
+		errors.Is(err, service.ErrInvalidPassword), errors.Is(err, service.ErrInvalidTokenName),
+		errors.Is(err, service.ErrInvalidScope), errors.Is(err, service.ErrInvalidRole),
+		errors.Is(err, service.ErrShareInbox), errors.Is(err, service.ErrUnknownWorkspace),
+		errors.Is(err, service.ErrInvalidWorkspaceSlug), errors.Is(err, service.ErrInvalidWorkspaceName),
+		errors.Is(err, service.ErrUnknownOwner), errors.Is(err, service.ErrUnknownFixtureList):
+		return http.StatusBadRequest, err.Error()
+	}
+	return http.StatusInternalServerError, fallback
+}
+
+// writeJSON encodes v as the JSON response body with the given status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v) // struct'ı json'a çevir
+}
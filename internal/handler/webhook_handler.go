@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+)
+
+// WebhookHandler handles HTTP requests for webhooks
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(svc *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		service: svc,
+	}
+}
+
+// forUser returns the service scoped to the signed-in user of r
+func (h *WebhookHandler) forUser(r *http.Request) *service.WebhookService {
+	return inWorkspace(r, h.service).ForUser(userID(r))
+}
+
+// CreateWebhook handles POST /api/webhooks
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if !requireJSON(w, r) {
+		return
+	}
+
+	var request model.WebhookInput
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.forUser(r).CreateWebhook(r.Context(), request)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to create webhook")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, webhook)
+}
+
+// GetWebhooks handles GET /api/webhooks
+func (h *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.forUser(r).GetWebhooks(r.Context())
+	if err != nil {
+		writeInternalError(w, r, err, "Failed to get webhooks")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, webhooks)
+}
+
+// GetWebhook handles GET /api/webhooks/{id}
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.forUser(r).GetWebhook(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to get webhook")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, webhook)
+}
+
+// UpdateWebhook handles PUT /api/webhooks/{id}
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+	if !requireJSON(w, r) {
+		return
+	}
+
+	var request model.WebhookInput
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.forUser(r).ReplaceWebhook(r.Context(), id, request)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to update webhook")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, webhook)
+}
+
+// DeleteWebhook handles DELETE /api/webhooks/{id}
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.forUser(r).DeleteWebhook(r.Context(), id); err != nil {
+		writeServiceError(w, r, err, "Failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDeliveries handles GET /api/webhooks/{id}/deliveries
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseWebhookID(w, r)
+	if !ok {
+		return
+	}
+	limit, err := parseLimit(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.forUser(r).GetDeliveries(r.Context(), id, limit)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to get deliveries")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// parseWebhookID reads the {id} path value, answering 400 when it is not a positive integer
+func parseWebhookID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"todo-app/internal/logging"
+	"todo-app/internal/model"
+	"todo-app/internal/service"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+const (
+	// wsSendQueue is how many replies may wait for the writer before the
+	// connection stops reading commands
+	wsSendQueue = 16
+
+	// wsWriteTimeout disconnects a client that stops reading its messages
+	wsWriteTimeout = 10 * time.Second
+
+	// wsPingInterval and wsPongTimeout detect connections that went away silently
+	wsPingInterval = 30 * time.Second
+	wsPongTimeout  = 10 * time.Second
+
+	// wsReadLimit is the largest command accepted, in bytes
+	wsReadLimit = 64 << 10
+)
+
+// WebSocketHandler serves todo commands and list subscriptions over a WebSocket
+type WebSocketHandler struct {
+	lists   *service.ListService
+	service *service.TodoService
+	clients atomic.Int64 // numbers the connections to tell their writes apart
+}
+
+// NewWebSocketHandler creates a new WebSocket handler
+func NewWebSocketHandler(lists *service.ListService, todos *service.TodoService) *WebSocketHandler {
+	return &WebSocketHandler{
+		lists:   lists,
+		service: todos,
+	}
+}
+
+// wsCommand is a message from a client. Every command is answered by an ack
+// or an error carrying its request_id.
+type wsCommand struct {
+	Type      string           `json:"type"` // subscribe, unsubscribe, create, update or delete
+	RequestID string           `json:"request_id"`
+	ListID    int              `json:"list_id"` // subscribe and unsubscribe
+	ID        int              `json:"id"`      // update and delete
+	Todo      *model.TodoInput `json:"todo"`    // create
+	Patch     *model.TodoPatch `json:"patch"`   // update
+}
+
+// wsMessage is a message to a client: an ack or error answering a command,
+// or an event of another client's change in a subscribed list
+type wsMessage struct {
+	Type      string           `json:"type"` // ack, error or event
+	RequestID string           `json:"request_id,omitempty"`
+	Todo      *model.Todo      `json:"todo,omitempty"`   // ack of create and update
+	Event     *model.TodoEvent `json:"event,omitempty"`  // event
+	Status    int              `json:"status,omitempty"` // error: the HTTP status the REST API would answer
+	Error     string           `json:"error,omitempty"`
+}
+
+// Serve handles GET /api/ws
+func (h *WebSocketHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	// The connection outlives the server's read and write timeouts, which
+	// stay set on it once hijacked; pings and wsWriteTimeout take their place
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return // Accept has answered the request
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(wsReadLimit)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	todos := inWorkspace(r, h.service).ForUser(userID(r))
+	origin := fmt.Sprintf("ws-%d", h.clients.Add(1))
+	client := &wsClient{
+		conn:       conn,
+		lists:      inWorkspace(r, h.lists).ForUser(userID(r)),
+		todos:      todos.WithOrigin(origin),
+		origin:     origin,
+		readOnly:   !allows(r, model.ScopeTodosWrite),
+		send:       make(chan wsMessage, wsSendQueue),
+		subscribed: make(map[int]bool),
+		logger:     logging.FromContext(r.Context()),
+	}
+
+	sub := todos.Subscribe(ctx, eventBuffer)
+	defer sub.Cancel()
+
+	go func() {
+		client.writeLoop(ctx, sub.Events())
+		cancel() // Stops the read loop too
+	}()
+	client.readLoop(ctx)
+}
+
+// wsClient is the state of one WebSocket connection
+type wsClient struct {
+	conn     *websocket.Conn
+	lists    *service.ListService // acts as the signed-in user
+	todos    *service.TodoService // writes as origin
+	origin   string
+	readOnly bool           // an API token without todos:write
+	send     chan wsMessage // replies for the writer
+	logger   *slog.Logger   // of the request that opened the connection
+
+	mu         sync.Mutex
+	subscribed map[int]bool // list IDs
+}
+
+// readLoop answers commands until the connection closes or ctx is done
+func (c *wsClient) readLoop(ctx context.Context) {
+	for {
+		typ, data, err := c.conn.Read(ctx)
+		if err != nil {
+			return
+		}
+
+		var reply wsMessage
+		var cmd wsCommand
+		switch {
+		case typ != websocket.MessageText:
+			reply = wsError("", http.StatusBadRequest, "Commands must be text messages")
+		case json.Unmarshal(data, &cmd) != nil:
+			reply = wsError("", http.StatusBadRequest, "Invalid JSON")
+		default:
+			reply = c.handle(ctx, cmd)
+		}
+
+		// Blocks while the client does not read its replies, so it cannot
+		// queue up unlimited work
+		select {
+		case c.send <- reply:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// handle runs one command through the services
+func (c *wsClient) handle(ctx context.Context, cmd wsCommand) wsMessage {
+	ack := wsMessage{Type: "ack", RequestID: cmd.RequestID}
+
+	if c.readOnly && (cmd.Type == "create" || cmd.Type == "update" || cmd.Type == "delete") {
+		return wsError(cmd.RequestID, http.StatusForbidden, "API token lacks the "+model.ScopeTodosWrite+" scope")
+	}
+
+	switch cmd.Type {
+	case "subscribe", "unsubscribe":
+		if cmd.ListID <= 0 {
+			return wsError(cmd.RequestID, http.StatusBadRequest, "list_id must be a list ID")
+		}
+		if cmd.Type == "subscribe" {
+			if _, err := c.lists.GetList(ctx, cmd.ListID); err != nil {
+				return c.serviceError(cmd.RequestID, err, "Failed to get list")
+			}
+		}
+		c.mu.Lock()
+		if cmd.Type == "subscribe" {
+			c.subscribed[cmd.ListID] = true
+		} else {
+			delete(c.subscribed, cmd.ListID)
+		}
+		c.mu.Unlock()
+		return ack
+
+	case "create":
+		if cmd.Todo == nil {
+			return wsError(cmd.RequestID, http.StatusBadRequest, "todo is required")
+		}
+		todo, err := c.todos.CreateTodo(ctx, *cmd.Todo)
+		if err != nil {
+			return c.serviceError(cmd.RequestID, err, "Failed to create todo")
+		}
+		ack.Todo = todo
+		return ack
+
+	case "update":
+		if cmd.ID <= 0 {
+			return wsError(cmd.RequestID, http.StatusBadRequest, "Invalid todo ID")
+		}
+		if cmd.Patch == nil || cmd.Patch.IsEmpty() {
+			return wsError(cmd.RequestID, http.StatusBadRequest, "No fields to update")
+		}
+		todo, err := c.todos.PatchTodo(ctx, cmd.ID, *cmd.Patch)
+		if err != nil {
+			return c.serviceError(cmd.RequestID, err, "Failed to update todo")
+		}
+		ack.Todo = todo
+		return ack
+
+	case "delete":
+		if cmd.ID <= 0 {
+			return wsError(cmd.RequestID, http.StatusBadRequest, "Invalid todo ID")
+		}
+		if err := c.todos.DeleteTodo(ctx, cmd.ID); err != nil {
+			return c.serviceError(cmd.RequestID, err, "Failed to delete todo")
+		}
+		return ack
+	}
+
+	return wsError(cmd.RequestID, http.StatusBadRequest, fmt.Sprintf("Unknown command type %q", cmd.Type))
+}
+
+// writeLoop sends replies, the events of subscribed lists and pings until
+// a write fails or ctx is done
+func (c *wsClient) writeLoop(ctx context.Context, events <-chan *model.TodoEvent) {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		var msg wsMessage
+		select {
+		case <-ctx.Done():
+			return
+
+		case msg = <-c.send:
+
+		case event, ok := <-events:
+			if !ok && c.todos.SubscriptionsClosed() {
+				c.conn.Close(websocket.StatusGoingAway, "server is shutting down; reconnect")
+				return
+			}
+			if !ok {
+				c.conn.Close(websocket.StatusTryAgainLater, "too slow to keep up with changes; reconnect")
+				return
+			}
+			if event.Origin == c.origin || !c.wants(event) {
+				continue
+			}
+			msg = wsMessage{Type: "event", Event: event}
+
+		case <-ping.C:
+			pingCtx, cancel := context.WithTimeout(ctx, wsPongTimeout)
+			err := c.conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		writeCtx, cancel := context.WithTimeout(ctx, wsWriteTimeout)
+		err := wsjson.Write(writeCtx, c.conn, msg)
+		cancel()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// wants reports whether event touches a subscribed list, including the list
+// an updated todo was moved out of
+func (c *wsClient) wants(event *model.TodoEvent) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.subscribed[event.Todo.ListID] || event.FromListID != 0 && c.subscribed[event.FromListID]
+}
+
+func wsError(requestID string, status int, message string) wsMessage {
+	return wsMessage{Type: "error", RequestID: requestID, Status: status, Error: message}
+}
+
+// serviceError maps a service error to the error reply of the command with
+// requestID, logging the unexpected ones
+func (c *wsClient) serviceError(requestID string, err error, fallback string) wsMessage {
+	if status, message, ok := contextErrorStatus(err); ok {
+		c.logger.Warn("Command failed", "error", err, "command_id", requestID)
+		return wsError(requestID, status, message)
+	}
+	status, message := serviceErrorStatus(err, fallback)
+	if status == http.StatusInternalServerError {
+		c.logger.Error(message, "error", err, "command_id", requestID)
+	}
+	return wsError(requestID, status, message)
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+)
+
+// WorkspaceHandler handles HTTP requests for the workspace of the signed-in user
+type WorkspaceHandler struct {
+	service *service.WorkspaceService
+}
+
+// NewWorkspaceHandler creates a new workspace handler
+func NewWorkspaceHandler(svc *service.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		service: svc,
+	}
+}
+
+// GetWorkspace handles GET /api/workspace
+func (h *WorkspaceHandler) GetWorkspace(w http.ResponseWriter, r *http.Request) {
+	user := UserFromContext(r.Context())
+	if user == nil {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	workspace, err := h.service.GetWorkspace(r.Context(), user.WorkspaceID)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to get workspace")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, workspace)
+}
+
+// workspaceKey is the context key of the storage of the signed-in user's workspace
+type workspaceKey struct{}
+
+// WithWorkspace returns a context carrying the storage of a workspace
+func WithWorkspace(ctx context.Context, repo repository.TodoRepository) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, repo)
+}
+
+// workspaced is a service that can be moved onto the storage of one workspace
+type workspaced[S any] interface {
+	ForWorkspace(repo repository.TodoRepository) S
+}
+
+// inWorkspace returns svc working in the workspace of the signed-in user of
+// r, or svc itself for requests made without an account
+func inWorkspace[S workspaced[S]](r *http.Request, svc S) S {
+	if repo, ok := r.Context().Value(workspaceKey{}).(repository.TodoRepository); ok {
+		return svc.ForWorkspace(repo)
+	}
+	return svc
+}
@@ -0,0 +1,54 @@
+// Package httputil holds the pieces the HTTP middlewares share.
+package httputil
+
+import "net/http"
+
+// ResponseRecorder remembers the status a handler answers with and counts
+// the bytes of the body. Unwrap lets http.ResponseController, and so event
+// streams and WebSockets, reach the flushing, hijacking and deadlines of the
+// underlying writer.
+type ResponseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// NewResponseRecorder returns a recorder passing what is written on to w
+func NewResponseRecorder(w http.ResponseWriter) *ResponseRecorder {
+	return &ResponseRecorder{ResponseWriter: w}
+}
+
+// Status returns the status the handler answered with, 200 when it wrote
+// nothing
+func (w *ResponseRecorder) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Bytes returns how many bytes of body the handler wrote
+func (w *ResponseRecorder) Bytes() int64 {
+	return w.bytes
+}
+
+func (w *ResponseRecorder) WriteHeader(code int) {
+	// 1xx responses other than 101 are informational and followed by the real status
+	if w.status == 0 && (code >= 200 || code == http.StatusSwitchingProtocols) {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *ResponseRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
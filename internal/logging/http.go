@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"todo-app/internal/httputil"
+)
+
+// RequestIDHeader carries the ID of a request: nginx sets it to $request_id
+// and the server echoes it, or the one it made up, in the response
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs taken from clients
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key of the ID of a request
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the ID of the request ctx belongs to, or ""
+// outside a request
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Middleware returns next serving each request with an ID, taken from the
+// X-Request-ID header when it holds a sensible one and made up otherwise,
+// and a logger tagged with it in the request's context. Once next is done it
+// logs the request with its status, the bytes of its body and how long it
+// took; server errors at warn level, everything else at info.
+func Middleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		requestLogger := logger.With("request_id", id)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		ctx = WithLogger(ctx, requestLogger)
+		rec := httputil.NewResponseRecorder(w)
+
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		status := rec.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelWarn
+		}
+		requestLogger.LogAttrs(ctx, level, "Request served",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int64("bytes", rec.Bytes()),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("remote_addr", r.RemoteAddr),
+		)
+	})
+}
+
+// validRequestID reports whether id may be logged as it is: not empty, not
+// too long, and only letters, digits and -_.:, so a client cannot forge log
+// lines or fields with it
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range []byte(id) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns 16 random bytes in hex, like nginx's $request_id
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b) // Never fails, see crypto/rand.Read
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,52 @@
+// Package logging sets up the structured logger of the server and carries
+// it through the context of each request, tagged with the request's ID, so
+// the handlers, services and repositories it reaches log with that ID.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Log formats, for LOG_FORMAT
+const (
+	FormatJSON = "json" // one JSON object per line, for the log pipeline
+	FormatText = "text" // key=value pairs, for reading in a terminal
+)
+
+// New returns a logger writing to w in format, json or text, that drops
+// the records below level: debug, info, warn or error
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: must be debug, info, warn or error", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("invalid log format %q: must be %s or %s", format, FormatJSON, FormatText)
+}
+
+// loggerKey is the context key of the logger of a request
+type loggerKey struct{}
+
+// WithLogger returns a context carrying logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger ctx carries, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
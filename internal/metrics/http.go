@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"todo-app/internal/httputil"
+)
+
+// unmatchedRoute labels the requests no route pattern matched
+const unmatchedRoute = "unmatched"
+
+// InstrumentHTTP returns next counting and timing every request by method,
+// the route pattern of mux it matches and status. Labelling by pattern
+// rather than path keeps one series per route, whatever the IDs in the URL.
+func (m *Metrics) InstrumentHTTP(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		route := routeOf(mux, r)
+		rec := httputil.NewResponseRecorder(w)
+
+		next.ServeHTTP(rec, r)
+
+		labels := []string{methodLabel(r.Method), route, strconv.Itoa(rec.Status())}
+		m.requests.Inc(labels...)
+		m.requestDuration.Observe(time.Since(start).Seconds(), labels...)
+	})
+}
+
+// routeOf returns the path of the mux pattern r matches, without its
+// method, such as /api/todos/{id}
+func routeOf(mux *http.ServeMux, r *http.Request) string {
+	_, pattern := mux.Handler(r)
+	if pattern == "" {
+		return unmatchedRoute
+	}
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
+
+// methodLabel keeps the label to the standard methods, so made-up ones
+// cannot add series
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"time"
+
+	"todo-app/internal/model"
+)
+
+// Bucket upper bounds, in seconds
+var (
+	// HTTPBuckets suit requests taking a few milliseconds up to the write timeout
+	HTTPBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+	// QueryBuckets suit repository calls, mostly well under a millisecond with SQLite
+	QueryBuckets = []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, 1}
+)
+
+// Metrics are what the server exports at GET /metrics
+type Metrics struct {
+	registry        *Registry
+	requests        *CounterVec
+	requestDuration *HistogramVec
+	queryDuration   *HistogramVec
+}
+
+// New creates the server's metrics
+func New() *Metrics {
+	r := NewRegistry()
+	return &Metrics{
+		registry: r,
+		requests: r.NewCounterVec("todoapp_http_requests_total",
+			"HTTP requests served, by method, route pattern and status.",
+			"method", "route", "status"),
+		requestDuration: r.NewHistogramVec("todoapp_http_request_duration_seconds",
+			"Time taken to serve HTTP requests, by method, route pattern and status. Event streams and WebSockets last as long as their client stays.",
+			HTTPBuckets, "method", "route", "status"),
+		queryDuration: r.NewHistogramVec("todoapp_repository_query_duration_seconds",
+			"Time taken by repository calls, by repository method.",
+			QueryBuckets, "method"),
+	}
+}
+
+// ObserveQuery records a repository call; it is a repository.QueryObserver
+func (m *Metrics) ObserveQuery(method string, took time.Duration) {
+	m.queryDuration.Observe(took.Seconds(), method)
+}
+
+// WatchPool exports the statistics of a database/sql connection pool, read
+// from stats at each scrape, under the names of the Prometheus Go client's
+// DBStatsCollector
+func (m *Metrics) WatchPool(stats func() sql.DBStats) {
+	gauge := func(name, help string, value func(sql.DBStats) float64) {
+		m.registry.NewGaugeFunc(name, help, func() float64 { return value(stats()) })
+	}
+	counter := func(name, help string, value func(sql.DBStats) float64) {
+		m.registry.NewCounterFunc(name, help, func() float64 { return value(stats()) })
+	}
+
+	gauge("go_sql_max_open_connections", "Maximum number of open connections to the database.",
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })
+	gauge("go_sql_open_connections", "The number of established connections both in use and idle.",
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) })
+	gauge("go_sql_in_use_connections", "The number of connections currently in use.",
+		func(s sql.DBStats) float64 { return float64(s.InUse) })
+	gauge("go_sql_idle_connections", "The number of idle connections.",
+		func(s sql.DBStats) float64 { return float64(s.Idle) })
+	counter("go_sql_wait_count_total", "The total number of connections waited for.",
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) })
+	counter("go_sql_wait_duration_seconds_total", "The total time blocked waiting for a new connection.",
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })
+	counter("go_sql_max_idle_closed_total", "The total number of connections closed due to SetMaxIdleConns.",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) })
+	counter("go_sql_max_idle_time_closed_total", "The total number of connections closed due to SetConnMaxIdleTime.",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) })
+	counter("go_sql_max_lifetime_closed_total", "The total number of connections closed due to SetConnMaxLifetime.",
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) })
+}
+
+// WatchTodos exports how many todos there are, and how many are open, as
+// count returns them at each scrape. When it fails the gauges keep their
+// last values.
+func (m *Metrics) WatchTodos(count func(ctx context.Context) (model.TodoCounts, error)) {
+	total := m.registry.NewGauge("todoapp_todos", "Todos in every workspace.")
+	open := m.registry.NewGauge("todoapp_todos_open", "Todos in every workspace that are not completed.")
+
+	m.registry.OnCollect(func() {
+		counts, err := count(context.Background()) // Bounded by the repository's query timeout
+		if err != nil {
+			slog.Error("Counting todos for the metrics failed", "error", err)
+			return
+		}
+		total.Set(float64(counts.Total))
+		open.Set(float64(counts.Open))
+	})
+}
+
+// WriteTo writes every metric to w in the Prometheus text format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	return m.registry.WriteTo(w)
+}
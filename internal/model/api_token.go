@@ -0,0 +1,41 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// API token scopes, each allowing a group of routes
+const (
+	ScopeTodosRead     = "todos:read"     // read todos, tags, lists and their events
+	ScopeTodosWrite    = "todos:write"    // create, change and delete todos, tags and lists
+	ScopeWebhooksRead  = "webhooks:read"  // read webhooks and their deliveries
+	ScopeWebhooksWrite = "webhooks:write" // create, replace and delete webhooks
+)
+
+// Scopes lists every API token scope in the order they are shown
+var Scopes = []string{ScopeTodosRead, ScopeTodosWrite, ScopeWebhooksRead, ScopeWebhooksWrite}
+
+// APIToken lets a script call the API as a user, limited to its scopes.
+// Like a session, the server only keeps a hash of the token.
+type APIToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"-"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Token      string     `json:"token,omitempty"` // Only shown when the token is created
+	TokenHash  string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"` // nil until first used
+}
+
+// HasScope reports whether the token allows scope
+func (t *APIToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+// APITokenInput holds the fields a client sends to create an API token
+type APITokenInput struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
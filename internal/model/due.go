@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the date-only format accepted for all-day due dates
+const DateLayout = "2006-01-02"
+
+// ParseDue parses an RFC3339 timestamp or a YYYY-MM-DD date. Timestamps are
+// converted to UTC; dates become midnight UTC and report allDay.
+func ParseDue(value string) (dueAt time.Time, allDay bool, err error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), false, nil
+	}
+	if t, err := time.Parse(DateLayout, value); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("%q is neither an RFC3339 timestamp nor a YYYY-MM-DD date", value)
+}
+
+// Optional distinguishes a JSON field that is absent from one explicitly set to null
+type Optional[T any] struct {
+	Set   bool // The field was present in the JSON
+	Value *T   // nil when the field was null
+}
+
+// UnmarshalJSON is only called for fields present in the input
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	o.Value = &v
+	return nil
+}
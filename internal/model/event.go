@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// TodoEventType names what happened to a todo
+type TodoEventType string
+
+// Todo event types
+const (
+	TodoCreated TodoEventType = "created"
+	TodoUpdated TodoEventType = "updated"
+	TodoDeleted TodoEventType = "deleted"
+)
+
+// TodoEvent is one entry of the todo change log. IDs only grow, so a client
+// that saw an event can resume from its ID.
+type TodoEvent struct {
+	ID          int64         `json:"id"`
+	WorkspaceID int           `json:"-"`
+	UserID      int           `json:"-"` // The owner of the todo
+	Type        TodoEventType `json:"type"`
+	TodoID      int           `json:"todo_id"`
+	Todo        *Todo         `json:"todo"` // The todo after the change, or as it was before being deleted
+	FromListID  int           `json:"-"`    // The list an update moved the todo out of, whose members still get the event
+	CreatedAt   time.Time     `json:"created_at"`
+
+	// Only set on events published live, not kept in the change log
+	Origin string `json:"-"` // TodoService.WithOrigin of the writer
+}
@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+// FilterOp is the kind of a FilterExpr node
+type FilterOp int
+
+// Filter expression kinds
+const (
+	FilterCompare FilterOp = iota // Field Cmp value
+	FilterAnd                     // Left and Right both match
+	FilterOr                      // Left or Right matches
+	FilterNot                     // Left does not match
+)
+
+// Fields a filter expression can compare
+const (
+	FieldTag       = "tag"
+	FieldText      = "text"
+	FieldList      = "list"
+	FieldPriority  = "priority"
+	FieldDue       = "due"
+	FieldCreated   = "created"
+	FieldUpdated   = "updated"
+	FieldCompleted = "completed"
+	FieldOverdue   = "overdue"
+)
+
+// FilterCmp is a comparison operator of a filter expression
+type FilterCmp string
+
+// Filter comparison operators
+const (
+	CmpEq FilterCmp = "="
+	CmpNe FilterCmp = "!="
+	CmpLt FilterCmp = "<"
+	CmpLe FilterCmp = "<="
+	CmpGt FilterCmp = ">"
+	CmpGe FilterCmp = ">="
+)
+
+// FilterExpr is a parsed ?filter= expression. Comparisons never match a
+// missing due date except due:none, so NOT always selects exactly the todos
+// its operand does not; the parser writes != as NOT of =.
+type FilterExpr struct {
+	Op          FilterOp
+	Left, Right *FilterExpr // FilterAnd and FilterOr use both, FilterNot only Left
+
+	// FilterCompare only; the value field depends on Field
+	Field string
+	Cmp   FilterCmp
+	Text  string     // tag (normalized) and text (matched as a case-insensitive substring)
+	Int   int        // list ID and priority rank
+	Time  *time.Time // due, created and updated in UTC; nil for due:none
+	Bool  bool       // completed and overdue
+}
+
+// Compare reports whether c, the result of comparing a value to the
+// expression's one (-1, 0 or +1), satisfies cmp
+func (cmp FilterCmp) Compare(c int) bool {
+	switch cmp {
+	case CmpEq:
+		return c == 0
+	case CmpNe:
+		return c != 0
+	case CmpLt:
+		return c < 0
+	case CmpLe:
+		return c <= 0
+	case CmpGt:
+		return c > 0
+	case CmpGe:
+		return c >= 0
+	}
+	return false
+}
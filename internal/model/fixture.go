@@ -0,0 +1,36 @@
+package model
+
+// Fixtures are the users, lists and todos the test endpoints seed, each
+// list and todo owned by a user named by username
+type Fixtures struct {
+	Users []Credentials `json:"users"`
+	Lists []FixtureList `json:"lists"`
+	Todos []FixtureTodo `json:"todos"`
+}
+
+// FixtureList is a list to seed
+type FixtureList struct {
+	Owner string `json:"owner"`
+	Name  string `json:"name"`
+}
+
+// FixtureTodo is a todo to seed; List names one of the owner's lists and
+// takes the place of ListID
+type FixtureTodo struct {
+	Owner string `json:"owner"`
+	List  string `json:"list"`
+	TodoInput
+}
+
+// SeededUser is a seeded user with a session to sign in with
+type SeededUser struct {
+	*User
+	Session string `json:"session"`
+}
+
+// Seeded is what seeding the fixtures created
+type Seeded struct {
+	Users []SeededUser `json:"users"`
+	Lists []*List      `json:"lists"`
+	Todos []*Todo      `json:"todos"`
+}
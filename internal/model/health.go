@@ -0,0 +1,23 @@
+package model
+
+// Readiness statuses
+const (
+	ReadinessReady       = "ready"
+	ReadinessUnavailable = "unavailable"
+)
+
+// Readiness tells whether the server can take requests, with the outcome of
+// each check
+type Readiness struct {
+	Status        string            `json:"status"`                   // ReadinessReady or ReadinessUnavailable
+	Checks        map[string]string `json:"checks"`                   // check name -> "ok" or what is wrong
+	SchemaVersion int               `json:"schema_version,omitempty"` // highest migration applied to the database
+}
+
+// BuildInfo describes the running binary
+type BuildInfo struct {
+	Commit        string `json:"commit"`
+	BuildTime     string `json:"build_time"`
+	GoVersion     string `json:"go_version"`
+	SchemaVersion int    `json:"schema_version"` // highest migration the binary has for its database; 0 without one
+}
@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// List groups todos; every todo belongs to exactly one list
+type List struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"-"` // The owner; the inbox has none, every user sees their own todos in it
+	Name      string    `json:"name"`
+	Inbox     bool      `json:"inbox"` // The default list; it cannot be deleted or shared
+	Role      ListRole  `json:"role"`  // What the user reading the list may do with it
+	TodoCount int       `json:"todo_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListInput holds the fields a client sends to create or rename a list
+type ListInput struct {
+	Name string `json:"name"`
+}
+
+// ListRole is what a user may do with a list and its todos
+type ListRole string
+
+// List roles, from most to least allowed
+const (
+	ListOwner  ListRole = "owner"  // everything, including renaming, deleting and sharing the list
+	ListEditor ListRole = "editor" // read, add, change and delete its todos
+	ListViewer ListRole = "viewer" // read its todos
+)
+
+// CanEdit reports whether the role may add, change and delete todos
+func (r ListRole) CanEdit() bool {
+	return r == ListOwner || r == ListEditor
+}
+
+// ListMember is a user a list is shared with, or who is invited to it until
+// they accept
+type ListMember struct {
+	ListID    int       `json:"list_id"`
+	ListName  string    `json:"list_name"`
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username"`
+	Role      ListRole  `json:"role"`
+	Accepted  bool      `json:"accepted"` // false while the invitation is pending
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListMemberInput holds the fields a client sends to invite a user to a list
+// or change their role
+type ListMemberInput struct {
+	Username string `json:"username"` // only when inviting
+	Role     string `json:"role"`
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Page sizes accepted by paginated listings
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// ErrInvalidCursor is returned when a cursor string cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a position in a sorted listing: the sort field values and ID
+// of the todo the next page starts after. Clients only see it encoded.
+type Cursor struct {
+	Sort      string     `json:"s"`           // FormatSort of the listing the cursor belongs to
+	Backward  bool       `json:"b,omitempty"` // page towards the start of the listing
+	ID        int        `json:"i"`
+	Priority  Priority   `json:"p,omitempty"`
+	DueAt     *time.Time `json:"d,omitempty"` // nil is a todo without due date when sorting on due_at
+	CreatedAt *time.Time `json:"c,omitempty"`
+	UpdatedAt *time.Time `json:"u,omitempty"`
+	Text      *string    `json:"t,omitempty"`
+}
+
+// NewCursor returns the position of todo in a listing ordered by keys
+func NewCursor(todo *Todo, keys []SortKey, backward bool) *Cursor {
+	c := &Cursor{Sort: FormatSort(keys), Backward: backward, ID: todo.ID}
+	for _, key := range keys {
+		switch key.Field {
+		case SortPriority:
+			c.Priority = todo.Priority
+		case SortDueAt:
+			c.DueAt = todo.DueAt
+		case SortCreatedAt:
+			createdAt := todo.CreatedAt
+			c.CreatedAt = &createdAt
+		case SortUpdatedAt:
+			updatedAt := todo.UpdatedAt
+			c.UpdatedAt = &updatedAt
+		case SortText:
+			text := todo.Text
+			c.Text = &text
+		}
+	}
+	return c
+}
+
+// Position returns a todo holding the cursor's sort field values
+func (c *Cursor) Position() *Todo {
+	todo := &Todo{ID: c.ID, Priority: c.Priority, DueAt: c.DueAt}
+	if c.CreatedAt != nil {
+		todo.CreatedAt = *c.CreatedAt
+	}
+	if c.UpdatedAt != nil {
+		todo.UpdatedAt = *c.UpdatedAt
+	}
+	if c.Text != nil {
+		todo.Text = *c.Text
+	}
+	return todo
+}
+
+// Encode returns the opaque URL-safe form of the cursor
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor produced by Encode
+func DecodeCursor(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	if _, err := ParseSort(c.Sort); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// TodoPage is one page of a paginated todo listing
+type TodoPage struct {
+	Todos      []*Todo `json:"data"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	PrevCursor string  `json:"prev_cursor,omitempty"`
+}
@@ -0,0 +1,296 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInvalidSearch is wrapped by every ParseSearch error
+var ErrInvalidSearch = errors.New("invalid search query")
+
+// SearchOp is the kind of a SearchExpr node
+type SearchOp int
+
+// Search expression kinds
+const (
+	SearchMatch SearchOp = iota // Words appear next to each other
+	SearchAnd                   // Left and Right both match
+	SearchOr                    // Left or Right matches
+	SearchNot                   // Left matches and Right does not
+)
+
+// SearchExpr is a parsed full-text search query
+type SearchExpr struct {
+	Op          SearchOp
+	Words       []string // SearchMatch: one word, or the words of a phrase in order
+	Prefix      bool     // SearchMatch: the last word also matches longer words starting with it
+	Left, Right *SearchExpr
+}
+
+// SearchResult is a todo matched by a search with its highlighted snippet
+type SearchResult struct {
+	*Todo
+	Snippet string  `json:"snippet"` // HTML-escaped excerpt of the text, matches wrapped in <mark>
+	Rank    float64 `json:"rank"`    // relevance; higher is better, only comparable within one search
+}
+
+// SearchToken is one word of a text with its byte offsets
+type SearchToken struct {
+	Word       string // lower-cased
+	Start, End int
+}
+
+// TokenizeSearch splits text into lower-cased runs of letters and digits, the
+// words a search matches against
+func TokenizeSearch(text string) []SearchToken {
+	var tokens []SearchToken
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			tokens = append(tokens, SearchToken{Word: strings.ToLower(text[start:i]), Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, SearchToken{Word: strings.ToLower(text[start:]), Start: start, End: len(text)})
+	}
+	return tokens
+}
+
+// ParseSearch parses a query in the FTS5 style: words, "quoted phrases",
+// a trailing * for prefixes, AND, OR, NOT and parentheses. Adjacent terms
+// are AND-ed; NOT binds tighter than AND, which binds tighter than OR.
+// Errors report the 1-based character position of the offending token.
+func ParseSearch(query string) (*SearchExpr, error) {
+	tokens, err := lexSearch(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: query is empty", ErrInvalidSearch)
+	}
+
+	p := &searchParser{tokens: tokens, end: utf8.RuneCountInString(query) + 1}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok != nil {
+		return nil, p.errorAt(tok, "unexpected %s", tok)
+	}
+	return expr, nil
+}
+
+type searchTokenKind int
+
+const (
+	searchTerm searchTokenKind = iota
+	searchAndOp
+	searchOrOp
+	searchNotOp
+	searchOpen
+	searchClose
+)
+
+// searchLexeme is one token of a search query
+type searchLexeme struct {
+	kind   searchTokenKind
+	text   string
+	words  []string // searchTerm only
+	prefix bool     // searchTerm only
+	pos    int      // 1-based character position
+}
+
+func (t *searchLexeme) String() string {
+	if t.kind == searchTerm {
+		return fmt.Sprintf("%q", t.text)
+	}
+	return t.text
+}
+
+// lexSearch splits query into terms, operators and parentheses
+func lexSearch(query string) ([]*searchLexeme, error) {
+	var tokens []*searchLexeme
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(' || r == ')':
+			kind := searchOpen
+			if r == ')' {
+				kind = searchClose
+			}
+			tokens = append(tokens, &searchLexeme{kind: kind, text: string(r), pos: i + 1})
+			i++
+
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("%w: unterminated phrase at position %d", ErrInvalidSearch, i+1)
+			}
+			tok := &searchLexeme{kind: searchTerm, text: string(runes[i : end+1]), pos: i + 1}
+			i = end + 1
+			if i < len(runes) && runes[i] == '*' {
+				tok.prefix = true
+				i++
+			}
+			tokens = append(tokens, tok)
+
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(`()"`, runes[end]) {
+				end++
+			}
+			text := string(runes[i:end])
+			tok := &searchLexeme{kind: searchTerm, text: text, pos: i + 1}
+			switch text {
+			case "AND":
+				tok.kind = searchAndOp
+			case "OR":
+				tok.kind = searchOrOp
+			case "NOT":
+				tok.kind = searchNotOp
+			default:
+				if strings.HasSuffix(text, "*") {
+					tok.prefix = true
+					text = text[:len(text)-1]
+				}
+				if strings.Contains(text, "*") {
+					return nil, fmt.Errorf("%w: * must end a term at position %d", ErrInvalidSearch, i+1)
+				}
+			}
+			tokens = append(tokens, tok)
+			i = end
+		}
+	}
+
+	for _, tok := range tokens {
+		if tok.kind != searchTerm {
+			continue
+		}
+		for _, t := range TokenizeSearch(tok.text) {
+			tok.words = append(tok.words, t.Word)
+		}
+		if len(tok.words) == 0 {
+			return nil, fmt.Errorf("%w: %s has no words to search for at position %d", ErrInvalidSearch, tok, tok.pos)
+		}
+	}
+	return tokens, nil
+}
+
+// searchParser is a recursive descent parser over the tokens of a query
+type searchParser struct {
+	tokens []*searchLexeme
+	next   int
+	end    int // position reported for a query that ends too early
+}
+
+func (p *searchParser) peek() *searchLexeme {
+	if p.next < len(p.tokens) {
+		return p.tokens[p.next]
+	}
+	return nil
+}
+
+func (p *searchParser) errorAt(tok *searchLexeme, format string, args ...any) error {
+	pos := p.end
+	if tok != nil {
+		pos = tok.pos
+	}
+	return fmt.Errorf("%w: %s at position %d", ErrInvalidSearch, fmt.Sprintf(format, args...), pos)
+}
+
+// parseOr parses and ("OR" and)*
+func (p *searchParser) parseOr() (*SearchExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok != nil && tok.kind == searchOrOp; tok = p.peek() {
+		p.next++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &SearchExpr{Op: SearchOr, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// parseAnd parses not (["AND"] not)*
+func (p *searchParser) parseAnd() (*SearchExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok == nil || tok.kind == searchOrOp || tok.kind == searchClose {
+			return left, nil
+		}
+		if tok.kind == searchAndOp {
+			p.next++
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &SearchExpr{Op: SearchAnd, Left: left, Right: right}
+	}
+}
+
+// parseNot parses primary ("NOT" primary)*
+func (p *searchParser) parseNot() (*SearchExpr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for tok := p.peek(); tok != nil && tok.kind == searchNotOp; tok = p.peek() {
+		p.next++
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		left = &SearchExpr{Op: SearchNot, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// parsePrimary parses a term or a parenthesized query
+func (p *searchParser) parsePrimary() (*SearchExpr, error) {
+	tok := p.peek()
+	switch {
+	case tok == nil:
+		return nil, p.errorAt(nil, "expected a term")
+	case tok.kind == searchTerm:
+		p.next++
+		return &SearchExpr{Op: SearchMatch, Words: tok.words, Prefix: tok.prefix}, nil
+	case tok.kind == searchOpen:
+		p.next++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		// parseOr only stops at the end of the query or at a )
+		if p.peek() == nil {
+			return nil, fmt.Errorf("%w: unclosed ( at position %d", ErrInvalidSearch, tok.pos)
+		}
+		p.next++
+		return expr, nil
+	}
+	return nil, p.errorAt(tok, "expected a term, got %s", tok)
+}
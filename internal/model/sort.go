@@ -0,0 +1,77 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sort fields accepted by ParseSort
+const (
+	SortPriority  = "priority"
+	SortDueAt     = "due_at"
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+	SortText      = "text"
+)
+
+var sortFields = []string{SortPriority, SortDueAt, SortCreatedAt, SortUpdatedAt, SortText}
+
+// SortKey orders a listing by one field
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// DefaultSort lists the newest todos first
+var DefaultSort = []SortKey{{Field: SortCreatedAt, Desc: true}}
+
+// FormatSort renders keys in the canonical form accepted by ParseSort
+func FormatSort(keys []SortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		direction := "asc"
+		if key.Desc {
+			direction = "desc"
+		}
+		parts[i] = key.Field + ":" + direction
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseSort parses a comma separated list of field[:asc|:desc] keys,
+// e.g. "priority:desc,due_at". Fields default to ascending order.
+func ParseSort(value string) ([]SortKey, error) {
+	var keys []SortKey
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(value, ",") {
+		field, direction, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if field == "" {
+			return nil, fmt.Errorf("empty sort key in %q", value)
+		}
+
+		known := false
+		for _, f := range sortFields {
+			known = known || f == field
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown sort key %q (want one of %s)", field, strings.Join(sortFields, ", "))
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("sort key %q given more than once", field)
+		}
+		seen[field] = true
+
+		key := SortKey{Field: field}
+		switch direction {
+		case "", "asc":
+		case "desc":
+			key.Desc = true
+		default:
+			return nil, fmt.Errorf("unknown sort direction %q for %q (want asc or desc)", direction, field)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
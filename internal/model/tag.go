@@ -0,0 +1,49 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTagLength is the longest tag name accepted, in characters
+const MaxTagLength = 50
+
+// Tag is a label shared by any number of todos of one user
+type Tag struct {
+	ID        int    `json:"id"`
+	UserID    int    `json:"-"`
+	Name      string `json:"name"`
+	TodoCount int    `json:"todo_count"`
+}
+
+// NormalizeTag trims and lower-cases a tag name, rejecting empty and over-long names
+func NormalizeTag(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", fmt.Errorf("tag name cannot be empty")
+	}
+	if utf8.RuneCountInString(name) > MaxTagLength {
+		return "", fmt.Errorf("tag %q is longer than %d characters", name, MaxTagLength)
+	}
+	return name, nil
+}
+
+// NormalizeTags normalizes every name and returns them sorted without duplicates
+func NormalizeTags(names []string) ([]string, error) {
+	tags := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		tag, err := NormalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
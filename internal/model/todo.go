@@ -4,8 +4,85 @@ import "time"
 
 // Todo represents a todo item
 type Todo struct {
-	ID        int       `json:"id"`
-	Text      string    `json:"text"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-} 
\ No newline at end of file
+	ID          int        `json:"id"`
+	UserID      int        `json:"-"` // The owner; 0 for todos made before accounts existed
+	Text        string     `json:"text"`
+	ListID      int        `json:"list_id"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at"`
+	Priority    Priority   `json:"priority"`
+	DueAt       *time.Time `json:"due_at"`      // Always UTC; midnight of the due day when DueAllDay
+	DueAllDay   bool       `json:"due_all_day"` // Due some time on the date of DueAt rather than at an instant
+	Tags        []string   `json:"tags"`        // Normalized names, sorted; never nil when read from a repository
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TodoCounts is how many todos there are, and how many of them are open
+type TodoCounts struct {
+	Total int
+	Open  int // not completed
+}
+
+// TodoInput holds the fields a client sends to create or replace a todo
+type TodoInput struct {
+	Text      string   `json:"text"`
+	ListID    int      `json:"list_id"` // 0 creates the todo in the inbox, or keeps its list on PUT
+	Completed bool     `json:"completed"`
+	Priority  string   `json:"priority"` // none, low, medium, high or urgent; empty for none
+	DueAt     string   `json:"due_at"`   // RFC3339 timestamp or YYYY-MM-DD date, empty for none
+	Tags      []string `json:"tags"`
+}
+
+// TodoPatch holds a partial update for a todo; unset fields are left unchanged
+type TodoPatch struct {
+	Text      *string          `json:"text"`
+	ListID    *int             `json:"list_id"` // moves the todo to another list
+	Completed *bool            `json:"completed"`
+	Priority  *string          `json:"priority"`
+	DueAt     Optional[string] `json:"due_at"` // null clears the due date
+	Tags      *[]string        `json:"tags"`   // replaces the whole set
+}
+
+// IsEmpty reports whether the patch changes nothing
+func (p TodoPatch) IsEmpty() bool {
+	return p.Text == nil && p.ListID == nil && p.Completed == nil && p.Priority == nil && !p.DueAt.Set && p.Tags == nil
+}
+
+// TodoFilter narrows and orders the todos returned by a listing; zero values
+// match every todo UserID can see in DefaultSort order
+type TodoFilter struct {
+	UserID    int // only the todos this user can see: their own in the inbox, all in lists they own or joined
+	ListID    int // 0 matches every list
+	Completed *bool
+	DueBefore *time.Time  // due_at < DueBefore
+	DueAfter  *time.Time  // due_at >= DueAfter
+	Overdue   bool        // not completed and past due as of Now
+	Now       time.Time   // reference time for Overdue
+	Tags      []string    // normalized tag names to match
+	AnyTag    bool        // match todos with any of Tags instead of all of them
+	Where     *FilterExpr // ?filter= expression, AND-ed with the fields above
+	Sort      []SortKey   // ties are broken on id in the direction of the last key
+	Cursor    *Cursor     // only todos after (or before, when Backward) this position
+	Limit     int         // at most this many todos; 0 for all of them
+}
+
+// SortKeys returns the sort order of the filter, DefaultSort when none is set
+func (f TodoFilter) SortKeys() []SortKey {
+	if len(f.Sort) == 0 {
+		return DefaultSort
+	}
+	return f.Sort
+}
+
+// IsOverdue reports whether todo is not completed and past due at now.
+// An all-day todo becomes overdue once its whole day has passed.
+func (t *Todo) IsOverdue(now time.Time) bool {
+	if t.Completed || t.DueAt == nil {
+		return false
+	}
+	if t.DueAllDay {
+		return !now.Before(t.DueAt.Add(24 * time.Hour))
+	}
+	return t.DueAt.Before(now)
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// User is an account that owns todos
+type User struct {
+	ID           int       `json:"id"`
+	WorkspaceID  int       `json:"workspace_id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Credentials holds the fields a client sends to register or sign in
+type Credentials struct {
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	Workspace string `json:"workspace,omitempty"` // The slug of the workspace to join when registering; the default one when empty
+}
+
+// Session is a signed-in browser. The token itself is only known to the
+// client; the server keeps its hash.
+type Session struct {
+	TokenHash string
+	UserID    int
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook is an HTTP endpoint notified of todo changes
+type Webhook struct {
+	ID         int             `json:"id"`
+	UserID     int             `json:"-"` // Only the changes to this user's todos are sent
+	URL        string          `json:"url"`
+	Secret     string          `json:"secret,omitempty"` // Signs the payloads; only shown when the webhook is created
+	EventTypes []TodoEventType `json:"event_types"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// WebhookInput holds the fields a client sends to create or replace a webhook
+type WebhookInput struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`      // empty generates one on create and keeps the current one on PUT
+	EventTypes []string `json:"event_types"` // empty subscribes to every type
+}
+
+// DeliveryStatus is the state of a webhook delivery
+type DeliveryStatus string
+
+// Webhook delivery states
+const (
+	DeliveryPending   DeliveryStatus = "pending"   // waiting for its first or next attempt
+	DeliveryDelivered DeliveryStatus = "delivered" // the endpoint answered 2xx
+	DeliveryFailed    DeliveryStatus = "failed"    // every attempt failed; no more retries
+)
+
+// WebhookDelivery is one event queued for one webhook, with its attempts
+type WebhookDelivery struct {
+	ID            int64              `json:"id"`
+	WebhookID     int                `json:"webhook_id"`
+	EventID       int64              `json:"event_id"`
+	EventType     TodoEventType      `json:"event_type"`
+	Payload       json.RawMessage    `json:"payload"` // The request body, fixed when the event happened
+	Status        DeliveryStatus     `json:"status"`
+	NextAttemptAt *time.Time         `json:"next_attempt_at"` // nil once delivered or failed
+	Attempts      []*DeliveryAttempt `json:"attempts"`        // oldest first
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+// DeliveryAttempt is one try at sending a delivery
+type DeliveryAttempt struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  int       `json:"status_code,omitempty"` // 0 when no response arrived
+	Error       string    `json:"error,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+}
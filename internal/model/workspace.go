@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// DefaultWorkspaceID is the workspace of everything made before workspaces
+// existed, and of users who register without naming one
+const DefaultWorkspaceID = 1
+
+// Workspace is a tenant: its users see and share only its todos, tags,
+// lists and webhooks
+type Workspace struct {
+	ID        int               `json:"id"`
+	Slug      string            `json:"slug"` // Unique, named by users when they register
+	Name      string            `json:"name"`
+	Settings  WorkspaceSettings `json:"settings"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// WorkspaceSettings switches features on or off for a whole workspace
+type WorkspaceSettings struct {
+	Sharing      bool `json:"sharing"`      // users may share lists with each other
+	Webhooks     bool `json:"webhooks"`     // users may create webhooks
+	Registration bool `json:"registration"` // anyone may register into it by its slug
+}
+
+// DefaultWorkspaceSettings are the settings of the workspace with the given
+// ID nobody changed. Only the default workspace takes registrations.
+func DefaultWorkspaceSettings(workspaceID int) WorkspaceSettings {
+	return WorkspaceSettings{Sharing: true, Webhooks: true, Registration: workspaceID == DefaultWorkspaceID}
+}
+
+// WorkspaceInput holds the fields given to create a workspace
+type WorkspaceInput struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// WorkspaceSettingsPatch holds the settings to change; nil fields stay as they are
+type WorkspaceSettingsPatch struct {
+	Sharing      *bool `json:"sharing"`
+	Webhooks     *bool `json:"webhooks"`
+	Registration *bool `json:"registration"`
+}
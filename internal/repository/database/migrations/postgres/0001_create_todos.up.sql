@@ -0,0 +1,12 @@
+-- Create todos table
+CREATE TABLE todos (
+    id SERIAL PRIMARY KEY,
+    text TEXT NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create index on created_at for performance
+CREATE INDEX idx_todos_created_at ON todos(created_at);
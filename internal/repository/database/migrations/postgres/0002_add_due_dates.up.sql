@@ -0,0 +1,5 @@
+-- Optional due date; all-day todos store midnight UTC of the due day
+ALTER TABLE todos ADD COLUMN due_at TIMESTAMPTZ;
+ALTER TABLE todos ADD COLUMN due_all_day BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_todos_due_at ON todos(due_at);
@@ -0,0 +1,18 @@
+-- Lists own todos; the single inbox list holds todos created without a list
+CREATE TABLE lists (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    is_inbox BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_lists_inbox ON lists(is_inbox) WHERE is_inbox;
+
+INSERT INTO lists (name, is_inbox, created_at, updated_at) VALUES ('Inbox', TRUE, NOW(), NOW());
+
+ALTER TABLE todos ADD COLUMN list_id INTEGER REFERENCES lists(id);
+UPDATE todos SET list_id = (SELECT id FROM lists WHERE is_inbox);
+ALTER TABLE todos ALTER COLUMN list_id SET NOT NULL;
+
+CREATE INDEX idx_todos_list_id ON todos(list_id);
@@ -0,0 +1,2 @@
+-- Speeds up full-text search over todo text
+CREATE INDEX idx_todos_text_search ON todos USING GIN (to_tsvector('simple', text));
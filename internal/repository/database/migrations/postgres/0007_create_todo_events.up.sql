@@ -0,0 +1,8 @@
+-- Change log of todo writes; clients resume a stream after the last event ID they saw
+CREATE TABLE todo_events (
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    todo_id INTEGER NOT NULL,
+    payload TEXT,
+    created_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,16 @@
+-- Fails when two users have a tag of the same name; merge or rename them first
+ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_user_id_name_key;
+ALTER TABLE tags ADD CONSTRAINT tags_name_key UNIQUE (name);
+ALTER TABLE tags DROP COLUMN IF EXISTS user_id;
+
+DROP INDEX IF EXISTS idx_webhooks_user_id;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS user_id;
+
+DROP INDEX IF EXISTS idx_todo_events_user_id;
+ALTER TABLE todo_events DROP COLUMN IF EXISTS user_id;
+
+DROP INDEX IF EXISTS idx_todos_user_id;
+ALTER TABLE todos DROP COLUMN IF EXISTS user_id;
+
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,32 @@
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    username TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+-- Only a hash of each session token is stored, so reading the table does not sign anyone in
+CREATE TABLE sessions (
+    token_hash TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_sessions_expires_at ON sessions(expires_at);
+
+-- Todos, tags, events and webhooks belong to a user. 0 stands for rows made
+-- before accounts existed; the first user to register adopts them.
+ALTER TABLE todos ADD COLUMN user_id INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX idx_todos_user_id ON todos(user_id);
+
+ALTER TABLE todo_events ADD COLUMN user_id INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX idx_todo_events_user_id ON todo_events(user_id, id);
+
+ALTER TABLE webhooks ADD COLUMN user_id INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX idx_webhooks_user_id ON webhooks(user_id);
+
+-- Tag names become unique per user
+ALTER TABLE tags ADD COLUMN user_id INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE tags DROP CONSTRAINT tags_name_key;
+ALTER TABLE tags ADD CONSTRAINT tags_user_id_name_key UNIQUE (user_id, name);
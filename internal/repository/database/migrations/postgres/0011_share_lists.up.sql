@@ -0,0 +1,29 @@
+-- Lists belong to the user who made them. The inbox keeps user 0: every user
+-- has one, seeing only their own todos in it.
+ALTER TABLE lists ADD COLUMN user_id INTEGER NOT NULL DEFAULT 0;
+
+-- The users a list is shared with besides its owner. An invitation is a
+-- member who has not accepted yet.
+CREATE TABLE list_members (
+    list_id INTEGER NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL, -- editor or viewer
+    accepted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX idx_list_members_user_id ON list_members(user_id);
+
+-- Lists were shared by everyone until now: the first user owns them, and
+-- everyone else with todos in one becomes its editor
+UPDATE lists SET user_id = COALESCE((SELECT MIN(id) FROM users), 0) WHERE NOT is_inbox;
+
+INSERT INTO list_members (list_id, user_id, role, accepted, created_at)
+SELECT DISTINCT t.list_id, t.user_id, 'editor', TRUE, NOW()
+FROM todos t JOIN lists l ON l.id = t.list_id
+WHERE NOT l.is_inbox AND t.user_id <> l.user_id AND t.user_id <> 0;
+
+-- Events remember the list of their todo, so that its members can read them
+ALTER TABLE todo_events ADD COLUMN list_id INTEGER;
+UPDATE todo_events SET list_id = (SELECT list_id FROM todos WHERE todos.id = todo_events.todo_id);
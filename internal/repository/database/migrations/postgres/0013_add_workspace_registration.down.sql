@@ -0,0 +1 @@
+ALTER TABLE workspace_settings DROP COLUMN IF EXISTS registration;
@@ -0,0 +1 @@
+ALTER TABLE todo_events DROP COLUMN IF EXISTS from_list_id;
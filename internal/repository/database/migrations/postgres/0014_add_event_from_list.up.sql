@@ -0,0 +1,3 @@
+-- Updates remember the list they moved their todo out of, so that its
+-- members resuming the event stream still read them
+ALTER TABLE todo_events ADD COLUMN from_list_id INTEGER;
@@ -1,10 +1,12 @@
 -- Create todos table
-CREATE TABLE IF NOT EXISTS todos (
+CREATE TABLE todos (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     text TEXT NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT 0,
+    completed_at DATETIME,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
 
 -- Create index on created_at for performance
-CREATE INDEX IF NOT EXISTS idx_todos_created_at ON todos(created_at); 
\ No newline at end of file
+CREATE INDEX idx_todos_created_at ON todos(created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_todos_due_at;
+ALTER TABLE todos DROP COLUMN due_all_day;
+ALTER TABLE todos DROP COLUMN due_at;
@@ -0,0 +1,5 @@
+-- Optional due date, stored in UTC; all-day todos store midnight of the due day
+ALTER TABLE todos ADD COLUMN due_at DATETIME;
+ALTER TABLE todos ADD COLUMN due_all_day BOOLEAN NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_todos_due_at ON todos(due_at);
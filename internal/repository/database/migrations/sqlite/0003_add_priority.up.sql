@@ -0,0 +1,4 @@
+-- Priority rank: 0 none, 1 low, 2 medium, 3 high, 4 urgent
+ALTER TABLE todos ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_todos_priority ON todos(priority);
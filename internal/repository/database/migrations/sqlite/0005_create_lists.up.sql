@@ -0,0 +1,20 @@
+-- Lists own todos; the single inbox list holds todos created without a list
+CREATE TABLE lists (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    is_inbox BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_lists_inbox ON lists(is_inbox) WHERE is_inbox;
+
+INSERT INTO lists (name, is_inbox, created_at, updated_at)
+VALUES ('Inbox', 1, strftime('%Y-%m-%d %H:%M:%f', 'now'), strftime('%Y-%m-%d %H:%M:%f', 'now'));
+
+-- Without NOT NULL or REFERENCES so that the down migration can drop the column again;
+-- the repository always sets it to an existing list
+ALTER TABLE todos ADD COLUMN list_id INTEGER;
+UPDATE todos SET list_id = (SELECT id FROM lists WHERE is_inbox);
+
+CREATE INDEX idx_todos_list_id ON todos(list_id);
@@ -0,0 +1,9 @@
+-- Change log of todo writes; AUTOINCREMENT never reuses an ID, so clients can
+-- resume a stream after the last event they saw
+CREATE TABLE todo_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    type TEXT NOT NULL,
+    todo_id INTEGER NOT NULL,
+    payload TEXT,
+    created_at DATETIME NOT NULL
+);
@@ -0,0 +1,21 @@
+-- Fails when two users have a tag of the same name; merge or rename them first
+CREATE TABLE tags_by_name (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE
+);
+
+INSERT INTO tags_by_name (id, name) SELECT id, name FROM tags;
+DROP TABLE tags;
+ALTER TABLE tags_by_name RENAME TO tags;
+
+DROP INDEX IF EXISTS idx_webhooks_user_id;
+ALTER TABLE webhooks DROP COLUMN user_id;
+
+DROP INDEX IF EXISTS idx_todo_events_user_id;
+ALTER TABLE todo_events DROP COLUMN user_id;
+
+DROP INDEX IF EXISTS idx_todos_user_id;
+ALTER TABLE todos DROP COLUMN user_id;
+
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,40 @@
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+-- Only a hash of each session token is stored, so reading the table does not sign anyone in
+CREATE TABLE sessions (
+    token_hash TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_sessions_expires_at ON sessions(expires_at);
+
+-- Todos, tags, events and webhooks belong to a user. 0 stands for rows made
+-- before accounts existed; the first user to register adopts them.
+ALTER TABLE todos ADD COLUMN user_id INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX idx_todos_user_id ON todos(user_id);
+
+ALTER TABLE todo_events ADD COLUMN user_id INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX idx_todo_events_user_id ON todo_events(user_id, id);
+
+ALTER TABLE webhooks ADD COLUMN user_id INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX idx_webhooks_user_id ON webhooks(user_id);
+
+-- Tag names become unique per user. SQLite cannot drop the UNIQUE constraint
+-- on name, so the table is rebuilt keeping its IDs.
+CREATE TABLE tags_by_user (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL DEFAULT 0,
+    name TEXT NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+INSERT INTO tags_by_user (id, name) SELECT id, name FROM tags;
+DROP TABLE tags;
+ALTER TABLE tags_by_user RENAME TO tags;
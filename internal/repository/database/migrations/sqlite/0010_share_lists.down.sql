@@ -0,0 +1,3 @@
+ALTER TABLE todo_events DROP COLUMN list_id;
+DROP TABLE IF EXISTS list_members;
+ALTER TABLE lists DROP COLUMN user_id;
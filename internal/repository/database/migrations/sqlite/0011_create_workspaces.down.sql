@@ -0,0 +1,32 @@
+-- Only the default workspace fits the schema from before
+DELETE FROM todo_tags WHERE todo_id IN (SELECT id FROM todos WHERE workspace_id <> 1);
+DELETE FROM todos WHERE workspace_id <> 1;
+DELETE FROM tags WHERE workspace_id <> 1;
+DELETE FROM lists WHERE workspace_id <> 1;
+DELETE FROM list_members WHERE workspace_id <> 1;
+DELETE FROM todo_events WHERE workspace_id <> 1;
+DELETE FROM webhook_attempts WHERE delivery_id IN (SELECT id FROM webhook_deliveries WHERE workspace_id <> 1);
+DELETE FROM webhook_deliveries WHERE workspace_id <> 1;
+DELETE FROM webhooks WHERE workspace_id <> 1;
+DELETE FROM sessions WHERE user_id IN (SELECT id FROM users WHERE workspace_id <> 1);
+DELETE FROM api_tokens WHERE user_id IN (SELECT id FROM users WHERE workspace_id <> 1);
+DELETE FROM users WHERE workspace_id <> 1;
+
+DROP INDEX IF EXISTS idx_lists_inbox;
+CREATE UNIQUE INDEX idx_lists_inbox ON lists(is_inbox) WHERE is_inbox;
+
+DROP INDEX IF EXISTS idx_todo_events_workspace_id;
+DROP INDEX IF EXISTS idx_lists_workspace_id;
+DROP INDEX IF EXISTS idx_todos_workspace_id;
+
+ALTER TABLE webhook_deliveries DROP COLUMN workspace_id;
+ALTER TABLE webhooks DROP COLUMN workspace_id;
+ALTER TABLE todo_events DROP COLUMN workspace_id;
+ALTER TABLE list_members DROP COLUMN workspace_id;
+ALTER TABLE lists DROP COLUMN workspace_id;
+ALTER TABLE tags DROP COLUMN workspace_id;
+ALTER TABLE todos DROP COLUMN workspace_id;
+ALTER TABLE users DROP COLUMN workspace_id;
+
+DROP TABLE IF EXISTS workspace_settings;
+DROP TABLE IF EXISTS workspaces;
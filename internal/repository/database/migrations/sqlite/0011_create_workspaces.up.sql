@@ -0,0 +1,37 @@
+-- Workspaces are the tenants above users and lists. Every row of a workspace
+-- carries its ID; the rows made until now belong to the default workspace 1.
+CREATE TABLE workspaces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+
+INSERT INTO workspaces (slug, name, created_at, updated_at)
+VALUES ('default', 'Default', strftime('%Y-%m-%d %H:%M:%f', 'now'), strftime('%Y-%m-%d %H:%M:%f', 'now'));
+
+-- A workspace without a row has the default settings
+CREATE TABLE workspace_settings (
+    workspace_id INTEGER PRIMARY KEY,
+    sharing BOOLEAN NOT NULL DEFAULT 1,
+    webhooks BOOLEAN NOT NULL DEFAULT 1,
+    updated_at DATETIME NOT NULL
+);
+
+ALTER TABLE users ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE todos ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE tags ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE lists ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE list_members ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE todo_events ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE webhooks ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE webhook_deliveries ADD COLUMN workspace_id INTEGER NOT NULL DEFAULT 1;
+
+CREATE INDEX idx_todos_workspace_id ON todos(workspace_id);
+CREATE INDEX idx_lists_workspace_id ON lists(workspace_id);
+CREATE INDEX idx_todo_events_workspace_id ON todo_events(workspace_id, id);
+
+-- Every workspace has its own inbox
+DROP INDEX idx_lists_inbox;
+CREATE UNIQUE INDEX idx_lists_inbox ON lists(workspace_id) WHERE is_inbox;
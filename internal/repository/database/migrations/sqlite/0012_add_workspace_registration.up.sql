@@ -0,0 +1,5 @@
+-- Anyone may register into a workspace by its slug only while it takes
+-- registrations. The default workspace keeps taking them; the others close.
+ALTER TABLE workspace_settings ADD COLUMN registration BOOLEAN NOT NULL DEFAULT 0;
+
+UPDATE workspace_settings SET registration = 1 WHERE workspace_id = 1;
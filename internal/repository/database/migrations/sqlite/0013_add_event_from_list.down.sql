@@ -0,0 +1 @@
+ALTER TABLE todo_events DROP COLUMN from_list_id;
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+)
+
+// dialect captures the SQL differences between the supported databases
+type dialect struct {
+	name string
+
+	// numberedPlaceholders selects $1, $2, ... instead of ?
+	numberedPlaceholders bool
+
+	// skipLocked ends a SELECT of rows to claim, locking them and passing
+	// over those another transaction locked. SQLite needs none: it runs
+	// one write at a time.
+	skipLocked string
+}
+
+var (
+	sqliteDialect   = dialect{name: "sqlite"}
+	postgresDialect = dialect{name: "postgres", numberedPlaceholders: true, skipLocked: " FOR UPDATE SKIP LOCKED"}
+)
+
+// rebind rewrites the ? placeholders of query into the dialect's bind syntax.
+// Queries must not contain a literal question mark.
+func (d dialect) rebind(query string) string {
+	if !d.numberedPlaceholders {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
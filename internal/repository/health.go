@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrDatabaseBusy is returned by Ping when another connection keeps the
+// SQLite database locked, so writes would wait on it
+var ErrDatabaseBusy = errors.New("database is locked by another connection")
+
+// HealthChecker is implemented by the repositories backed by a database, to
+// tell whether it can take requests
+type HealthChecker interface {
+	// Ping checks that the database answers before ctx is done and, with
+	// SQLite, that no other connection holds the write lock
+	Ping(ctx context.Context) error
+
+	// SchemaVersion returns the highest migration applied to the database
+	// before ctx is done, only reading it. It fails when an applied
+	// migration is unknown or was changed.
+	SchemaVersion(ctx context.Context) (int, error)
+
+	// LatestSchemaVersion returns the highest migration this build has;
+	// migrations are pending while SchemaVersion is lower
+	LatestSchemaVersion() int
+}
+
+// PoolReporter is implemented by the repositories on a database/sql
+// connection pool
+type PoolReporter interface {
+	// PoolStats returns the statistics of the connection pool
+	PoolStats() sql.DBStats
+}
+
+var (
+	_ HealthChecker = (*SQLiteTodoRepository)(nil)
+	_ HealthChecker = (*PostgresTodoRepository)(nil)
+	_ PoolReporter  = (*SQLiteTodoRepository)(nil)
+	_ PoolReporter  = (*PostgresTodoRepository)(nil)
+)
+
+// PoolStats returns the statistics of the connection pool
+func (r *sqlTodoRepository) PoolStats() sql.DBStats {
+	return r.db.Stats()
+}
+
+// Ping takes the write lock and gives it back at once, so a database
+// another connection or process keeps locked fails rather than answering
+// reads while every write waits out the busy timeout
+func (r *SQLiteTodoRepository) Ping(ctx context.Context) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return sqliteBusy(err)
+	}
+	defer conn.Close()
+
+	// Interrupting SQLite does not cut short its wait for a lock, so wait
+	// no longer than ctx allows, then put the connection's timeout back
+	if deadline, ok := ctx.Deadline(); ok {
+		var busyTimeout int
+		if err := conn.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+			return sqliteBusy(err)
+		}
+		wait := max(time.Until(deadline).Milliseconds(), 1)
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("PRAGMA busy_timeout = %d", wait)); err != nil {
+			return sqliteBusy(err)
+		}
+		defer conn.ExecContext(context.Background(), fmt.Sprintf("PRAGMA busy_timeout = %d", busyTimeout))
+	}
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return sqliteBusy(err)
+	}
+	_, err = conn.ExecContext(context.Background(), "ROLLBACK")
+	return err
+}
+
+// sqliteBusy reports lock errors, and running out of time waiting for the
+// lock or a connection, as ErrDatabaseBusy
+func sqliteBusy(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %v", ErrDatabaseBusy, err)
+	}
+	return err
+}
+
+// SchemaVersion checks the database against the embedded SQLite migrations
+func (r *SQLiteTodoRepository) SchemaVersion(ctx context.Context) (int, error) {
+	return schemaVersion(ctx, r.db, sqliteMigrationsDir, sqliteDialect)
+}
+
+// LatestSchemaVersion returns the highest embedded SQLite migration
+func (r *SQLiteTodoRepository) LatestSchemaVersion() int {
+	return latestSchemaVersion(sqliteMigrationsDir)
+}
+
+// Ping checks that the database answers before ctx is done
+func (r *PostgresTodoRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// SchemaVersion checks the database against the embedded PostgreSQL migrations
+func (r *PostgresTodoRepository) SchemaVersion(ctx context.Context) (int, error) {
+	return schemaVersion(ctx, r.db, postgresMigrationsDir, postgresDialect)
+}
+
+// LatestSchemaVersion returns the highest embedded PostgreSQL migration
+func (r *PostgresTodoRepository) LatestSchemaVersion() int {
+	return latestSchemaVersion(postgresMigrationsDir)
+}
+
+// schemaVersion returns the highest migration applied to db after checking
+// the applied ones against those in dir
+func schemaVersion(ctx context.Context, db *sql.DB, dir string, d dialect) (int, error) {
+	migrator, err := newMigrator(db, schemaFS, dir, d)
+	if err != nil {
+		return 0, err
+	}
+	return migrator.verifiedVersion(ctx)
+}
+
+// latestSchemaVersion returns the highest migration in dir
+func latestSchemaVersion(dir string) int {
+	migrations, err := loadMigrations(schemaFS, dir)
+	if err != nil || len(migrations) == 0 {
+		return 0 // Cannot happen: the same migrations were loaded to open the repository
+	}
+	return migrations[len(migrations)-1].Version
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"todo-app/internal/model"
+)
+
+// CreateAPIToken adds a new API token
+func (r *InMemoryTodoRepository) CreateAPIToken(ctx context.Context, token *model.APIToken) (*model.APIToken, error) {
+	r.dir.mu.Lock()
+	defer r.dir.mu.Unlock()
+
+	r.dir.nextAPITokenID++
+	token.ID = r.dir.nextAPITokenID
+	token.CreatedAt = time.Now().Round(0)
+
+	r.dir.apiTokens[token.ID] = copyAPIToken(token)
+	return token, nil
+}
+
+// GetAPITokens returns every API token of userID by ID
+func (r *InMemoryTodoRepository) GetAPITokens(ctx context.Context, userID int) ([]*model.APIToken, error) {
+	r.dir.mu.RLock()
+	defer r.dir.mu.RUnlock()
+
+	tokens := make([]*model.APIToken, 0)
+	for _, token := range r.dir.apiTokens {
+		if token.UserID == userID {
+			tokens = append(tokens, copyAPIToken(token))
+		}
+	}
+	slices.SortFunc(tokens, func(a, b *model.APIToken) int { return a.ID - b.ID })
+	return tokens, nil
+}
+
+// GetAPIToken returns the API token with the given ID
+func (r *InMemoryTodoRepository) GetAPIToken(ctx context.Context, id int) (*model.APIToken, error) {
+	r.dir.mu.RLock()
+	defer r.dir.mu.RUnlock()
+
+	token, ok := r.dir.apiTokens[id]
+	if !ok {
+		return nil, ErrAPITokenNotFound
+	}
+	return copyAPIToken(token), nil
+}
+
+// GetAPITokenByHash returns the API token with the given token hash
+func (r *InMemoryTodoRepository) GetAPITokenByHash(ctx context.Context, tokenHash string) (*model.APIToken, error) {
+	r.dir.mu.RLock()
+	defer r.dir.mu.RUnlock()
+
+	for _, token := range r.dir.apiTokens {
+		if token.TokenHash == tokenHash {
+			return copyAPIToken(token), nil
+		}
+	}
+	return nil, ErrAPITokenNotFound
+}
+
+// TouchAPIToken sets the last use of an API token to now
+func (r *InMemoryTodoRepository) TouchAPIToken(ctx context.Context, id int) error {
+	r.dir.mu.Lock()
+	defer r.dir.mu.Unlock()
+
+	token, ok := r.dir.apiTokens[id]
+	if !ok {
+		return ErrAPITokenNotFound
+	}
+	now := time.Now().Round(0)
+	token.LastUsedAt = &now
+	return nil
+}
+
+// DeleteAPIToken removes the API token with the given ID
+func (r *InMemoryTodoRepository) DeleteAPIToken(ctx context.Context, id int) error {
+	r.dir.mu.Lock()
+	defer r.dir.mu.Unlock()
+
+	if _, ok := r.dir.apiTokens[id]; !ok {
+		return ErrAPITokenNotFound
+	}
+	delete(r.dir.apiTokens, id)
+	return nil
+}
+
+func copyAPIToken(token *model.APIToken) *model.APIToken {
+	c := *token
+	c.Scopes = slices.Clone(token.Scopes)
+	c.LastUsedAt = copyTime(token.LastUsedAt)
+	c.Token = "" // Never stored
+	return &c
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"todo-app/internal/model"
+)
+
+// AppendEvent adds an event to the change log and queues a delivery of it
+// to every webhook of its user subscribed to its type
+func (r *InMemoryTodoRepository) AppendEvent(ctx context.Context, event *model.TodoEvent) (*model.TodoEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextEventID++
+	event.ID = r.nextEventID
+	event.WorkspaceID = r.workspaceID
+	event.CreatedAt = time.Now().Round(0)
+
+	stored := copyEvent(event)
+	stored.Origin = "" // Live-only, like the SQL log
+	r.events = append(r.events, stored)
+
+	if err := r.queueDeliveries(event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// GetEvents returns at most limit events with an ID above afterID, oldest
+// first, of the todos of userID and those in, or moved out of, the lists
+// userID owns or joined
+func (r *InMemoryTodoRepository) GetEvents(ctx context.Context, userID int, afterID int64, limit int) ([]*model.TodoEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]*model.TodoEvent, 0)
+	for _, event := range r.events {
+		if len(events) == limit {
+			break
+		}
+		if event.ID > afterID && (event.UserID == userID || event.Todo != nil && r.joined(event.Todo.ListID, userID) ||
+			event.FromListID != 0 && r.joined(event.FromListID, userID)) {
+			events = append(events, copyEvent(event))
+		}
+	}
+	return events, nil
+}
+
+func copyEvent(event *model.TodoEvent) *model.TodoEvent {
+	c := *event
+	if event.Todo != nil {
+		c.Todo = copyTodo(event.Todo)
+	}
+	return &c
+}
@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"todo-app/internal/model"
+)
+
+// CreateList adds a new, empty list
+func (r *InMemoryTodoRepository) CreateList(ctx context.Context, list *model.List) (*model.List, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().Round(0)
+
+	list.ID = r.nextListID
+	list.Inbox = false
+	list.TodoCount = 0
+	list.CreatedAt = now
+	list.UpdatedAt = now
+	r.nextListID++
+
+	stored := *list
+	r.lists[list.ID] = &stored
+	return list, nil
+}
+
+// GetLists returns the inbox and the lists userID owns or joined with the
+// role of userID and the number of todos userID can see, the inbox first and
+// then by creation
+func (r *InMemoryTodoRepository) GetLists(ctx context.Context, userID int) ([]*model.List, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	lists := make([]*model.List, 0, len(r.lists))
+	for _, list := range r.lists {
+		var role model.ListRole
+		switch member := r.members[list.ID][userID]; {
+		case list.Inbox || list.UserID == userID:
+			role = model.ListOwner
+		case member != nil && member.Accepted:
+			role = member.Role
+		default:
+			continue
+		}
+
+		c := *list
+		c.Role = role
+		for _, todo := range r.todos {
+			if todo.ListID == list.ID && r.visible(todo, userID) {
+				c.TodoCount++
+			}
+		}
+		lists = append(lists, &c)
+	}
+
+	sort.Slice(lists, func(i, j int) bool {
+		if lists[i].Inbox != lists[j].Inbox {
+			return lists[i].Inbox
+		}
+		return lists[i].ID < lists[j].ID
+	})
+
+	return lists, nil
+}
+
+// GetList returns the list with the given ID
+func (r *InMemoryTodoRepository) GetList(ctx context.Context, id int) (*model.List, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list, ok := r.lists[id]
+	if !ok {
+		return nil, ErrListNotFound
+	}
+	return r.readList(list), nil
+}
+
+// UpdateList saves the name of an existing list and bumps updated_at
+func (r *InMemoryTodoRepository) UpdateList(ctx context.Context, list *model.List) (*model.List, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.lists[list.ID]
+	if !ok {
+		return nil, ErrListNotFound
+	}
+
+	stored.Name = list.Name
+	stored.UpdatedAt = time.Now().Round(0)
+
+	return r.readList(stored), nil
+}
+
+// DeleteList moves the todos of a list to the inbox and removes the list
+func (r *InMemoryTodoRepository) DeleteList(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.lists[id]; !ok {
+		return ErrListNotFound
+	}
+	if id == r.inboxID {
+		return ErrDeleteInbox
+	}
+
+	for _, todo := range r.todos {
+		if todo.ListID == id {
+			todo.ListID = r.inboxID
+		}
+	}
+	delete(r.lists, id)
+	delete(r.members, id)
+	return nil
+}
+
+// AddListMember adds a member or invitation of a list
+func (r *InMemoryTodoRepository) AddListMember(ctx context.Context, member *model.ListMember) (*model.ListMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.members[member.ListID][member.UserID]; ok {
+		return nil, ErrMemberExists
+	}
+	if r.members[member.ListID] == nil {
+		r.members[member.ListID] = make(map[int]*model.ListMember)
+	}
+
+	member.CreatedAt = time.Now().Round(0)
+	c := *member
+	c.ListName, c.Username = "", "" // Read from the list, and left to the services
+	r.members[member.ListID][member.UserID] = &c
+	return member, nil
+}
+
+// GetListMember returns the membership or invitation of userID to a list
+func (r *InMemoryTodoRepository) GetListMember(ctx context.Context, listID, userID int) (*model.ListMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	member, ok := r.members[listID][userID]
+	if !ok {
+		return nil, ErrMemberNotFound
+	}
+	return r.readMember(member), nil
+}
+
+// GetListMembers returns the members and invitations of a list in the order they were added
+func (r *InMemoryTodoRepository) GetListMembers(ctx context.Context, listID int) ([]*model.ListMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	members := make([]*model.ListMember, 0, len(r.members[listID]))
+	for _, member := range r.members[listID] {
+		members = append(members, r.readMember(member))
+	}
+	sortMembers(members)
+	return members, nil
+}
+
+// GetInvitations returns the invitations userID has not accepted yet, oldest first
+func (r *InMemoryTodoRepository) GetInvitations(ctx context.Context, userID int) ([]*model.ListMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invitations := make([]*model.ListMember, 0)
+	for _, members := range r.members {
+		if member, ok := members[userID]; ok && !member.Accepted {
+			invitations = append(invitations, r.readMember(member))
+		}
+	}
+	sortMembers(invitations)
+	return invitations, nil
+}
+
+// UpdateListMember saves the role and acceptance of a member
+func (r *InMemoryTodoRepository) UpdateListMember(ctx context.Context, member *model.ListMember) (*model.ListMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.members[member.ListID][member.UserID]
+	if !ok {
+		return nil, ErrMemberNotFound
+	}
+	stored.Role = member.Role
+	stored.Accepted = member.Accepted
+	return r.readMember(stored), nil
+}
+
+// DeleteListMember removes a member or invitation
+func (r *InMemoryTodoRepository) DeleteListMember(ctx context.Context, listID, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.members[listID][userID]; !ok {
+		return ErrMemberNotFound
+	}
+	delete(r.members[listID], userID)
+	return nil
+}
+
+// readMember returns a copy of a stored member with the name of its list;
+// r.mu must be held
+func (r *InMemoryTodoRepository) readMember(member *model.ListMember) *model.ListMember {
+	c := *member
+	c.ListName = r.lists[member.ListID].Name
+	return &c
+}
+
+// sortMembers orders members by when they were added, like the SQL backends
+func sortMembers(members []*model.ListMember) {
+	sort.Slice(members, func(i, j int) bool {
+		if !members[i].CreatedAt.Equal(members[j].CreatedAt) {
+			return members[i].CreatedAt.Before(members[j].CreatedAt)
+		}
+		if members[i].ListID != members[j].ListID {
+			return members[i].ListID < members[j].ListID
+		}
+		return members[i].UserID < members[j].UserID
+	})
+}
+
+// visible reports whether userID can see todo: every todo in the lists they
+// own or joined, and their own in the inbox; r.mu must be held
+func (r *InMemoryTodoRepository) visible(todo *model.Todo, userID int) bool {
+	if todo.ListID == r.inboxID {
+		return todo.UserID == userID
+	}
+	return r.joined(todo.ListID, userID)
+}
+
+// joined reports whether userID owns or has accepted to join a list other
+// than the inbox; r.mu must be held
+func (r *InMemoryTodoRepository) joined(listID, userID int) bool {
+	list, ok := r.lists[listID]
+	if !ok || list.Inbox {
+		return false
+	}
+	member := r.members[listID][userID]
+	return list.UserID == userID || member != nil && member.Accepted
+}
+
+// readList returns a copy of a stored list with its todo count; r.mu must be held
+func (r *InMemoryTodoRepository) readList(list *model.List) *model.List {
+	c := *list
+	c.TodoCount = 0
+	for _, todo := range r.todos {
+		if todo.ListID == list.ID {
+			c.TodoCount++
+		}
+	}
+	return &c
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"todo-app/internal/model"
+)
+
+// SearchTodos matches query against the words of every todo userID can see, ranking todos
+// by the share of their words that matched and breaking ties newest first
+func (r *InMemoryTodoRepository) SearchTodos(ctx context.Context, query *model.SearchExpr, userID int, limit int) ([]*model.SearchResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := make([]*model.SearchResult, 0)
+	for _, todo := range r.todos {
+		if !r.visible(todo, userID) {
+			continue
+		}
+		tokens := model.TokenizeSearch(todo.Text)
+		ok, hits := matchSearch(query, tokens)
+		if !ok {
+			continue
+		}
+		results = append(results, &model.SearchResult{
+			Todo:    r.read(todo),
+			Snippet: memorySnippet(todo.Text, tokens, hits),
+			Rank:    float64(len(hits)) / float64(len(tokens)),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].ID > results[j].ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// matchSearch reports whether tokens satisfy query and which of them it matched
+func matchSearch(query *model.SearchExpr, tokens []model.SearchToken) (bool, []int) {
+	switch query.Op {
+	case model.SearchAnd, model.SearchOr:
+		leftOK, left := matchSearch(query.Left, tokens)
+		rightOK, right := matchSearch(query.Right, tokens)
+		matched := leftOK || rightOK
+		if query.Op == model.SearchAnd {
+			matched = leftOK && rightOK
+		}
+		if !matched {
+			return false, nil
+		}
+		return true, mergeHits(left, right)
+	case model.SearchNot:
+		if excluded, _ := matchSearch(query.Right, tokens); excluded {
+			return false, nil
+		}
+		return matchSearch(query.Left, tokens)
+	}
+
+	var hits []int
+	for start := 0; start+len(query.Words) <= len(tokens); start++ {
+		if matchesPhrase(query, tokens[start:start+len(query.Words)]) {
+			for i := range query.Words {
+				hits = append(hits, start+i)
+			}
+		}
+	}
+	return len(hits) > 0, mergeHits(hits)
+}
+
+// matchesPhrase reports whether tokens are the words of a SearchMatch query
+func matchesPhrase(query *model.SearchExpr, tokens []model.SearchToken) bool {
+	last := len(query.Words) - 1
+	for i, word := range query.Words {
+		if i == last && query.Prefix {
+			if !strings.HasPrefix(tokens[i].Word, word) {
+				return false
+			}
+		} else if tokens[i].Word != word {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeHits returns the sorted, deduplicated token indexes of every hits list
+func mergeHits(hits ...[]int) []int {
+	merged := slices.Concat(hits...)
+	slices.Sort(merged)
+	return slices.Compact(merged)
+}
+
+// memorySnippet marks the hit tokens of text like the SQL backends' snippets,
+// keeping a window of snippetWords words from just before the first hit
+func memorySnippet(text string, tokens []model.SearchToken, hits []int) string {
+	first, last := 0, len(tokens)
+	if len(tokens) > snippetWords {
+		first = min(max(hits[0]-2, 0), len(tokens)-snippetWords)
+		last = first + snippetWords
+	}
+
+	var b strings.Builder
+	from := 0
+	if first > 0 {
+		b.WriteString(snippetEllipsis)
+		from = tokens[first].Start
+	}
+	for i := first; i < last; i++ {
+		if !slices.Contains(hits, i) {
+			continue
+		}
+		b.WriteString(text[from:tokens[i].Start])
+		b.WriteString(snippetOpen + text[tokens[i].Start:tokens[i].End] + snippetClose)
+		from = tokens[i].End
+	}
+	if last < len(tokens) {
+		b.WriteString(text[from:tokens[last-1].End])
+		b.WriteString(snippetEllipsis)
+	} else {
+		b.WriteString(text[from:])
+	}
+	return renderSnippet(b.String())
+}
@@ -0,0 +1,585 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"todo-app/internal/model"
+)
+
+// InMemoryTodoRepository implements TodoRepository with a map guarded by a mutex.
+// Nothing is persisted; it is meant for tests and throwaway instances. Every
+// workspace has its own InMemoryTodoRepository, sharing one memoryDirectory.
+type InMemoryTodoRepository struct {
+	mu          sync.RWMutex
+	txMu        sync.Mutex // serializes Atomically
+	workspaceID int
+	dir         *memoryDirectory
+	settings    model.WorkspaceSettings
+	todos       map[int]*model.Todo
+	nextID      int
+	tags        map[int]string       // tag ID -> name
+	tagUsers    map[int]int          // tag ID -> user ID
+	todoTags    map[int]map[int]bool // todo ID -> set of tag IDs
+	nextTagID   int
+	lists       map[int]*model.List
+	inboxID     int
+	nextListID  int
+	members     map[int]map[int]*model.ListMember // list ID -> user ID -> member, without names
+	events      []*model.TodoEvent                // change log, oldest first
+	nextEventID int64
+
+	webhooks       map[int]*model.Webhook
+	nextWebhookID  int                      // last ID handed out
+	deliveries     []*model.WebhookDelivery // by ID; replaced, never changed in place
+	nextDeliveryID int64                    // last ID handed out
+}
+
+var _ TodoRepository = (*InMemoryTodoRepository)(nil)
+
+// NewInMemoryTodoRepository creates an in-memory todo repository holding only
+// the default workspace with its inbox list
+func NewInMemoryTodoRepository() *InMemoryTodoRepository {
+	dir := newMemoryDirectory()
+	return dir.store(model.DefaultWorkspaceID)
+}
+
+// newMemoryWorkspace creates the empty store of a workspace with its inbox list
+func newMemoryWorkspace(id int, dir *memoryDirectory) *InMemoryTodoRepository {
+	now := time.Now().Round(0)
+	return &InMemoryTodoRepository{
+		workspaceID: id,
+		dir:         dir,
+		settings:    model.DefaultWorkspaceSettings(id),
+		todos:       make(map[int]*model.Todo),
+		nextID:      1,
+		tags:        make(map[int]string),
+		tagUsers:    make(map[int]int),
+		todoTags:    make(map[int]map[int]bool),
+		nextTagID:   1,
+		lists:       map[int]*model.List{1: {ID: 1, Name: "Inbox", Inbox: true, CreatedAt: now, UpdatedAt: now}},
+		inboxID:     1,
+		nextListID:  2,
+		members:     make(map[int]map[int]*model.ListMember),
+		webhooks:    make(map[int]*model.Webhook),
+	}
+}
+
+// Create adds a new todo to the store
+func (r *InMemoryTodoRepository) Create(ctx context.Context, todo *model.Todo) (*model.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().Round(0) // Drop the monotonic reading like a database round trip would
+
+	if todo.ListID == 0 {
+		todo.ListID = r.inboxID
+	}
+	todo.ID = r.nextID
+	todo.CreatedAt = now
+	todo.UpdatedAt = now
+	r.nextID++
+
+	r.todos[todo.ID] = copyTodo(todo)
+	r.setTags(todo.ID, todo.UserID, todo.Tags)
+	todo.Tags = r.tagNames(todo.ID)
+	return todo, nil
+}
+
+// GetAll returns the todos matching filter in filter.Sort order
+func (r *InMemoryTodoRepository) GetAll(ctx context.Context, filter model.TodoFilter) ([]*model.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todos := make([]*model.Todo, 0, len(r.todos))
+	for _, todo := range r.todos {
+		todo = r.read(todo)
+		if r.matchesFilter(todo, filter) {
+			todos = append(todos, todo)
+		}
+	}
+
+	keys := filter.SortKeys()
+	sort.Slice(todos, func(i, j int) bool {
+		return lessTodo(todos[i], todos[j], keys)
+	})
+
+	if filter.Cursor != nil {
+		position := filter.Cursor.Position()
+		todos = slices.DeleteFunc(todos, func(todo *model.Todo) bool {
+			if filter.Cursor.Backward {
+				return !lessTodo(todo, position, keys)
+			}
+			return !lessTodo(position, todo, keys)
+		})
+	}
+
+	if filter.Limit > 0 && len(todos) > filter.Limit {
+		// A backward page ends right before the cursor
+		if filter.Cursor != nil && filter.Cursor.Backward {
+			todos = todos[len(todos)-filter.Limit:]
+		} else {
+			todos = todos[:filter.Limit]
+		}
+	}
+
+	return todos, nil
+}
+
+// GetByID returns the todo with the given ID
+func (r *InMemoryTodoRepository) GetByID(ctx context.Context, id int) (*model.Todo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todo, ok := r.todos[id]
+	if !ok {
+		return nil, ErrTodoNotFound
+	}
+	return r.read(todo), nil
+}
+
+// Update saves the editable fields of an existing todo and bumps updated_at
+func (r *InMemoryTodoRepository) Update(ctx context.Context, todo *model.Todo) (*model.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.todos[todo.ID]
+	if !ok {
+		return nil, ErrTodoNotFound
+	}
+
+	stored.Text = todo.Text
+	if todo.ListID != 0 {
+		stored.ListID = todo.ListID
+	}
+	stored.Completed = todo.Completed
+	stored.CompletedAt = copyTime(todo.CompletedAt)
+	stored.Priority = todo.Priority
+	stored.DueAt = copyTime(todo.DueAt)
+	stored.DueAllDay = todo.DueAllDay
+	stored.UpdatedAt = time.Now().Round(0)
+	r.setTags(stored.ID, stored.UserID, todo.Tags)
+
+	return r.read(stored), nil
+}
+
+// Delete removes the todo with the given ID
+func (r *InMemoryTodoRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.todos[id]; !ok {
+		return ErrTodoNotFound
+	}
+	delete(r.todos, id)
+	delete(r.todoTags, id)
+	return nil
+}
+
+// CountTodos counts the todos of the workspace, and those not completed
+func (r *InMemoryTodoRepository) CountTodos(ctx context.Context) (model.TodoCounts, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := model.TodoCounts{Total: len(r.todos)}
+	for _, todo := range r.todos {
+		if !todo.Completed {
+			counts.Open++
+		}
+	}
+	return counts, nil
+}
+
+// GetTags returns every tag of userID with its todo count, ordered by name
+func (r *InMemoryTodoRepository) GetTags(ctx context.Context, userID int) ([]*model.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tags := make([]*model.Tag, 0)
+	for id := range r.tags {
+		if r.tagUsers[id] == userID {
+			tags = append(tags, r.tag(id))
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+// GetTag returns the tag with the given ID
+func (r *InMemoryTodoRepository) GetTag(ctx context.Context, id int) (*model.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.tags[id]; !ok {
+		return nil, ErrTagNotFound
+	}
+	return r.tag(id), nil
+}
+
+// RenameTag gives a tag a new name, unused among the tags of its user
+func (r *InMemoryTodoRepository) RenameTag(ctx context.Context, id int, name string) (*model.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tags[id]; !ok {
+		return nil, ErrTagNotFound
+	}
+	if other, ok := r.tagID(r.tagUsers[id], name); ok && other != id {
+		return nil, ErrTagExists
+	}
+
+	r.tags[id] = name
+	return r.tag(id), nil
+}
+
+// MergeTags relinks the todos of the source tag to the target tag and deletes the source
+func (r *InMemoryTodoRepository) MergeTags(ctx context.Context, sourceID, targetID int) (*model.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, sourceOK := r.tags[sourceID]
+	_, targetOK := r.tags[targetID]
+	if !sourceOK || !targetOK || r.tagUsers[sourceID] != r.tagUsers[targetID] {
+		return nil, ErrTagNotFound
+	}
+
+	if sourceID != targetID {
+		for _, tagIDs := range r.todoTags {
+			if tagIDs[sourceID] {
+				delete(tagIDs, sourceID)
+				tagIDs[targetID] = true
+			}
+		}
+		delete(r.tags, sourceID)
+		delete(r.tagUsers, sourceID)
+	}
+	return r.tag(targetID), nil
+}
+
+// Truncate removes everything but the default workspace and its inbox list,
+// users and the other workspaces included
+func (r *InMemoryTodoRepository) Truncate(ctx context.Context) error {
+	r.dir.mu.Lock()
+	defer r.dir.mu.Unlock()
+	r.dir.reset()
+
+	r = r.dir.stores[model.DefaultWorkspaceID]
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.settings = model.DefaultWorkspaceSettings(r.workspaceID)
+	r.todos = make(map[int]*model.Todo)
+	r.tags = make(map[int]string)
+	r.tagUsers = make(map[int]int)
+	r.todoTags = make(map[int]map[int]bool)
+	r.lists = map[int]*model.List{r.inboxID: r.lists[r.inboxID]}
+	r.members = make(map[int]map[int]*model.ListMember)
+	r.events = nil
+	r.webhooks = make(map[int]*model.Webhook)
+	r.deliveries = nil
+	return nil
+}
+
+// Atomically runs fn and, when it fails, restores the state from before.
+// Other writers are not held off meanwhile; the store is meant for tests.
+func (r *InMemoryTodoRepository) Atomically(ctx context.Context, fn func(tx TxRepository) error) error {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+
+	r.mu.RLock()
+	saved := r.snapshot()
+	r.mu.RUnlock()
+
+	if err := fn(r); err != nil {
+		r.mu.Lock()
+		r.restore(saved)
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// memorySnapshot is a deep copy of the data of an InMemoryTodoRepository. The
+// directory is left out: nothing inside Atomically can change it.
+type memorySnapshot struct {
+	todos       map[int]*model.Todo
+	nextID      int
+	tags        map[int]string
+	tagUsers    map[int]int
+	todoTags    map[int]map[int]bool
+	nextTagID   int
+	lists       map[int]*model.List
+	nextListID  int
+	members     map[int]map[int]*model.ListMember
+	events      []*model.TodoEvent
+	nextEventID int64
+
+	webhooks       map[int]*model.Webhook
+	nextWebhookID  int
+	deliveries     []*model.WebhookDelivery
+	nextDeliveryID int64
+}
+
+// snapshot copies the state; r.mu must be held
+func (r *InMemoryTodoRepository) snapshot() *memorySnapshot {
+	saved := &memorySnapshot{
+		todos:       make(map[int]*model.Todo, len(r.todos)),
+		nextID:      r.nextID,
+		tags:        maps.Clone(r.tags),
+		tagUsers:    maps.Clone(r.tagUsers),
+		todoTags:    make(map[int]map[int]bool, len(r.todoTags)),
+		nextTagID:   r.nextTagID,
+		lists:       make(map[int]*model.List, len(r.lists)),
+		nextListID:  r.nextListID,
+		members:     make(map[int]map[int]*model.ListMember, len(r.members)),
+		events:      slices.Clone(r.events),
+		nextEventID: r.nextEventID,
+
+		webhooks:       make(map[int]*model.Webhook, len(r.webhooks)),
+		nextWebhookID:  r.nextWebhookID,
+		deliveries:     slices.Clone(r.deliveries),
+		nextDeliveryID: r.nextDeliveryID,
+	}
+	for id, webhook := range r.webhooks {
+		saved.webhooks[id] = copyWebhook(webhook)
+	}
+	for id, todo := range r.todos {
+		saved.todos[id] = copyTodo(todo)
+	}
+	for id, tagIDs := range r.todoTags {
+		saved.todoTags[id] = maps.Clone(tagIDs)
+	}
+	for id, list := range r.lists {
+		c := *list
+		saved.lists[id] = &c
+	}
+	for listID, members := range r.members {
+		saved.members[listID] = make(map[int]*model.ListMember, len(members))
+		for userID, member := range members {
+			c := *member
+			saved.members[listID][userID] = &c
+		}
+	}
+	return saved
+}
+
+// restore puts back the state saved by snapshot; r.mu must be held
+func (r *InMemoryTodoRepository) restore(saved *memorySnapshot) {
+	r.todos, r.nextID = saved.todos, saved.nextID
+	r.tags, r.tagUsers, r.todoTags, r.nextTagID = saved.tags, saved.tagUsers, saved.todoTags, saved.nextTagID
+	r.lists, r.nextListID, r.members = saved.lists, saved.nextListID, saved.members
+	r.events, r.nextEventID = saved.events, saved.nextEventID
+	r.webhooks, r.nextWebhookID = saved.webhooks, saved.nextWebhookID
+	r.deliveries, r.nextDeliveryID = saved.deliveries, saved.nextDeliveryID
+}
+
+// Close is a no-op; the store lives as long as the value
+func (r *InMemoryTodoRepository) Close() error {
+	return nil
+}
+
+// setTags replaces the tags of a todo of userID, creating tags that do not
+// exist yet; r.mu must be held
+func (r *InMemoryTodoRepository) setTags(todoID, userID int, names []string) {
+	tagIDs := make(map[int]bool, len(names))
+	for _, name := range names {
+		id, ok := r.tagID(userID, name)
+		if !ok {
+			id = r.nextTagID
+			r.nextTagID++
+			r.tags[id] = name
+			r.tagUsers[id] = userID
+		}
+		tagIDs[id] = true
+	}
+	r.todoTags[todoID] = tagIDs
+}
+
+// tagID looks a tag of userID up by name; r.mu must be held
+func (r *InMemoryTodoRepository) tagID(userID int, name string) (int, bool) {
+	for id, tagName := range r.tags {
+		if tagName == name && r.tagUsers[id] == userID {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// tagNames returns the sorted tag names of a todo; r.mu must be held
+func (r *InMemoryTodoRepository) tagNames(todoID int) []string {
+	names := make([]string, 0, len(r.todoTags[todoID]))
+	for id := range r.todoTags[todoID] {
+		names = append(names, r.tags[id])
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tag returns a tag with its todo count; r.mu must be held
+func (r *InMemoryTodoRepository) tag(id int) *model.Tag {
+	tag := &model.Tag{ID: id, UserID: r.tagUsers[id], Name: r.tags[id]}
+	for _, tagIDs := range r.todoTags {
+		if tagIDs[id] {
+			tag.TodoCount++
+		}
+	}
+	return tag
+}
+
+// read returns a copy of a stored todo with its tags filled in; r.mu must be held
+func (r *InMemoryTodoRepository) read(todo *model.Todo) *model.Todo {
+	c := copyTodo(todo)
+	c.Tags = r.tagNames(todo.ID)
+	return c
+}
+
+// matchesFilter mirrors the WHERE clause built by the SQL backends; r.mu must be held
+func (r *InMemoryTodoRepository) matchesFilter(todo *model.Todo, filter model.TodoFilter) bool {
+	if !r.visible(todo, filter.UserID) {
+		return false
+	}
+	if filter.ListID != 0 && todo.ListID != filter.ListID {
+		return false
+	}
+	if filter.Completed != nil && todo.Completed != *filter.Completed {
+		return false
+	}
+	if filter.DueBefore != nil && (todo.DueAt == nil || !todo.DueAt.Before(*filter.DueBefore)) {
+		return false
+	}
+	if filter.DueAfter != nil && (todo.DueAt == nil || todo.DueAt.Before(*filter.DueAfter)) {
+		return false
+	}
+	if filter.Overdue && !todo.IsOverdue(filter.Now) {
+		return false
+	}
+	if len(filter.Tags) > 0 && !matchesTags(todo.Tags, filter.Tags, filter.AnyTag) {
+		return false
+	}
+	if filter.Where != nil && !matchesExpr(todo, filter.Where, filter.Now) {
+		return false
+	}
+	return true
+}
+
+// matchesExpr evaluates a filter expression like the condition compiled by filterExprCondition
+func matchesExpr(todo *model.Todo, expr *model.FilterExpr, now time.Time) bool {
+	switch expr.Op {
+	case model.FilterAnd:
+		return matchesExpr(todo, expr.Left, now) && matchesExpr(todo, expr.Right, now)
+	case model.FilterOr:
+		return matchesExpr(todo, expr.Left, now) || matchesExpr(todo, expr.Right, now)
+	case model.FilterNot:
+		return !matchesExpr(todo, expr.Left, now)
+	}
+
+	switch expr.Field {
+	case model.FieldTag:
+		return expr.Cmp.Compare(boolCompare(slices.Contains(todo.Tags, expr.Text)))
+	case model.FieldText:
+		return expr.Cmp.Compare(boolCompare(strings.Contains(strings.ToLower(todo.Text), strings.ToLower(expr.Text))))
+	case model.FieldList:
+		return expr.Cmp.Compare(cmp.Compare(todo.ListID, expr.Int))
+	case model.FieldPriority:
+		return expr.Cmp.Compare(cmp.Compare(int(todo.Priority), expr.Int))
+	case model.FieldDue:
+		if expr.Time == nil {
+			return expr.Cmp.Compare(boolCompare(todo.DueAt == nil))
+		}
+		return todo.DueAt != nil && expr.Cmp.Compare(todo.DueAt.Compare(*expr.Time))
+	case model.FieldCreated:
+		return expr.Cmp.Compare(todo.CreatedAt.Compare(*expr.Time))
+	case model.FieldUpdated:
+		return expr.Cmp.Compare(todo.UpdatedAt.Compare(*expr.Time))
+	case model.FieldCompleted:
+		return expr.Cmp.Compare(boolCompare(todo.Completed == expr.Bool))
+	case model.FieldOverdue:
+		return expr.Cmp.Compare(boolCompare(todo.IsOverdue(now) == expr.Bool))
+	}
+	return false
+}
+
+// boolCompare turns a match into the comparison result expected by FilterCmp.Compare:
+// 0 (equal) when matched
+func boolCompare(matched bool) int {
+	if matched {
+		return 0
+	}
+	return 1
+}
+
+// matchesTags reports whether tags hold all of wanted, or at least one of them when matchAny
+func matchesTags(tags, wanted []string, matchAny bool) bool {
+	if matchAny {
+		return slices.ContainsFunc(wanted, func(tag string) bool { return slices.Contains(tags, tag) })
+	}
+	for _, tag := range wanted {
+		if !slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// lessTodo reports whether a sorts before b under keys, breaking ties on id
+// in the direction of the last key like the SQL backends' ORDER BY
+func lessTodo(a, b *model.Todo, keys []model.SortKey) bool {
+	for _, key := range keys {
+		if key.Field == model.SortDueAt && (a.DueAt == nil) != (b.DueAt == nil) {
+			return b.DueAt == nil // Undated todos sort last in both directions
+		}
+		if c := compareField(a, b, key.Field); c != 0 {
+			return (c < 0) != key.Desc
+		}
+	}
+	if keys[len(keys)-1].Desc {
+		return a.ID > b.ID
+	}
+	return a.ID < b.ID
+}
+
+// compareField orders a and b by one sort field like the SQL backends' ORDER BY;
+// todos without a due date compare equal on due_at
+func compareField(a, b *model.Todo, field string) int {
+	switch field {
+	case model.SortPriority:
+		return cmp.Compare(a.Priority, b.Priority)
+	case model.SortDueAt:
+		if a.DueAt == nil || b.DueAt == nil {
+			return 0
+		}
+		return a.DueAt.Compare(*b.DueAt)
+	case model.SortCreatedAt:
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case model.SortUpdatedAt:
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	case model.SortText:
+		return strings.Compare(strings.ToLower(a.Text), strings.ToLower(b.Text))
+	}
+	return 0
+}
+
+// copyTodo returns a deep copy so callers never share the stored value
+func copyTodo(todo *model.Todo) *model.Todo {
+	c := *todo
+	c.CompletedAt = copyTime(todo.CompletedAt)
+	c.DueAt = copyTime(todo.DueAt)
+	c.Tags = slices.Clone(todo.Tags)
+	return &c
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"todo-app/internal/model"
+)
+
+// CreateUser adds a new user
+func (r *InMemoryTodoRepository) CreateUser(ctx context.Context, user *model.User) (*model.User, error) {
+	r.dir.mu.Lock()
+	defer r.dir.mu.Unlock()
+
+	if user.WorkspaceID == 0 {
+		user.WorkspaceID = model.DefaultWorkspaceID
+	}
+	for _, other := range r.dir.users {
+		if other.Username == user.Username {
+			return nil, ErrUsernameTaken
+		}
+	}
+
+	r.dir.nextUserID++
+	user.ID = r.dir.nextUserID
+	user.CreatedAt = time.Now().Round(0)
+	c := *user
+	r.dir.users[user.ID] = &c
+	return user, nil
+}
+
+// AssignOwner gives userID everything user 0 owns in the workspace, except the inbox
+func (r *InMemoryTodoRepository) AssignOwner(ctx context.Context, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, todo := range r.todos {
+		if todo.UserID == 0 {
+			todo.UserID = userID
+		}
+	}
+	for id, owner := range r.tagUsers {
+		if owner == 0 {
+			r.tagUsers[id] = userID
+		}
+	}
+	for i, event := range r.events {
+		if event.UserID == 0 {
+			c := copyEvent(event) // Snapshots share the logged events
+			c.UserID = userID
+			if c.Todo != nil {
+				c.Todo.UserID = userID
+			}
+			r.events[i] = c
+		}
+	}
+	for _, webhook := range r.webhooks {
+		if webhook.UserID == 0 {
+			webhook.UserID = userID
+		}
+	}
+	for _, list := range r.lists {
+		if list.UserID == 0 && !list.Inbox {
+			list.UserID = userID
+		}
+	}
+	return nil
+}
+
+// GetUser returns the user with the given ID
+func (r *InMemoryTodoRepository) GetUser(ctx context.Context, id int) (*model.User, error) {
+	r.dir.mu.RLock()
+	defer r.dir.mu.RUnlock()
+
+	user, ok := r.dir.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	c := *user
+	return &c, nil
+}
+
+// GetUserByUsername returns the user with the given username
+func (r *InMemoryTodoRepository) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	r.dir.mu.RLock()
+	defer r.dir.mu.RUnlock()
+
+	for _, user := range r.dir.users {
+		if user.Username == username {
+			c := *user
+			return &c, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+// CreateSession adds a new session and removes every expired one
+func (r *InMemoryTodoRepository) CreateSession(ctx context.Context, session *model.Session) error {
+	r.dir.mu.Lock()
+	defer r.dir.mu.Unlock()
+
+	now := time.Now()
+	for hash, other := range r.dir.sessions {
+		if !other.ExpiresAt.After(now) {
+			delete(r.dir.sessions, hash)
+		}
+	}
+
+	session.CreatedAt = now.Round(0)
+	c := *session
+	r.dir.sessions[session.TokenHash] = &c
+	return nil
+}
+
+// GetSession returns the session with the given token hash, even when expired
+func (r *InMemoryTodoRepository) GetSession(ctx context.Context, tokenHash string) (*model.Session, error) {
+	r.dir.mu.RLock()
+	defer r.dir.mu.RUnlock()
+
+	session, ok := r.dir.sessions[tokenHash]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	c := *session
+	return &c, nil
+}
+
+// DeleteSession removes the session with the given token hash
+func (r *InMemoryTodoRepository) DeleteSession(ctx context.Context, tokenHash string) error {
+	r.dir.mu.Lock()
+	defer r.dir.mu.Unlock()
+
+	if _, ok := r.dir.sessions[tokenHash]; !ok {
+		return ErrSessionNotFound
+	}
+	delete(r.dir.sessions, tokenHash)
+	return nil
+}
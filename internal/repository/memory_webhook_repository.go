@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"todo-app/internal/model"
+)
+
+// CreateWebhook adds a new webhook
+func (r *InMemoryTodoRepository) CreateWebhook(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().Round(0)
+	r.nextWebhookID++
+	webhook.ID = r.nextWebhookID
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+
+	r.webhooks[webhook.ID] = copyWebhook(webhook)
+	return webhook, nil
+}
+
+// GetWebhooks returns every webhook of userID by ID
+func (r *InMemoryTodoRepository) GetWebhooks(ctx context.Context, userID int) ([]*model.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	webhooks := make([]*model.Webhook, 0)
+	for _, webhook := range r.webhooks {
+		if webhook.UserID == userID {
+			webhooks = append(webhooks, copyWebhook(webhook))
+		}
+	}
+	slices.SortFunc(webhooks, func(a, b *model.Webhook) int { return a.ID - b.ID })
+	return webhooks, nil
+}
+
+// GetWebhook returns the webhook with the given ID
+func (r *InMemoryTodoRepository) GetWebhook(ctx context.Context, id int) (*model.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+	return copyWebhook(webhook), nil
+}
+
+// UpdateWebhook saves the URL, secret and event types of an existing webhook
+func (r *InMemoryTodoRepository) UpdateWebhook(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.webhooks[webhook.ID]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+
+	stored.URL = webhook.URL
+	stored.Secret = webhook.Secret
+	stored.EventTypes = slices.Clone(webhook.EventTypes)
+	stored.UpdatedAt = time.Now().Round(0)
+	return copyWebhook(stored), nil
+}
+
+// DeleteWebhook removes a webhook with its deliveries
+func (r *InMemoryTodoRepository) DeleteWebhook(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.webhooks[id]; !ok {
+		return ErrWebhookNotFound
+	}
+	delete(r.webhooks, id)
+	r.deliveries = slices.DeleteFunc(r.deliveries, func(d *model.WebhookDelivery) bool { return d.WebhookID == id })
+	return nil
+}
+
+// GetDeliveries returns the latest limit deliveries of a webhook, newest first
+func (r *InMemoryTodoRepository) GetDeliveries(ctx context.Context, webhookID int, limit int) ([]*model.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+	for i := len(r.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if r.deliveries[i].WebhookID == webhookID {
+			deliveries = append(deliveries, copyDelivery(r.deliveries[i]))
+		}
+	}
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries moves the next attempt of at most limit pending
+// deliveries due by now, the longest waiting, to until and returns them
+// oldest first
+func (r *InMemoryTodoRepository) ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []int // indexes into r.deliveries
+	for i, delivery := range r.deliveries {
+		if delivery.Status == model.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, i)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b int) int {
+		return r.deliveries[a].NextAttemptAt.Compare(*r.deliveries[b].NextAttemptAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	slices.Sort(due) // r.deliveries is in ID order
+
+	deliveries := make([]*model.WebhookDelivery, 0, len(due))
+	for _, i := range due {
+		claimed := copyDelivery(r.deliveries[i]) // Stored deliveries are never changed in place
+		claimed.NextAttemptAt = copyTime(&until)
+		claimed.UpdatedAt = time.Now().Round(0)
+		r.deliveries[i] = claimed
+		deliveries = append(deliveries, copyDelivery(claimed))
+	}
+	return deliveries, nil
+}
+
+// RecordDeliveryAttempt adds an attempt to a delivery and saves its new
+// status and next attempt time
+func (r *InMemoryTodoRepository) RecordDeliveryAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt *model.DeliveryAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.deliveries, func(d *model.WebhookDelivery) bool { return d.ID == delivery.ID })
+	if i < 0 {
+		return ErrWebhookNotFound
+	}
+
+	stored := copyDelivery(r.deliveries[i]) // Stored deliveries are never changed in place
+	stored.Status = delivery.Status
+	stored.NextAttemptAt = copyTime(delivery.NextAttemptAt)
+	c := *attempt
+	stored.Attempts = append(stored.Attempts, &c)
+	stored.UpdatedAt = time.Now().Round(0)
+	r.deliveries[i] = stored
+	return nil
+}
+
+// queueDeliveries adds a pending delivery of event for every webhook of its
+// user subscribed to its type; r.mu must be held
+func (r *InMemoryTodoRepository) queueDeliveries(event *model.TodoEvent) error {
+	var payload []byte
+	for id := 1; id <= r.nextWebhookID; id++ {
+		webhook, ok := r.webhooks[id]
+		if !ok || webhook.UserID != event.UserID || !slices.Contains(webhook.EventTypes, event.Type) {
+			continue
+		}
+		if payload == nil {
+			var err error
+			if payload, err = json.Marshal(event); err != nil {
+				return err
+			}
+		}
+
+		r.nextDeliveryID++
+		next := event.CreatedAt
+		r.deliveries = append(r.deliveries, &model.WebhookDelivery{
+			ID:            r.nextDeliveryID,
+			WebhookID:     id,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        model.DeliveryPending,
+			NextAttemptAt: &next,
+			Attempts:      []*model.DeliveryAttempt{},
+			CreatedAt:     event.CreatedAt,
+			UpdatedAt:     event.CreatedAt,
+		})
+	}
+	return nil
+}
+
+func copyWebhook(webhook *model.Webhook) *model.Webhook {
+	c := *webhook
+	c.EventTypes = slices.Clone(webhook.EventTypes)
+	return &c
+}
+
+func copyDelivery(delivery *model.WebhookDelivery) *model.WebhookDelivery {
+	c := *delivery
+	c.NextAttemptAt = copyTime(delivery.NextAttemptAt)
+	c.Attempts = make([]*model.DeliveryAttempt, len(delivery.Attempts))
+	for i, attempt := range delivery.Attempts {
+		a := *attempt
+		c.Attempts[i] = &a
+	}
+	return &c
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"todo-app/internal/model"
+)
+
+// memoryDirectory holds what the workspaces of an in-memory store share:
+// the workspaces themselves, users, sessions and API tokens
+type memoryDirectory struct {
+	mu              sync.RWMutex
+	workspaces      map[int]*model.Workspace
+	nextWorkspaceID int                             // last ID handed out
+	stores          map[int]*InMemoryTodoRepository // workspace ID -> its data, made on first use
+
+	users      map[int]*model.User
+	nextUserID int                       // last ID handed out
+	sessions   map[string]*model.Session // token hash -> session
+
+	apiTokens      map[int]*model.APIToken
+	nextAPITokenID int // last ID handed out
+}
+
+func newMemoryDirectory() *memoryDirectory {
+	dir := &memoryDirectory{stores: make(map[int]*InMemoryTodoRepository)}
+	dir.reset()
+	return dir
+}
+
+// reset removes everything but the default workspace and its store; d.mu
+// must be held unless d is new
+func (d *memoryDirectory) reset() {
+	now := time.Now().Round(0)
+	d.workspaces = map[int]*model.Workspace{
+		model.DefaultWorkspaceID: {ID: model.DefaultWorkspaceID, Slug: "default", Name: "Default", CreatedAt: now, UpdatedAt: now},
+	}
+	d.nextWorkspaceID = model.DefaultWorkspaceID
+	for id := range d.stores {
+		if id != model.DefaultWorkspaceID {
+			delete(d.stores, id)
+		}
+	}
+	d.users = make(map[int]*model.User)
+	d.nextUserID = 0
+	d.sessions = make(map[string]*model.Session)
+	d.apiTokens = make(map[int]*model.APIToken)
+	d.nextAPITokenID = 0
+}
+
+// store returns the store of a workspace, making it on first use; d.mu must be held
+func (d *memoryDirectory) store(workspaceID int) *InMemoryTodoRepository {
+	store, ok := d.stores[workspaceID]
+	if !ok {
+		store = newMemoryWorkspace(workspaceID, d)
+		d.stores[workspaceID] = store
+	}
+	return store
+}
+
+// ForWorkspace returns the store of the workspace with the given ID
+func (r *InMemoryTodoRepository) ForWorkspace(id int) TodoRepository {
+	r.dir.mu.Lock()
+	defer r.dir.mu.Unlock()
+	return r.dir.store(id)
+}
+
+// WorkspaceID returns the ID of the workspace the store holds
+func (r *InMemoryTodoRepository) WorkspaceID() int {
+	return r.workspaceID
+}
+
+// CreateWorkspace adds a new workspace with its inbox
+func (r *InMemoryTodoRepository) CreateWorkspace(ctx context.Context, workspace *model.Workspace) (*model.Workspace, error) {
+	r.dir.mu.Lock()
+	defer r.dir.mu.Unlock()
+
+	for _, other := range r.dir.workspaces {
+		if other.Slug == workspace.Slug {
+			return nil, ErrWorkspaceExists
+		}
+	}
+
+	now := time.Now().Round(0)
+	r.dir.nextWorkspaceID++
+	workspace.ID = r.dir.nextWorkspaceID
+	workspace.Settings = model.DefaultWorkspaceSettings(workspace.ID)
+	workspace.CreatedAt = now
+	workspace.UpdatedAt = now
+
+	c := *workspace
+	r.dir.workspaces[workspace.ID] = &c
+	r.dir.store(workspace.ID)
+	return workspace, nil
+}
+
+// GetWorkspaces returns every workspace by ID
+func (r *InMemoryTodoRepository) GetWorkspaces(ctx context.Context) ([]*model.Workspace, error) {
+	r.dir.mu.RLock()
+	defer r.dir.mu.RUnlock()
+
+	workspaces := make([]*model.Workspace, 0, len(r.dir.workspaces))
+	for _, workspace := range r.dir.workspaces {
+		workspaces = append(workspaces, readWorkspace(workspace))
+	}
+	slices.SortFunc(workspaces, func(a, b *model.Workspace) int { return a.ID - b.ID })
+	return workspaces, nil
+}
+
+// GetWorkspace returns the workspace with the given ID
+func (r *InMemoryTodoRepository) GetWorkspace(ctx context.Context, id int) (*model.Workspace, error) {
+	r.dir.mu.RLock()
+	defer r.dir.mu.RUnlock()
+
+	workspace, ok := r.dir.workspaces[id]
+	if !ok {
+		return nil, ErrWorkspaceNotFound
+	}
+	return readWorkspace(workspace), nil
+}
+
+// GetWorkspaceBySlug returns the workspace with the given slug
+func (r *InMemoryTodoRepository) GetWorkspaceBySlug(ctx context.Context, slug string) (*model.Workspace, error) {
+	r.dir.mu.RLock()
+	defer r.dir.mu.RUnlock()
+
+	for _, workspace := range r.dir.workspaces {
+		if workspace.Slug == slug {
+			return readWorkspace(workspace), nil
+		}
+	}
+	return nil, ErrWorkspaceNotFound
+}
+
+// GetWorkspaceSettings returns the settings of the store's workspace
+func (r *InMemoryTodoRepository) GetWorkspaceSettings(ctx context.Context) (model.WorkspaceSettings, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.settings, nil
+}
+
+// UpdateWorkspaceSettings saves the settings of the store's workspace
+func (r *InMemoryTodoRepository) UpdateWorkspaceSettings(ctx context.Context, settings model.WorkspaceSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.settings = settings
+	return nil
+}
+
+// readWorkspace returns a copy of a stored workspace without settings, like the SQL backends
+func readWorkspace(workspace *model.Workspace) *model.Workspace {
+	c := *workspace
+	c.Settings = model.WorkspaceSettings{}
+	return &c
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"todo-app/internal/model"
+)
+
+// postgresHeadlineOptions makes ts_headline mark matches like the other backends
+var postgresHeadlineOptions = "StartSel=" + snippetOpen + ", StopSel=" + snippetClose +
+	", MaxWords=" + strconv.Itoa(snippetWords) + ", MinWords=4"
+
+// SearchTodos ranks the matching todos with ts_rank over the 'simple' text
+// search configuration, breaking ties newest first
+func (r *PostgresTodoRepository) SearchTodos(ctx context.Context, query *model.SearchExpr, userID int, limit int) ([]*model.SearchResult, error) {
+	ctx, end := r.call(ctx, "SearchTodos")
+	defer end()
+
+	return r.scanSearchResults(ctx, `
+		SELECT `+todoColumns+`, ts_headline('simple', text, q, ?), ts_rank(to_tsvector('simple', text), q) AS score
+		FROM todos, to_tsquery('simple', ?) q
+		WHERE to_tsvector('simple', text) @@ q AND `+visibleTodos+`
+		ORDER BY score DESC, id DESC
+		LIMIT ?
+	`, slices.Concat([]any{postgresHeadlineOptions, tsQuery(query)}, r.visibleArgs(userID), []any{limit})...)
+}
+
+// tsQuery renders query in to_tsquery syntax
+func tsQuery(query *model.SearchExpr) string {
+	switch query.Op {
+	case model.SearchAnd:
+		return "(" + tsQuery(query.Left) + " & " + tsQuery(query.Right) + ")"
+	case model.SearchOr:
+		return "(" + tsQuery(query.Left) + " | " + tsQuery(query.Right) + ")"
+	case model.SearchNot:
+		return "(" + tsQuery(query.Left) + " & !" + tsQuery(query.Right) + ")"
+	}
+
+	lexemes := make([]string, len(query.Words))
+	for i, word := range query.Words {
+		lexemes[i] = "'" + strings.ReplaceAll(word, "'", "''") + "'"
+	}
+	if query.Prefix {
+		lexemes[len(lexemes)-1] += ":*"
+	}
+	return "(" + strings.Join(lexemes, " <-> ") + ")"
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"database/sql"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// postgresMigrationsDir is the directory of schemaFS holding the PostgreSQL migrations
+const postgresMigrationsDir = "database/migrations/postgres"
+
+// PostgresTodoRepository implements TodoRepository using PostgreSQL, so several
+// backend instances can share one database
+type PostgresTodoRepository struct {
+	*sqlTodoRepository
+}
+
+var _ TodoRepository = (*PostgresTodoRepository)(nil)
+
+// NewPostgresTodoRepository connects to the database at dbURL and applies pending migrations
+func NewPostgresTodoRepository(dbURL string) (*PostgresTodoRepository, error) {
+	db, err := OpenPostgres(dbURL)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := NewPostgresMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := migrator.Up(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &PostgresTodoRepository{
+		sqlTodoRepository: newSQLTodoRepository(db, postgresDialect),
+	}, nil
+}
+
+// ForWorkspace returns a repository on the same database working in the
+// workspace with the given ID
+func (r *PostgresTodoRepository) ForWorkspace(id int) TodoRepository {
+	return &PostgresTodoRepository{sqlTodoRepository: r.sqlTodoRepository.forWorkspace(id)}
+}
+
+func (r *PostgresTodoRepository) withCallOptions(opts CallOptions) TodoRepository {
+	return &PostgresTodoRepository{sqlTodoRepository: r.sqlTodoRepository.withCalls(opts)}
+}
+
+// OpenPostgres connects to the database at dbURL without running migrations
+func OpenPostgres(dbURL string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", dbURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// NewPostgresMigrator returns a migrator for the embedded PostgreSQL migrations
+func NewPostgresMigrator(db *sql.DB) (*Migrator, error) {
+	return newMigrator(db, schemaFS, postgresMigrationsDir, postgresDialect)
+}
@@ -0,0 +1,1960 @@
+// Package repositorytest holds the conformance suite every repository.TodoRepository
+// implementation must pass. Backends run it from their own tests:
+//
+//	repositorytest.Run(t, func(t *testing.T) repository.TodoRepository {
+//		return repository.NewInMemoryTodoRepository()
+//	})
+package repositorytest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/internal/filterlang"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// missingID is an ID no conformance test ever creates
+const missingID = 1 << 30
+
+// Factory returns an empty repository for one subtest; backends that share
+// storage between subtests should Truncate before returning it
+type Factory func(t *testing.T) repository.TodoRepository
+
+// Run executes the conformance suite against the repositories built by newRepo
+func Run(t *testing.T, newRepo Factory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo repository.TodoRepository)
+	}{
+		{"Create", testCreate},
+		{"GetAll_NewestFirst", testGetAllNewestFirst},
+		{"GetAll_Empty", testGetAllEmpty},
+		{"GetAll_CompletedFilter", testGetAllCompletedFilter},
+		{"CountTodos", testCountTodos},
+		{"GetAll_DueFilters", testGetAllDueFilters},
+		{"GetAll_Overdue", testGetAllOverdue},
+		{"GetAll_SortByPriority", testGetAllSortByPriority},
+		{"GetAll_SortByDueAt", testGetAllSortByDueAt},
+		{"GetAll_SortByText", testGetAllSortByText},
+		{"GetAll_CursorPages", testGetAllCursorPages},
+		{"GetAll_CursorWithFilter", testGetAllCursorWithFilter},
+		{"GetAll_TagFilter", testGetAllTagFilter},
+		{"GetAll_Where", testGetAllWhere},
+		{"Tags_RoundTrip", testTagsRoundTrip},
+		{"GetTags_Counts", testGetTagsCounts},
+		{"RenameTag", testRenameTag},
+		{"RenameTag_Conflict", testRenameTagConflict},
+		{"MergeTags", testMergeTags},
+		{"Tags_NotFound", testTagsNotFound},
+		{"Search_RanksAndHighlights", testSearchRanksAndHighlights},
+		{"Search_Syntax", testSearchSyntax},
+		{"Search_FollowsWrites", testSearchFollowsWrites},
+		{"Search_EscapesSnippets", testSearchEscapesSnippets},
+		{"Lists_InboxByDefault", testListsInboxByDefault},
+		{"Lists_CRUD", testListsCRUD},
+		{"Lists_MoveAndFilter", testListsMoveAndFilter},
+		{"DeleteList_MovesTodosToInbox", testDeleteListMovesTodosToInbox},
+		{"DeleteList_Errors", testDeleteListErrors},
+		{"ListMembers_CRUD", testListMembersCRUD},
+		{"ListMembers_SeeSharedTodos", testListMembersSeeSharedTodos},
+		{"Events_AppendAndRead", testEventsAppendAndRead},
+		{"Events_ResumeAcrossMoves", testEventsResumeAcrossMoves},
+		{"Atomically_RollsBack", testAtomicallyRollsBack},
+		{"Webhooks_CRUD", testWebhooksCRUD},
+		{"Webhooks_QueueDeliveries", testWebhooksQueueDeliveries},
+		{"Webhooks_RecordAttempts", testWebhooksRecordAttempts},
+		{"Users_CreateAndGet", testUsersCreateAndGet},
+		{"Users_AssignOwner", testUsersAssignOwner},
+		{"Users_KeepTodosApart", testUsersKeepTodosApart},
+		{"Users_KeepEventsAndWebhooksApart", testUsersKeepEventsAndWebhooksApart},
+		{"Sessions", testSessions},
+		{"APITokens", testAPITokens},
+		{"Workspaces_CRUD", testWorkspacesCRUD},
+		{"Workspaces_KeepDataApart", testWorkspacesKeepDataApart},
+		{"Workspaces_Settings", testWorkspacesSettings},
+		{"GetByID", testGetByID},
+		{"GetByID_NotFound", testGetByIDNotFound},
+		{"Update", testUpdate},
+		{"Update_NotFound", testUpdateNotFound},
+		{"Delete", testDelete},
+		{"Delete_NotFound", testDeleteNotFound},
+		{"Truncate", testTruncate},
+		{"Truncate_KeepsInbox", testTruncateKeepsInbox},
+		{"ReturnsCopies", testReturnsCopies},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRepo(t)
+			t.Cleanup(func() { repo.Close() })
+			tt.run(t, repo)
+		})
+	}
+}
+
+func mustCreate(t *testing.T, repo repository.TodoRepository, text string) *model.Todo {
+	ctx := t.Context()
+	t.Helper()
+	todo, err := repo.Create(ctx, &model.Todo{Text: text})
+	require.NoError(t, err)
+	return todo
+}
+
+func texts(todos []*model.Todo) []string {
+	result := make([]string, len(todos))
+	for i, todo := range todos {
+		result[i] = todo.Text
+	}
+	return result
+}
+
+func testCreate(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// When: Creating two todos
+	first, err := repo.Create(ctx, &model.Todo{Text: "first"})
+	require.NoError(t, err)
+	second, err := repo.Create(ctx, &model.Todo{Text: "second"})
+	require.NoError(t, err)
+
+	// Then: IDs and timestamps are assigned
+	assert.Positive(t, first.ID)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.Equal(t, "first", first.Text)
+	assert.False(t, first.Completed)
+	assert.Nil(t, first.CompletedAt)
+	assert.False(t, first.CreatedAt.IsZero())
+	assert.False(t, first.UpdatedAt.IsZero())
+}
+
+func testGetAllNewestFirst(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Three todos created in order
+	for _, text := range []string{"todo 1", "todo 2", "todo 3"} {
+		mustCreate(t, repo, text)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	// When: Listing all todos
+	todos, err := repo.GetAll(ctx, model.TodoFilter{})
+
+	// Then: The newest comes first
+	require.NoError(t, err)
+	assert.Equal(t, []string{"todo 3", "todo 2", "todo 1"}, texts(todos))
+}
+
+func testGetAllEmpty(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	todos, err := repo.GetAll(ctx, model.TodoFilter{})
+
+	require.NoError(t, err)
+	assert.NotNil(t, todos, "an empty list must encode as [] rather than null")
+	assert.Empty(t, todos)
+}
+
+func testGetAllCompletedFilter(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: One open and one completed todo
+	mustCreate(t, repo, "open")
+	done := mustCreate(t, repo, "done")
+	completedAt := time.Now().UTC().Truncate(time.Second)
+	done.Completed = true
+	done.CompletedAt = &completedAt
+	_, err := repo.Update(ctx, done)
+	require.NoError(t, err)
+
+	// When: Filtering on both states
+	yes, no := true, false
+	completed, err := repo.GetAll(ctx, model.TodoFilter{Completed: &yes})
+	require.NoError(t, err)
+	open, err := repo.GetAll(ctx, model.TodoFilter{Completed: &no})
+	require.NoError(t, err)
+
+	// Then: Each filter only matches its own state
+	assert.Equal(t, []string{"done"}, texts(completed))
+	require.NotNil(t, completed[0].CompletedAt)
+	assert.True(t, completedAt.Equal(*completed[0].CompletedAt))
+	assert.Equal(t, []string{"open"}, texts(open))
+}
+
+func testCountTodos(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: An empty workspace
+	counts, err := repo.CountTodos(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, model.TodoCounts{}, counts)
+
+	// When: Two users add three todos, one of them completed, and another workspace adds one
+	mustCreate(t, repo, "open")
+	_, err = repo.Create(ctx, &model.Todo{UserID: 2, Text: "open too"})
+	require.NoError(t, err)
+	done := mustCreate(t, repo, "done")
+	done.Completed = true
+	_, err = repo.Update(ctx, done)
+	require.NoError(t, err)
+
+	other := repo.ForWorkspace(mustCreateWorkspace(t, repo, "other").ID)
+	mustCreate(t, other, "elsewhere")
+
+	// Then: Every todo of the workspace counts, whoever owns it
+	counts, err = repo.CountTodos(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, model.TodoCounts{Total: 3, Open: 2}, counts)
+}
+
+func createDue(t *testing.T, repo repository.TodoRepository, text string, dueAt time.Time, allDay bool) *model.Todo {
+	ctx := t.Context()
+	t.Helper()
+	todo, err := repo.Create(ctx, &model.Todo{Text: text, DueAt: &dueAt, DueAllDay: allDay})
+	require.NoError(t, err)
+	return todo
+}
+
+func testGetAllDueFilters(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Todos due on different days and one without a due date
+	mustCreate(t, repo, "no due date")
+	createDue(t, repo, "october", time.Date(2026, 10, 20, 9, 30, 0, 0, time.UTC), false)
+	createDue(t, repo, "november first", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), true)
+	createDue(t, repo, "november later", time.Date(2026, 11, 15, 18, 0, 0, 0, time.UTC), false)
+
+	// When: Listing with due_before / due_after bounds
+	before := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	dueBefore, err := repo.GetAll(ctx, model.TodoFilter{DueBefore: &before})
+	require.NoError(t, err)
+	dueAfter, err := repo.GetAll(ctx, model.TodoFilter{DueAfter: &before})
+	require.NoError(t, err)
+
+	// Then: due_before is exclusive, due_after inclusive, and undated todos never match
+	assert.ElementsMatch(t, []string{"october"}, texts(dueBefore))
+	assert.ElementsMatch(t, []string{"november first", "november later"}, texts(dueAfter))
+
+	// And: The due date round-trips in UTC with its all-day flag
+	for _, todo := range dueAfter {
+		require.NotNil(t, todo.DueAt)
+		assert.Equal(t, time.UTC, todo.DueAt.Location())
+		if todo.Text == "november first" {
+			assert.True(t, todo.DueAllDay)
+			assert.True(t, before.Equal(*todo.DueAt))
+		}
+	}
+}
+
+func testGetAllOverdue(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: A reference time of 2026-11-01 12:00 UTC
+	now := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+	createDue(t, repo, "past instant", now.Add(-time.Hour), false)
+	createDue(t, repo, "future instant", now.Add(time.Hour), false)
+	createDue(t, repo, "yesterday all day", time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC), true)
+	createDue(t, repo, "today all day", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), true)
+	mustCreate(t, repo, "no due date")
+
+	done := createDue(t, repo, "completed past", now.Add(-2*time.Hour), false)
+	done.Completed = true
+	_, err := repo.Update(ctx, done)
+	require.NoError(t, err)
+
+	// When: Listing overdue todos
+	todos, err := repo.GetAll(ctx, model.TodoFilter{Overdue: true, Now: now})
+
+	// Then: Only open todos whose instant or whole day has passed match
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"past instant", "yesterday all day"}, texts(todos))
+}
+
+func createPriority(t *testing.T, repo repository.TodoRepository, text string, priority model.Priority) *model.Todo {
+	ctx := t.Context()
+	t.Helper()
+	todo, err := repo.Create(ctx, &model.Todo{Text: text, Priority: priority})
+	require.NoError(t, err)
+	return todo
+}
+
+func testGetAllSortByPriority(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Todos of mixed priority, two of them tied on high
+	createPriority(t, repo, "low", model.PriorityLow)
+	createPriority(t, repo, "high a", model.PriorityHigh)
+	createPriority(t, repo, "urgent", model.PriorityUrgent)
+	createPriority(t, repo, "high b", model.PriorityHigh)
+	mustCreate(t, repo, "none")
+
+	// When: Sorting by priority in both directions
+	desc, err := repo.GetAll(ctx, model.TodoFilter{Sort: []model.SortKey{{Field: model.SortPriority, Desc: true}}})
+	require.NoError(t, err)
+	asc, err := repo.GetAll(ctx, model.TodoFilter{Sort: []model.SortKey{{Field: model.SortPriority}}})
+	require.NoError(t, err)
+
+	// Then: Ties are broken on id in the same direction and the priority round-trips
+	assert.Equal(t, []string{"urgent", "high b", "high a", "low", "none"}, texts(desc))
+	assert.Equal(t, []string{"none", "low", "high a", "high b", "urgent"}, texts(asc))
+	assert.Equal(t, model.PriorityUrgent, desc[0].Priority)
+}
+
+func testGetAllSortByDueAt(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Todos due on different days and two without a due date
+	mustCreate(t, repo, "undated a")
+	createDue(t, repo, "later", time.Date(2026, 11, 15, 18, 0, 0, 0, time.UTC), false)
+	createDue(t, repo, "sooner", time.Date(2026, 10, 20, 9, 30, 0, 0, time.UTC), false)
+	mustCreate(t, repo, "undated b")
+
+	// When: Sorting by due date in both directions
+	asc, err := repo.GetAll(ctx, model.TodoFilter{Sort: []model.SortKey{{Field: model.SortDueAt}}})
+	require.NoError(t, err)
+	desc, err := repo.GetAll(ctx, model.TodoFilter{Sort: []model.SortKey{{Field: model.SortDueAt, Desc: true}}})
+	require.NoError(t, err)
+
+	// Then: Undated todos come last either way
+	assert.Equal(t, []string{"sooner", "later", "undated a", "undated b"}, texts(asc))
+	assert.Equal(t, []string{"later", "sooner", "undated b", "undated a"}, texts(desc))
+}
+
+func testGetAllSortByText(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Todos whose text differs in case
+	mustCreate(t, repo, "banana")
+	mustCreate(t, repo, "Cherry")
+	mustCreate(t, repo, "apple")
+
+	// When: Sorting by text, then by priority for equal texts
+	todos, err := repo.GetAll(ctx, model.TodoFilter{Sort: []model.SortKey{
+		{Field: model.SortText},
+		{Field: model.SortPriority, Desc: true},
+	}})
+
+	// Then: Text order ignores case
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apple", "banana", "Cherry"}, texts(todos))
+}
+
+// ids returns the IDs of todos in order
+func ids(todos []*model.Todo) []int {
+	result := make([]int, len(todos))
+	for i, todo := range todos {
+		result[i] = todo.ID
+	}
+	return result
+}
+
+func testGetAllCursorPages(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Todos with tied priorities, tied and missing due dates and mixed-case text
+	due := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	for i, todo := range []*model.Todo{
+		{Text: "b", Priority: model.PriorityHigh, DueAt: &due},
+		{Text: "A", Priority: model.PriorityHigh},
+		{Text: "c", Priority: model.PriorityLow, DueAt: &due},
+		{Text: "a", Priority: model.PriorityNone},
+		{Text: "d", Priority: model.PriorityHigh},
+		{Text: "e", Priority: model.PriorityUrgent, DueAt: &due},
+		{Text: "f", Priority: model.PriorityLow},
+	} {
+		if i%2 == 0 {
+			due = due.Add(time.Hour)
+		}
+		_, err := repo.Create(ctx, todo)
+		require.NoError(t, err)
+	}
+
+	sorts := [][]model.SortKey{
+		model.DefaultSort,
+		{{Field: model.SortCreatedAt}},
+		{{Field: model.SortPriority, Desc: true}},
+		{{Field: model.SortDueAt}, {Field: model.SortPriority}},
+		{{Field: model.SortDueAt, Desc: true}},
+		{{Field: model.SortText, Desc: true}},
+	}
+	for _, keys := range sorts {
+		t.Run(model.FormatSort(keys), func(t *testing.T) {
+			all, err := repo.GetAll(ctx, model.TodoFilter{Sort: keys})
+			require.NoError(t, err)
+
+			// When: Walking forward two todos at a time
+			var forward []*model.Todo
+			filter := model.TodoFilter{Sort: keys, Limit: 2}
+			for {
+				page, err := repo.GetAll(ctx, filter)
+				require.NoError(t, err)
+				if len(page) == 0 {
+					break
+				}
+				forward = append(forward, page...)
+				filter.Cursor = model.NewCursor(page[len(page)-1], keys, false)
+			}
+
+			// And: Walking backward from the end
+			var backward []*model.Todo
+			filter.Cursor = model.NewCursor(all[len(all)-1], keys, true)
+			backward = append(backward, all[len(all)-1])
+			for {
+				page, err := repo.GetAll(ctx, filter)
+				require.NoError(t, err)
+				if len(page) == 0 {
+					break
+				}
+				backward = append(append([]*model.Todo{}, page...), backward...)
+				filter.Cursor = model.NewCursor(page[0], keys, true)
+			}
+
+			// Then: Both walks visit every todo once in the full listing's order
+			assert.Equal(t, ids(all), ids(forward))
+			assert.Equal(t, ids(all), ids(backward))
+		})
+	}
+}
+
+func testGetAllCursorWithFilter(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Open and completed todos
+	for _, text := range []string{"open 1", "done 1", "open 2", "open 3"} {
+		todo := mustCreate(t, repo, text)
+		if text == "done 1" {
+			todo.Completed = true
+			_, err := repo.Update(ctx, todo)
+			require.NoError(t, err)
+		}
+	}
+
+	// When: Paging through open todos from the first one
+	open := false
+	first, err := repo.GetAll(ctx, model.TodoFilter{Completed: &open, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	rest, err := repo.GetAll(ctx, model.TodoFilter{
+		Completed: &open,
+		Cursor:    model.NewCursor(first[0], model.DefaultSort, false),
+	})
+
+	// Then: The cursor and the filter both apply
+	require.NoError(t, err)
+	assert.Equal(t, []string{"open 3"}, texts(first))
+	assert.Equal(t, []string{"open 2", "open 1"}, texts(rest))
+}
+
+func createTagged(t *testing.T, repo repository.TodoRepository, text string, tags ...string) *model.Todo {
+	ctx := t.Context()
+	t.Helper()
+	todo, err := repo.Create(ctx, &model.Todo{Text: text, Tags: tags})
+	require.NoError(t, err)
+	return todo
+}
+
+// tagByName finds a tag in the GetTags listing
+func tagByName(t *testing.T, repo repository.TodoRepository, name string) *model.Tag {
+	ctx := t.Context()
+	t.Helper()
+	tags, err := repo.GetTags(ctx, 0)
+	require.NoError(t, err)
+	for _, tag := range tags {
+		if tag.Name == name {
+			return tag
+		}
+	}
+	t.Fatalf("tag %q not found", name)
+	return nil
+}
+
+func testGetAllTagFilter(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Todos with overlapping tags
+	createTagged(t, repo, "work only", "work")
+	createTagged(t, repo, "work and urgent", "urgent", "work")
+	createTagged(t, repo, "home", "home")
+	mustCreate(t, repo, "untagged")
+
+	// When: Filtering on work and urgent with both modes
+	all, err := repo.GetAll(ctx, model.TodoFilter{Tags: []string{"urgent", "work"}})
+	require.NoError(t, err)
+	anyOf, err := repo.GetAll(ctx, model.TodoFilter{Tags: []string{"home", "urgent"}, AnyTag: true})
+	require.NoError(t, err)
+	unknown, err := repo.GetAll(ctx, model.TodoFilter{Tags: []string{"nope"}})
+	require.NoError(t, err)
+
+	// Then: All requires every tag, any requires one of them
+	assert.ElementsMatch(t, []string{"work and urgent"}, texts(all))
+	assert.ElementsMatch(t, []string{"work and urgent", "home"}, texts(anyOf))
+	assert.Empty(t, unknown)
+}
+
+func testGetAllWhere(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	date := func(value string) *time.Time {
+		d, _, err := model.ParseDue(value)
+		require.NoError(t, err)
+		return &d
+	}
+	for _, todo := range []*model.Todo{
+		{Text: "report", Tags: []string{"work"}, Priority: model.PriorityHigh, DueAt: date("2026-10-20T09:00:00Z")},
+		{Text: "slides", Tags: []string{"work"}, Priority: model.PriorityLow, DueAt: date("2026-12-01"), DueAllDay: true},
+		{Text: "groceries 100% organic", Tags: []string{"home"}, Priority: model.PriorityUrgent},
+		{Text: "taxes", Tags: []string{"work"}, Priority: model.PriorityUrgent, DueAt: date("2026-10-01"), DueAllDay: true, Completed: true},
+	} {
+		_, err := repo.Create(ctx, todo)
+		require.NoError(t, err)
+	}
+	now := time.Date(2026, 10, 25, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		filter   string
+		expected []string
+	}{
+		{filter: "tag:work AND priority>=high AND due<2026-11-01 AND NOT completed", expected: []string{"report"}},
+		{filter: "due:none", expected: []string{"groceries 100% organic"}},
+		{filter: "NOT due<2026-11-01", expected: []string{"groceries 100% organic", "slides"}},
+		{filter: "due!=2026-12-01", expected: []string{"groceries 100% organic", "report", "taxes"}},
+		{filter: "due:2026-12-01", expected: []string{"slides"}},
+		{filter: "priority>high OR tag:home", expected: []string{"groceries 100% organic", "taxes"}},
+		{filter: "text:REP", expected: []string{"report"}},
+		{filter: "text:%", expected: []string{"groceries 100% organic"}},
+		{filter: "completed:false AND tag!=work", expected: []string{"groceries 100% organic"}},
+		{filter: "overdue", expected: []string{"report"}},
+		{filter: "NOT overdue AND tag:work", expected: []string{"slides", "taxes"}},
+		{filter: "created>=2020-01-01 AND updated<2020-01-01", expected: []string{}},
+		{filter: fmt.Sprintf("list:%d AND NOT (tag:work OR tag:home)", inbox(t, repo).ID), expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			where, err := filterlang.Parse(tt.filter)
+			require.NoError(t, err)
+
+			todos, err := repo.GetAll(ctx, model.TodoFilter{Where: where, Now: now, Sort: []model.SortKey{{Field: model.SortText}}})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, texts(todos))
+		})
+	}
+
+	t.Run("the largest filters", func(t *testing.T) {
+		for _, filter := range []string{
+			strings.Repeat("tag:nope OR ", filterlang.MaxComparisons-1) + "tag:home",
+			strings.Repeat("NOT (", filterlang.MaxDepth/2) + "tag:home" + strings.Repeat(")", filterlang.MaxDepth/2),
+		} {
+			where, err := filterlang.Parse(filter)
+			require.NoError(t, err)
+
+			todos, err := repo.GetAll(ctx, model.TodoFilter{Where: where, Now: now})
+
+			require.NoError(t, err)
+			assert.Equal(t, []string{"groceries 100% organic"}, texts(todos))
+		}
+	})
+}
+
+func testTagsRoundTrip(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: A todo created with tags
+	created := createTagged(t, repo, "tagged", "b", "a")
+
+	// Then: Tags are read back sorted, and untagged todos have an empty list
+	stored, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, stored.Tags)
+	assert.Equal(t, []string{}, mustCreate(t, repo, "plain").Tags)
+
+	// When: Replacing the tags on update
+	stored.Tags = []string{"c"}
+	updated, err := repo.Update(ctx, stored)
+
+	// Then: The old links are gone
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, updated.Tags)
+	todos, err := repo.GetAll(ctx, model.TodoFilter{Tags: []string{"a"}})
+	require.NoError(t, err)
+	assert.Empty(t, todos)
+}
+
+func testGetTagsCounts(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Tags used by a different number of todos, one of them deleted
+	createTagged(t, repo, "one", "work", "home")
+	createTagged(t, repo, "two", "work")
+	removed := createTagged(t, repo, "three", "work", "errand")
+	require.NoError(t, repo.Delete(ctx, removed.ID))
+
+	// When: Listing tags
+	tags, err := repo.GetTags(ctx, 0)
+
+	// Then: Tags are sorted by name with their usage counts
+	require.NoError(t, err)
+	counts := make(map[string]int)
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+		counts[tag.Name] = tag.TodoCount
+		assert.Positive(t, tag.ID)
+	}
+	assert.Equal(t, []string{"errand", "home", "work"}, names)
+	assert.Equal(t, map[string]int{"errand": 0, "home": 1, "work": 2}, counts)
+}
+
+func testRenameTag(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: A todo tagged "wrk"
+	todo := createTagged(t, repo, "typo", "wrk")
+	tag := tagByName(t, repo, "wrk")
+
+	// When: Renaming the tag
+	renamed, err := repo.RenameTag(ctx, tag.ID, "work")
+
+	// Then: The todo carries the new name
+	require.NoError(t, err)
+	assert.Equal(t, model.Tag{ID: tag.ID, Name: "work", TodoCount: 1}, *renamed)
+	stored, err := repo.GetByID(ctx, todo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"work"}, stored.Tags)
+}
+
+func testRenameTagConflict(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	createTagged(t, repo, "todo", "work", "wrk")
+
+	_, err := repo.RenameTag(ctx, tagByName(t, repo, "wrk").ID, "work")
+
+	assert.ErrorIs(t, err, repository.ErrTagExists)
+}
+
+func testMergeTags(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Todos tagged with a duplicate tag, one of them with both tags
+	only := createTagged(t, repo, "only wrk", "wrk")
+	both := createTagged(t, repo, "both", "work", "wrk")
+	source, target := tagByName(t, repo, "wrk"), tagByName(t, repo, "work")
+
+	// When: Merging wrk into work
+	merged, err := repo.MergeTags(ctx, source.ID, target.ID)
+
+	// Then: Every todo is tagged once with work and wrk is gone
+	require.NoError(t, err)
+	assert.Equal(t, model.Tag{ID: target.ID, Name: "work", TodoCount: 2}, *merged)
+	for _, id := range []int{only.ID, both.ID} {
+		todo, err := repo.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"work"}, todo.Tags)
+	}
+	tags, err := repo.GetTags(ctx, 0)
+	require.NoError(t, err)
+	assert.Len(t, tags, 1)
+}
+
+func testTagsNotFound(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	createTagged(t, repo, "todo", "work")
+	work := tagByName(t, repo, "work")
+
+	_, err := repo.RenameTag(ctx, missingID, "x")
+	assert.ErrorIs(t, err, repository.ErrTagNotFound)
+	_, err = repo.MergeTags(ctx, missingID, work.ID)
+	assert.ErrorIs(t, err, repository.ErrTagNotFound)
+	_, err = repo.MergeTags(ctx, work.ID, missingID)
+	assert.ErrorIs(t, err, repository.ErrTagNotFound)
+}
+
+// inbox returns the default list
+// search runs query, skipping the test on backends built without full-text search
+func search(t *testing.T, repo repository.TodoRepository, query string, limit int) []*model.SearchResult {
+	ctx := t.Context()
+	t.Helper()
+	expr, err := model.ParseSearch(query)
+	require.NoError(t, err)
+
+	results, err := repo.SearchTodos(ctx, expr, 0, limit)
+	if errors.Is(err, repository.ErrSearchUnavailable) {
+		t.Skip("full-text search is not available; build with -tags sqlite_fts5")
+	}
+	require.NoError(t, err)
+	return results
+}
+
+func resultTexts(results []*model.SearchResult) []string {
+	texts := make([]string, len(results))
+	for i, result := range results {
+		texts[i] = result.Text
+	}
+	return texts
+}
+
+func testSearchRanksAndHighlights(t *testing.T, repo repository.TodoRepository) {
+	// Given: Todos mentioning milk once, several times and not at all
+	createTagged(t, repo, "Buy milk and bread for the weekend", "errand")
+	mustCreate(t, repo, "Milk milk milk")
+	mustCreate(t, repo, "Call mom")
+
+	// When: Searching for milk
+	results := search(t, repo, "milk", 10)
+
+	// Then: The todo mentioning milk most often comes first, with highlighted snippets
+	require.Equal(t, []string{"Milk milk milk", "Buy milk and bread for the weekend"}, resultTexts(results))
+	assert.Greater(t, results[0].Rank, results[1].Rank)
+	assert.Equal(t, "<mark>Milk</mark> <mark>milk</mark> <mark>milk</mark>", results[0].Snippet)
+	assert.Equal(t, "Buy <mark>milk</mark> and bread for the weekend", results[1].Snippet)
+	assert.Equal(t, []string{"errand"}, results[1].Tags)
+
+	// And: The limit keeps only the best matches
+	assert.Equal(t, []string{"Milk milk milk"}, resultTexts(search(t, repo, "milk", 1)))
+}
+
+func testSearchSyntax(t *testing.T, repo repository.TodoRepository) {
+	for _, text := range []string{"Buy oat milk", "Buy milk", "Milkshake recipe", "Pay bills"} {
+		mustCreate(t, repo, text)
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{query: `"oat milk"`, expected: []string{"Buy oat milk"}},
+		{query: `"milk oat"`, expected: []string{}},
+		{query: `milk*`, expected: []string{"Buy milk", "Buy oat milk", "Milkshake recipe"}},
+		{query: `buy milk`, expected: []string{"Buy milk", "Buy oat milk"}},
+		{query: `buy NOT oat`, expected: []string{"Buy milk"}},
+		{query: `bills OR recipe`, expected: []string{"Milkshake recipe", "Pay bills"}},
+		{query: `(oat AND buy) OR pay`, expected: []string{"Buy oat milk", "Pay bills"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			texts := resultTexts(search(t, repo, tt.query, 10))
+			sort.Strings(texts)
+			assert.Equal(t, tt.expected, texts)
+		})
+	}
+}
+
+func testSearchFollowsWrites(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: A todo found by its text
+	todo := mustCreate(t, repo, "Renew passport")
+	require.Len(t, search(t, repo, "passport", 10), 1)
+
+	// When: Its text changes
+	todo.Text = "Renew driving licence"
+	_, err := repo.Update(ctx, todo)
+	require.NoError(t, err)
+
+	// Then: Only the new text matches
+	assert.Empty(t, search(t, repo, "passport", 10))
+	assert.Len(t, search(t, repo, "licence", 10), 1)
+
+	// And: A deleted todo is no longer found
+	require.NoError(t, repo.Delete(ctx, todo.ID))
+	assert.Empty(t, search(t, repo, "licence", 10))
+}
+
+func testSearchEscapesSnippets(t *testing.T, repo repository.TodoRepository) {
+	mustCreate(t, repo, "<b>milk</b> & bread")
+
+	results := search(t, repo, "milk", 10)
+
+	require.Len(t, results, 1)
+	assert.Equal(t, "&lt;b&gt;<mark>milk</mark>&lt;/b&gt; &amp; bread", results[0].Snippet)
+}
+
+func inbox(t *testing.T, repo repository.TodoRepository) *model.List {
+	ctx := t.Context()
+	t.Helper()
+	lists, err := repo.GetLists(ctx, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, lists)
+	require.True(t, lists[0].Inbox, "the inbox must be listed first")
+	return lists[0]
+}
+
+func mustCreateList(t *testing.T, repo repository.TodoRepository, name string) *model.List {
+	ctx := t.Context()
+	t.Helper()
+	list, err := repo.CreateList(ctx, &model.List{Name: name})
+	require.NoError(t, err)
+	return list
+}
+
+func testListsInboxByDefault(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: An empty repository holds only the inbox
+	lists, err := repo.GetLists(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, lists, 1)
+	assert.Equal(t, "Inbox", lists[0].Name)
+
+	// When: Creating a todo without a list
+	todo := mustCreate(t, repo, "unsorted")
+
+	// Then: It lands in the inbox
+	assert.Equal(t, lists[0].ID, todo.ListID)
+	assert.Equal(t, 1, inbox(t, repo).TodoCount)
+}
+
+func testListsCRUD(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// When: Creating lists
+	work := mustCreateList(t, repo, "Work")
+	home := mustCreateList(t, repo, "Home")
+
+	// Then: They follow the inbox in creation order
+	assert.Positive(t, work.ID)
+	assert.False(t, work.Inbox)
+	assert.False(t, work.CreatedAt.IsZero())
+	lists, err := repo.GetLists(ctx, 0)
+	require.NoError(t, err)
+	names := make([]string, len(lists))
+	for i, list := range lists {
+		names[i] = list.Name
+	}
+	assert.Equal(t, []string{"Inbox", "Work", "Home"}, names)
+
+	// When: Renaming one
+	time.Sleep(2 * time.Millisecond)
+	renamed, err := repo.UpdateList(ctx, &model.List{ID: home.ID, Name: "Personal"})
+
+	// Then: The name is saved and updated_at bumped
+	require.NoError(t, err)
+	assert.Equal(t, "Personal", renamed.Name)
+	assert.True(t, renamed.UpdatedAt.After(home.UpdatedAt))
+	stored, err := repo.GetList(ctx, home.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Personal", stored.Name)
+
+	// And: Unknown lists are reported
+	_, err = repo.GetList(ctx, missingID)
+	assert.ErrorIs(t, err, repository.ErrListNotFound)
+	_, err = repo.UpdateList(ctx, &model.List{ID: missingID, Name: "ghost"})
+	assert.ErrorIs(t, err, repository.ErrListNotFound)
+}
+
+func testListsMoveAndFilter(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: A todo in a work list and one in the inbox
+	work := mustCreateList(t, repo, "Work")
+	report, err := repo.Create(ctx, &model.Todo{Text: "report", ListID: work.ID})
+	require.NoError(t, err)
+	milk := mustCreate(t, repo, "milk")
+
+	// When: Saving a todo without a list ID
+	report.ListID = 0
+	report.Text = "quarterly report"
+	updated, err := repo.Update(ctx, report)
+
+	// Then: It stays in its list
+	require.NoError(t, err)
+	assert.Equal(t, work.ID, updated.ListID)
+
+	// When: Moving the inbox todo to the work list
+	milk.ListID = work.ID
+	_, err = repo.Update(ctx, milk)
+	require.NoError(t, err)
+
+	// Then: Filtering on the list finds both and the counts follow
+	todos, err := repo.GetAll(ctx, model.TodoFilter{ListID: work.ID})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"quarterly report", "milk"}, texts(todos))
+	stored, err := repo.GetList(ctx, work.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stored.TodoCount)
+	assert.Equal(t, 0, inbox(t, repo).TodoCount)
+}
+
+func testDeleteListMovesTodosToInbox(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: A list with a todo
+	work := mustCreateList(t, repo, "Work")
+	todo, err := repo.Create(ctx, &model.Todo{Text: "report", ListID: work.ID})
+	require.NoError(t, err)
+
+	// When: Deleting the list
+	require.NoError(t, repo.DeleteList(ctx, work.ID))
+
+	// Then: The list is gone and the todo moved to the inbox
+	_, err = repo.GetList(ctx, work.ID)
+	assert.ErrorIs(t, err, repository.ErrListNotFound)
+	stored, err := repo.GetByID(ctx, todo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, inbox(t, repo).ID, stored.ListID)
+}
+
+func testDeleteListErrors(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	assert.ErrorIs(t, repo.DeleteList(ctx, missingID), repository.ErrListNotFound)
+	assert.ErrorIs(t, repo.DeleteList(ctx, inbox(t, repo).ID), repository.ErrDeleteInbox)
+}
+
+func testListMembersCRUD(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Alice's list and two other users
+	alice := mustCreateUser(t, repo, "alice")
+	bob := mustCreateUser(t, repo, "bob")
+	carol := mustCreateUser(t, repo, "carol")
+	work, err := repo.CreateList(ctx, &model.List{UserID: alice.ID, Name: "Work"})
+	require.NoError(t, err)
+
+	// When: She invites both of them
+	invited, err := repo.AddListMember(ctx, &model.ListMember{ListID: work.ID, UserID: bob.ID, Role: model.ListEditor})
+	require.NoError(t, err)
+	_, err = repo.AddListMember(ctx, &model.ListMember{ListID: work.ID, UserID: carol.ID, Role: model.ListViewer})
+	require.NoError(t, err)
+
+	// Then: The invitations are pending, in the order they were sent
+	assert.False(t, invited.CreatedAt.IsZero())
+	members, err := repo.GetListMembers(ctx, work.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, bob.ID, members[0].UserID)
+	assert.Equal(t, "Work", members[0].ListName)
+	assert.Equal(t, model.ListEditor, members[0].Role)
+	assert.False(t, members[0].Accepted)
+	assert.Equal(t, model.ListViewer, members[1].Role)
+
+	invitations, err := repo.GetInvitations(ctx, bob.ID)
+	require.NoError(t, err)
+	require.Len(t, invitations, 1)
+	assert.Equal(t, work.ID, invitations[0].ListID)
+
+	// And: Nobody is invited twice
+	_, err = repo.AddListMember(ctx, &model.ListMember{ListID: work.ID, UserID: bob.ID, Role: model.ListViewer})
+	assert.ErrorIs(t, err, repository.ErrMemberExists)
+
+	// When: Bob accepts and Carol is made an editor
+	_, err = repo.UpdateListMember(ctx, &model.ListMember{ListID: work.ID, UserID: bob.ID, Role: model.ListEditor, Accepted: true})
+	require.NoError(t, err)
+	updated, err := repo.UpdateListMember(ctx, &model.ListMember{ListID: work.ID, UserID: carol.ID, Role: model.ListEditor})
+	require.NoError(t, err)
+
+	// Then: The changes are saved
+	assert.Equal(t, model.ListEditor, updated.Role)
+	member, err := repo.GetListMember(ctx, work.ID, bob.ID)
+	require.NoError(t, err)
+	assert.True(t, member.Accepted)
+	invitations, err = repo.GetInvitations(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Empty(t, invitations)
+
+	// When: Carol is removed
+	require.NoError(t, repo.DeleteListMember(ctx, work.ID, carol.ID))
+
+	// Then: She is no longer a member, and missing members are reported
+	_, err = repo.GetListMember(ctx, work.ID, carol.ID)
+	assert.ErrorIs(t, err, repository.ErrMemberNotFound)
+	assert.ErrorIs(t, repo.DeleteListMember(ctx, work.ID, carol.ID), repository.ErrMemberNotFound)
+	_, err = repo.UpdateListMember(ctx, &model.ListMember{ListID: work.ID, UserID: carol.ID, Role: model.ListViewer})
+	assert.ErrorIs(t, err, repository.ErrMemberNotFound)
+
+	// When: The list is deleted
+	require.NoError(t, repo.DeleteList(ctx, work.ID))
+
+	// Then: Its members go with it
+	_, err = repo.GetListMember(ctx, work.ID, bob.ID)
+	assert.ErrorIs(t, err, repository.ErrMemberNotFound)
+}
+
+func testListMembersSeeSharedTodos(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Alice's list shared with Bob, who has not accepted yet, and a todo of each in the inbox
+	alice := mustCreateUser(t, repo, "alice")
+	bob := mustCreateUser(t, repo, "bob")
+	work, err := repo.CreateList(ctx, &model.List{UserID: alice.ID, Name: "Work"})
+	require.NoError(t, err)
+	_, err = repo.AddListMember(ctx, &model.ListMember{ListID: work.ID, UserID: bob.ID, Role: model.ListViewer})
+	require.NoError(t, err)
+	report, err := repo.Create(ctx, &model.Todo{UserID: alice.ID, Text: "Write the report", ListID: work.ID})
+	require.NoError(t, err)
+	_, err = repo.AppendEvent(ctx, &model.TodoEvent{UserID: alice.ID, Type: model.TodoCreated, TodoID: report.ID, Todo: report})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &model.Todo{UserID: alice.ID, Text: "Buy the report cover"})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &model.Todo{UserID: bob.ID, Text: "Walk the dog"})
+	require.NoError(t, err)
+
+	// Then: Until he accepts, Bob only sees his inbox
+	lists, err := repo.GetLists(ctx, bob.ID)
+	require.NoError(t, err)
+	require.Len(t, lists, 1)
+	assert.Equal(t, 1, lists[0].TodoCount)
+	todos, err := repo.GetAll(ctx, model.TodoFilter{UserID: bob.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Walk the dog"}, texts(todos))
+
+	// When: He accepts
+	_, err = repo.UpdateListMember(ctx, &model.ListMember{ListID: work.ID, UserID: bob.ID, Role: model.ListViewer, Accepted: true})
+	require.NoError(t, err)
+
+	// Then: He sees the list with his role and its todos, but still only his own in the inbox
+	lists, err = repo.GetLists(ctx, bob.ID)
+	require.NoError(t, err)
+	require.Len(t, lists, 2)
+	assert.Equal(t, model.ListOwner, lists[0].Role)
+	assert.Equal(t, 1, lists[0].TodoCount)
+	assert.Equal(t, "Work", lists[1].Name)
+	assert.Equal(t, model.ListViewer, lists[1].Role)
+	assert.Equal(t, 1, lists[1].TodoCount)
+	todos, err = repo.GetAll(ctx, model.TodoFilter{UserID: bob.ID})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Walk the dog", "Write the report"}, texts(todos))
+
+	// And: Alice owns the list and sees her two todos
+	lists, err = repo.GetLists(ctx, alice.ID)
+	require.NoError(t, err)
+	require.Len(t, lists, 2)
+	assert.Equal(t, model.ListOwner, lists[1].Role)
+	todos, err = repo.GetAll(ctx, model.TodoFilter{UserID: alice.ID})
+	require.NoError(t, err)
+	assert.Len(t, todos, 2)
+
+	// And: He reads the events of the shared list
+	events, err := repo.GetEvents(ctx, bob.ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, report.ID, events[0].TodoID)
+
+	// And: He finds its todos, not those of Alice's inbox
+	expr, err := model.ParseSearch("report")
+	require.NoError(t, err)
+	results, err := repo.SearchTodos(ctx, expr, bob.ID, 10)
+	if errors.Is(err, repository.ErrSearchUnavailable) {
+		return
+	}
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Write the report", results[0].Text)
+}
+
+func testEventsAppendAndRead(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: A change log recording a todo's life
+	todo := createTagged(t, repo, "Buy milk", "errands")
+	created, err := repo.AppendEvent(ctx, &model.TodoEvent{Type: model.TodoCreated, TodoID: todo.ID, Todo: todo})
+	require.NoError(t, err)
+	todo.Completed = true
+	updated, err := repo.AppendEvent(ctx, &model.TodoEvent{Type: model.TodoUpdated, TodoID: todo.ID, Todo: todo})
+	require.NoError(t, err)
+	deleted, err := repo.AppendEvent(ctx, &model.TodoEvent{Type: model.TodoDeleted, TodoID: todo.ID})
+	require.NoError(t, err)
+
+	// Then: IDs grow and the times are filled in
+	assert.Greater(t, updated.ID, created.ID)
+	assert.Greater(t, deleted.ID, updated.ID)
+	assert.False(t, created.CreatedAt.IsZero())
+
+	// When: Reading the whole log
+	events, err := repo.GetEvents(ctx, 0, 0, 10)
+
+	// Then: The events come back oldest first with their todos
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, []int64{created.ID, updated.ID, deleted.ID}, []int64{events[0].ID, events[1].ID, events[2].ID})
+	assert.Equal(t, model.TodoCreated, events[0].Type)
+	require.NotNil(t, events[0].Todo)
+	assert.Equal(t, "Buy milk", events[0].Todo.Text)
+	assert.Equal(t, []string{"errands"}, events[0].Todo.Tags)
+	assert.False(t, events[0].Todo.Completed)
+	assert.True(t, events[1].Todo.Completed)
+	assert.Equal(t, model.TodoDeleted, events[2].Type)
+	assert.Equal(t, todo.ID, events[2].TodoID)
+	assert.Nil(t, events[2].Todo)
+
+	// And: Reading resumes after a given ID and stops at the limit
+	events, err = repo.GetEvents(ctx, 0, created.ID, 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, updated.ID, events[0].ID)
+
+	events, err = repo.GetEvents(ctx, 0, deleted.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	// And: Truncate clears the log
+	require.NoError(t, repo.Truncate(ctx))
+	events, err = repo.GetEvents(ctx, 0, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func testEventsResumeAcrossMoves(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Alice's list shared with Bob, and a todo of hers in it that Bob saw created
+	alice := mustCreateUser(t, repo, "alice")
+	bob := mustCreateUser(t, repo, "bob")
+	carol := mustCreateUser(t, repo, "carol")
+	work, err := repo.CreateList(ctx, &model.List{UserID: alice.ID, Name: "Work"})
+	require.NoError(t, err)
+	_, err = repo.AddListMember(ctx, &model.ListMember{ListID: work.ID, UserID: bob.ID, Role: model.ListEditor, Accepted: true})
+	require.NoError(t, err)
+	report, err := repo.Create(ctx, &model.Todo{UserID: alice.ID, Text: "Write the report", ListID: work.ID})
+	require.NoError(t, err)
+	created, err := repo.AppendEvent(ctx, &model.TodoEvent{UserID: alice.ID, Type: model.TodoCreated, TodoID: report.ID, Todo: report})
+	require.NoError(t, err)
+
+	// When: Alice moves it to her inbox
+	lists, err := repo.GetLists(ctx, alice.ID)
+	require.NoError(t, err)
+	report.ListID = lists[0].ID
+	report, err = repo.Update(ctx, report)
+	require.NoError(t, err)
+	moved, err := repo.AppendEvent(ctx, &model.TodoEvent{UserID: alice.ID, Type: model.TodoUpdated, TodoID: report.ID, Todo: report, FromListID: work.ID})
+	require.NoError(t, err)
+
+	// Then: Bob, resuming after the creation, reads the move out of the list
+	events, err := repo.GetEvents(ctx, bob.ID, created.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, moved.ID, events[0].ID)
+	assert.Equal(t, work.ID, events[0].FromListID)
+	assert.Equal(t, lists[0].ID, events[0].Todo.ListID)
+
+	// And: Carol, who never joined the list, reads nothing
+	events, err = repo.GetEvents(ctx, carol.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func testAtomicallyRollsBack(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: A transaction that creates a todo, logs it and then fails
+	failure := errors.New("boom")
+	err := repo.Atomically(ctx, func(tx repository.TxRepository) error {
+		todo, err := tx.Create(ctx, &model.Todo{Text: "never kept"})
+		require.NoError(t, err)
+		_, err = tx.AppendEvent(ctx, &model.TodoEvent{Type: model.TodoCreated, TodoID: todo.ID, Todo: todo})
+		require.NoError(t, err)
+		return failure
+	})
+
+	// Then: The error comes back and neither write is kept
+	assert.ErrorIs(t, err, failure)
+	todos, err := repo.GetAll(ctx, model.TodoFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, todos)
+	events, err := repo.GetEvents(ctx, 0, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	// When: A transaction succeeds
+	err = repo.Atomically(ctx, func(tx repository.TxRepository) error {
+		_, err := tx.Create(ctx, &model.Todo{Text: "kept"})
+		return err
+	})
+
+	// Then: Its writes are kept
+	require.NoError(t, err)
+	todos, err = repo.GetAll(ctx, model.TodoFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kept"}, texts(todos))
+}
+
+func mustCreateWebhook(t *testing.T, repo repository.TodoRepository, url string, types ...model.TodoEventType) *model.Webhook {
+	ctx := t.Context()
+	t.Helper()
+	webhook, err := repo.CreateWebhook(ctx, &model.Webhook{URL: url, Secret: "s3cret", EventTypes: types})
+	require.NoError(t, err)
+	return webhook
+}
+
+func testWebhooksCRUD(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Two webhooks
+	first := mustCreateWebhook(t, repo, "https://example.com/a", model.TodoCreated, model.TodoDeleted)
+	second := mustCreateWebhook(t, repo, "https://example.com/b", model.TodoUpdated)
+
+	// Then: IDs and timestamps are assigned
+	assert.Positive(t, first.ID)
+	assert.Greater(t, second.ID, first.ID)
+	assert.False(t, first.CreatedAt.IsZero())
+
+	// And: They are read back by ID with their secrets and event types
+	webhooks, err := repo.GetWebhooks(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 2)
+	assert.Equal(t, first.ID, webhooks[0].ID)
+	found, err := repo.GetWebhook(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", found.Secret)
+	assert.Equal(t, []model.TodoEventType{model.TodoCreated, model.TodoDeleted}, found.EventTypes)
+
+	// When: One is updated
+	found.URL = "https://example.com/c"
+	found.EventTypes = []model.TodoEventType{model.TodoUpdated}
+	updated, err := repo.UpdateWebhook(ctx, found)
+
+	// Then: The change is kept
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/c", updated.URL)
+	found, err = repo.GetWebhook(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []model.TodoEventType{model.TodoUpdated}, found.EventTypes)
+
+	// When: One is deleted
+	require.NoError(t, repo.DeleteWebhook(ctx, second.ID))
+
+	// Then: It is gone
+	_, err = repo.GetWebhook(ctx, second.ID)
+	assert.ErrorIs(t, err, repository.ErrWebhookNotFound)
+
+	// And: Missing webhooks are reported
+	_, err = repo.UpdateWebhook(ctx, &model.Webhook{ID: missingID, URL: "https://example.com"})
+	assert.ErrorIs(t, err, repository.ErrWebhookNotFound)
+	assert.ErrorIs(t, repo.DeleteWebhook(ctx, missingID), repository.ErrWebhookNotFound)
+}
+
+func testWebhooksQueueDeliveries(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: A webhook for every change and one for deletions only
+	all := mustCreateWebhook(t, repo, "https://example.com/all", model.TodoCreated, model.TodoUpdated, model.TodoDeleted)
+	deletions := mustCreateWebhook(t, repo, "https://example.com/deleted", model.TodoDeleted)
+
+	// When: A todo is created
+	todo := mustCreate(t, repo, "Buy milk")
+	event, err := repo.AppendEvent(ctx, &model.TodoEvent{Type: model.TodoCreated, TodoID: todo.ID, Todo: todo})
+	require.NoError(t, err)
+
+	// Then: Only the webhook for every change gets a pending delivery of the event
+	deliveries, err := repo.GetDeliveries(ctx, all.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	delivery := deliveries[0]
+	assert.Equal(t, event.ID, delivery.EventID)
+	assert.Equal(t, model.TodoCreated, delivery.EventType)
+	assert.Equal(t, model.DeliveryPending, delivery.Status)
+	require.NotNil(t, delivery.NextAttemptAt)
+	assert.Empty(t, delivery.Attempts)
+	assert.JSONEq(t, fmt.Sprintf(`{"id":%d,"type":"created","todo_id":%d}`, event.ID, todo.ID),
+		filterJSON(t, delivery.Payload, "id", "type", "todo_id"))
+
+	deliveries, err = repo.GetDeliveries(ctx, deletions.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	// When: It is deleted
+	_, err = repo.AppendEvent(ctx, &model.TodoEvent{Type: model.TodoDeleted, TodoID: todo.ID, Todo: todo})
+	require.NoError(t, err)
+
+	// Then: Both webhooks get a delivery, newest first
+	deliveries, err = repo.GetDeliveries(ctx, all.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, model.TodoDeleted, deliveries[0].EventType)
+	deliveries, err = repo.GetDeliveries(ctx, deletions.ID, 10)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+
+	// And: Deliveries made in a failed transaction are dropped with its event
+	_ = repo.Atomically(ctx, func(tx repository.TxRepository) error {
+		_, err := tx.AppendEvent(ctx, &model.TodoEvent{Type: model.TodoDeleted, TodoID: todo.ID, Todo: todo})
+		require.NoError(t, err)
+		return errors.New("rolled back")
+	})
+	deliveries, err = repo.GetDeliveries(ctx, deletions.ID, 10)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+
+	// And: Deleting a webhook deletes its deliveries, and Truncate everything
+	require.NoError(t, repo.DeleteWebhook(ctx, deletions.ID))
+	deliveries, err = repo.GetDeliveries(ctx, deletions.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	require.NoError(t, repo.Truncate(ctx))
+	webhooks, err := repo.GetWebhooks(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+	deliveries, err = repo.GetDeliveries(ctx, all.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
+
+func testWebhooksRecordAttempts(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Two queued deliveries
+	webhook := mustCreateWebhook(t, repo, "https://example.com", model.TodoCreated)
+	for _, text := range []string{"first", "second"} {
+		todo := mustCreate(t, repo, text)
+		_, err := repo.AppendEvent(ctx, &model.TodoEvent{Type: model.TodoCreated, TodoID: todo.ID, Todo: todo})
+		require.NoError(t, err)
+	}
+
+	// Then: None is due before it was queued, and both are claimed, the oldest first
+	lease := time.Now().Add(10 * time.Minute).UTC()
+	early, err := repo.ClaimDueDeliveries(ctx, time.Now().Add(-time.Hour), lease, 10)
+	require.NoError(t, err)
+	assert.Empty(t, early)
+	due, err := repo.ClaimDueDeliveries(ctx, time.Now().Add(time.Second), lease, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assert.Less(t, due[0].ID, due[1].ID)
+	assert.WithinDuration(t, lease, *due[0].NextAttemptAt, time.Millisecond)
+
+	// And: Claimed, they are not claimed again until the lease ends
+	again, err := repo.ClaimDueDeliveries(ctx, time.Now().Add(time.Second), lease, 10)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+	again, err = repo.ClaimDueDeliveries(ctx, lease.Add(time.Second), lease.Add(time.Hour), 1)
+	require.NoError(t, err)
+	require.Len(t, again, 1)
+	assert.Equal(t, due[0].ID, again[0].ID)
+
+	// When: The first fails and is retried in a minute, and the second is delivered
+	retry := time.Now().Add(time.Minute).UTC()
+	first, second := due[0], due[1]
+	first.NextAttemptAt = &retry
+	require.NoError(t, repo.RecordDeliveryAttempt(ctx, first, &model.DeliveryAttempt{
+		AttemptedAt: time.Now().UTC(), StatusCode: 500, Error: "endpoint answered 500", DurationMS: 12,
+	}))
+	second.Status, second.NextAttemptAt = model.DeliveryDelivered, nil
+	require.NoError(t, repo.RecordDeliveryAttempt(ctx, second, &model.DeliveryAttempt{
+		AttemptedAt: time.Now().UTC(), StatusCode: 204, DurationMS: 3,
+	}))
+
+	// Then: The attempts are listed with the deliveries
+	deliveries, err := repo.GetDeliveries(ctx, webhook.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, model.DeliveryDelivered, deliveries[0].Status)
+	assert.Nil(t, deliveries[0].NextAttemptAt)
+	require.Len(t, deliveries[1].Attempts, 1)
+	attempt := deliveries[1].Attempts[0]
+	assert.Equal(t, 500, attempt.StatusCode)
+	assert.Equal(t, "endpoint answered 500", attempt.Error)
+	assert.Equal(t, int64(12), attempt.DurationMS)
+	assert.Equal(t, model.DeliveryPending, deliveries[1].Status)
+	assert.WithinDuration(t, retry, *deliveries[1].NextAttemptAt, time.Millisecond)
+
+	// And: The limit keeps the newest
+	deliveries, err = repo.GetDeliveries(ctx, webhook.ID, 1)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, second.ID, deliveries[0].ID)
+
+	// And: Neither is due now, and the first is due after its retry time
+	due, err = repo.ClaimDueDeliveries(ctx, time.Now().Add(time.Second), lease, 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+	due, err = repo.ClaimDueDeliveries(ctx, retry.Add(time.Second), lease, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, first.ID, due[0].ID)
+	require.Len(t, due[0].Attempts, 1)
+
+	// And: Recording an attempt of a deleted delivery is reported
+	require.NoError(t, repo.DeleteWebhook(ctx, webhook.ID))
+	assert.ErrorIs(t, repo.RecordDeliveryAttempt(ctx, first, &model.DeliveryAttempt{AttemptedAt: time.Now()}), repository.ErrWebhookNotFound)
+}
+
+// filterJSON returns the given fields of the JSON object data, re-encoded
+func filterJSON(t *testing.T, data []byte, fields ...string) string {
+	t.Helper()
+	var object map[string]any
+	require.NoError(t, json.Unmarshal(data, &object))
+	filtered := make(map[string]any, len(fields))
+	for _, field := range fields {
+		filtered[field] = object[field]
+	}
+	encoded, err := json.Marshal(filtered)
+	require.NoError(t, err)
+	return string(encoded)
+}
+
+func mustCreateUser(t *testing.T, repo repository.TodoRepository, username string) *model.User {
+	ctx := t.Context()
+	t.Helper()
+	user, err := repo.CreateUser(ctx, &model.User{Username: username, PasswordHash: "hash of " + username})
+	require.NoError(t, err)
+	return user
+}
+
+func testUsersCreateAndGet(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// When: Creating two users
+	alice := mustCreateUser(t, repo, "alice")
+	bob := mustCreateUser(t, repo, "bob")
+
+	// Then: IDs and timestamps are assigned
+	assert.Positive(t, alice.ID)
+	assert.NotEqual(t, alice.ID, bob.ID)
+	assert.False(t, alice.CreatedAt.IsZero())
+
+	// And: They are read back by ID and by username
+	found, err := repo.GetUser(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", found.Username)
+	assert.Equal(t, "hash of alice", found.PasswordHash)
+	found, err = repo.GetUserByUsername(ctx, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, found.ID)
+
+	// And: Usernames are unique and missing users are reported
+	_, err = repo.CreateUser(ctx, &model.User{Username: "alice", PasswordHash: "other"})
+	assert.ErrorIs(t, err, repository.ErrUsernameTaken)
+	_, err = repo.GetUser(ctx, missingID)
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+	_, err = repo.GetUserByUsername(ctx, "carol")
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+
+	// And: Truncate removes them
+	require.NoError(t, repo.Truncate(ctx))
+	_, err = repo.GetUser(ctx, alice.ID)
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+}
+
+func testUsersAssignOwner(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: A todo, its tag, event, webhook and list made before accounts
+	// existed, and another workspace with an unowned todo of its own
+	todo := createTagged(t, repo, "Buy milk", "errands")
+	_, err := repo.AppendEvent(ctx, &model.TodoEvent{Type: model.TodoCreated, TodoID: todo.ID, Todo: todo})
+	require.NoError(t, err)
+	mustCreateWebhook(t, repo, "https://example.com/hook", model.TodoCreated)
+	_, err = repo.CreateList(ctx, &model.List{Name: "Errands"})
+	require.NoError(t, err)
+	acme := mustCreateWorkspace(t, repo, "acme")
+	_, err = repo.ForWorkspace(acme.ID).Create(ctx, &model.Todo{Text: "Acme's todo"})
+	require.NoError(t, err)
+
+	// When: Users register in both workspaces
+	first := mustCreateUser(t, repo, "alice")
+	acmeFirst, err := repo.CreateUser(ctx, &model.User{WorkspaceID: acme.ID, Username: "bob", PasswordHash: "hash of bob"})
+	require.NoError(t, err)
+
+	// Then: Registering gives them nothing
+	owned := func(repo repository.TodoRepository, userID int) []string {
+		t.Helper()
+		todos, err := repo.GetAll(ctx, model.TodoFilter{UserID: userID})
+		require.NoError(t, err)
+		return texts(todos)
+	}
+	assert.Empty(t, owned(repo, first.ID))
+	assert.Equal(t, []string{"Buy milk"}, owned(repo, 0))
+	assert.Empty(t, owned(repo.ForWorkspace(acme.ID), acmeFirst.ID))
+
+	// When: The operator names the owner of the default workspace's data
+	require.NoError(t, repo.AssignOwner(ctx, first.ID))
+
+	// Then: That user owns all of it but the inbox
+	todos, err := repo.GetAll(ctx, model.TodoFilter{UserID: first.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Buy milk"}, texts(todos))
+	assert.Equal(t, first.ID, todos[0].UserID)
+	tags, err := repo.GetTags(ctx, first.ID)
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	assert.Equal(t, first.ID, tags[0].UserID)
+	events, err := repo.GetEvents(ctx, first.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+	webhooks, err := repo.GetWebhooks(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Len(t, webhooks, 1)
+	lists, err := repo.GetLists(ctx, first.ID)
+	require.NoError(t, err)
+	var owners []int
+	for _, list := range lists {
+		if !list.Inbox {
+			owners = append(owners, list.UserID)
+		}
+	}
+	assert.Equal(t, []int{first.ID}, owners)
+
+	// And: Nothing is left without an owner, a late registrant gets nothing,
+	// and the other workspace's data is untouched
+	late := mustCreateUser(t, repo, "carol")
+	for _, userID := range []int{0, late.ID} {
+		assert.Empty(t, owned(repo, userID))
+		tags, err := repo.GetTags(ctx, userID)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+	}
+	assert.Empty(t, owned(repo.ForWorkspace(acme.ID), acmeFirst.ID))
+	assert.Equal(t, []string{"Acme's todo"}, owned(repo.ForWorkspace(acme.ID), 0))
+}
+
+func testUsersKeepTodosApart(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Two users with a todo tagged "work" each
+	alice := mustCreateUser(t, repo, "alice")
+	bob := mustCreateUser(t, repo, "bob")
+	aliceTodo, err := repo.Create(ctx, &model.Todo{UserID: alice.ID, Text: "Write the report", Tags: []string{"work"}})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &model.Todo{UserID: bob.ID, Text: "Review the report", Tags: []string{"work", "review"}})
+	require.NoError(t, err)
+
+	// Then: Each one lists only their own todos
+	todos, err := repo.GetAll(ctx, model.TodoFilter{UserID: alice.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Write the report"}, texts(todos))
+	todos, err = repo.GetAll(ctx, model.TodoFilter{UserID: bob.ID, Tags: []string{"work"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Review the report"}, texts(todos))
+
+	// And: Their tags of the same name are different tags
+	aliceTags, err := repo.GetTags(ctx, alice.ID)
+	require.NoError(t, err)
+	require.Len(t, aliceTags, 1)
+	assert.Equal(t, 1, aliceTags[0].TodoCount)
+	bobTags, err := repo.GetTags(ctx, bob.ID)
+	require.NoError(t, err)
+	require.Len(t, bobTags, 2)
+	bobWork, review := bobTags[1], bobTags[0]
+	assert.Equal(t, "work", bobWork.Name)
+	assert.NotEqual(t, aliceTags[0].ID, bobWork.ID)
+
+	tag, err := repo.GetTag(ctx, bobWork.ID)
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, tag.UserID)
+	_, err = repo.GetTag(ctx, missingID)
+	assert.ErrorIs(t, err, repository.ErrTagNotFound)
+
+	// And: Renaming only conflicts with tags of the same user
+	_, err = repo.RenameTag(ctx, review.ID, "work")
+	assert.ErrorIs(t, err, repository.ErrTagExists)
+	renamed, err := repo.RenameTag(ctx, aliceTags[0].ID, "review")
+	require.NoError(t, err)
+	assert.Equal(t, "review", renamed.Name)
+
+	// And: Tags of different users cannot be merged
+	_, err = repo.MergeTags(ctx, renamed.ID, review.ID)
+	assert.ErrorIs(t, err, repository.ErrTagNotFound)
+
+	// And: Updating a todo keeps its owner and tags it with the owner's tags
+	aliceTodo.Tags = []string{"work"}
+	_, err = repo.Update(ctx, aliceTodo)
+	require.NoError(t, err)
+	stored, err := repo.GetByID(ctx, aliceTodo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, stored.UserID)
+	bobTags, err = repo.GetTags(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, bobTags[1].TodoCount)
+
+	// And: Searching finds only the searching user's todos
+	expr, err := model.ParseSearch("report")
+	require.NoError(t, err)
+	results, err := repo.SearchTodos(ctx, expr, alice.ID, 10)
+	if errors.Is(err, repository.ErrSearchUnavailable) {
+		return
+	}
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Write the report", results[0].Text)
+}
+
+func testUsersKeepEventsAndWebhooksApart(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Two users with a webhook each
+	alice := mustCreateUser(t, repo, "alice")
+	bob := mustCreateUser(t, repo, "bob")
+	aliceHook, err := repo.CreateWebhook(ctx, &model.Webhook{UserID: alice.ID, URL: "https://example.com/a", EventTypes: []model.TodoEventType{model.TodoCreated}})
+	require.NoError(t, err)
+	bobHook, err := repo.CreateWebhook(ctx, &model.Webhook{UserID: bob.ID, URL: "https://example.com/b", EventTypes: []model.TodoEventType{model.TodoCreated}})
+	require.NoError(t, err)
+
+	// When: Alice creates a todo
+	todo, err := repo.Create(ctx, &model.Todo{UserID: alice.ID, Text: "Buy milk"})
+	require.NoError(t, err)
+	event, err := repo.AppendEvent(ctx, &model.TodoEvent{UserID: alice.ID, Type: model.TodoCreated, TodoID: todo.ID, Todo: todo})
+	require.NoError(t, err)
+
+	// Then: Only she reads the event, and only her webhook gets it
+	events, err := repo.GetEvents(ctx, alice.ID, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, event.ID, events[0].ID)
+	assert.Equal(t, alice.ID, events[0].UserID)
+	events, err = repo.GetEvents(ctx, bob.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	deliveries, err := repo.GetDeliveries(ctx, aliceHook.ID, 10)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	deliveries, err = repo.GetDeliveries(ctx, bobHook.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	// And: Each one lists only their own webhooks
+	webhooks, err := repo.GetWebhooks(ctx, bob.ID)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, bobHook.ID, webhooks[0].ID)
+	assert.Equal(t, bob.ID, webhooks[0].UserID)
+}
+
+func testSessions(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: A user with a current and an expired session
+	user := mustCreateUser(t, repo, "alice")
+	expired := &model.Session{TokenHash: "expired", UserID: user.ID, ExpiresAt: time.Now().Add(-time.Minute)}
+	require.NoError(t, repo.CreateSession(ctx, expired))
+	current := &model.Session{TokenHash: "current", UserID: user.ID, ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.CreateSession(ctx, current))
+
+	// Then: The current one is read back by its token hash
+	found, err := repo.GetSession(ctx, "current")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.UserID)
+	assert.False(t, found.CreatedAt.IsZero())
+	assert.WithinDuration(t, current.ExpiresAt, found.ExpiresAt, time.Millisecond)
+
+	// And: Creating it removed the expired one
+	_, err = repo.GetSession(ctx, "expired")
+	assert.ErrorIs(t, err, repository.ErrSessionNotFound)
+
+	// When: The current one is deleted
+	require.NoError(t, repo.DeleteSession(ctx, "current"))
+
+	// Then: It is gone
+	_, err = repo.GetSession(ctx, "current")
+	assert.ErrorIs(t, err, repository.ErrSessionNotFound)
+	assert.ErrorIs(t, repo.DeleteSession(ctx, "current"), repository.ErrSessionNotFound)
+}
+
+func testAPITokens(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Two users, one with two API tokens
+	alice := mustCreateUser(t, repo, "alice")
+	bob := mustCreateUser(t, repo, "bob")
+	ci, err := repo.CreateAPIToken(ctx, &model.APIToken{UserID: alice.ID, Name: "CI", Scopes: []string{model.ScopeTodosRead}, TokenHash: "ci"})
+	require.NoError(t, err)
+	_, err = repo.CreateAPIToken(ctx, &model.APIToken{UserID: alice.ID, Name: "Backup", Scopes: []string{model.ScopeTodosRead, model.ScopeWebhooksRead}, TokenHash: "backup"})
+	require.NoError(t, err)
+
+	// Then: IDs and timestamps are assigned, and each user lists their own tokens
+	assert.NotZero(t, ci.ID)
+	assert.False(t, ci.CreatedAt.IsZero())
+	tokens, err := repo.GetAPITokens(ctx, alice.ID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, "CI", tokens[0].Name)
+	assert.Equal(t, []string{model.ScopeTodosRead, model.ScopeWebhooksRead}, tokens[1].Scopes)
+	assert.Nil(t, tokens[0].LastUsedAt)
+	tokens, err = repo.GetAPITokens(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+
+	// And: A token is found by its hash
+	found, err := repo.GetAPITokenByHash(ctx, "ci")
+	require.NoError(t, err)
+	assert.Equal(t, ci.ID, found.ID)
+	assert.Equal(t, alice.ID, found.UserID)
+	assert.True(t, ci.CreatedAt.Equal(found.CreatedAt))
+
+	// When: It is used
+	require.NoError(t, repo.TouchAPIToken(ctx, ci.ID))
+
+	// Then: Its last use is recorded
+	found, err = repo.GetAPIToken(ctx, ci.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found.LastUsedAt)
+	assert.WithinDuration(t, time.Now(), *found.LastUsedAt, time.Minute)
+
+	// When: It is deleted
+	require.NoError(t, repo.DeleteAPIToken(ctx, ci.ID))
+
+	// Then: It is gone
+	_, err = repo.GetAPITokenByHash(ctx, "ci")
+	assert.ErrorIs(t, err, repository.ErrAPITokenNotFound)
+	_, err = repo.GetAPIToken(ctx, ci.ID)
+	assert.ErrorIs(t, err, repository.ErrAPITokenNotFound)
+	assert.ErrorIs(t, repo.TouchAPIToken(ctx, ci.ID), repository.ErrAPITokenNotFound)
+	assert.ErrorIs(t, repo.DeleteAPIToken(ctx, ci.ID), repository.ErrAPITokenNotFound)
+}
+
+func mustCreateWorkspace(t *testing.T, repo repository.TodoRepository, slug string) *model.Workspace {
+	ctx := t.Context()
+	t.Helper()
+	workspace, err := repo.CreateWorkspace(ctx, &model.Workspace{Slug: slug, Name: "Workspace " + slug})
+	require.NoError(t, err)
+	return workspace
+}
+
+func testWorkspacesCRUD(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: A new repository works in the default workspace
+	assert.Equal(t, model.DefaultWorkspaceID, repo.WorkspaceID())
+	defaultWorkspace, err := repo.GetWorkspace(ctx, model.DefaultWorkspaceID)
+	require.NoError(t, err)
+	assert.Equal(t, "default", defaultWorkspace.Slug)
+
+	// When: Creating a workspace
+	acme := mustCreateWorkspace(t, repo, "acme")
+
+	// Then: It gets an ID, timestamps and the default settings, closed to registration
+	assert.NotEqual(t, model.DefaultWorkspaceID, acme.ID)
+	assert.False(t, acme.CreatedAt.IsZero())
+	assert.Equal(t, model.DefaultWorkspaceSettings(acme.ID), acme.Settings)
+	assert.False(t, acme.Settings.Registration)
+
+	// And: It is read back by ID, by slug and in the list of workspaces
+	found, err := repo.GetWorkspace(ctx, acme.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Workspace acme", found.Name)
+	found, err = repo.GetWorkspaceBySlug(ctx, "acme")
+	require.NoError(t, err)
+	assert.Equal(t, acme.ID, found.ID)
+	workspaces, err := repo.GetWorkspaces(ctx)
+	require.NoError(t, err)
+	require.Len(t, workspaces, 2)
+	assert.Equal(t, []string{"default", "acme"}, []string{workspaces[0].Slug, workspaces[1].Slug})
+
+	// And: It has an inbox of its own
+	scoped := repo.ForWorkspace(acme.ID)
+	assert.Equal(t, acme.ID, scoped.WorkspaceID())
+	lists, err := scoped.GetLists(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, lists, 1)
+	assert.True(t, lists[0].Inbox)
+	assert.Equal(t, lists[0].ID, mustCreate(t, scoped, "in acme").ListID)
+
+	// And: Slugs are unique and missing workspaces are reported
+	_, err = repo.CreateWorkspace(ctx, &model.Workspace{Slug: "acme", Name: "Other"})
+	assert.ErrorIs(t, err, repository.ErrWorkspaceExists)
+	_, err = repo.GetWorkspace(ctx, missingID)
+	assert.ErrorIs(t, err, repository.ErrWorkspaceNotFound)
+	_, err = repo.GetWorkspaceBySlug(ctx, "globex")
+	assert.ErrorIs(t, err, repository.ErrWorkspaceNotFound)
+
+	// And: Truncate removes every workspace but the default one
+	require.NoError(t, repo.Truncate(ctx))
+	_, err = repo.GetWorkspace(ctx, acme.ID)
+	assert.ErrorIs(t, err, repository.ErrWorkspaceNotFound)
+	_, err = repo.GetWorkspace(ctx, model.DefaultWorkspaceID)
+	assert.NoError(t, err)
+}
+
+func testWorkspacesKeepDataApart(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Alice's todo, list, webhook and event in the default workspace
+	acme := mustCreateWorkspace(t, repo, "acme")
+	alice := mustCreateUser(t, repo, "alice")
+	bob, err := repo.CreateUser(ctx, &model.User{WorkspaceID: acme.ID, Username: "bob", PasswordHash: "hash of bob"})
+	require.NoError(t, err)
+
+	work, err := repo.CreateList(ctx, &model.List{UserID: alice.ID, Name: "Work"})
+	require.NoError(t, err)
+	todo, err := repo.Create(ctx, &model.Todo{UserID: alice.ID, Text: "Write the report", ListID: work.ID, Tags: []string{"work"}})
+	require.NoError(t, err)
+	tags, err := repo.GetTags(ctx, alice.ID)
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	tag := tags[0]
+	hook, err := repo.CreateWebhook(ctx, &model.Webhook{UserID: alice.ID, URL: "https://example.com/hook", Secret: "s3cret", EventTypes: []model.TodoEventType{model.TodoCreated}})
+	require.NoError(t, err)
+	event, err := repo.AppendEvent(ctx, &model.TodoEvent{UserID: alice.ID, Type: model.TodoCreated, TodoID: todo.ID, Todo: todo})
+	require.NoError(t, err)
+	assert.Equal(t, model.DefaultWorkspaceID, event.WorkspaceID)
+
+	// When: Reading them in another workspace, even by ID
+	other := repo.ForWorkspace(acme.ID)
+
+	// Then: None of them exist there
+	todos, err := other.GetAll(ctx, model.TodoFilter{UserID: alice.ID})
+	require.NoError(t, err)
+	assert.Empty(t, todos)
+	_, err = other.GetByID(ctx, todo.ID)
+	assert.ErrorIs(t, err, repository.ErrTodoNotFound)
+	_, err = other.Update(ctx, &model.Todo{ID: todo.ID, Text: "hijacked"})
+	assert.ErrorIs(t, err, repository.ErrTodoNotFound)
+	assert.ErrorIs(t, other.Delete(ctx, todo.ID), repository.ErrTodoNotFound)
+	_, err = other.GetTag(ctx, tag.ID)
+	assert.ErrorIs(t, err, repository.ErrTagNotFound)
+	_, err = other.RenameTag(ctx, tag.ID, "hijacked")
+	assert.ErrorIs(t, err, repository.ErrTagNotFound)
+	_, err = other.GetList(ctx, work.ID)
+	assert.ErrorIs(t, err, repository.ErrListNotFound)
+	assert.ErrorIs(t, other.DeleteList(ctx, work.ID), repository.ErrListNotFound)
+	_, err = other.GetWebhook(ctx, hook.ID)
+	assert.ErrorIs(t, err, repository.ErrWebhookNotFound)
+	events, err := other.GetEvents(ctx, alice.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+	due, err := other.ClaimDueDeliveries(ctx, time.Now().Add(time.Minute), time.Now().Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	// And: Users are shared, each knowing their workspace
+	found, err := other.GetUser(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, acme.ID, found.WorkspaceID)
+	found, err = other.GetUserByUsername(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, model.DefaultWorkspaceID, found.WorkspaceID)
+
+	// And: The default workspace still has everything
+	stored, err := repo.GetByID(ctx, todo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Write the report", stored.Text)
+	tags, err = repo.GetTags(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Len(t, tags, 1)
+	due, err = repo.ClaimDueDeliveries(ctx, time.Now().Add(time.Minute), time.Now().Add(time.Hour), 10)
+	require.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	// When: Bob adds a todo with the same tag in his workspace
+	_, err = other.Create(ctx, &model.Todo{UserID: bob.ID, Text: "Plan the launch", Tags: []string{"work"}})
+	require.NoError(t, err)
+
+	// Then: Only his workspace sees it
+	todos, err = other.GetAll(ctx, model.TodoFilter{UserID: bob.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Plan the launch"}, texts(todos))
+	todos, err = repo.GetAll(ctx, model.TodoFilter{UserID: bob.ID})
+	require.NoError(t, err)
+	assert.Empty(t, todos)
+	todos, err = repo.GetAll(ctx, model.TodoFilter{UserID: alice.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Write the report"}, texts(todos))
+}
+
+func testWorkspacesSettings(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: Two workspaces nobody configured
+	acme := repo.ForWorkspace(mustCreateWorkspace(t, repo, "acme").ID)
+	settings, err := acme.GetWorkspaceSettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, model.WorkspaceSettings{Sharing: true, Webhooks: true, Registration: false}, settings)
+	settings, err = repo.GetWorkspaceSettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, model.WorkspaceSettings{Sharing: true, Webhooks: true, Registration: true}, settings)
+
+	// When: Turning sharing off in one of them, twice, and registration on
+	require.NoError(t, acme.UpdateWorkspaceSettings(ctx, model.WorkspaceSettings{Sharing: false, Webhooks: true}))
+	require.NoError(t, acme.UpdateWorkspaceSettings(ctx, model.WorkspaceSettings{Sharing: false, Webhooks: false, Registration: true}))
+
+	// Then: Only that workspace has the last settings
+	settings, err = acme.GetWorkspaceSettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, model.WorkspaceSettings{Sharing: false, Webhooks: false, Registration: true}, settings)
+	settings, err = repo.GetWorkspaceSettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, model.DefaultWorkspaceSettings(model.DefaultWorkspaceID), settings)
+}
+
+func testGetByID(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	created := mustCreate(t, repo, "find me")
+
+	todo, err := repo.GetByID(ctx, created.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, todo.ID)
+	assert.Equal(t, "find me", todo.Text)
+	assert.True(t, created.CreatedAt.Equal(todo.CreatedAt))
+}
+
+func testGetByIDNotFound(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	_, err := repo.GetByID(ctx, missingID)
+
+	assert.ErrorIs(t, err, repository.ErrTodoNotFound)
+}
+
+func testUpdate(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: An existing todo
+	created := mustCreate(t, repo, "typo todoo")
+	time.Sleep(2 * time.Millisecond)
+
+	// When: Saving new values
+	completedAt := time.Now().UTC().Truncate(time.Second)
+	updated, err := repo.Update(ctx, &model.Todo{
+		ID:          created.ID,
+		Text:        "typo todo",
+		Completed:   true,
+		CompletedAt: &completedAt,
+	})
+
+	// Then: The change is returned and persisted, created_at kept, updated_at bumped
+	require.NoError(t, err)
+	assert.Equal(t, "typo todo", updated.Text)
+	assert.True(t, created.CreatedAt.Equal(updated.CreatedAt))
+	assert.True(t, updated.UpdatedAt.After(created.UpdatedAt))
+
+	stored, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "typo todo", stored.Text)
+	assert.True(t, stored.Completed)
+	require.NotNil(t, stored.CompletedAt)
+	assert.True(t, completedAt.Equal(*stored.CompletedAt))
+}
+
+func testUpdateNotFound(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	_, err := repo.Update(ctx, &model.Todo{ID: missingID, Text: "ghost"})
+
+	assert.ErrorIs(t, err, repository.ErrTodoNotFound)
+}
+
+func testDelete(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	keep := mustCreate(t, repo, "keep")
+	remove := mustCreate(t, repo, "remove")
+
+	require.NoError(t, repo.Delete(ctx, remove.ID))
+
+	todos, err := repo.GetAll(ctx, model.TodoFilter{})
+	require.NoError(t, err)
+	require.Len(t, todos, 1)
+	assert.Equal(t, keep.ID, todos[0].ID)
+
+	_, err = repo.GetByID(ctx, remove.ID)
+	assert.ErrorIs(t, err, repository.ErrTodoNotFound)
+}
+
+func testDeleteNotFound(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	err := repo.Delete(ctx, missingID)
+
+	assert.ErrorIs(t, err, repository.ErrTodoNotFound)
+}
+
+func testTruncate(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	mustCreate(t, repo, "todo 1")
+	createTagged(t, repo, "todo 2", "work")
+
+	require.NoError(t, repo.Truncate(ctx))
+
+	todos, err := repo.GetAll(ctx, model.TodoFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, todos)
+	tags, err := repo.GetTags(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+}
+
+func testTruncateKeepsInbox(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	before := inbox(t, repo)
+	mustCreateList(t, repo, "Work")
+
+	require.NoError(t, repo.Truncate(ctx))
+
+	lists, err := repo.GetLists(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, lists, 1)
+	assert.Equal(t, before.ID, lists[0].ID)
+	assert.Equal(t, before.ID, mustCreate(t, repo, "after truncate").ListID)
+}
+
+func testReturnsCopies(t *testing.T, repo repository.TodoRepository) {
+	ctx := t.Context()
+	// Given: A todo read from the repository
+	created := mustCreate(t, repo, "original")
+	todo, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+
+	// When: The caller mutates the returned value without saving it
+	todo.Text = "mutated"
+
+	// Then: The stored todo is unchanged
+	stored, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "original", stored.Text)
+}
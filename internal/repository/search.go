@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"todo-app/internal/model"
+)
+
+// The backends mark matches in snippets with these control characters, which
+// cannot be confused with todo text once renderSnippet has escaped it
+const (
+	snippetOpen     = "\x02"
+	snippetClose    = "\x03"
+	snippetEllipsis = "…"
+
+	// snippetWords is the number of words a snippet shows around the matches
+	snippetWords = 12
+)
+
+var snippetMarks = strings.NewReplacer(snippetOpen, "<mark>", snippetClose, "</mark>")
+
+// renderSnippet HTML-escapes a raw snippet and turns its match markers into <mark> tags
+func renderSnippet(raw string) string {
+	return snippetMarks.Replace(html.EscapeString(raw))
+}
+
+// scanSearchResults reads todos selected with todoColumns followed by the raw
+// snippet and the rank, and loads their tags
+func (r *sqlTodoRepository) scanSearchResults(ctx context.Context, query string, args ...any) ([]*model.SearchResult, error) {
+	rows, err := r.q.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]*model.SearchResult, 0)
+	todos := make([]*model.Todo, 0)
+	for rows.Next() {
+		result := &model.SearchResult{}
+		todo, err := scanTodo(withExtra(rows, &result.Snippet, &result.Rank))
+		if err != nil {
+			return nil, err
+		}
+		result.Todo = todo
+		result.Snippet = renderSnippet(result.Snippet)
+		results = append(results, result)
+		todos = append(todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.loadTags(ctx, todos); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// extraScanner scans columns selected after the ones its caller asks for
+type extraScanner struct {
+	row   rowScanner
+	extra []any
+}
+
+func withExtra(row rowScanner, extra ...any) rowScanner {
+	return extraScanner{row: row, extra: extra}
+}
+
+func (s extraScanner) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"todo-app/internal/model"
+)
+
+// apiTokenColumns is the column list read by scanAPIToken
+const apiTokenColumns = `id, user_id, name, token_hash, scopes, created_at, last_used_at`
+
+// CreateAPIToken stores a new API token
+func (r *sqlTodoRepository) CreateAPIToken(ctx context.Context, token *model.APIToken) (*model.APIToken, error) {
+	ctx, end := r.call(ctx, "CreateAPIToken")
+	defer end()
+
+	now := r.now()
+
+	query := `INSERT INTO api_tokens (user_id, name, token_hash, scopes, created_at) VALUES (?, ?, ?, ?, ?) RETURNING id`
+	err := r.q.QueryRowContext(ctx, r.dialect.rebind(query), token.UserID, token.Name, token.TokenHash, strings.Join(token.Scopes, " "), now).Scan(&token.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	token.CreatedAt = now
+	return token, nil
+}
+
+// GetAPITokens returns every API token of userID by ID
+func (r *sqlTodoRepository) GetAPITokens(ctx context.Context, userID int) ([]*model.APIToken, error) {
+	ctx, end := r.call(ctx, "GetAPITokens")
+	defer end()
+
+	rows, err := r.q.QueryContext(ctx, r.dialect.rebind(`SELECT `+apiTokenColumns+` FROM api_tokens WHERE user_id = ? ORDER BY id`), userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]*model.APIToken, 0)
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// GetAPIToken returns one API token or ErrAPITokenNotFound
+func (r *sqlTodoRepository) GetAPIToken(ctx context.Context, id int) (*model.APIToken, error) {
+	ctx, end := r.call(ctx, "GetAPIToken")
+	defer end()
+
+	return r.getAPIToken(ctx, `id = ?`, id)
+}
+
+// GetAPITokenByHash returns the API token with tokenHash or ErrAPITokenNotFound
+func (r *sqlTodoRepository) GetAPITokenByHash(ctx context.Context, tokenHash string) (*model.APIToken, error) {
+	ctx, end := r.call(ctx, "GetAPITokenByHash")
+	defer end()
+
+	return r.getAPIToken(ctx, `token_hash = ?`, tokenHash)
+}
+
+func (r *sqlTodoRepository) getAPIToken(ctx context.Context, condition string, arg any) (*model.APIToken, error) {
+	token, err := scanAPIToken(r.q.QueryRowContext(ctx, r.dialect.rebind(`SELECT `+apiTokenColumns+` FROM api_tokens WHERE `+condition), arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPITokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// TouchAPIToken sets the last use of an API token to now
+func (r *sqlTodoRepository) TouchAPIToken(ctx context.Context, id int) error {
+	ctx, end := r.call(ctx, "TouchAPIToken")
+	defer end()
+
+	result, err := r.q.ExecContext(ctx, r.dialect.rebind(`UPDATE api_tokens SET last_used_at = ? WHERE id = ?`), r.now(), id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrAPITokenNotFound)
+}
+
+// DeleteAPIToken removes one API token or returns ErrAPITokenNotFound
+func (r *sqlTodoRepository) DeleteAPIToken(ctx context.Context, id int) error {
+	ctx, end := r.call(ctx, "DeleteAPIToken")
+	defer end()
+
+	result, err := r.q.ExecContext(ctx, r.dialect.rebind(`DELETE FROM api_tokens WHERE id = ?`), id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrAPITokenNotFound)
+}
+
+// scanAPIToken reads one API token selected with apiTokenColumns
+func scanAPIToken(row rowScanner) (*model.APIToken, error) {
+	token := &model.APIToken{}
+	var scopes string
+	var lastUsedAt sql.NullTime
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash, &scopes, &token.CreatedAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	token.Scopes = strings.Fields(scopes)
+	if lastUsedAt.Valid {
+		token.LastUsedAt = &lastUsedAt.Time
+	}
+	return token, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"todo-app/internal/logging"
+)
+
+// DefaultQueryTimeout is how long a repository call may take unless
+// DB_QUERY_TIMEOUT says otherwise
+const DefaultQueryTimeout = 5 * time.Second
+
+// QueryObserver is told how long each call of a repository method took
+type QueryObserver func(method string, took time.Duration)
+
+// CallOptions says what the SQL repositories do around every method call.
+// Each call is also logged with its duration at debug level to the logger of
+// its context.
+type CallOptions struct {
+	// Timeout is how long a call, and so its queries, may take before giving
+	// up; none when 0. The calls made inside Atomically share the deadline of
+	// Atomically. With SQLite the wait for a lock another connection holds
+	// is bounded by the busy timeout instead, 5 seconds, as interrupting
+	// SQLite does not cut it short.
+	Timeout time.Duration
+
+	// Observe, when set, is told the duration of every call. The calls made
+	// inside Atomically count towards Atomically.
+	Observe QueryObserver
+}
+
+// WithCallOptions returns repo making its method calls as opts says, in
+// every workspace and in the workspace files opened from it. Repositories
+// kept in memory run no queries and are returned as they are.
+func WithCallOptions(repo TodoRepository, opts CallOptions) TodoRepository {
+	if r, ok := repo.(interface {
+		withCallOptions(opts CallOptions) TodoRepository
+	}); ok {
+		return r.withCallOptions(opts)
+	}
+	return repo
+}
+
+// withCalls returns a copy of the repository making its calls as opts says
+func (r *sqlTodoRepository) withCalls(opts CallOptions) *sqlTodoRepository {
+	c := *r
+	c.calls = opts
+	return &c
+}
+
+// callOptions returns the options the repository makes its calls with
+func (r *sqlTodoRepository) callOptions() CallOptions {
+	return r.calls
+}
+
+// callKey marks the context of a method call in progress
+type callKey struct{}
+
+// call starts the method call named method, returning its context, which
+// gives up once the call timeout has passed, and the func ending the call,
+// which reports and logs its duration. The calls a call makes itself, such as
+// Update reading the todo back, and those made inside Atomically are part of
+// it.
+func (r *sqlTodoRepository) call(ctx context.Context, method string) (context.Context, func()) {
+	if r.tx != nil || ctx.Value(callKey{}) != nil {
+		return ctx, func() {}
+	}
+	ctx = context.WithValue(ctx, callKey{}, method)
+	cancel := context.CancelFunc(func() {})
+	if r.calls.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.calls.Timeout)
+	}
+
+	start := time.Now()
+	return ctx, func() {
+		cancel()
+		took := time.Since(start)
+		if r.calls.Observe != nil {
+			r.calls.Observe(method, took)
+		}
+		logging.FromContext(ctx).DebugContext(ctx, "Repository call", "method", method, "workspace_id", r.workspaceID, "duration_ms", float64(took.Microseconds())/1000)
+	}
+}
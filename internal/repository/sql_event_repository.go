@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"todo-app/internal/model"
+)
+
+// AppendEvent adds an event to the change log, storing the todo as JSON, and
+// queues a delivery of it to every webhook of its user subscribed to its type
+func (r *sqlTodoRepository) AppendEvent(ctx context.Context, event *model.TodoEvent) (*model.TodoEvent, error) {
+	ctx, end := r.call(ctx, "AppendEvent")
+	defer end()
+
+	var payload, listID any // NULL for events without a todo
+	if event.Todo != nil {
+		listID = nullableID(event.Todo.ListID)
+		data, err := json.Marshal(event.Todo)
+		if err != nil {
+			return nil, err
+		}
+		payload = string(data)
+	}
+
+	now := r.now()
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		query := `INSERT INTO todo_events (workspace_id, user_id, list_id, from_list_id, type, todo_id, payload, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?) RETURNING id`
+		if err := tx.QueryRowContext(ctx, r.dialect.rebind(query), r.workspaceID, event.UserID, listID, nullableID(event.FromListID), event.Type, event.TodoID, payload, now).Scan(&event.ID); err != nil {
+			return err
+		}
+		event.WorkspaceID, event.CreatedAt = r.workspaceID, now
+		return r.queueDeliveries(ctx, tx, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// GetEvents returns at most limit events with an ID above afterID, oldest
+// first, of the todos of userID and those in, or moved out of, the lists
+// userID owns or joined
+func (r *sqlTodoRepository) GetEvents(ctx context.Context, userID int, afterID int64, limit int) ([]*model.TodoEvent, error) {
+	ctx, end := r.call(ctx, "GetEvents")
+	defer end()
+
+	query := `
+		SELECT id, user_id, type, todo_id, payload, from_list_id, created_at FROM todo_events
+		WHERE workspace_id = ? AND (user_id = ? OR list_id IN (` + joinedLists + `) OR from_list_id IN (` + joinedLists + `)) AND id > ?
+		ORDER BY id ASC LIMIT ?
+	`
+
+	rows, err := r.q.QueryContext(ctx, r.dialect.rebind(query), r.workspaceID, userID, userID, userID, userID, userID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*model.TodoEvent, 0)
+	for rows.Next() {
+		event := &model.TodoEvent{WorkspaceID: r.workspaceID}
+		var payload sql.NullString
+		var fromListID sql.NullInt64
+		if err := rows.Scan(&event.ID, &event.UserID, &event.Type, &event.TodoID, &payload, &fromListID, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.FromListID = int(fromListID.Int64)
+		if payload.Valid {
+			if err := json.Unmarshal([]byte(payload.String), &event.Todo); err != nil {
+				return nil, err
+			}
+			event.Todo.UserID = event.UserID
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"todo-app/internal/model"
+)
+
+// sqlComparisons maps filter operators to SQL
+var sqlComparisons = map[model.FilterCmp]string{
+	model.CmpEq: "=",
+	model.CmpNe: "<>",
+	model.CmpLt: "<",
+	model.CmpLe: "<=",
+	model.CmpGt: ">",
+	model.CmpGe: ">=",
+}
+
+// filterExprCondition compiles a filter expression into a parameterised WHERE
+// condition. Every condition is true or false, never NULL, so NOT stays exact.
+func filterExprCondition(expr *model.FilterExpr, now time.Time) (string, []any) {
+	switch expr.Op {
+	case model.FilterAnd, model.FilterOr:
+		op := " AND "
+		if expr.Op == model.FilterOr {
+			op = " OR "
+		}
+		left, leftArgs := filterExprCondition(expr.Left, now)
+		right, rightArgs := filterExprCondition(expr.Right, now)
+		return "(" + left + op + right + ")", append(leftArgs, rightArgs...)
+	case model.FilterNot:
+		operand, args := filterExprCondition(expr.Left, now)
+		return "NOT " + operand, args
+	}
+
+	cmp := sqlComparisons[expr.Cmp]
+	switch expr.Field {
+	case model.FieldTag:
+		condition := taggedWith + "= ?)"
+		if expr.Cmp == model.CmpNe {
+			condition = "NOT " + condition
+		}
+		return "(" + condition + ")", []any{expr.Text}
+	case model.FieldText:
+		like := "LIKE"
+		if expr.Cmp == model.CmpNe {
+			like = "NOT LIKE"
+		}
+		return "(LOWER(text) " + like + " ? ESCAPE '\\')", []any{"%" + escapeLike(strings.ToLower(expr.Text)) + "%"}
+	case model.FieldList:
+		return "(list_id " + cmp + " ?)", []any{expr.Int}
+	case model.FieldPriority:
+		return "(priority " + cmp + " ?)", []any{expr.Int}
+	case model.FieldDue:
+		if expr.Time == nil {
+			if expr.Cmp == model.CmpNe {
+				return "(due_at IS NOT NULL)", nil
+			}
+			return "(due_at IS NULL)", nil
+		}
+		return "(due_at IS NOT NULL AND due_at " + cmp + " ?)", []any{expr.Time.UTC()}
+	case model.FieldCreated:
+		return "(created_at " + cmp + " ?)", []any{expr.Time.UTC()}
+	case model.FieldUpdated:
+		return "(updated_at " + cmp + " ?)", []any{expr.Time.UTC()}
+	case model.FieldCompleted:
+		return "(completed = ?)", []any{expr.Bool == (expr.Cmp == model.CmpEq)}
+	case model.FieldOverdue:
+		condition, args := overdueCondition(now)
+		if expr.Bool != (expr.Cmp == model.CmpEq) {
+			condition = "NOT " + condition
+		}
+		return condition, args
+	}
+	return "(1 = 0)", nil
+}
+
+// escapeLike escapes the LIKE wildcards of s for ESCAPE '\'
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"todo-app/internal/model"
+)
+
+// listQuery and listGroupBy select lists with their todo counts;
+// a WHERE clause goes between them and ORDER BY after listGroupBy
+const (
+	listQuery = `
+		SELECT l.id, l.user_id, l.name, l.is_inbox, COUNT(t.id), l.created_at, l.updated_at
+		FROM lists l LEFT JOIN todos t ON t.list_id = l.id
+	`
+	listGroupBy = ` GROUP BY l.id, l.user_id, l.name, l.is_inbox, l.created_at, l.updated_at`
+)
+
+// joinedLists selects the IDs of the lists besides the inbox that a user owns
+// or has accepted to join, binding the user ID twice
+const joinedLists = `SELECT id FROM lists WHERE user_id = ? AND NOT is_inbox UNION SELECT list_id FROM list_members WHERE user_id = ? AND accepted`
+
+// visibleTodos is the condition on todos that a user can see in a workspace:
+// all in the lists they own or joined, and their own in the inbox. It binds
+// the workspace ID and then the user ID three times; see visibleArgs.
+const visibleTodos = `(workspace_id = ? AND (list_id IN (` + joinedLists + `) OR (user_id = ? AND list_id IN (SELECT id FROM lists WHERE is_inbox))))`
+
+// visibleArgs returns the arguments of visibleTodos for userID
+func (r *sqlTodoRepository) visibleArgs(userID int) []any {
+	return []any{r.workspaceID, userID, userID, userID}
+}
+
+// CreateList adds a new, empty list
+func (r *sqlTodoRepository) CreateList(ctx context.Context, list *model.List) (*model.List, error) {
+	ctx, end := r.call(ctx, "CreateList")
+	defer end()
+
+	now := r.now()
+
+	query := `INSERT INTO lists (workspace_id, user_id, name, is_inbox, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?) RETURNING id`
+
+	if err := r.q.QueryRowContext(ctx, r.dialect.rebind(query), r.workspaceID, list.UserID, list.Name, false, now, now).Scan(&list.ID); err != nil {
+		return nil, err
+	}
+
+	list.Inbox = false
+	list.TodoCount = 0
+	list.CreatedAt = now
+	list.UpdatedAt = now
+
+	return list, nil
+}
+
+// GetLists returns the inbox and the lists userID owns or joined with the
+// role of userID and the number of todos userID can see, the inbox first and
+// then by creation
+func (r *sqlTodoRepository) GetLists(ctx context.Context, userID int) ([]*model.List, error) {
+	ctx, end := r.call(ctx, "GetLists")
+	defer end()
+
+	query := `
+		SELECT l.id, l.user_id, l.name, l.is_inbox, COUNT(t.id), l.created_at, l.updated_at, m.role
+		FROM lists l
+		LEFT JOIN list_members m ON m.list_id = l.id AND m.user_id = ? AND m.accepted
+		LEFT JOIN todos t ON t.list_id = l.id AND (NOT l.is_inbox OR t.user_id = ?)
+		WHERE l.workspace_id = ? AND (l.is_inbox OR l.user_id = ? OR m.role IS NOT NULL)
+		GROUP BY l.id, l.user_id, l.name, l.is_inbox, l.created_at, l.updated_at, m.role
+		ORDER BY l.is_inbox DESC, l.id ASC
+	`
+	rows, err := r.q.QueryContext(ctx, r.dialect.rebind(query), userID, userID, r.workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := make([]*model.List, 0)
+	for rows.Next() {
+		list := &model.List{}
+		var role sql.NullString
+		err := rows.Scan(&list.ID, &list.UserID, &list.Name, &list.Inbox, &list.TodoCount, &list.CreatedAt, &list.UpdatedAt, &role)
+		if err != nil {
+			return nil, err
+		}
+		list.Role = model.ListRole(role.String)
+		if list.Inbox || list.UserID == userID {
+			list.Role = model.ListOwner
+		}
+		lists = append(lists, list)
+	}
+
+	return lists, rows.Err()
+}
+
+// GetList returns the list with the given ID
+func (r *sqlTodoRepository) GetList(ctx context.Context, id int) (*model.List, error) {
+	ctx, end := r.call(ctx, "GetList")
+	defer end()
+
+	list, err := scanList(r.q.QueryRowContext(ctx, r.dialect.rebind(listQuery+` WHERE l.id = ? AND l.workspace_id = ?`+listGroupBy), id, r.workspaceID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrListNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// UpdateList saves the name of an existing list and bumps updated_at
+func (r *sqlTodoRepository) UpdateList(ctx context.Context, list *model.List) (*model.List, error) {
+	ctx, end := r.call(ctx, "UpdateList")
+	defer end()
+
+	result, err := r.q.ExecContext(ctx, r.dialect.rebind(`UPDATE lists SET name = ?, updated_at = ? WHERE id = ? AND workspace_id = ?`),
+		list.Name, r.now(), list.ID, r.workspaceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := requireAffected(result, ErrListNotFound); err != nil {
+		return nil, err
+	}
+
+	return r.GetList(ctx, list.ID)
+}
+
+// DeleteList moves the todos of a list to the inbox and removes the list
+func (r *sqlTodoRepository) DeleteList(ctx context.Context, id int) error {
+	ctx, end := r.call(ctx, "DeleteList")
+	defer end()
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		var inbox bool
+		err := tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT is_inbox FROM lists WHERE id = ? AND workspace_id = ?`), id, r.workspaceID).Scan(&inbox)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrListNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if inbox {
+			return ErrDeleteInbox
+		}
+
+		query := `UPDATE todos SET list_id = (SELECT id FROM lists WHERE workspace_id = ? AND is_inbox = ?) WHERE workspace_id = ? AND list_id = ?`
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(query), r.workspaceID, true, r.workspaceID, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM list_members WHERE workspace_id = ? AND list_id = ?`), r.workspaceID, id); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM lists WHERE id = ? AND workspace_id = ?`), id, r.workspaceID)
+		return err
+	})
+}
+
+// listMemberQuery selects members with the name of their list; a WHERE
+// clause starting with the workspace follows
+const listMemberQuery = `
+	SELECT m.list_id, l.name, m.user_id, m.role, m.accepted, m.created_at
+	FROM list_members m JOIN lists l ON l.id = m.list_id
+	WHERE m.workspace_id = ?
+`
+
+// AddListMember stores a member or invitation of a list
+func (r *sqlTodoRepository) AddListMember(ctx context.Context, member *model.ListMember) (*model.ListMember, error) {
+	ctx, end := r.call(ctx, "AddListMember")
+	defer end()
+
+	now := r.now()
+
+	// ON CONFLICT keeps a failed insert from aborting a surrounding PostgreSQL transaction
+	query := `INSERT INTO list_members (workspace_id, list_id, user_id, role, accepted, created_at) VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (list_id, user_id) DO NOTHING`
+	result, err := r.q.ExecContext(ctx, r.dialect.rebind(query), r.workspaceID, member.ListID, member.UserID, member.Role, member.Accepted, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAffected(result, ErrMemberExists); err != nil {
+		return nil, err
+	}
+
+	member.CreatedAt = now
+	return member, nil
+}
+
+// GetListMember returns the membership or invitation of userID to a list
+func (r *sqlTodoRepository) GetListMember(ctx context.Context, listID, userID int) (*model.ListMember, error) {
+	ctx, end := r.call(ctx, "GetListMember")
+	defer end()
+
+	member, err := scanListMember(r.q.QueryRowContext(ctx, r.dialect.rebind(listMemberQuery+` AND m.list_id = ? AND m.user_id = ?`), r.workspaceID, listID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// GetListMembers returns the members and invitations of a list in the order they were added
+func (r *sqlTodoRepository) GetListMembers(ctx context.Context, listID int) ([]*model.ListMember, error) {
+	ctx, end := r.call(ctx, "GetListMembers")
+	defer end()
+
+	return r.queryListMembers(ctx, listMemberQuery+` AND m.list_id = ? ORDER BY m.created_at, m.user_id`, listID)
+}
+
+// GetInvitations returns the invitations userID has not accepted yet, oldest first
+func (r *sqlTodoRepository) GetInvitations(ctx context.Context, userID int) ([]*model.ListMember, error) {
+	ctx, end := r.call(ctx, "GetInvitations")
+	defer end()
+
+	return r.queryListMembers(ctx, listMemberQuery+` AND m.user_id = ? AND NOT m.accepted ORDER BY m.created_at, m.list_id`, userID)
+}
+
+// queryListMembers runs a listMemberQuery with one argument after the workspace
+func (r *sqlTodoRepository) queryListMembers(ctx context.Context, query string, arg any) ([]*model.ListMember, error) {
+	rows, err := r.q.QueryContext(ctx, r.dialect.rebind(query), r.workspaceID, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]*model.ListMember, 0)
+	for rows.Next() {
+		member, err := scanListMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// UpdateListMember saves the role and acceptance of a member
+func (r *sqlTodoRepository) UpdateListMember(ctx context.Context, member *model.ListMember) (*model.ListMember, error) {
+	ctx, end := r.call(ctx, "UpdateListMember")
+	defer end()
+
+	result, err := r.q.ExecContext(ctx, r.dialect.rebind(`UPDATE list_members SET role = ?, accepted = ? WHERE workspace_id = ? AND list_id = ? AND user_id = ?`),
+		member.Role, member.Accepted, r.workspaceID, member.ListID, member.UserID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAffected(result, ErrMemberNotFound); err != nil {
+		return nil, err
+	}
+	return r.GetListMember(ctx, member.ListID, member.UserID)
+}
+
+// DeleteListMember removes a member or invitation
+func (r *sqlTodoRepository) DeleteListMember(ctx context.Context, listID, userID int) error {
+	ctx, end := r.call(ctx, "DeleteListMember")
+	defer end()
+
+	result, err := r.q.ExecContext(ctx, r.dialect.rebind(`DELETE FROM list_members WHERE workspace_id = ? AND list_id = ? AND user_id = ?`), r.workspaceID, listID, userID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrMemberNotFound)
+}
+
+// scanList reads one list selected with listQuery
+func scanList(row rowScanner) (*model.List, error) {
+	list := &model.List{}
+	err := row.Scan(&list.ID, &list.UserID, &list.Name, &list.Inbox, &list.TodoCount, &list.CreatedAt, &list.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// scanListMember reads one member selected with listMemberQuery
+func scanListMember(row rowScanner) (*model.ListMember, error) {
+	member := &model.ListMember{}
+	err := row.Scan(&member.ListID, &member.ListName, &member.UserID, &member.Role, &member.Accepted, &member.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
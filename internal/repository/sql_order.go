@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"todo-app/internal/model"
+)
+
+// orderTerm is one ORDER BY expression with the way to read its value from a cursor
+type orderTerm struct {
+	expr  string
+	desc  bool
+	value func(c *model.Cursor) any // nil means SQL NULL
+
+	// bind wraps the placeholder compared against expr, e.g. LOWER(?)
+	bind string
+}
+
+// orderTerms expands keys into ORDER BY terms, breaking ties on id in the
+// direction of the last key. Todos without a due date sort last in both
+// directions, as SQLite and PostgreSQL disagree on where NULLs go.
+func orderTerms(keys []model.SortKey) []orderTerm {
+	var terms []orderTerm
+	for _, key := range keys {
+		switch key.Field {
+		case model.SortPriority:
+			terms = append(terms, orderTerm{expr: "priority", desc: key.Desc, value: func(c *model.Cursor) any { return int(c.Priority) }})
+		case model.SortDueAt:
+			terms = append(terms,
+				orderTerm{expr: "(due_at IS NULL)", value: func(c *model.Cursor) any { return c.DueAt == nil }},
+				orderTerm{expr: "due_at", desc: key.Desc, value: func(c *model.Cursor) any { return utcOrNil(c.DueAt) }},
+			)
+		case model.SortCreatedAt:
+			terms = append(terms, orderTerm{expr: "created_at", desc: key.Desc, value: func(c *model.Cursor) any { return utcOrNil(c.CreatedAt) }})
+		case model.SortUpdatedAt:
+			terms = append(terms, orderTerm{expr: "updated_at", desc: key.Desc, value: func(c *model.Cursor) any { return utcOrNil(c.UpdatedAt) }})
+		case model.SortText:
+			terms = append(terms, orderTerm{expr: "LOWER(text)", bind: "LOWER(?)", desc: key.Desc, value: func(c *model.Cursor) any {
+				if c.Text == nil {
+					return ""
+				}
+				return *c.Text
+			}})
+		}
+	}
+
+	last := keys[len(keys)-1]
+	return append(terms, orderTerm{expr: "id", desc: last.Desc, value: func(c *model.Cursor) any { return c.ID }})
+}
+
+// orderClause renders terms as an ORDER BY list, reversed for backward pages
+func orderClause(terms []orderTerm, backward bool) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		direction := " ASC"
+		if term.desc != backward {
+			direction = " DESC"
+		}
+		parts[i] = term.expr + direction
+	}
+	return strings.Join(parts, ", ")
+}
+
+// keysetCondition matches the rows after the cursor in the order of terms, or
+// before it for a backward cursor: the first term that differs decides.
+func keysetCondition(terms []orderTerm, cursor *model.Cursor) (string, []any) {
+	var (
+		disjuncts []string
+		args      []any
+		equal     []string // conditions holding the earlier terms equal
+		equalArgs []any
+	)
+	for _, term := range terms {
+		value := term.value(cursor)
+		bind := term.bind
+		if bind == "" {
+			bind = "?"
+		}
+
+		// Nothing sorts after NULL within a term, so only later terms can decide
+		if value != nil {
+			op := " > "
+			if term.desc != cursor.Backward {
+				op = " < "
+			}
+			conjuncts := append(slices.Clone(equal), term.expr+op+bind)
+			disjuncts = append(disjuncts, "("+strings.Join(conjuncts, " AND ")+")")
+			args = append(append(args, equalArgs...), value)
+
+			equal = append(equal, term.expr+" = "+bind)
+			equalArgs = append(equalArgs, value)
+		} else {
+			equal = append(equal, term.expr+" IS NULL")
+		}
+	}
+	return "(" + strings.Join(disjuncts, " OR ") + ")", args
+}
+
+// utcOrNil binds an optional cursor time in UTC, like the stored values
+func utcOrNil(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
@@ -0,0 +1,643 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"todo-app/internal/model"
+)
+
+// sqlTodoRepository holds the todo queries shared by the database/sql backends.
+// Queries are written with ? placeholders and rebound for the dialect.
+type sqlTodoRepository struct {
+	db          *sql.DB
+	q           querier // db, or tx inside Atomically
+	tx          *sql.Tx
+	dialect     dialect
+	workspaceID int // every query on workspace data is limited to its rows
+	calls       CallOptions
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func newSQLTodoRepository(db *sql.DB, dialect dialect) *sqlTodoRepository {
+	return &sqlTodoRepository{db: db, q: db, dialect: dialect, workspaceID: model.DefaultWorkspaceID}
+}
+
+// forWorkspace returns a copy of the repository working in the workspace with the given ID
+func (r *sqlTodoRepository) forWorkspace(id int) *sqlTodoRepository {
+	c := *r
+	c.workspaceID = id
+	return &c
+}
+
+// WorkspaceID returns the ID of the workspace the repository works in
+func (r *sqlTodoRepository) WorkspaceID() int {
+	return r.workspaceID
+}
+
+// now returns the current time in UTC at the microsecond precision every backend can store.
+// UTC keeps SQLite's text timestamps comparable for sorting and keyset pagination.
+func (r *sqlTodoRepository) now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// Create adds a new todo and its tags to the database
+func (r *sqlTodoRepository) Create(ctx context.Context, todo *model.Todo) (*model.Todo, error) {
+	ctx, end := r.call(ctx, "Create")
+	defer end()
+
+	now := r.now()
+
+	query := `
+		INSERT INTO todos (workspace_id, user_id, text, list_id, completed, completed_at, priority, due_at, due_all_day, created_at, updated_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		if todo.ListID == 0 {
+			if err := tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT id FROM lists WHERE workspace_id = ? AND is_inbox = ?`), r.workspaceID, true).Scan(&todo.ListID); err != nil {
+				return err
+			}
+		}
+
+		err := tx.QueryRowContext(ctx, r.dialect.rebind(query),
+			r.workspaceID, todo.UserID, todo.Text, todo.ListID, todo.Completed, todo.CompletedAt, todo.Priority, utc(todo.DueAt), todo.DueAllDay, now, now,
+		).Scan(&todo.ID) // ← Son eklenen ID'yi al
+		if err != nil {
+			return err
+		}
+		return r.setTags(ctx, tx, todo.ID, todo.Tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	todo.CreatedAt = now
+	todo.UpdatedAt = now
+	if todo.Tags == nil {
+		todo.Tags = []string{}
+	}
+
+	return todo, nil
+}
+
+// GetAll returns the todos matching filter in filter.Sort order
+func (r *sqlTodoRepository) GetAll(ctx context.Context, filter model.TodoFilter) ([]*model.Todo, error) {
+	ctx, end := r.call(ctx, "GetAll")
+	defer end()
+
+	conditions, args := r.filterConditions(filter)
+
+	// A backward page is read in reverse order and flipped once scanned
+	terms := orderTerms(filter.SortKeys())
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	if filter.Cursor != nil {
+		condition, cursorArgs := keysetCondition(terms, filter.Cursor)
+		conditions = append(conditions, condition)
+		args = append(args, cursorArgs...)
+	}
+
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE ` + strings.Join(conditions, " AND ")
+	query += ` ORDER BY ` + orderClause(terms, backward)
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.q.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := make([]*model.Todo, 0) // Initialize as empty slice, not nil
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if backward {
+		slices.Reverse(todos)
+	}
+
+	if err := r.loadTags(ctx, todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// filterConditions translates filter into AND-ed WHERE conditions with their arguments.
+// Times are bound in UTC so SQLite's text timestamps compare in order.
+func (r *sqlTodoRepository) filterConditions(filter model.TodoFilter) ([]string, []any) {
+	conditions := []string{visibleTodos}
+	args := r.visibleArgs(filter.UserID)
+	if filter.ListID != 0 {
+		conditions = append(conditions, "list_id = ?")
+		args = append(args, filter.ListID)
+	}
+	if filter.Completed != nil {
+		conditions = append(conditions, "completed = ?")
+		args = append(args, *filter.Completed)
+	}
+	if filter.DueBefore != nil {
+		conditions = append(conditions, "due_at < ?")
+		args = append(args, filter.DueBefore.UTC())
+	}
+	if filter.DueAfter != nil {
+		conditions = append(conditions, "due_at >= ?")
+		args = append(args, filter.DueAfter.UTC())
+	}
+	if filter.Overdue {
+		condition, overdueArgs := overdueCondition(filter.Now)
+		conditions = append(conditions, condition)
+		args = append(args, overdueArgs...)
+	}
+	if len(filter.Tags) > 0 {
+		if filter.AnyTag {
+			conditions = append(conditions, taggedWith+"IN ("+placeholders(len(filter.Tags))+"))")
+			for _, tag := range filter.Tags {
+				args = append(args, tag)
+			}
+		} else {
+			for _, tag := range filter.Tags {
+				conditions = append(conditions, taggedWith+"= ?)")
+				args = append(args, tag)
+			}
+		}
+	}
+	if filter.Where != nil {
+		condition, whereArgs := filterExprCondition(filter.Where, filter.Now)
+		conditions = append(conditions, condition)
+		args = append(args, whereArgs...)
+	}
+	return conditions, args
+}
+
+// taggedWith starts a condition on the tag names of a todo; callers complete the comparison on t.name
+const taggedWith = "id IN (SELECT tt.todo_id FROM todo_tags tt JOIN tags t ON t.id = tt.tag_id WHERE t.name "
+
+// overdueCondition matches todos that are not completed and past due at now.
+// All-day todos are overdue once their whole day has passed.
+func overdueCondition(now time.Time) (string, []any) {
+	now = now.UTC()
+	return "(completed = ? AND due_at IS NOT NULL AND ((due_all_day = ? AND due_at < ?) OR (due_all_day = ? AND due_at <= ?)))",
+		[]any{false, false, now, true, now.Add(-24 * time.Hour)}
+}
+
+// GetByID returns the todo with the given ID
+func (r *sqlTodoRepository) GetByID(ctx context.Context, id int) (*model.Todo, error) {
+	ctx, end := r.call(ctx, "GetByID")
+	defer end()
+
+	query := `SELECT ` + todoColumns + ` FROM todos WHERE id = ? AND workspace_id = ?`
+
+	todo, err := scanTodo(r.q.QueryRowContext(ctx, r.dialect.rebind(query), id, r.workspaceID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTodoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadTags(ctx, []*model.Todo{todo}); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// Update saves the editable fields and tags of an existing todo and bumps updated_at
+func (r *sqlTodoRepository) Update(ctx context.Context, todo *model.Todo) (*model.Todo, error) {
+	ctx, end := r.call(ctx, "Update")
+	defer end()
+
+	now := r.now()
+
+	query := `
+		UPDATE todos 
+		SET text = ?, list_id = COALESCE(?, list_id), completed = ?, completed_at = ?, priority = ?, due_at = ?, due_all_day = ?, updated_at = ? 
+		WHERE id = ? AND workspace_id = ?
+	`
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, r.dialect.rebind(query),
+			todo.Text, nullableID(todo.ListID), todo.Completed, todo.CompletedAt, todo.Priority, utc(todo.DueAt), todo.DueAllDay, now, todo.ID, r.workspaceID,
+		)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result, ErrTodoNotFound); err != nil {
+			return err
+		}
+		return r.setTags(ctx, tx, todo.ID, todo.Tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, todo.ID)
+}
+
+// Delete removes the todo with the given ID
+func (r *sqlTodoRepository) Delete(ctx context.Context, id int) error {
+	ctx, end := r.call(ctx, "Delete")
+	defer end()
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM todos WHERE id = ? AND workspace_id = ?`), id, r.workspaceID)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result, ErrTodoNotFound); err != nil {
+			return err
+		}
+
+		// SQLite only enforces ON DELETE CASCADE with foreign keys enabled, so unlink explicitly
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM todo_tags WHERE todo_id = ?`), id)
+		return err
+	})
+}
+
+// CountTodos counts the todos of the workspace, and those not completed
+func (r *sqlTodoRepository) CountTodos(ctx context.Context) (model.TodoCounts, error) {
+	ctx, end := r.call(ctx, "CountTodos")
+	defer end()
+
+	var counts model.TodoCounts
+	err := r.q.QueryRowContext(ctx,
+		r.dialect.rebind(`SELECT COUNT(*), COUNT(CASE WHEN completed THEN NULL ELSE 1 END) FROM todos WHERE workspace_id = ?`),
+		r.workspaceID,
+	).Scan(&counts.Total, &counts.Open)
+	return counts, err
+}
+
+// setTags replaces the tags of a todo, creating the tags its user does not have yet
+func (r *sqlTodoRepository) setTags(ctx context.Context, tx *sql.Tx, todoID int, names []string) error {
+	if _, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM todo_tags WHERE todo_id = ?`), todoID); err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	var userID int
+	if err := tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT user_id FROM todos WHERE id = ? AND workspace_id = ?`), todoID, r.workspaceID).Scan(&userID); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		_, err := tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO tags (workspace_id, user_id, name) VALUES (?, ?, ?) ON CONFLICT (user_id, name) DO NOTHING`), r.workspaceID, userID, name)
+		if err != nil {
+			return err
+		}
+
+		var tagID int
+		if err := tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT id FROM tags WHERE workspace_id = ? AND user_id = ? AND name = ?`), r.workspaceID, userID, name).Scan(&tagID); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO todo_tags (todo_id, tag_id) VALUES (?, ?)`), todoID, tagID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTagsBatch bounds the IN list of one loadTags query below every driver's parameter limit
+const loadTagsBatch = 500
+
+// loadTags fills in the sorted tag names of todos
+func (r *sqlTodoRepository) loadTags(ctx context.Context, todos []*model.Todo) error {
+	byID := make(map[int]*model.Todo, len(todos))
+	for _, todo := range todos {
+		todo.Tags = []string{}
+		byID[todo.ID] = todo
+	}
+
+	for start := 0; start < len(todos); start += loadTagsBatch {
+		batch := todos[start:min(start+loadTagsBatch, len(todos))]
+		args := make([]any, len(batch))
+		for i, todo := range batch {
+			args[i] = todo.ID
+		}
+
+		query := `
+			SELECT tt.todo_id, t.name FROM todo_tags tt JOIN tags t ON t.id = tt.tag_id
+			WHERE tt.todo_id IN (` + placeholders(len(batch)) + `)
+			ORDER BY t.name
+		`
+		if err := r.scanTodoTags(ctx, byID, query, args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanTodoTags appends the tag names returned by query to the todos in byID
+func (r *sqlTodoRepository) scanTodoTags(ctx context.Context, byID map[int]*model.Todo, query string, args []any) error {
+	rows, err := r.q.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			todoID int
+			name   string
+		)
+		if err := rows.Scan(&todoID, &name); err != nil {
+			return err
+		}
+		byID[todoID].Tags = append(byID[todoID].Tags, name)
+	}
+	return rows.Err()
+}
+
+// GetTags returns every tag of userID with its todo count, ordered by name
+func (r *sqlTodoRepository) GetTags(ctx context.Context, userID int) ([]*model.Tag, error) {
+	ctx, end := r.call(ctx, "GetTags")
+	defer end()
+
+	query := `SELECT ` + tagColumns + ` FROM tags t ` + tagCountJoin + ` WHERE t.workspace_id = ? AND t.user_id = ? ` + tagGroupBy + ` ORDER BY t.name`
+	rows, err := r.q.QueryContext(ctx, r.dialect.rebind(query), r.workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]*model.Tag, 0)
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// GetTag returns the tag with the given ID
+func (r *sqlTodoRepository) GetTag(ctx context.Context, id int) (*model.Tag, error) {
+	ctx, end := r.call(ctx, "GetTag")
+	defer end()
+
+	return r.getTag(ctx, r.q, id)
+}
+
+// RenameTag gives a tag a new, unused name
+func (r *sqlTodoRepository) RenameTag(ctx context.Context, id int, name string) (*model.Tag, error) {
+	ctx, end := r.call(ctx, "RenameTag")
+	defer end()
+
+	var tag *model.Tag
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		current, err := r.getTag(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		var taken int
+		err = tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT COUNT(*) FROM tags WHERE workspace_id = ? AND user_id = ? AND name = ? AND id <> ?`), r.workspaceID, current.UserID, name, id).Scan(&taken)
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrTagExists
+		}
+
+		if _, err := tx.ExecContext(ctx, r.dialect.rebind(`UPDATE tags SET name = ? WHERE id = ? AND workspace_id = ?`), name, id, r.workspaceID); err != nil {
+			return err
+		}
+
+		tag, err = r.getTag(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// MergeTags relinks the todos of the source tag to the target tag and deletes the source
+func (r *sqlTodoRepository) MergeTags(ctx context.Context, sourceID, targetID int) (*model.Tag, error) {
+	ctx, end := r.call(ctx, "MergeTags")
+	defer end()
+
+	var tag *model.Tag
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		source, err := r.getTag(ctx, tx, sourceID)
+		if err != nil {
+			return err
+		}
+		target, err := r.getTag(ctx, tx, targetID)
+		if err != nil {
+			return err
+		}
+		if source.UserID != target.UserID {
+			return ErrTagNotFound
+		}
+
+		if sourceID != targetID {
+			statements := []struct {
+				query string
+				args  []any
+			}{
+				{
+					`INSERT INTO todo_tags (todo_id, tag_id)
+					SELECT todo_id, CAST(? AS INTEGER) FROM todo_tags
+					WHERE tag_id = ? AND todo_id NOT IN (SELECT todo_id FROM todo_tags WHERE tag_id = ?)`,
+					[]any{targetID, sourceID, targetID},
+				},
+				{`DELETE FROM todo_tags WHERE tag_id = ?`, []any{sourceID}},
+				{`DELETE FROM tags WHERE id = ? AND workspace_id = ?`, []any{sourceID, r.workspaceID}},
+			}
+			for _, stmt := range statements {
+				if _, err := tx.ExecContext(ctx, r.dialect.rebind(stmt.query), stmt.args...); err != nil {
+					return err
+				}
+			}
+		}
+
+		tag, err = r.getTag(ctx, tx, targetID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// tagColumns, tagCountJoin and tagGroupBy select a tag with its todo count
+const (
+	tagColumns   = `t.id, t.user_id, t.name, COUNT(tt.todo_id)`
+	tagCountJoin = `LEFT JOIN todo_tags tt ON tt.tag_id = t.id`
+	tagGroupBy   = `GROUP BY t.id, t.user_id, t.name`
+)
+
+// getTag reads one tag with its todo count
+func (r *sqlTodoRepository) getTag(ctx context.Context, q querier, id int) (*model.Tag, error) {
+	query := `SELECT ` + tagColumns + ` FROM tags t ` + tagCountJoin + ` WHERE t.id = ? AND t.workspace_id = ? ` + tagGroupBy
+
+	tag, err := scanTag(q.QueryRowContext(ctx, r.dialect.rebind(query), id, r.workspaceID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTagNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// scanTag reads one tag selected with tagColumns
+func scanTag(row rowScanner) (*model.Tag, error) {
+	tag := &model.Tag{}
+	if err := row.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.TodoCount); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// placeholders returns n comma separated ? placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// inTx runs fn in a transaction, rolling back when it fails. Inside
+// Atomically fn joins the surrounding transaction.
+func (r *sqlTodoRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Atomically runs fn against a repository bound to one transaction,
+// committing when fn succeeds and rolling back when it fails
+func (r *sqlTodoRepository) Atomically(ctx context.Context, fn func(tx TxRepository) error) error {
+	ctx, end := r.call(ctx, "Atomically")
+	defer end()
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		return fn(r.bound(tx))
+	})
+}
+
+// bound returns a copy of the repository running its queries in tx
+func (r *sqlTodoRepository) bound(tx *sql.Tx) *sqlTodoRepository {
+	return &sqlTodoRepository{db: r.db, q: tx, tx: tx, dialect: r.dialect, workspaceID: r.workspaceID, calls: r.calls}
+}
+
+// todoColumns is the column list read by scanTodo
+const todoColumns = `id, user_id, text, list_id, completed, completed_at, priority, due_at, due_all_day, created_at, updated_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTodo reads one todo selected with todoColumns
+func scanTodo(row rowScanner) (*model.Todo, error) {
+	todo := &model.Todo{}
+	var completedAt, dueAt sql.NullTime
+	err := row.Scan(
+		&todo.ID, &todo.UserID, &todo.Text, &todo.ListID, &todo.Completed, &completedAt, &todo.Priority,
+		&dueAt, &todo.DueAllDay, &todo.CreatedAt, &todo.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		todo.CompletedAt = &completedAt.Time
+	}
+	if dueAt.Valid {
+		due := dueAt.Time.UTC()
+		todo.DueAt = &due
+	}
+	return todo, nil
+}
+
+// utc converts an optional time to UTC for storage
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// nullableID binds a zero ID as NULL
+func nullableID(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// requireAffected maps an UPDATE/DELETE that touched no rows to notFound
+func requireAffected(result sql.Result, notFound error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// Close closes the database connection
+func (r *sqlTodoRepository) Close() error {
+	return r.db.Close()
+}
+
+// Truncate removes everything but the default workspace and its inbox list,
+// users and the other workspaces included. It is not limited to the
+// repository's workspace.
+func (r *sqlTodoRepository) Truncate(ctx context.Context) error {
+	ctx, end := r.call(ctx, "Truncate")
+	defer end()
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		for _, query := range []string{
+			`DELETE FROM todo_tags`, `DELETE FROM tags`, `DELETE FROM todos`, `DELETE FROM todo_events`,
+			`DELETE FROM webhook_attempts`, `DELETE FROM webhook_deliveries`, `DELETE FROM webhooks`,
+			`DELETE FROM list_members`, `DELETE FROM api_tokens`, `DELETE FROM sessions`, `DELETE FROM users`,
+			`DELETE FROM workspace_settings`,
+		} {
+			if _, err := tx.ExecContext(ctx, query); err != nil {
+				return err
+			}
+		}
+		_, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM lists WHERE is_inbox = ? OR workspace_id <> ?`), false, model.DefaultWorkspaceID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM workspaces WHERE id <> ?`), model.DefaultWorkspaceID)
+		return err
+	})
+}
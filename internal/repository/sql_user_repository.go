@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"todo-app/internal/model"
+)
+
+// CreateUser stores a new user
+func (r *sqlTodoRepository) CreateUser(ctx context.Context, user *model.User) (*model.User, error) {
+	ctx, end := r.call(ctx, "CreateUser")
+	defer end()
+
+	now := r.now()
+	if user.WorkspaceID == 0 {
+		user.WorkspaceID = model.DefaultWorkspaceID
+	}
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var taken int
+		if err := tx.QueryRowContext(ctx, r.dialect.rebind(`SELECT COUNT(*) FROM users WHERE username = ?`), user.Username).Scan(&taken); err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrUsernameTaken
+		}
+
+		query := `INSERT INTO users (workspace_id, username, password_hash, created_at) VALUES (?, ?, ?, ?) RETURNING id`
+		return tx.QueryRowContext(ctx, r.dialect.rebind(query), user.WorkspaceID, user.Username, user.PasswordHash, now).Scan(&user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user.CreatedAt = now
+	return user, nil
+}
+
+// AssignOwner gives userID everything user 0 owns in the workspace, except the inbox
+func (r *sqlTodoRepository) AssignOwner(ctx context.Context, userID int) error {
+	ctx, end := r.call(ctx, "AssignOwner")
+	defer end()
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		for _, table := range []string{"todos", "tags", "todo_events", "webhooks"} {
+			query := `UPDATE ` + table + ` SET user_id = ? WHERE user_id = 0 AND workspace_id = ?`
+			if _, err := tx.ExecContext(ctx, r.dialect.rebind(query), userID, r.workspaceID); err != nil {
+				return err
+			}
+		}
+		query := `UPDATE lists SET user_id = ? WHERE user_id = 0 AND NOT is_inbox AND workspace_id = ?`
+		_, err := tx.ExecContext(ctx, r.dialect.rebind(query), userID, r.workspaceID)
+		return err
+	})
+}
+
+// GetUser returns one user or ErrUserNotFound
+func (r *sqlTodoRepository) GetUser(ctx context.Context, id int) (*model.User, error) {
+	ctx, end := r.call(ctx, "GetUser")
+	defer end()
+
+	return r.getUser(ctx, `id = ?`, id)
+}
+
+// GetUserByUsername returns one user or ErrUserNotFound
+func (r *sqlTodoRepository) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	ctx, end := r.call(ctx, "GetUserByUsername")
+	defer end()
+
+	return r.getUser(ctx, `username = ?`, username)
+}
+
+func (r *sqlTodoRepository) getUser(ctx context.Context, condition string, arg any) (*model.User, error) {
+	query := `SELECT id, workspace_id, username, password_hash, created_at FROM users WHERE ` + condition
+	user := &model.User{}
+	err := r.q.QueryRowContext(ctx, r.dialect.rebind(query), arg).Scan(&user.ID, &user.WorkspaceID, &user.Username, &user.PasswordHash, &user.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// CreateSession stores a new session and removes every expired one
+func (r *sqlTodoRepository) CreateSession(ctx context.Context, session *model.Session) error {
+	ctx, end := r.call(ctx, "CreateSession")
+	defer end()
+
+	now := r.now()
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM sessions WHERE expires_at <= ?`), now); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO sessions (token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, r.dialect.rebind(query), session.TokenHash, session.UserID, now, session.ExpiresAt.UTC()); err != nil {
+			return err
+		}
+		session.CreatedAt = now
+		return nil
+	})
+}
+
+// GetSession returns one session, even when expired, or ErrSessionNotFound
+func (r *sqlTodoRepository) GetSession(ctx context.Context, tokenHash string) (*model.Session, error) {
+	ctx, end := r.call(ctx, "GetSession")
+	defer end()
+
+	query := `SELECT token_hash, user_id, created_at, expires_at FROM sessions WHERE token_hash = ?`
+	session := &model.Session{}
+	err := r.q.QueryRowContext(ctx, r.dialect.rebind(query), tokenHash).Scan(&session.TokenHash, &session.UserID, &session.CreatedAt, &session.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// DeleteSession removes one session or returns ErrSessionNotFound
+func (r *sqlTodoRepository) DeleteSession(ctx context.Context, tokenHash string) error {
+	ctx, end := r.call(ctx, "DeleteSession")
+	defer end()
+
+	result, err := r.q.ExecContext(ctx, r.dialect.rebind(`DELETE FROM sessions WHERE token_hash = ?`), tokenHash)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrSessionNotFound)
+}
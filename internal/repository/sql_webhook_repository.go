@@ -0,0 +1,342 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"todo-app/internal/model"
+)
+
+const (
+	webhookColumns  = `id, user_id, url, secret, event_types, created_at, updated_at`
+	deliveryColumns = `id, webhook_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at`
+)
+
+// CreateWebhook stores a new webhook
+func (r *sqlTodoRepository) CreateWebhook(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error) {
+	ctx, end := r.call(ctx, "CreateWebhook")
+	defer end()
+
+	now := r.now()
+
+	query := `INSERT INTO webhooks (workspace_id, user_id, url, secret, event_types, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?) RETURNING id`
+	err := r.q.QueryRowContext(ctx, r.dialect.rebind(query), r.workspaceID, webhook.UserID, webhook.URL, webhook.Secret, formatEventTypes(webhook.EventTypes), now, now).Scan(&webhook.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	return webhook, nil
+}
+
+// GetWebhooks returns every webhook of userID by ID
+func (r *sqlTodoRepository) GetWebhooks(ctx context.Context, userID int) ([]*model.Webhook, error) {
+	ctx, end := r.call(ctx, "GetWebhooks")
+	defer end()
+
+	rows, err := r.q.QueryContext(ctx, r.dialect.rebind(`SELECT `+webhookColumns+` FROM webhooks WHERE workspace_id = ? AND user_id = ? ORDER BY id`), r.workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]*model.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// GetWebhook returns one webhook or ErrWebhookNotFound
+func (r *sqlTodoRepository) GetWebhook(ctx context.Context, id int) (*model.Webhook, error) {
+	ctx, end := r.call(ctx, "GetWebhook")
+	defer end()
+
+	webhook, err := scanWebhook(r.q.QueryRowContext(ctx, r.dialect.rebind(`SELECT `+webhookColumns+` FROM webhooks WHERE id = ? AND workspace_id = ?`), id, r.workspaceID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// UpdateWebhook saves the URL, secret and event types of an existing webhook
+func (r *sqlTodoRepository) UpdateWebhook(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error) {
+	ctx, end := r.call(ctx, "UpdateWebhook")
+	defer end()
+
+	result, err := r.q.ExecContext(ctx, r.dialect.rebind(`UPDATE webhooks SET url = ?, secret = ?, event_types = ?, updated_at = ? WHERE id = ? AND workspace_id = ?`),
+		webhook.URL, webhook.Secret, formatEventTypes(webhook.EventTypes), r.now(), webhook.ID, r.workspaceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireAffected(result, ErrWebhookNotFound); err != nil {
+		return nil, err
+	}
+
+	return r.GetWebhook(ctx, webhook.ID)
+}
+
+// DeleteWebhook removes a webhook with its deliveries
+func (r *sqlTodoRepository) DeleteWebhook(ctx context.Context, id int) error {
+	ctx, end := r.call(ctx, "DeleteWebhook")
+	defer end()
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM webhooks WHERE id = ? AND workspace_id = ?`), id, r.workspaceID)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result, ErrWebhookNotFound); err != nil {
+			return err
+		}
+
+		// SQLite does not enforce the foreign keys, so cascade by hand
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM webhook_attempts WHERE delivery_id IN (SELECT id FROM webhook_deliveries WHERE webhook_id = ?)`), id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(`DELETE FROM webhook_deliveries WHERE webhook_id = ?`), id)
+		return err
+	})
+}
+
+// queueDeliveries adds a pending delivery of event for every webhook of its
+// user subscribed to its type
+func (r *sqlTodoRepository) queueDeliveries(ctx context.Context, tx *sql.Tx, event *model.TodoEvent) error {
+	query := `SELECT id FROM webhooks WHERE workspace_id = ? AND user_id = ? AND event_types LIKE ? ORDER BY id`
+	rows, err := tx.QueryContext(ctx, r.dialect.rebind(query), r.workspaceID, event.UserID, "%,"+string(event.Type)+",%")
+	if err != nil {
+		return err
+	}
+	var webhookIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		webhookIDs = append(webhookIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(webhookIDs) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	insert := r.dialect.rebind(`
+		INSERT INTO webhook_deliveries (workspace_id, webhook_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	for _, id := range webhookIDs {
+		_, err := tx.ExecContext(ctx, insert, r.workspaceID, id, event.ID, event.Type, string(payload), model.DeliveryPending, event.CreatedAt, event.CreatedAt, event.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDeliveries returns the latest limit deliveries of a webhook, newest first
+func (r *sqlTodoRepository) GetDeliveries(ctx context.Context, webhookID int, limit int) ([]*model.WebhookDelivery, error) {
+	ctx, end := r.call(ctx, "GetDeliveries")
+	defer end()
+
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE workspace_id = ? AND webhook_id = ? ORDER BY id DESC LIMIT ?`
+	return r.queryDeliveries(ctx, query, r.workspaceID, webhookID, limit)
+}
+
+// ClaimDueDeliveries moves the next attempt of at most limit pending
+// deliveries due by now, the longest waiting, to until in one statement, so
+// two dispatchers never claim the same delivery, and returns them oldest first
+func (r *sqlTodoRepository) ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	ctx, end := r.call(ctx, "ClaimDueDeliveries")
+	defer end()
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		claim := `UPDATE webhook_deliveries SET next_attempt_at = ?, updated_at = ? WHERE id IN (
+			SELECT id FROM webhook_deliveries WHERE workspace_id = ? AND status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at, id LIMIT ?` + r.dialect.skipLocked + `
+		) RETURNING id`
+		rows, err := tx.QueryContext(ctx, r.dialect.rebind(claim), until.UTC(), r.now(), r.workspaceID, model.DeliveryPending, now.UTC(), limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		var ids []any
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		rows.Close()
+		if len(ids) == 0 {
+			return nil
+		}
+
+		query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id IN (` + placeholders(len(ids)) + `) ORDER BY id`
+		deliveries, err = r.bound(tx).queryDeliveries(ctx, query, ids...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RecordDeliveryAttempt adds an attempt to a delivery and saves its new
+// status and next attempt time
+func (r *sqlTodoRepository) RecordDeliveryAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt *model.DeliveryAttempt) error {
+	ctx, end := r.call(ctx, "RecordDeliveryAttempt")
+	defer end()
+
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, r.dialect.rebind(`UPDATE webhook_deliveries SET status = ?, next_attempt_at = ?, updated_at = ? WHERE id = ? AND workspace_id = ?`),
+			delivery.Status, utc(delivery.NextAttemptAt), r.now(), delivery.ID, r.workspaceID,
+		)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result, ErrWebhookNotFound); err != nil {
+			return err
+		}
+
+		var statusCode, message any // NULL when unset
+		if attempt.StatusCode != 0 {
+			statusCode = attempt.StatusCode
+		}
+		if attempt.Error != "" {
+			message = attempt.Error
+		}
+		_, err = tx.ExecContext(ctx, r.dialect.rebind(`INSERT INTO webhook_attempts (delivery_id, attempted_at, status_code, error, duration_ms) VALUES (?, ?, ?, ?, ?)`),
+			delivery.ID, attempt.AttemptedAt.UTC(), statusCode, message, attempt.DurationMS,
+		)
+		return err
+	})
+}
+
+// queryDeliveries runs a query selecting deliveryColumns and loads their attempts
+func (r *sqlTodoRepository) queryDeliveries(ctx context.Context, query string, args ...any) ([]*model.WebhookDelivery, error) {
+	rows, err := r.q.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+	byID := make(map[int64]*model.WebhookDelivery)
+	for rows.Next() {
+		delivery := &model.WebhookDelivery{Attempts: []*model.DeliveryAttempt{}}
+		var payload string
+		var nextAttemptAt sql.NullTime
+		err := rows.Scan(
+			&delivery.ID, &delivery.WebhookID, &delivery.EventID, &delivery.EventType, &payload,
+			&delivery.Status, &nextAttemptAt, &delivery.CreatedAt, &delivery.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		delivery.Payload = json.RawMessage(payload)
+		if nextAttemptAt.Valid {
+			delivery.NextAttemptAt = &nextAttemptAt.Time
+		}
+		deliveries = append(deliveries, delivery)
+		byID[delivery.ID] = delivery
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if len(deliveries) == 0 {
+		return deliveries, nil
+	}
+	return deliveries, r.loadAttempts(ctx, byID)
+}
+
+// loadAttempts fills in the attempts of the deliveries in byID
+func (r *sqlTodoRepository) loadAttempts(ctx context.Context, byID map[int64]*model.WebhookDelivery) error {
+	ids := make([]any, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+
+	query := `SELECT delivery_id, attempted_at, status_code, error, duration_ms FROM webhook_attempts WHERE delivery_id IN (` + placeholders(len(ids)) + `) ORDER BY id`
+	rows, err := r.q.QueryContext(ctx, r.dialect.rebind(query), ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var deliveryID int64
+		var statusCode sql.NullInt64
+		var message sql.NullString
+		attempt := &model.DeliveryAttempt{}
+		if err := rows.Scan(&deliveryID, &attempt.AttemptedAt, &statusCode, &message, &attempt.DurationMS); err != nil {
+			return err
+		}
+		attempt.StatusCode = int(statusCode.Int64)
+		attempt.Error = message.String
+		delivery := byID[deliveryID]
+		delivery.Attempts = append(delivery.Attempts, attempt)
+	}
+	return rows.Err()
+}
+
+// scanWebhook reads one webhook selected with webhookColumns
+func scanWebhook(row rowScanner) (*model.Webhook, error) {
+	webhook := &model.Webhook{}
+	var eventTypes string
+	err := row.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret, &eventTypes, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	webhook.EventTypes = parseEventTypes(eventTypes)
+	return webhook, nil
+}
+
+// formatEventTypes stores event types as ",created,deleted," for LIKE matching
+func formatEventTypes(types []model.TodoEventType) string {
+	var b strings.Builder
+	b.WriteString(",")
+	for _, t := range types {
+		b.WriteString(string(t) + ",")
+	}
+	return b.String()
+}
+
+func parseEventTypes(s string) []model.TodoEventType {
+	types := []model.TodoEventType{}
+	for _, t := range strings.Split(strings.Trim(s, ","), ",") {
+		if t != "" {
+			types = append(types, model.TodoEventType(t))
+		}
+	}
+	return types
+}
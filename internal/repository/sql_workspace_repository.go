@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"todo-app/internal/model"
+)
+
+const workspaceColumns = `id, slug, name, created_at, updated_at`
+
+// CreateWorkspace stores a new workspace and its inbox
+func (r *sqlTodoRepository) CreateWorkspace(ctx context.Context, workspace *model.Workspace) (*model.Workspace, error) {
+	ctx, end := r.call(ctx, "CreateWorkspace")
+	defer end()
+
+	now := r.now()
+
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		// ON CONFLICT keeps a failed insert from aborting a surrounding PostgreSQL transaction
+		query := `INSERT INTO workspaces (slug, name, created_at, updated_at) VALUES (?, ?, ?, ?) ON CONFLICT (slug) DO NOTHING RETURNING id`
+		err := tx.QueryRowContext(ctx, r.dialect.rebind(query), workspace.Slug, workspace.Name, now, now).Scan(&workspace.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWorkspaceExists
+		}
+		if err != nil {
+			return err
+		}
+		return r.forWorkspace(workspace.ID).ensureInbox(ctx, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	workspace.Settings = model.DefaultWorkspaceSettings(workspace.ID)
+	workspace.CreatedAt = now
+	workspace.UpdatedAt = now
+	return workspace, nil
+}
+
+// ensureInbox adds the inbox list of the repository's workspace unless it has one
+func (r *sqlTodoRepository) ensureInbox(ctx context.Context, q querier) error {
+	var inboxes int
+	err := q.QueryRowContext(ctx, r.dialect.rebind(`SELECT COUNT(*) FROM lists WHERE workspace_id = ? AND is_inbox = ?`), r.workspaceID, true).Scan(&inboxes)
+	if err != nil || inboxes > 0 {
+		return err
+	}
+
+	now := r.now()
+	query := `INSERT INTO lists (workspace_id, user_id, name, is_inbox, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err = q.ExecContext(ctx, r.dialect.rebind(query), r.workspaceID, 0, "Inbox", true, now, now)
+	return err
+}
+
+// GetWorkspaces returns every workspace by ID
+func (r *sqlTodoRepository) GetWorkspaces(ctx context.Context) ([]*model.Workspace, error) {
+	ctx, end := r.call(ctx, "GetWorkspaces")
+	defer end()
+
+	rows, err := r.q.QueryContext(ctx, `SELECT `+workspaceColumns+` FROM workspaces ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := make([]*model.Workspace, 0)
+	for rows.Next() {
+		workspace, err := scanWorkspace(rows)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, workspace)
+	}
+	return workspaces, rows.Err()
+}
+
+// GetWorkspace returns one workspace or ErrWorkspaceNotFound
+func (r *sqlTodoRepository) GetWorkspace(ctx context.Context, id int) (*model.Workspace, error) {
+	ctx, end := r.call(ctx, "GetWorkspace")
+	defer end()
+
+	return r.getWorkspace(ctx, `id = ?`, id)
+}
+
+// GetWorkspaceBySlug returns one workspace or ErrWorkspaceNotFound
+func (r *sqlTodoRepository) GetWorkspaceBySlug(ctx context.Context, slug string) (*model.Workspace, error) {
+	ctx, end := r.call(ctx, "GetWorkspaceBySlug")
+	defer end()
+
+	return r.getWorkspace(ctx, `slug = ?`, slug)
+}
+
+func (r *sqlTodoRepository) getWorkspace(ctx context.Context, condition string, arg any) (*model.Workspace, error) {
+	workspace, err := scanWorkspace(r.q.QueryRowContext(ctx, r.dialect.rebind(`SELECT `+workspaceColumns+` FROM workspaces WHERE `+condition), arg))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// GetWorkspaceSettings returns the settings of the repository's workspace
+func (r *sqlTodoRepository) GetWorkspaceSettings(ctx context.Context) (model.WorkspaceSettings, error) {
+	ctx, end := r.call(ctx, "GetWorkspaceSettings")
+	defer end()
+
+	settings := model.DefaultWorkspaceSettings(r.workspaceID)
+	query := `SELECT sharing, webhooks, registration FROM workspace_settings WHERE workspace_id = ?`
+	err := r.q.QueryRowContext(ctx, r.dialect.rebind(query), r.workspaceID).Scan(&settings.Sharing, &settings.Webhooks, &settings.Registration)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return settings, err
+	}
+	return settings, nil
+}
+
+// UpdateWorkspaceSettings saves the settings of the repository's workspace
+func (r *sqlTodoRepository) UpdateWorkspaceSettings(ctx context.Context, settings model.WorkspaceSettings) error {
+	ctx, end := r.call(ctx, "UpdateWorkspaceSettings")
+	defer end()
+
+	query := `
+		INSERT INTO workspace_settings (workspace_id, sharing, webhooks, registration, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (workspace_id) DO UPDATE SET sharing = excluded.sharing, webhooks = excluded.webhooks,
+			registration = excluded.registration, updated_at = excluded.updated_at
+	`
+	_, err := r.q.ExecContext(ctx, r.dialect.rebind(query), r.workspaceID, settings.Sharing, settings.Webhooks, settings.Registration, r.now())
+	return err
+}
+
+// scanWorkspace reads one workspace selected with workspaceColumns
+func scanWorkspace(row rowScanner) (*model.Workspace, error) {
+	workspace := &model.Workspace{}
+	err := row.Scan(&workspace.ID, &workspace.Slug, &workspace.Name, &workspace.CreatedAt, &workspace.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
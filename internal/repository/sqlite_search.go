@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"todo-app/internal/model"
+)
+
+// sqliteSearchTriggers keep todos_fts in sync with todos.text
+var sqliteSearchTriggers = []string{"todos_fts_insert", "todos_fts_delete", "todos_fts_update"}
+
+// sqliteSearchSchema creates the FTS5 index over todos.text, its triggers and
+// fills it from the existing todos. The index lives outside the migrations
+// because FTS5 is only compiled in with the sqlite_fts5 build tag.
+const sqliteSearchSchema = `
+	CREATE VIRTUAL TABLE IF NOT EXISTS todos_fts USING fts5(text, content='todos', content_rowid='id');
+
+	CREATE TRIGGER todos_fts_insert AFTER INSERT ON todos BEGIN
+		INSERT INTO todos_fts (rowid, text) VALUES (new.id, new.text);
+	END;
+
+	CREATE TRIGGER todos_fts_delete AFTER DELETE ON todos BEGIN
+		INSERT INTO todos_fts (todos_fts, rowid, text) VALUES ('delete', old.id, old.text);
+	END;
+
+	CREATE TRIGGER todos_fts_update AFTER UPDATE OF text ON todos BEGIN
+		INSERT INTO todos_fts (todos_fts, rowid, text) VALUES ('delete', old.id, old.text);
+		INSERT INTO todos_fts (rowid, text) VALUES (new.id, new.text);
+	END;
+
+	INSERT INTO todos_fts (todos_fts) VALUES ('rebuild');
+`
+
+// dropUnusableSearchTriggers drops the search index triggers when the driver
+// lacks FTS5, as they break every write to todos and every migration changing
+// it, and reports whether FTS5 is supported
+func dropUnusableSearchTriggers(db *sql.DB) (bool, error) {
+	var supported bool
+	if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&supported); err != nil {
+		return false, err
+	}
+	if supported {
+		return true, nil
+	}
+
+	for _, trigger := range sqliteSearchTriggers {
+		if _, err := db.Exec(`DROP TRIGGER IF EXISTS ` + trigger); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// prepareSQLiteSearch makes sure the search index and its triggers exist when
+// the driver supports FTS5 and reports whether searching is possible. Without
+// FTS5 the triggers are dropped, since they would make every write fail; the
+// index is rebuilt once a build with FTS5 opens the database again.
+func prepareSQLiteSearch(db *sql.DB) (bool, error) {
+	supported, err := dropUnusableSearchTriggers(db)
+	if err != nil || !supported {
+		return false, err
+	}
+
+	args := make([]any, len(sqliteSearchTriggers))
+	for i, trigger := range sqliteSearchTriggers {
+		args[i] = trigger
+	}
+	var triggers int
+	query := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN (` + placeholders(len(args)) + `)`
+	if err := db.QueryRow(query, args...).Scan(&triggers); err != nil {
+		return false, err
+	}
+	if triggers == len(sqliteSearchTriggers) {
+		return true, nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	for _, trigger := range sqliteSearchTriggers {
+		if _, err := tx.Exec(`DROP TRIGGER IF EXISTS ` + trigger); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+	if _, err := tx.Exec(sqliteSearchSchema); err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("create search index: %w", err)
+	}
+	return true, tx.Commit()
+}
+
+// RebuildSearchIndex refills the search index from the todos table
+func (r *SQLiteTodoRepository) RebuildSearchIndex(ctx context.Context) error {
+	ctx, end := r.call(ctx, "RebuildSearchIndex")
+	defer end()
+
+	if !r.searchable {
+		return ErrSearchUnavailable
+	}
+	_, err := r.q.ExecContext(ctx, `INSERT INTO todos_fts (todos_fts) VALUES ('rebuild')`)
+	return err
+}
+
+// SearchTodos ranks the matching todos with FTS5's bm25, breaking ties newest first
+func (r *SQLiteTodoRepository) SearchTodos(ctx context.Context, query *model.SearchExpr, userID int, limit int) ([]*model.SearchResult, error) {
+	ctx, end := r.call(ctx, "SearchTodos")
+	defer end()
+
+	if !r.searchable {
+		return nil, ErrSearchUnavailable
+	}
+
+	// The hits subquery keeps todos_fts.text from clashing with todos.text
+	return r.scanSearchResults(ctx, `
+		SELECT `+todoColumns+`, hits.snippet, -hits.score FROM todos
+		JOIN (
+			SELECT rowid AS hit_id, bm25(todos_fts) AS score,
+				snippet(todos_fts, 0, char(2), char(3), ?, ?) AS snippet
+			FROM todos_fts WHERE todos_fts MATCH ? AND rowid IN (SELECT id FROM todos WHERE `+visibleTodos+`)
+			ORDER BY score, rowid DESC LIMIT ?
+		) hits ON hits.hit_id = todos.id
+		ORDER BY hits.score, todos.id DESC
+	`, slices.Concat([]any{snippetEllipsis, snippetWords, fts5Query(query)}, r.visibleArgs(userID), []any{limit})...)
+}
+
+// fts5Query renders query in FTS5 syntax, quoting every term so no input can
+// reach FTS5 features such as column filters
+func fts5Query(query *model.SearchExpr) string {
+	switch query.Op {
+	case model.SearchAnd:
+		return "(" + fts5Query(query.Left) + " AND " + fts5Query(query.Right) + ")"
+	case model.SearchOr:
+		return "(" + fts5Query(query.Left) + " OR " + fts5Query(query.Right) + ")"
+	case model.SearchNot:
+		return "(" + fts5Query(query.Left) + " NOT " + fts5Query(query.Right) + ")"
+	}
+
+	term := `"` + strings.Join(query.Words, " ") + `"`
+	if query.Prefix {
+		term += "*"
+	}
+	return term
+}
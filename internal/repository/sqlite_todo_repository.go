@@ -3,118 +3,189 @@ package repository
 import (
 	"database/sql"
 	"embed"
-	"time"
-
-	"todo-app/internal/model"
+	"fmt"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-//go:embed database/schema.sql
+//go:embed database/migrations/*/*.sql
 var schemaFS embed.FS
 
+// sqliteMigrationsDir is the directory of schemaFS holding the SQLite migrations
+const sqliteMigrationsDir = "database/migrations/sqlite"
+
 // SQLiteTodoRepository implements TodoRepository using SQLite
 type SQLiteTodoRepository struct {
-	db     *sql.DB
-	dbPath string
+	*sqlTodoRepository
+	dbPath     string
+	searchable bool // the driver supports FTS5 and the search index is in place
 }
 
+var _ TodoRepository = (*SQLiteTodoRepository)(nil)
+
 // NewSQLiteTodoRepository creates a new SQLite todo repository
 func NewSQLiteTodoRepository(dbPath string) (*SQLiteTodoRepository, error) {
-	db, err := sql.Open("sqlite3", dbPath) // databse bağlantısını aç
+	db, err := OpenSQLite(dbPath)
 	if err != nil {
 		return nil, err
 	}
 
 	repo := &SQLiteTodoRepository{
-		db:     db,
-		dbPath: dbPath,
+		sqlTodoRepository: newSQLTodoRepository(db, sqliteDialect),
+		dbPath:            dbPath,
 	}
 
 	// Run migrations
 	if err := repo.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if repo.searchable, err = prepareSQLiteSearch(db); err != nil {
+		db.Close()
 		return nil, err
 	}
 
 	return repo, nil
 }
 
-// migrate runs database migrations
-func (r *SQLiteTodoRepository) migrate() error {
-	schema, err := schemaFS.ReadFile("database/schema.sql")
+// OpenSQLite opens the SQLite database at dbPath without running migrations.
+// Transactions take the write lock when they begin: one that read first
+// could not upgrade its lock while another connection writes, and would fail
+// with "database is locked" instead of waiting for it.
+func OpenSQLite(dbPath string) (*sql.DB, error) {
+	separator := "?"
+	if strings.Contains(dbPath, "?") {
+		separator = "&"
+	}
+	db, err := sql.Open("sqlite3", dbPath+separator+"_txlock=immediate") // databse bağlantısını aç
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = r.db.Exec(string(schema))
-	return err
-}
+	// Every connection to ":memory:" gets its own empty database
+	if dbPath == ":memory:" {
+		db.SetMaxOpenConns(1)
+	}
 
-// Create adds a new todo to the database
-func (r *SQLiteTodoRepository) Create(todo *model.Todo) (*model.Todo, error) {
-	now := time.Now()
+	return db, nil
+}
 
-	query := `
-		INSERT INTO todos (text, created_at, updated_at) 
-		VALUES (?, ?, ?)
-	`
+// NewSQLiteMigrator returns a migrator for the embedded SQLite migrations,
+// adopting databases created before schema_migrations existed
+func NewSQLiteMigrator(db *sql.DB) (*Migrator, error) {
+	if _, err := dropUnusableSearchTriggers(db); err != nil {
+		return nil, err
+	}
 
-	result, err := r.db.Exec(query, todo.Text, now, now)
+	migrator, err := newMigrator(db, schemaFS, sqliteMigrationsDir, sqliteDialect)
 	if err != nil {
 		return nil, err
 	}
 
-	id, err := result.LastInsertId() // ← Son eklenen ID'yi al
-	if err != nil {
+	if err := adoptLegacySQLiteSchema(db, migrator); err != nil {
 		return nil, err
 	}
 
-	todo.ID = int(id)
-	todo.CreatedAt = now
-	todo.UpdatedAt = now
+	return migrator, nil
+}
+
+// migrate applies pending migrations
+func (r *SQLiteTodoRepository) migrate() error {
+	migrator, err := NewSQLiteMigrator(r.db)
+	if err != nil {
+		return err
+	}
+
+	_, err = migrator.Up()
+	return err
+}
+
+// adoptLegacySQLiteSchema brings a database created from the old single schema.sql
+// up to the first migration and records it as applied
+func adoptLegacySQLiteSchema(db *sql.DB, migrator *Migrator) error {
+	hasMigrations, err := sqliteTableExists(db, "schema_migrations")
+	if err != nil || hasMigrations {
+		return err
+	}
+	hasTodos, err := sqliteTableExists(db, "todos")
+	if err != nil || !hasTodos {
+		return err
+	}
+
+	// Databases created before completion tracking keep their old todos table
+	err = addMissingColumns(db, "todos", map[string]string{
+		"completed":    "BOOLEAN NOT NULL DEFAULT 0",
+		"completed_at": "DATETIME",
+	})
+	if err != nil {
+		return err
+	}
 
-	return todo, nil
+	return migrator.Baseline(1)
 }
 
-// GetAll returns all todos from the database, ordered by created_at DESC
-func (r *SQLiteTodoRepository) GetAll() ([]*model.Todo, error) {
-	query := `
-		SELECT id, text, created_at, updated_at 
-		FROM todos 
-		ORDER BY created_at DESC
-	`
+func sqliteTableExists(db *sql.DB, table string) (bool, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&count)
+	return count > 0, err
+}
 
-	rows, err := r.db.Query(query)
+// addMissingColumns adds each column that the existing table does not have yet
+func addMissingColumns(db *sql.DB, table string, columns map[string]string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 
-	todos := make([]*model.Todo, 0) // Initialize as empty slice, not nil
+	existing := make(map[string]bool)
 	for rows.Next() {
-		todo := &model.Todo{}
-		err := rows.Scan(&todo.ID, &todo.Text, &todo.CreatedAt, &todo.UpdatedAt)
-		if err != nil {
-			return nil, err
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   bool
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			rows.Close()
+			return err
 		}
-		todos = append(todos, todo)
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
-	return todos, rows.Err()
+	for name, definition := range columns {
+		if existing[name] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, name, definition)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// DBPath returns the database file path
-func (r *SQLiteTodoRepository) DBPath() string {
-	return r.dbPath
+// ForWorkspace returns a repository on the same database working in the
+// workspace with the given ID
+func (r *SQLiteTodoRepository) ForWorkspace(id int) TodoRepository {
+	c := *r
+	c.sqlTodoRepository = r.sqlTodoRepository.forWorkspace(id)
+	return &c
 }
 
-// Close closes the database connection
-func (r *SQLiteTodoRepository) Close() error {
-	return r.db.Close()
+func (r *SQLiteTodoRepository) withCallOptions(opts CallOptions) TodoRepository {
+	c := *r
+	c.sqlTodoRepository = r.sqlTodoRepository.withCalls(opts)
+	return &c
 }
 
-func (r *SQLiteTodoRepository) Truncate() error {
-	query := `DELETE FROM todos`
-	_, err := r.db.Exec(query)
-	return err
+// DBPath returns the database file path
+func (r *SQLiteTodoRepository) DBPath() string {
+	return r.dbPath
 }
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"todo-app/internal/model"
+)
+
+var (
+	// ErrTodoNotFound is returned when no todo exists with the requested ID
+	ErrTodoNotFound = errors.New("todo not found")
+
+	// ErrTagNotFound is returned when no tag exists with the requested ID
+	ErrTagNotFound = errors.New("tag not found")
+
+	// ErrTagExists is returned when renaming a tag to the name of another tag
+	ErrTagExists = errors.New("tag already exists")
+
+	// ErrListNotFound is returned when no list exists with the requested ID
+	ErrListNotFound = errors.New("list not found")
+
+	// ErrDeleteInbox is returned when deleting the inbox list
+	ErrDeleteInbox = errors.New("the inbox list cannot be deleted")
+
+	// ErrSearchUnavailable is returned by SearchTodos when the backend has no full-text index
+	ErrSearchUnavailable = errors.New("full-text search is not available")
+
+	// ErrWebhookNotFound is returned when no webhook exists with the requested ID
+	ErrWebhookNotFound = errors.New("webhook not found")
+
+	// ErrUserNotFound is returned when no user exists with the requested ID or username
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrUsernameTaken is returned when creating a user with the username of another
+	ErrUsernameTaken = errors.New("username is already taken")
+
+	// ErrSessionNotFound is returned when no session exists with the requested token hash
+	ErrSessionNotFound = errors.New("session not found")
+
+	// ErrMemberNotFound is returned when a user is neither a member of nor invited to a list
+	ErrMemberNotFound = errors.New("list member not found")
+
+	// ErrMemberExists is returned when adding a user who is already a member of or invited to a list
+	ErrMemberExists = errors.New("user is already a member of the list")
+
+	// ErrAPITokenNotFound is returned when no API token exists with the requested ID or token hash
+	ErrAPITokenNotFound = errors.New("API token not found")
+
+	// ErrWorkspaceNotFound is returned when no workspace exists with the requested ID or slug
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+
+	// ErrWorkspaceExists is returned when creating a workspace with the slug of another
+	ErrWorkspaceExists = errors.New("workspace already exists")
+)
+
+// TodoRepository is the storage contract for todos. Every implementation must
+// pass the shared conformance suite in internal/repository/repositorytest.
+//
+// Todos, tags, lists, events and webhooks belong to the user in their UserID;
+// user 0 owns the ones made without an account. A user sees every todo of
+// the lists they own or have joined as a member, but only their own in the
+// inbox. Methods reading by ID return the record whoever may see it, and the
+// services check access.
+//
+// A repository works in one workspace, the default one until ForWorkspace:
+// it reads and changes only the todos, tags, lists, members, events,
+// webhooks and settings of that workspace, as if the others did not exist.
+// Users, sessions, API tokens and the workspaces themselves are shared by all.
+type TodoRepository interface {
+	// ForWorkspace returns a repository on the same storage working in the
+	// workspace with the given ID. It does not check that the workspace exists.
+	ForWorkspace(id int) TodoRepository
+
+	// WorkspaceID returns the ID of the workspace the repository works in
+	WorkspaceID() int
+
+	// Create stores a new todo with its tags and fills in its ID and timestamps.
+	// A todo without ListID is stored in the inbox list. Its tags are those of
+	// its user.
+	Create(ctx context.Context, todo *model.Todo) (*model.Todo, error)
+
+	// GetAll returns the todos matching filter in filter.Sort order; with a
+	// cursor only the todos after it (before it when Backward), still in that
+	// order, and at most filter.Limit of them when set
+	GetAll(ctx context.Context, filter model.TodoFilter) ([]*model.Todo, error)
+
+	// SearchTodos returns at most limit todos userID can see matching query,
+	// best match first, or ErrSearchUnavailable
+	SearchTodos(ctx context.Context, query *model.SearchExpr, userID int, limit int) ([]*model.SearchResult, error)
+
+	// GetByID returns one todo or ErrTodoNotFound
+	GetByID(ctx context.Context, id int) (*model.Todo, error)
+
+	// Update saves the editable fields and tags of an existing todo or returns
+	// ErrTodoNotFound. A todo without ListID stays in its current list; the
+	// owner never changes.
+	Update(ctx context.Context, todo *model.Todo) (*model.Todo, error)
+
+	// Delete removes one todo or returns ErrTodoNotFound
+	Delete(ctx context.Context, id int) error
+
+	// CountTodos returns how many todos the workspace has, of every user,
+	// and how many of them are not completed
+	CountTodos(ctx context.Context) (model.TodoCounts, error)
+
+	// GetTags returns every tag of userID with the number of todos using it, by name
+	GetTags(ctx context.Context, userID int) ([]*model.Tag, error)
+
+	// GetTag returns one tag with its todo count or ErrTagNotFound
+	GetTag(ctx context.Context, id int) (*model.Tag, error)
+
+	// RenameTag renames a tag, returning ErrTagNotFound, or ErrTagExists when
+	// its user has another tag of that name
+	RenameTag(ctx context.Context, id int, name string) (*model.Tag, error)
+
+	// MergeTags moves every todo of the source tag to the target tag and
+	// deletes the source, returning the target, or ErrTagNotFound when either
+	// is missing or they belong to different users
+	MergeTags(ctx context.Context, sourceID, targetID int) (*model.Tag, error)
+
+	// CreateList stores a new list owned by its UserID and fills in its ID and timestamps
+	CreateList(ctx context.Context, list *model.List) (*model.List, error)
+
+	// GetLists returns the inbox and every list userID owns or has joined,
+	// with the role of userID and the number of todos userID can see in each,
+	// the inbox first
+	GetLists(ctx context.Context, userID int) ([]*model.List, error)
+
+	// GetList returns one list, without Role and counting every todo in it,
+	// or ErrListNotFound
+	GetList(ctx context.Context, id int) (*model.List, error)
+
+	// UpdateList saves the name of an existing list or returns ErrListNotFound
+	UpdateList(ctx context.Context, list *model.List) (*model.List, error)
+
+	// DeleteList moves the todos of a list to the inbox and removes the list
+	// with its members, or returns ErrListNotFound or ErrDeleteInbox
+	DeleteList(ctx context.Context, id int) error
+
+	// AddListMember stores a member or invitation of a list and fills in its
+	// CreatedAt, or returns ErrMemberExists. Members are read back with the
+	// name of their list but without Username, as users may be stored elsewhere.
+	AddListMember(ctx context.Context, member *model.ListMember) (*model.ListMember, error)
+
+	// GetListMember returns the membership or invitation of userID to a list,
+	// or ErrMemberNotFound
+	GetListMember(ctx context.Context, listID, userID int) (*model.ListMember, error)
+
+	// GetListMembers returns the members and invitations of a list in the
+	// order they were added
+	GetListMembers(ctx context.Context, listID int) ([]*model.ListMember, error)
+
+	// GetInvitations returns the invitations userID has not accepted yet, oldest first
+	GetInvitations(ctx context.Context, userID int) ([]*model.ListMember, error)
+
+	// UpdateListMember saves the Role and Accepted of a member or returns ErrMemberNotFound
+	UpdateListMember(ctx context.Context, member *model.ListMember) (*model.ListMember, error)
+
+	// DeleteListMember removes a member or invitation or returns ErrMemberNotFound
+	DeleteListMember(ctx context.Context, listID, userID int) error
+
+	// AppendEvent adds an event to the change log and fills in its ID, which
+	// is greater than that of every event appended before, and CreatedAt. In
+	// the same transaction it queues a pending delivery of the event, due at
+	// once, for every webhook of the event's user subscribed to its type.
+	AppendEvent(ctx context.Context, event *model.TodoEvent) (*model.TodoEvent, error)
+
+	// GetEvents returns at most limit events with an ID above afterID, oldest
+	// first, of the todos of userID and of those in the lists userID owns or
+	// has joined
+	GetEvents(ctx context.Context, userID int, afterID int64, limit int) ([]*model.TodoEvent, error)
+
+	// CreateWebhook stores a new webhook and fills in its ID and timestamps
+	CreateWebhook(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error)
+
+	// GetWebhooks returns every webhook of userID by ID
+	GetWebhooks(ctx context.Context, userID int) ([]*model.Webhook, error)
+
+	// GetWebhook returns one webhook or ErrWebhookNotFound
+	GetWebhook(ctx context.Context, id int) (*model.Webhook, error)
+
+	// UpdateWebhook saves the URL, secret and event types of an existing
+	// webhook or returns ErrWebhookNotFound
+	UpdateWebhook(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error)
+
+	// DeleteWebhook removes a webhook with its deliveries or returns ErrWebhookNotFound
+	DeleteWebhook(ctx context.Context, id int) error
+
+	// GetDeliveries returns the latest limit deliveries of a webhook with
+	// their attempts, newest first
+	GetDeliveries(ctx context.Context, webhookID int, limit int) ([]*model.WebhookDelivery, error)
+
+	// ClaimDueDeliveries returns at most limit pending deliveries whose next
+	// attempt is due by now, the longest waiting, with their attempts, oldest
+	// first. It moves their next attempt to until at once, so no other
+	// dispatcher, on this database or sharing it, claims them before until
+	// unless RecordDeliveryAttempt reschedules them.
+	ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]*model.WebhookDelivery, error)
+
+	// RecordDeliveryAttempt adds attempt to a delivery and saves the delivery's
+	// Status and NextAttemptAt, or returns ErrWebhookNotFound when the webhook
+	// has been deleted since
+	RecordDeliveryAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt *model.DeliveryAttempt) error
+
+	// CreateUser stores a new user of its WorkspaceID and fills in its ID and
+	// CreatedAt, or returns ErrUsernameTaken. Usernames are unique across
+	// workspaces.
+	CreateUser(ctx context.Context, user *model.User) (*model.User, error)
+
+	// AssignOwner gives userID everything user 0 owns in the workspace, the
+	// data made before accounts existed, except the inbox
+	AssignOwner(ctx context.Context, userID int) error
+
+	// GetUser returns one user or ErrUserNotFound
+	GetUser(ctx context.Context, id int) (*model.User, error)
+
+	// GetUserByUsername returns one user or ErrUserNotFound
+	GetUserByUsername(ctx context.Context, username string) (*model.User, error)
+
+	// CreateSession stores a new session and removes every expired one
+	CreateSession(ctx context.Context, session *model.Session) error
+
+	// GetSession returns one session, even when expired, or ErrSessionNotFound
+	GetSession(ctx context.Context, tokenHash string) (*model.Session, error)
+
+	// DeleteSession removes one session or returns ErrSessionNotFound
+	DeleteSession(ctx context.Context, tokenHash string) error
+
+	// CreateAPIToken stores a new API token and fills in its ID and CreatedAt
+	CreateAPIToken(ctx context.Context, token *model.APIToken) (*model.APIToken, error)
+
+	// GetAPITokens returns every API token of userID by ID
+	GetAPITokens(ctx context.Context, userID int) ([]*model.APIToken, error)
+
+	// GetAPIToken returns one API token or ErrAPITokenNotFound
+	GetAPIToken(ctx context.Context, id int) (*model.APIToken, error)
+
+	// GetAPITokenByHash returns the API token with tokenHash or ErrAPITokenNotFound
+	GetAPITokenByHash(ctx context.Context, tokenHash string) (*model.APIToken, error)
+
+	// TouchAPIToken sets the LastUsedAt of an API token to now or returns ErrAPITokenNotFound
+	TouchAPIToken(ctx context.Context, id int) error
+
+	// DeleteAPIToken removes one API token or returns ErrAPITokenNotFound
+	DeleteAPIToken(ctx context.Context, id int) error
+
+	// CreateWorkspace stores a new workspace with its inbox and fills in its
+	// ID and timestamps, or returns ErrWorkspaceExists
+	CreateWorkspace(ctx context.Context, workspace *model.Workspace) (*model.Workspace, error)
+
+	// GetWorkspaces returns every workspace by ID, without settings
+	GetWorkspaces(ctx context.Context) ([]*model.Workspace, error)
+
+	// GetWorkspace returns one workspace, without settings, or ErrWorkspaceNotFound
+	GetWorkspace(ctx context.Context, id int) (*model.Workspace, error)
+
+	// GetWorkspaceBySlug returns one workspace, without settings, or ErrWorkspaceNotFound
+	GetWorkspaceBySlug(ctx context.Context, slug string) (*model.Workspace, error)
+
+	// GetWorkspaceSettings returns the settings of the repository's workspace,
+	// the defaults until they are changed
+	GetWorkspaceSettings(ctx context.Context) (model.WorkspaceSettings, error)
+
+	// UpdateWorkspaceSettings saves the settings of the repository's workspace
+	UpdateWorkspaceSettings(ctx context.Context, settings model.WorkspaceSettings) error
+
+	// Atomically runs fn so that either all or none of its writes are kept
+	Atomically(ctx context.Context, fn func(tx TxRepository) error) error
+
+	// Truncate removes everything but the default workspace and its inbox
+	// list, users and the other workspaces included
+	Truncate(ctx context.Context) error
+
+	// Close releases the underlying storage
+	Close() error
+}
+
+// TxRepository is the part of TodoRepository available inside Atomically
+type TxRepository interface {
+	Create(ctx context.Context, todo *model.Todo) (*model.Todo, error)
+	GetByID(ctx context.Context, id int) (*model.Todo, error)
+	Update(ctx context.Context, todo *model.Todo) (*model.Todo, error)
+	Delete(ctx context.Context, id int) error
+	GetList(ctx context.Context, id int) (*model.List, error)
+	GetListMember(ctx context.Context, listID, userID int) (*model.ListMember, error)
+	AppendEvent(ctx context.Context, event *model.TodoEvent) (*model.TodoEvent, error)
+}
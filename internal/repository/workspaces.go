@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"todo-app/internal/model"
+)
+
+// Workspaces finds the storage of each workspace. The directory repository
+// it is made from keeps the workspaces, users, sessions and API tokens.
+type Workspaces interface {
+	// Open returns the repository of the workspace with the given ID, or ErrWorkspaceNotFound
+	Open(ctx context.Context, id int) (TodoRepository, error)
+
+	// Close releases the storage opened for workspaces, but not the directory
+	Close() error
+}
+
+// SharedWorkspaces keeps every workspace in the directory repository itself,
+// apart only by the workspace ID on each row
+func SharedWorkspaces(dir TodoRepository) Workspaces {
+	return sharedWorkspaces{dir: dir}
+}
+
+type sharedWorkspaces struct {
+	dir TodoRepository
+}
+
+func (w sharedWorkspaces) Open(ctx context.Context, id int) (TodoRepository, error) {
+	if _, err := w.dir.GetWorkspace(ctx, id); err != nil {
+		return nil, err
+	}
+	return w.dir.ForWorkspace(id), nil
+}
+
+func (w sharedWorkspaces) Close() error {
+	return nil
+}
+
+// SQLiteWorkspaceFiles keeps the default workspace in the directory
+// repository and every other one in a SQLite file of its own,
+// workspace-<ID>.db in a data directory. A file is created, migrated and
+// opened the first time its workspace is used, then kept open, making its
+// calls with the CallOptions of the directory.
+type SQLiteWorkspaceFiles struct {
+	dir     TodoRepository
+	dataDir string
+
+	mu    sync.Mutex
+	files map[int]*SQLiteTodoRepository // workspace ID -> its open file
+}
+
+var _ Workspaces = (*SQLiteWorkspaceFiles)(nil)
+
+// NewSQLiteWorkspaceFiles keeps the workspaces of dir other than the default
+// one in SQLite files under dataDir, creating dataDir when missing
+func NewSQLiteWorkspaceFiles(dir TodoRepository, dataDir string) (*SQLiteWorkspaceFiles, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, err
+	}
+	return &SQLiteWorkspaceFiles{
+		dir:     dir,
+		dataDir: dataDir,
+		files:   make(map[int]*SQLiteTodoRepository),
+	}, nil
+}
+
+// Path returns the SQLite file of the workspace with the given ID
+func (w *SQLiteWorkspaceFiles) Path(id int) string {
+	return filepath.Join(w.dataDir, fmt.Sprintf("workspace-%d.db", id))
+}
+
+// Open returns the directory for the default workspace and the repository
+// of its file for any other
+func (w *SQLiteWorkspaceFiles) Open(ctx context.Context, id int) (TodoRepository, error) {
+	if id == model.DefaultWorkspaceID {
+		return w.dir.ForWorkspace(id), nil
+	}
+	if _, err := w.dir.GetWorkspace(ctx, id); err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if repo, ok := w.files[id]; ok {
+		return repo, nil
+	}
+
+	file, err := NewSQLiteTodoRepository(w.Path(id))
+	if err != nil {
+		return nil, fmt.Errorf("open workspace %d: %w", id, err)
+	}
+	repo := file.ForWorkspace(id).(*SQLiteTodoRepository)
+	if dir, ok := w.dir.(interface{ callOptions() CallOptions }); ok {
+		repo.sqlTodoRepository = repo.withCalls(dir.callOptions())
+	}
+	if err := repo.ensureInbox(ctx, repo.db); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("open workspace %d: %w", id, err)
+	}
+
+	w.files[id] = repo
+	return repo, nil
+}
+
+// Close closes every workspace file opened so far
+func (w *SQLiteWorkspaceFiles) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var errs []error
+	for id, repo := range w.files {
+		errs = append(errs, repo.Close())
+		delete(w.files, id)
+	}
+	return errors.Join(errs...)
+}
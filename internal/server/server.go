@@ -0,0 +1,176 @@
+// Package server runs the HTTP server with hardened limits and shuts it down
+// gracefully: on a stop signal it fails its readiness probe for a while, so
+// load balancers stop sending it traffic, then stops accepting connections,
+// lets the requests in flight finish within a drain period, and releases the
+// resources the handlers use, such as the repository.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Config holds the limits of the server
+type Config struct {
+	Addr              string
+	ReadHeaderTimeout time.Duration // to read the request headers
+	ReadTimeout       time.Duration // to read the whole request, body included
+	WriteTimeout      time.Duration // from the end of the request headers to the end of the response; streams lift it
+	IdleTimeout       time.Duration // a keep-alive connection may wait for its next request
+	MaxHeaderBytes    int
+	ReadinessDelay    time.Duration // after the stop signal, readiness probes fail this long before connections are refused
+	DrainTimeout      time.Duration // requests in flight at shutdown may take this long to finish
+}
+
+// DefaultConfig returns the limits for serving on addr. The readiness delay
+// and the drain period together fit in the 10 seconds Docker waits after
+// SIGTERM before killing.
+func DefaultConfig(addr string) Config {
+	return Config{
+		Addr:              addr,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		MaxHeaderBytes:    64 << 10,
+		ReadinessDelay:    2 * time.Second,
+		DrainTimeout:      7 * time.Second,
+	}
+}
+
+// Server is an http.Server that shuts down gracefully
+type Server struct {
+	http           *http.Server
+	readinessDelay time.Duration
+	drainTimeout   time.Duration
+
+	mu       sync.Mutex
+	stoppers []func()       // run on the stop signal, before the readiness delay
+	closers  []func() error // run after draining, last registered first
+}
+
+// New creates a server for handler with the limits of cfg
+func New(cfg Config, handler http.Handler) *Server {
+	return &Server{
+		http: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		},
+		readinessDelay: cfg.ReadinessDelay,
+		drainTimeout:   cfg.DrainTimeout,
+	}
+}
+
+// OnStop registers fn to be called as soon as the stop signal arrives, while
+// connections are still accepted for the readiness delay, to fail the
+// readiness probe
+func (s *Server) OnStop(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stoppers = append(s.stoppers, fn)
+}
+
+// OnDrain registers fn to be called once the server stops accepting
+// connections, after the readiness delay, to end the
+// long-lived requests, such as event streams and WebSockets, draining would
+// otherwise wait for
+func (s *Server) OnDrain(fn func()) {
+	s.http.RegisterOnShutdown(fn)
+}
+
+// OnShutdown registers fn to be called once the requests in flight are done,
+// or the drain period is over. They are called in the reverse order they
+// were registered, so what is opened first is closed last.
+func (s *Server) OnShutdown(fn func() error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closers = append(s.closers, fn)
+}
+
+// Run listens on the configured address and serves until ctx is done, then
+// shuts down. The OnShutdown functions run however it ends.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return errors.Join(err, s.close())
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves on ln until ctx is done, then calls the OnStop functions and
+// keeps serving for the readiness delay, so load balancers probing readiness
+// see the server leave before its connections are refused. It then stops
+// accepting connections, waits up to the drain period for the requests in
+// flight and calls the OnShutdown functions. It returns nil after a clean
+// shutdown.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	served := make(chan error, 1)
+	go func() {
+		served <- s.http.Serve(ln)
+	}()
+
+	var err error
+	select {
+	case err = <-served: // Failed before being asked to stop
+	case <-ctx.Done():
+		s.stop()
+		slog.Info("Shutting down: failing readiness before refusing connections", "readiness_delay", s.readinessDelay.String())
+		time.Sleep(s.readinessDelay)
+		slog.Info("Shutting down: draining requests in flight", "drain_timeout", s.drainTimeout.String())
+		err = s.shutdown()
+		if served := <-served; !errors.Is(served, http.ErrServerClosed) {
+			err = errors.Join(err, served)
+		}
+	}
+
+	return errors.Join(err, s.close())
+}
+
+// shutdown stops accepting connections and waits for the requests in
+// flight, cutting off the ones still running after the drain period
+func (s *Server) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.drainTimeout)
+	defer cancel()
+
+	if err := s.http.Shutdown(ctx); err != nil {
+		s.http.Close()
+		return fmt.Errorf("requests still running after %s were cut off: %w", s.drainTimeout, err)
+	}
+	return nil
+}
+
+// stop calls the OnStop functions
+func (s *Server) stop() {
+	s.mu.Lock()
+	stoppers := s.stoppers
+	s.mu.Unlock()
+
+	for _, fn := range stoppers {
+		fn()
+	}
+}
+
+// close calls the OnShutdown functions, last registered first
+func (s *Server) close() error {
+	s.mu.Lock()
+	closers := s.closers
+	s.closers = nil
+	s.mu.Unlock()
+
+	var errs []error
+	for i := len(closers) - 1; i >= 0; i-- {
+		errs = append(errs, closers[i]())
+	}
+	return errors.Join(errs...)
+}
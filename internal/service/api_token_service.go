@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"todo-app/internal/auth"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+)
+
+// apiTokenTouchInterval is how stale the last-used time of an API token may
+// get before a request updates it, so busy scripts do not write on every call
+const apiTokenTouchInterval = time.Minute
+
+var (
+	// ErrAPITokenNotFound is returned for an API token that is unknown or revoked
+	ErrAPITokenNotFound = repository.ErrAPITokenNotFound
+
+	// ErrInvalidTokenName is returned when creating an API token with an empty or too long name
+	ErrInvalidTokenName = errors.New("name must be 1 to 100 characters")
+
+	// ErrInvalidScope is returned when creating an API token without scopes or with an unknown one
+	ErrInvalidScope = errors.New("scopes must be one or more of todos:read, todos:write, webhooks:read and webhooks:write")
+)
+
+// APITokenService handles the API tokens scripts authenticate with. Every
+// operation acts on the tokens of one user, see ForUser.
+type APITokenService struct {
+	repo   repository.TodoRepository
+	userID int
+}
+
+// NewAPITokenService creates a new API token service
+func NewAPITokenService(repo repository.TodoRepository) *APITokenService {
+	return &APITokenService{
+		repo: repo,
+	}
+}
+
+// ForUser returns a service that only sees and changes the API tokens of userID
+func (s *APITokenService) ForUser(userID int) *APITokenService {
+	c := *s
+	c.userID = userID
+	return &c
+}
+
+// CreateAPIToken creates an API token. The returned token is the only one
+// showing its value; only its hash is stored.
+func (s *APITokenService) CreateAPIToken(ctx context.Context, input model.APITokenInput) (*model.APIToken, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return nil, ErrInvalidTokenName
+	}
+	if len(input.Scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+	for _, scope := range input.Scopes {
+		if !slices.Contains(model.Scopes, scope) {
+			return nil, fmt.Errorf("%w: got %q", ErrInvalidScope, scope)
+		}
+	}
+
+	value, err := auth.NewToken()
+	if err != nil {
+		return nil, err
+	}
+	token := &model.APIToken{
+		UserID:    s.userID,
+		Name:      name,
+		Token:     value,
+		TokenHash: auth.HashToken(value),
+	}
+	for _, scope := range model.Scopes { // In the order they are listed, without repeats
+		if slices.Contains(input.Scopes, scope) {
+			token.Scopes = append(token.Scopes, scope)
+		}
+	}
+	return s.repo.CreateAPIToken(ctx, token)
+}
+
+// GetAPITokens returns every API token without its value
+func (s *APITokenService) GetAPITokens(ctx context.Context) ([]*model.APIToken, error) {
+	return s.repo.GetAPITokens(ctx, s.userID)
+}
+
+// RevokeAPIToken deletes an API token; requests made with it fail from then on
+func (s *APITokenService) RevokeAPIToken(ctx context.Context, id int) error {
+	token, err := s.repo.GetAPIToken(ctx, id)
+	if err != nil {
+		return err
+	}
+	if token.UserID != s.userID {
+		return ErrAPITokenNotFound
+	}
+	return s.repo.DeleteAPIToken(ctx, id)
+}
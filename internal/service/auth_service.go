@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"todo-app/internal/auth"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+)
+
+// SessionLifetime is how long a session lasts after signing in
+const SessionLifetime = 30 * 24 * time.Hour
+
+var (
+	// ErrInvalidUsername is returned when registering a username of the wrong length or characters
+	ErrInvalidUsername = errors.New("username must be 3 to 32 characters: letters, digits, '.', '_' or '-'")
+
+	// ErrInvalidPassword is returned when registering a password that is too short or too long
+	ErrInvalidPassword = errors.New("password must be 8 to 1024 characters")
+
+	// ErrUsernameTaken is returned when registering a username that is already in use
+	ErrUsernameTaken = repository.ErrUsernameTaken
+
+	// ErrInvalidCredentials is returned when signing in with an unknown username or a wrong password
+	ErrInvalidCredentials = errors.New("invalid username or password")
+
+	// ErrSessionNotFound is returned for a session token that is unknown, expired or signed out
+	ErrSessionNotFound = repository.ErrSessionNotFound
+
+	// ErrUnknownWorkspace is returned when registering in a workspace that does not exist
+	ErrUnknownWorkspace = errors.New("no workspace has this slug")
+
+	// ErrRegistrationClosed is returned when registering in a workspace that does not take registrations
+	ErrRegistrationClosed = errors.New("this workspace does not take registrations; ask its administrator to open them")
+)
+
+// AuthService handles user accounts and their sessions
+type AuthService struct {
+	repo       repository.TodoRepository // the directory of workspaces and users
+	workspaces *WorkspaceService
+}
+
+// NewAuthService creates a new auth service over the directory repo, asking
+// workspaces whether a workspace takes registrations
+func NewAuthService(repo repository.TodoRepository, workspaces *WorkspaceService) *AuthService {
+	return &AuthService{
+		repo:       repo,
+		workspaces: workspaces,
+	}
+}
+
+// Register creates a user in the workspace with the slug credentials.Workspace,
+// the default one when empty, and signs them in, returning the session token.
+// The workspace must take registrations. Usernames are case-insensitive and
+// stored in lower case.
+func (s *AuthService) Register(ctx context.Context, credentials model.Credentials) (*model.User, string, error) {
+	username := strings.ToLower(strings.TrimSpace(credentials.Username))
+	if !validUsername(username) {
+		return nil, "", ErrInvalidUsername
+	}
+	if n := utf8.RuneCountInString(credentials.Password); n < 8 || n > 1024 {
+		return nil, "", ErrInvalidPassword
+	}
+
+	workspace, err := s.registrationWorkspace(ctx, credentials.Workspace)
+	if err != nil {
+		return nil, "", err
+	}
+
+	hash, err := auth.HashPassword(credentials.Password)
+	if err != nil {
+		return nil, "", err
+	}
+	user, err := s.repo.CreateUser(ctx, &model.User{WorkspaceID: workspace.ID, Username: username, PasswordHash: hash})
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := s.createSession(ctx, user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, token, nil
+}
+
+// registrationWorkspace returns the workspace with slug, the default one when
+// empty, or ErrRegistrationClosed when it does not take registrations
+func (s *AuthService) registrationWorkspace(ctx context.Context, slug string) (*model.Workspace, error) {
+	var (
+		workspace *model.Workspace
+		err       error
+	)
+	if slug = strings.ToLower(strings.TrimSpace(slug)); slug == "" {
+		workspace, err = s.workspaces.GetWorkspace(ctx, model.DefaultWorkspaceID)
+	} else {
+		workspace, err = s.workspaces.GetWorkspaceBySlug(ctx, slug)
+	}
+	if errors.Is(err, repository.ErrWorkspaceNotFound) {
+		return nil, ErrUnknownWorkspace
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !workspace.Settings.Registration {
+		return nil, ErrRegistrationClosed
+	}
+	return workspace, nil
+}
+
+// Login checks a username and password and starts a session, returning its token
+func (s *AuthService) Login(ctx context.Context, credentials model.Credentials) (*model.User, string, error) {
+	username := strings.ToLower(strings.TrimSpace(credentials.Username))
+	user, err := s.repo.GetUserByUsername(ctx, username)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		// Spend as long as for a real user, so timing does not tell which usernames exist
+		auth.VerifyPassword(credentials.Password, dummyHash())
+		return nil, "", ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	ok, err := auth.VerifyPassword(credentials.Password, user.PasswordHash)
+	if err != nil {
+		return nil, "", err
+	}
+	if !ok {
+		return nil, "", ErrInvalidCredentials
+	}
+
+	token, err := s.createSession(ctx, user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, token, nil
+}
+
+// Logout ends the session of token
+func (s *AuthService) Logout(ctx context.Context, token string) error {
+	return s.repo.DeleteSession(ctx, auth.HashToken(token))
+}
+
+// Authenticate returns the user signed in with a session token, or
+// ErrSessionNotFound when the session is unknown or has expired
+func (s *AuthService) Authenticate(ctx context.Context, token string) (*model.User, error) {
+	session, err := s.repo.GetSession(ctx, auth.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if !session.ExpiresAt.After(time.Now()) {
+		if err := s.repo.DeleteSession(ctx, session.TokenHash); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return nil, err
+		}
+		return nil, ErrSessionNotFound
+	}
+
+	user, err := s.repo.GetUser(ctx, session.UserID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	return user, err
+}
+
+// AuthenticateAPIToken returns the user an API token belongs to and the
+// token, or ErrAPITokenNotFound when it is unknown or revoked, and records
+// that the token was used
+func (s *AuthService) AuthenticateAPIToken(ctx context.Context, value string) (*model.User, *model.APIToken, error) {
+	token, err := s.repo.GetAPITokenByHash(ctx, auth.HashToken(value))
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := s.repo.GetUser(ctx, token.UserID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, nil, ErrAPITokenNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if token.LastUsedAt == nil || time.Since(*token.LastUsedAt) >= apiTokenTouchInterval {
+		if err := s.repo.TouchAPIToken(ctx, token.ID); err != nil {
+			return nil, nil, err
+		}
+	}
+	return user, token, nil
+}
+
+// createSession stores a new session of userID and returns its token; only
+// the token's hash is kept
+func (s *AuthService) createSession(ctx context.Context, userID int) (string, error) {
+	token, err := auth.NewToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.repo.CreateSession(ctx, &model.Session{
+		TokenHash: auth.HashToken(token),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(SessionLifetime),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// validUsername reports whether a lower-cased username is 3 to 32 of a-z, 0-9, '.', '_' and '-'
+func validUsername(username string) bool {
+	if len(username) < 3 || len(username) > 32 {
+		return false
+	}
+	for _, c := range username {
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '.' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// dummyHash is a password hash no password is known for, verified against
+// when a username does not exist
+var dummyHash = sync.OnceValue(func() string {
+	token, _ := auth.NewToken()
+	hash, _ := auth.HashPassword(token)
+	return hash
+})
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the todo service the time: when a todo is completed and what
+// is overdue. Tests may freeze it at a moment of their choosing.
+type Clock struct {
+	mu     sync.RWMutex
+	frozen *time.Time // nil while the clock follows the real time
+}
+
+// NewClock returns a clock following the real time
+func NewClock() *Clock {
+	return &Clock{}
+}
+
+// Now returns the frozen time, or the real one while not frozen
+func (c *Clock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.frozen != nil {
+		return *c.frozen
+	}
+	return time.Now()
+}
+
+// Freeze stops the clock at t until Unfreeze
+func (c *Clock) Freeze(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.frozen = &t
+}
+
+// Unfreeze makes the clock follow the real time again
+func (c *Clock) Unfreeze() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.frozen = nil
+}
+
+// Frozen returns the time the clock is frozen at, if it is
+func (c *Clock) Frozen() (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.frozen == nil {
+		return time.Time{}, false
+	}
+	return *c.frozen, true
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"todo-app/internal/buildinfo"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+)
+
+// ReadinessTimeout bounds the database checks of Readiness, so a database
+// that hangs or stays locked fails the probe instead of stalling it
+const ReadinessTimeout = 2 * time.Second
+
+// Readiness check names
+const (
+	CheckShutdown   = "shutdown"
+	CheckDatabase   = "database"
+	CheckMigrations = "migrations"
+)
+
+// checkOK is the outcome of a check that passed
+const checkOK = "ok"
+
+// HealthService tells whether the server is ready for requests and what it
+// was built from
+type HealthService struct {
+	repo     repository.TodoRepository
+	draining atomic.Bool
+}
+
+// NewHealthService creates a new health service checking the database of repo
+func NewHealthService(repo repository.TodoRepository) *HealthService {
+	return &HealthService{
+		repo: repo,
+	}
+}
+
+// Drain marks the server as shutting down: from then on it is not ready,
+// so load balancers stop sending it requests while those in flight finish
+func (s *HealthService) Drain() {
+	s.draining.Store(true)
+}
+
+// Readiness checks that the server is not shutting down, that its database
+// answers and is not locked, and that every migration is applied. A
+// repository without a database, such as the in-memory one, is always ready.
+func (s *HealthService) Readiness(ctx context.Context) *model.Readiness {
+	readiness := &model.Readiness{
+		Status: model.ReadinessReady,
+		Checks: map[string]string{CheckShutdown: checkOK},
+	}
+	fail := func(check string, err error) {
+		readiness.Status = model.ReadinessUnavailable
+		readiness.Checks[check] = err.Error()
+	}
+
+	if s.draining.Load() {
+		fail(CheckShutdown, errors.New("draining requests before shutting down"))
+	}
+
+	db, ok := s.repo.(repository.HealthChecker)
+	if !ok {
+		readiness.Checks[CheckDatabase] = checkOK
+		readiness.Checks[CheckMigrations] = checkOK
+		return readiness
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ReadinessTimeout)
+	defer cancel()
+	if err := db.Ping(ctx); err != nil {
+		fail(CheckDatabase, err)
+		// Reading the migrations would only wait on the same database
+		fail(CheckMigrations, errors.New("not checked: the database is unavailable"))
+		return readiness
+	}
+	readiness.Checks[CheckDatabase] = checkOK
+
+	applied, err := db.SchemaVersion(ctx)
+	switch latest := db.LatestSchemaVersion(); {
+	case err != nil:
+		fail(CheckMigrations, err)
+	case applied < latest:
+		readiness.SchemaVersion = applied
+		fail(CheckMigrations, fmt.Errorf("pending: the database is at version %d, this build at %d", applied, latest))
+	default:
+		readiness.SchemaVersion = applied
+		readiness.Checks[CheckMigrations] = checkOK
+	}
+	return readiness
+}
+
+// BuildInfo returns what the binary was built from, with the schema version
+// of the migrations it has for its database
+func (s *HealthService) BuildInfo() *model.BuildInfo {
+	build := buildinfo.Get()
+	info := &model.BuildInfo{
+		Commit:    build.Commit,
+		BuildTime: build.BuildTime,
+		GoVersion: build.GoVersion,
+	}
+	if db, ok := s.repo.(repository.HealthChecker); ok {
+		info.SchemaVersion = db.LatestSchemaVersion()
+	}
+	return info
+}
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+)
+
+// maxListNameLength is the longest list name accepted, in characters
+const maxListNameLength = 100
+
+var (
+	// ErrInvalidListName is returned when a list name is empty or too long
+	ErrInvalidListName = errors.New("list name must be 1 to 100 characters")
+
+	// ErrInvalidRole is returned when sharing a list with a role other than editor or viewer
+	ErrInvalidRole = errors.New("role must be editor or viewer")
+
+	// ErrShareInbox is returned when sharing the inbox list
+	ErrShareInbox = errors.New("the inbox cannot be shared")
+
+	// ErrNotListOwner is returned when someone other than its owner renames,
+	// deletes or shares a list
+	ErrNotListOwner = errors.New("only the owner of the list can do this")
+
+	// ErrInvitationNotFound is returned when accepting or declining an invitation the user does not have
+	ErrInvitationNotFound = errors.New("invitation not found")
+
+	// ErrListNotFound is returned when the requested list does not exist
+	ErrListNotFound = repository.ErrListNotFound
+
+	// ErrDeleteInbox is returned when deleting the inbox list
+	ErrDeleteInbox = repository.ErrDeleteInbox
+
+	// ErrMemberNotFound is returned when the user is not a member of the list
+	ErrMemberNotFound = repository.ErrMemberNotFound
+
+	// ErrMemberExists is returned when inviting a user who owns, joined or is already invited to the list
+	ErrMemberExists = repository.ErrMemberExists
+
+	// ErrUserNotFound is returned when inviting a username nobody has
+	ErrUserNotFound = repository.ErrUserNotFound
+)
+
+// ListService handles business logic for lists and their members. Every
+// operation acts as one user, see ForUser: only the owner of a list may
+// rename, delete or share it, and to everyone else who has not joined it
+// the list does not exist. Lists are shared within a workspace only.
+type ListService struct {
+	repo   repository.TodoRepository
+	users  repository.TodoRepository // where users are stored, which ForWorkspace does not change
+	userID int                       // 0 until ForUser: the lists made without an account
+}
+
+// NewListService creates a new list service
+func NewListService(repo repository.TodoRepository) *ListService {
+	return &ListService{
+		repo:  repo,
+		users: repo,
+	}
+}
+
+// ForWorkspace returns a service that works with repo, the storage of one workspace
+func (s *ListService) ForWorkspace(repo repository.TodoRepository) *ListService {
+	c := *s
+	c.repo = repo
+	return &c
+}
+
+// ForUser returns a service that acts as userID
+func (s *ListService) ForUser(userID int) *ListService {
+	c := *s
+	c.userID = userID
+	return &c
+}
+
+// CreateList creates a new, empty list owned by the user
+func (s *ListService) CreateList(ctx context.Context, input model.ListInput) (*model.List, error) {
+	name, err := listName(input.Name)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.repo.CreateList(ctx, &model.List{UserID: s.userID, Name: name})
+	if err != nil {
+		return nil, err
+	}
+	list.Role = model.ListOwner
+	return list, nil
+}
+
+// GetLists returns the inbox and every list the user owns or joined, the inbox first
+func (s *ListService) GetLists(ctx context.Context) ([]*model.List, error) {
+	return s.repo.GetLists(ctx, s.userID)
+}
+
+// GetList returns a single list by ID with the role of the user
+func (s *ListService) GetList(ctx context.Context, id int) (*model.List, error) {
+	lists, err := s.repo.GetLists(ctx, s.userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, list := range lists {
+		if list.ID == id {
+			return list, nil
+		}
+	}
+	return nil, ErrListNotFound
+}
+
+// RenameList changes the name of a list
+func (s *ListService) RenameList(ctx context.Context, id int, input model.ListInput) (*model.List, error) {
+	name, err := listName(input.Name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.owned(ctx, id); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.UpdateList(ctx, &model.List{ID: id, Name: name}); err != nil {
+		return nil, err
+	}
+	return s.GetList(ctx, id)
+}
+
+// DeleteList removes a list with its members, moving its todos to the inbox
+// of the users who made them
+func (s *ListService) DeleteList(ctx context.Context, id int) error {
+	if _, err := s.owned(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteList(ctx, id)
+}
+
+// owned returns a list the user owns, ErrNotListOwner for one they only
+// joined and ErrListNotFound for any other
+func (s *ListService) owned(ctx context.Context, id int) (*model.List, error) {
+	list, err := s.GetList(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if list.Role != model.ListOwner {
+		return nil, ErrNotListOwner
+	}
+	return list, nil
+}
+
+// GetMembers returns the owner and then the members and pending invitations
+// of a list in the order they were invited
+func (s *ListService) GetMembers(ctx context.Context, listID int) ([]*model.ListMember, error) {
+	list, err := s.GetList(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if list.Inbox {
+		return []*model.ListMember{}, nil
+	}
+
+	members, err := s.repo.GetListMembers(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.nameMembers(ctx, members...); err != nil {
+		return nil, err
+	}
+	owner, err := s.users.GetUser(ctx, list.UserID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return members, nil // Made before accounts existed
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append([]*model.ListMember{{
+		ListID:    list.ID,
+		ListName:  list.Name,
+		UserID:    owner.ID,
+		Username:  owner.Username,
+		Role:      model.ListOwner,
+		Accepted:  true,
+		CreatedAt: list.CreatedAt,
+	}}, members...), nil
+}
+
+// InviteMember invites a user of the same workspace to a list with a role.
+// They see the list once they accept the invitation. Workspaces may turn
+// sharing off, which keeps the members and invitations there are.
+func (s *ListService) InviteMember(ctx context.Context, listID int, input model.ListMemberInput) (*model.ListMember, error) {
+	role, err := memberRole(input.Role)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.owned(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if list.Inbox {
+		return nil, ErrShareInbox
+	}
+	settings, err := s.repo.GetWorkspaceSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Sharing {
+		return nil, ErrSharingDisabled
+	}
+
+	user, err := s.users.GetUserByUsername(ctx, strings.ToLower(strings.TrimSpace(input.Username)))
+	if err != nil {
+		return nil, err
+	}
+	if user.WorkspaceID != s.repo.WorkspaceID() {
+		return nil, ErrUserNotFound // Other workspaces are out of sight
+	}
+	if user.ID == s.userID {
+		return nil, ErrMemberExists
+	}
+
+	member, err := s.repo.AddListMember(ctx, &model.ListMember{ListID: listID, UserID: user.ID, Role: role})
+	if err != nil {
+		return nil, err
+	}
+	member.ListName, member.Username = list.Name, user.Username
+	return member, nil
+}
+
+// UpdateMember changes the role of a member or invitation
+func (s *ListService) UpdateMember(ctx context.Context, listID, userID int, input model.ListMemberInput) (*model.ListMember, error) {
+	role, err := memberRole(input.Role)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.owned(ctx, listID); err != nil {
+		return nil, err
+	}
+
+	member, err := s.repo.GetListMember(ctx, listID, userID)
+	if err != nil {
+		return nil, err
+	}
+	member.Role = role
+	if member, err = s.repo.UpdateListMember(ctx, member); err != nil {
+		return nil, err
+	}
+	return member, s.nameMembers(ctx, member)
+}
+
+// RemoveMember removes a member or withdraws an invitation. Besides the
+// owner, members may remove themselves to leave the list. The todos they
+// added stay in the list.
+func (s *ListService) RemoveMember(ctx context.Context, listID, userID int) error {
+	if userID != s.userID {
+		if _, err := s.owned(ctx, listID); err != nil {
+			return err
+		}
+	} else if _, err := s.GetList(ctx, listID); err != nil {
+		return err
+	}
+	return s.repo.DeleteListMember(ctx, listID, userID)
+}
+
+// GetInvitations returns the invitations of the user they have not answered yet, oldest first
+func (s *ListService) GetInvitations(ctx context.Context) ([]*model.ListMember, error) {
+	invitations, err := s.repo.GetInvitations(ctx, s.userID)
+	if err != nil {
+		return nil, err
+	}
+	return invitations, s.nameMembers(ctx, invitations...)
+}
+
+// nameMembers fills in the usernames of members, which the repository
+// leaves out as users may be stored apart from the workspace
+func (s *ListService) nameMembers(ctx context.Context, members ...*model.ListMember) error {
+	for _, member := range members {
+		user, err := s.users.GetUser(ctx, member.UserID)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		member.Username = user.Username
+	}
+	return nil
+}
+
+// AcceptInvitation joins the user to a list they were invited to and returns the list
+func (s *ListService) AcceptInvitation(ctx context.Context, listID int) (*model.List, error) {
+	member, err := s.invitation(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	member.Accepted = true
+	if _, err := s.repo.UpdateListMember(ctx, member); err != nil {
+		return nil, err
+	}
+	return s.GetList(ctx, listID)
+}
+
+// DeclineInvitation removes an invitation of the user
+func (s *ListService) DeclineInvitation(ctx context.Context, listID int) error {
+	if _, err := s.invitation(ctx, listID); err != nil {
+		return err
+	}
+	return s.repo.DeleteListMember(ctx, listID, s.userID)
+}
+
+// invitation returns the pending invitation of the user to a list or ErrInvitationNotFound
+func (s *ListService) invitation(ctx context.Context, listID int) (*model.ListMember, error) {
+	member, err := s.repo.GetListMember(ctx, listID, s.userID)
+	if errors.Is(err, repository.ErrMemberNotFound) || err == nil && member.Accepted {
+		return nil, ErrInvitationNotFound
+	}
+	return member, err
+}
+
+// memberReader reads the membership of a user to a list; the repository and
+// its transactions both do
+type memberReader interface {
+	GetListMember(ctx context.Context, listID, userID int) (*model.ListMember, error)
+}
+
+// listRole returns the role of userID in list, or "" when they have not
+// joined it. Everyone owns their part of the inbox.
+func listRole(ctx context.Context, repo memberReader, list *model.List, userID int) (model.ListRole, error) {
+	if list.Inbox || list.UserID == userID {
+		return model.ListOwner, nil
+	}
+	member, err := repo.GetListMember(ctx, list.ID, userID)
+	if errors.Is(err, repository.ErrMemberNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !member.Accepted {
+		return "", nil
+	}
+	return member.Role, nil
+}
+
+// memberRole parses the role a list is shared with
+func memberRole(role string) (model.ListRole, error) {
+	switch r := model.ListRole(strings.ToLower(strings.TrimSpace(role))); r {
+	case model.ListEditor, model.ListViewer:
+		return r, nil
+	}
+	return "", ErrInvalidRole
+}
+
+// listName trims name and checks its length
+func listName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxListNameLength {
+		return "", ErrInvalidListName
+	}
+	return name, nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+)
+
+var (
+	// ErrUnknownOwner is returned when a fixture names an owner that is not a user
+	ErrUnknownOwner = errors.New("fixture owner is not a user")
+
+	// ErrUnknownFixtureList is returned when a todo fixture names a list its owner does not have
+	ErrUnknownFixtureList = errors.New("fixture list is not a list of its owner")
+)
+
+// TestSupportService resets and fills the storage and freezes the clock for
+// the end-to-end tests. It is only served with ENV=test.
+type TestSupportService struct {
+	repo       repository.TodoRepository
+	auth       *AuthService
+	workspaces *WorkspaceService
+	lists      *ListService
+	todos      *TodoService
+}
+
+// NewTestSupportService creates a new test support service over the
+// directory repo and the services the app is made of
+func NewTestSupportService(repo repository.TodoRepository, auth *AuthService, workspaces *WorkspaceService, lists *ListService, todos *TodoService) *TestSupportService {
+	return &TestSupportService{
+		repo:       repo,
+		auth:       auth,
+		workspaces: workspaces,
+		lists:      lists,
+		todos:      todos,
+	}
+}
+
+// Truncate removes every record but the default workspace and its inbox
+func (s *TestSupportService) Truncate(ctx context.Context) error {
+	return s.repo.Truncate(ctx)
+}
+
+// Seed registers the users of fixtures, each with a session, then creates
+// the lists and todos, in that order. Owners may be seeded users or existing
+// ones. Seeding stops at the first fixture that fails, keeping what it made.
+func (s *TestSupportService) Seed(ctx context.Context, fixtures model.Fixtures) (*model.Seeded, error) {
+	seeded := &model.Seeded{
+		Users: make([]model.SeededUser, 0, len(fixtures.Users)),
+		Lists: make([]*model.List, 0, len(fixtures.Lists)),
+		Todos: make([]*model.Todo, 0, len(fixtures.Todos)),
+	}
+
+	for _, credentials := range fixtures.Users {
+		user, session, err := s.auth.Register(ctx, credentials)
+		if err != nil {
+			return nil, fmt.Errorf("user %q: %w", credentials.Username, err)
+		}
+		seeded.Users = append(seeded.Users, model.SeededUser{User: user, Session: session})
+	}
+
+	for _, fixture := range fixtures.Lists {
+		lists, _, err := s.forOwner(ctx, fixture.Owner)
+		if err != nil {
+			return nil, err
+		}
+		list, err := lists.CreateList(ctx, model.ListInput{Name: fixture.Name})
+		if err != nil {
+			return nil, fmt.Errorf("list %q: %w", fixture.Name, err)
+		}
+		seeded.Lists = append(seeded.Lists, list)
+	}
+
+	for _, fixture := range fixtures.Todos {
+		lists, todos, err := s.forOwner(ctx, fixture.Owner)
+		if err != nil {
+			return nil, err
+		}
+		input := fixture.TodoInput
+		if fixture.List != "" {
+			if input.ListID, err = listNamed(ctx, lists, fixture.List); err != nil {
+				return nil, err
+			}
+		}
+		todo, err := todos.CreateTodo(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("todo %q: %w", fixture.Text, err)
+		}
+		seeded.Todos = append(seeded.Todos, todo)
+	}
+
+	return seeded, nil
+}
+
+// FreezeClock stops the clock of the todo service at t
+func (s *TestSupportService) FreezeClock(t time.Time) {
+	s.todos.Clock().Freeze(t)
+}
+
+// UnfreezeClock makes the clock of the todo service follow the real time again
+func (s *TestSupportService) UnfreezeClock() {
+	s.todos.Clock().Unfreeze()
+}
+
+// Clock returns the time of the todo service's clock and whether it is frozen
+func (s *TestSupportService) Clock() (time.Time, bool) {
+	if frozen, ok := s.todos.Clock().Frozen(); ok {
+		return frozen, true
+	}
+	return s.todos.Clock().Now(), false
+}
+
+// forOwner returns the list and todo services acting as the user named
+// username, in their workspace
+func (s *TestSupportService) forOwner(ctx context.Context, username string) (*ListService, *TodoService, error) {
+	user, err := s.repo.GetUserByUsername(ctx, strings.ToLower(strings.TrimSpace(username)))
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnknownOwner, username)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repo, err := s.workspaces.Open(ctx, user.WorkspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.lists.ForWorkspace(repo).ForUser(user.ID), s.todos.ForWorkspace(repo).ForUser(user.ID), nil
+}
+
+// listNamed returns the ID of the list named name among those of lists' user
+func listNamed(ctx context.Context, lists *ListService, name string) (int, error) {
+	all, err := lists.GetLists(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, list := range all {
+		if list.Name == name {
+			return list.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownFixtureList, name)
+}
@@ -1,44 +1,591 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
+	"todo-app/internal/events"
+	"todo-app/internal/logging"
 	"todo-app/internal/model"
 	"todo-app/internal/repository"
 )
 
-// TodoService handles business logic for todos
+var (
+	// ErrEmptyText is returned when a todo would be saved without text
+	ErrEmptyText = errors.New("text cannot be empty")
+
+	// ErrInvalidDueDate is returned when a due date is neither RFC3339 nor YYYY-MM-DD
+	ErrInvalidDueDate = errors.New("due_at must be an RFC3339 timestamp or a YYYY-MM-DD date")
+
+	// ErrInvalidPriority is returned for a priority other than none, low, medium, high or urgent
+	ErrInvalidPriority = errors.New("priority must be one of none, low, medium, high, urgent")
+
+	// ErrInvalidTag is returned for an empty or over-long tag name
+	ErrInvalidTag = errors.New("invalid tag")
+
+	// ErrMergeSameTag is returned when merging a tag into itself
+	ErrMergeSameTag = errors.New("cannot merge a tag into itself")
+
+	// ErrCursorMismatch is returned when a cursor is used with another sort order than it was made for
+	ErrCursorMismatch = errors.New("cursor does not match the sort order")
+
+	// ErrUnknownList is returned when a todo is put in a list that does not exist
+	ErrUnknownList = errors.New("list_id does not match any list")
+
+	// ErrReadOnlyList is returned when a viewer of a shared list adds, changes or deletes its todos
+	ErrReadOnlyList = errors.New("the list is shared with you read-only")
+
+	// ErrTodoNotFound is returned when the requested todo does not exist
+	ErrTodoNotFound = repository.ErrTodoNotFound
+
+	// ErrTagNotFound is returned when the requested tag does not exist
+	ErrTagNotFound = repository.ErrTagNotFound
+
+	// ErrTagExists is returned when renaming a tag to a name already in use
+	ErrTagExists = repository.ErrTagExists
+
+	// ErrSearchUnavailable is returned when the storage backend cannot search todo text
+	ErrSearchUnavailable = repository.ErrSearchUnavailable
+)
+
+// TodoService handles business logic for todos. Every operation acts as one
+// user, see ForUser: on their tags, their todos in the inbox and the todos of
+// the lists they own or joined, as far as their role in the list allows.
 type TodoService struct {
-	repo *repository.SQLiteTodoRepository
+	repo   repository.TodoRepository
+	broker *events.Broker
+	origin string // copied onto the events of writes made through this service
+	userID int    // 0 until ForUser: the todos made without an account
+	clock  *Clock
+
+	// writeLocks serializes the writes of each workspace so that its events
+	// reach the broker in the order of their IDs
+	writeLocks *workspaceLocks
 }
 
 // NewTodoService creates a new todo service
-func NewTodoService(repo *repository.SQLiteTodoRepository) *TodoService {
+func NewTodoService(repo repository.TodoRepository) *TodoService {
 	return &TodoService{
-		repo: repo,
+		repo:       repo,
+		broker:     events.NewBroker(),
+		clock:      NewClock(),
+		writeLocks: &workspaceLocks{locks: make(map[int]*sync.Mutex)},
 	}
 }
 
+// workspaceLocks holds one mutex per workspace. Subscribers only hear of the
+// events of their own workspace, so writes in different workspaces need not
+// wait for each other.
+type workspaceLocks struct {
+	mu    sync.Mutex
+	locks map[int]*sync.Mutex
+}
+
+// get returns the mutex of workspaceID
+func (l *workspaceLocks) get(workspaceID int) *sync.Mutex {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock, ok := l.locks[workspaceID]
+	if !ok {
+		lock = &sync.Mutex{}
+		l.locks[workspaceID] = lock
+	}
+	return lock
+}
+
+// Clock returns the clock of s, shared by every service made from it
+func (s *TodoService) Clock() *Clock {
+	return s.clock
+}
+
+// WithOrigin returns a service sharing the storage and subscribers of s whose
+// writes publish events with the given Origin, so that a client can tell its
+// own changes from everyone else's
+func (s *TodoService) WithOrigin(origin string) *TodoService {
+	c := *s
+	c.origin = origin
+	return &c
+}
+
+// ForWorkspace returns a service sharing the subscribers of s that works
+// with repo, the storage of one workspace. Its subscribers only hear of the
+// writes made in that workspace.
+func (s *TodoService) ForWorkspace(repo repository.TodoRepository) *TodoService {
+	c := *s
+	c.repo = repo
+	return &c
+}
+
+// ForUser returns a service sharing the storage and subscribers of s that
+// acts as userID. To it the todos userID cannot see do not exist.
+func (s *TodoService) ForUser(userID int) *TodoService {
+	c := *s
+	c.userID = userID
+	return &c
+}
+
 // CreateTodo creates a new todo item
-func (s *TodoService) CreateTodo(text string) (*model.Todo, error) {
-	// Validate input
-	if strings.TrimSpace(text) == "" {
-		return nil, fmt.Errorf("text cannot be empty")
+func (s *TodoService) CreateTodo(ctx context.Context, input model.TodoInput) (*model.Todo, error) {
+	patch := inputPatch(input)
+	todo := &model.Todo{UserID: s.userID}
+
+	err := s.write(ctx, func(tx repository.TxRepository) (*model.TodoEvent, error) {
+		if err := s.checkList(ctx, tx, patch.ListID); err != nil {
+			return nil, err
+		}
+		if err := applyPatch(todo, patch, s.clock.Now()); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Create(ctx, todo); err != nil {
+			return nil, err
+		}
+		return &model.TodoEvent{Type: model.TodoCreated, TodoID: todo.ID, Todo: todo}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// GetAllTodos returns the todo items matching filter
+func (s *TodoService) GetAllTodos(ctx context.Context, filter model.TodoFilter) ([]*model.Todo, error) {
+	filter.UserID = s.userID
+	if (filter.Overdue || filter.Where != nil) && filter.Now.IsZero() {
+		filter.Now = s.clock.Now()
+	}
+	return s.repo.GetAll(ctx, filter)
+}
+
+// ListTodos returns one page of at most limit todos matching filter, starting
+// at filter.Cursor, with the cursors of the neighbouring pages
+func (s *TodoService) ListTodos(ctx context.Context, filter model.TodoFilter, limit int) (*model.TodoPage, error) {
+	keys := filter.SortKeys()
+	if filter.Cursor != nil && filter.Cursor.Sort != model.FormatSort(keys) {
+		return nil, ErrCursorMismatch
+	}
+
+	// One extra todo tells whether another page follows in the paging direction
+	filter.Limit = limit + 1
+	todos, err := s.GetAllTodos(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	more := len(todos) > limit
+	if more && backward {
+		todos = todos[1:]
+	} else if more {
+		todos = todos[:limit]
+	}
+
+	page := &model.TodoPage{Todos: todos}
+
+	// Following a cursor means there is a page on the side it came from
+	if len(todos) == 0 {
+		if filter.Cursor != nil {
+			back := *filter.Cursor
+			back.Backward = !back.Backward
+			if backward {
+				page.NextCursor = back.Encode()
+			} else {
+				page.PrevCursor = back.Encode()
+			}
+		}
+		return page, nil
+	}
+	if more || backward {
+		page.NextCursor = model.NewCursor(todos[len(todos)-1], keys, false).Encode()
+	}
+	if more && backward || filter.Cursor != nil && !backward {
+		page.PrevCursor = model.NewCursor(todos[0], keys, true).Encode()
+	}
+	return page, nil
+}
+
+// SearchTodos returns at most limit todos matching query, best match first
+func (s *TodoService) SearchTodos(ctx context.Context, query *model.SearchExpr, limit int) ([]*model.SearchResult, error) {
+	return s.repo.SearchTodos(ctx, query, s.userID, limit)
+}
+
+// GetTodo returns a single todo item by ID
+func (s *TodoService) GetTodo(ctx context.Context, id int) (*model.Todo, error) {
+	todo, _, err := s.access(ctx, s.repo, id)
+	return todo, err
+}
+
+// todoReader reads what access needs; the repository and its transactions both do
+type todoReader interface {
+	memberReader
+	GetByID(ctx context.Context, id int) (*model.Todo, error)
+	GetList(ctx context.Context, id int) (*model.List, error)
+}
+
+// access reads a todo by ID with the role of the user of s in its list, and
+// returns ErrTodoNotFound when the user cannot see it
+func (s *TodoService) access(ctx context.Context, repo todoReader, id int) (*model.Todo, model.ListRole, error) {
+	todo, err := repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	list, err := repo.GetList(ctx, todo.ListID)
+	if err != nil {
+		return nil, "", err
+	}
+	if list.Inbox && todo.UserID != s.userID {
+		return nil, "", ErrTodoNotFound
+	}
+	role, err := listRole(ctx, repo, list, s.userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if role == "" {
+		return nil, "", ErrTodoNotFound
+	}
+	return todo, role, nil
+}
+
+// edit reads a todo by ID like access, and returns ErrReadOnlyList when the
+// user may only view it
+func (s *TodoService) edit(ctx context.Context, repo todoReader, id int) (*model.Todo, error) {
+	todo, role, err := s.access(ctx, repo, id)
+	if err != nil {
+		return nil, err
+	}
+	if !role.CanEdit() {
+		return nil, ErrReadOnlyList
+	}
+	return todo, nil
+}
+
+// UpdateTodo replaces the editable fields of a todo item
+func (s *TodoService) UpdateTodo(ctx context.Context, id int, input model.TodoInput) (*model.Todo, error) {
+	return s.PatchTodo(ctx, id, inputPatch(input))
+}
+
+// PatchTodo applies the fields set in patch to a todo item
+func (s *TodoService) PatchTodo(ctx context.Context, id int, patch model.TodoPatch) (*model.Todo, error) {
+	var todo *model.Todo
+
+	err := s.write(ctx, func(tx repository.TxRepository) (*model.TodoEvent, error) {
+		var err error
+		if todo, err = s.edit(ctx, tx, id); err != nil {
+			return nil, err
+		}
+		fromList := todo.ListID
+
+		if err := s.checkList(ctx, tx, patch.ListID); err != nil {
+			return nil, err
+		}
+		if err := applyPatch(todo, patch, s.clock.Now()); err != nil {
+			return nil, err
+		}
+		if todo, err = tx.Update(ctx, todo); err != nil {
+			return nil, err
+		}
+
+		event := &model.TodoEvent{Type: model.TodoUpdated, TodoID: todo.ID, Todo: todo}
+		if todo.ListID != fromList {
+			event.FromListID = fromList
+		}
+		return event, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// inputPatch turns a full input into a patch that sets every field
+func inputPatch(input model.TodoInput) model.TodoPatch {
+	patch := model.TodoPatch{
+		Text:      &input.Text,
+		Completed: &input.Completed,
+		Priority:  &input.Priority,
+		DueAt:     model.Optional[string]{Set: true},
+		Tags:      &input.Tags,
+	}
+	if input.DueAt != "" {
+		patch.DueAt.Value = &input.DueAt
+	}
+	if input.ListID != 0 {
+		patch.ListID = &input.ListID
+	}
+	return patch
+}
+
+// checkList returns nil when listID is nil or names a list the user of s may
+// add todos to, ErrReadOnlyList when they may only view it and ErrUnknownList
+// otherwise
+func (s *TodoService) checkList(ctx context.Context, repo repository.TxRepository, listID *int) error {
+	if listID == nil {
+		return nil
+	}
+	list, err := repo.GetList(ctx, *listID)
+	if errors.Is(err, repository.ErrListNotFound) {
+		return ErrUnknownList
+	}
+	if err != nil {
+		return err
+	}
+	role, err := listRole(ctx, repo, list, s.userID)
+	switch {
+	case err != nil:
+		return err
+	case role == "":
+		return ErrUnknownList
+	case !role.CanEdit():
+		return ErrReadOnlyList
+	}
+	return nil
+}
+
+// applyPatch validates patch and copies its fields onto todo; now is when
+// the todo is completed if patch completes it
+func applyPatch(todo *model.Todo, patch model.TodoPatch, now time.Time) error {
+	if patch.Text != nil {
+		if strings.TrimSpace(*patch.Text) == "" {
+			return ErrEmptyText
+		}
+		todo.Text = *patch.Text
+	}
+	if patch.ListID != nil {
+		todo.ListID = *patch.ListID
+	}
+	if patch.Priority != nil {
+		priority, err := model.ParsePriority(*patch.Priority)
+		if err != nil {
+			return ErrInvalidPriority
+		}
+		todo.Priority = priority
+	}
+	if patch.DueAt.Set {
+		todo.DueAt, todo.DueAllDay = nil, false
+		if patch.DueAt.Value != nil {
+			dueAt, allDay, err := model.ParseDue(*patch.DueAt.Value)
+			if err != nil {
+				return ErrInvalidDueDate
+			}
+			todo.DueAt, todo.DueAllDay = &dueAt, allDay
+		}
+	}
+	if patch.Tags != nil {
+		tags, err := model.NormalizeTags(*patch.Tags)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTag, err)
+		}
+		todo.Tags = tags
+	}
+	if patch.Completed != nil {
+		setCompleted(todo, *patch.Completed, now)
+	}
+	return nil
+}
+
+// CompleteTodo marks a todo item as done
+func (s *TodoService) CompleteTodo(ctx context.Context, id int) (*model.Todo, error) {
+	completed := true
+	return s.PatchTodo(ctx, id, model.TodoPatch{Completed: &completed})
+}
+
+// ReopenTodo marks a done todo item as not done again
+func (s *TodoService) ReopenTodo(ctx context.Context, id int) (*model.Todo, error) {
+	completed := false
+	return s.PatchTodo(ctx, id, model.TodoPatch{Completed: &completed})
+}
+
+// setCompleted toggles completion, keeping the original completed_at
+// when an already completed todo is completed again
+func setCompleted(todo *model.Todo, completed bool, now time.Time) {
+	switch {
+	case completed && !todo.Completed:
+		todo.CompletedAt = &now
+	case !completed:
+		todo.CompletedAt = nil
+	}
+	todo.Completed = completed
+}
+
+// GetTags returns every tag with the number of todos using it
+func (s *TodoService) GetTags(ctx context.Context) ([]*model.Tag, error) {
+	return s.repo.GetTags(ctx, s.userID)
+}
+
+// RenameTag renames a tag; use MergeTags to fold it into an existing tag instead
+func (s *TodoService) RenameTag(ctx context.Context, id int, name string) (*model.Tag, error) {
+	name, err := model.NormalizeTag(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTag, err)
+	}
+	if err := s.ownTags(ctx, id); err != nil {
+		return nil, err
 	}
+	return s.repo.RenameTag(ctx, id, name)
+}
 
-	todo := &model.Todo{
-		Text: text,
+// MergeTags moves every todo tagged with the source tag to the target tag
+// and deletes the source
+func (s *TodoService) MergeTags(ctx context.Context, sourceID, targetID int) (*model.Tag, error) {
+	if sourceID == targetID {
+		return nil, ErrMergeSameTag
 	}
-	return s.repo.Create(todo)
+	if err := s.ownTags(ctx, sourceID, targetID); err != nil {
+		return nil, err
+	}
+	return s.repo.MergeTags(ctx, sourceID, targetID)
+}
+
+// ownTags returns ErrTagNotFound unless every tag exists and belongs to the user of s
+func (s *TodoService) ownTags(ctx context.Context, ids ...int) error {
+	for _, id := range ids {
+		tag, err := s.repo.GetTag(ctx, id)
+		if err != nil {
+			return err
+		}
+		if tag.UserID != s.userID {
+			return ErrTagNotFound
+		}
+	}
+	return nil
+}
+
+// DeleteTodo removes a todo item
+func (s *TodoService) DeleteTodo(ctx context.Context, id int) error {
+	return s.write(ctx, func(tx repository.TxRepository) (*model.TodoEvent, error) {
+		// The event carries the todo as it was, so subscribers know its list
+		todo, err := s.edit(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Delete(ctx, id); err != nil {
+			return nil, err
+		}
+		return &model.TodoEvent{Type: model.TodoDeleted, TodoID: id, Todo: todo}, nil
+	})
 }
 
-// GetAllTodos returns all todo items
-func (s *TodoService) GetAllTodos() ([]*model.Todo, error) {
-	return s.repo.GetAll()
+// CloseSubscriptions ends every subscription and refuses new ones, so that
+// the event streams finish when the server shuts down
+func (s *TodoService) CloseSubscriptions() {
+	s.broker.Close()
 }
 
-// TruncateTodos removes all todos (for testing only)
-func (s *TodoService) TruncateTodos() error {
-	return s.repo.Truncate()
+// SubscriptionsClosed reports whether CloseSubscriptions was called
+func (s *TodoService) SubscriptionsClosed() bool {
+	return s.broker.Closed()
+}
+
+// Subscribe starts receiving the events of writes made from now on in the
+// workspace to the user's todos and to the todos of the lists they own or
+// joined, including those moved out of such a list; see events.Broker for
+// what happens to a subscriber that falls behind. Which lists the user
+// joined is looked up with ctx, the context of the stream or connection, by
+// a goroutine of the subscription, so writes never wait on the lookups.
+func (s *TodoService) Subscribe(ctx context.Context, buffer int) *TodoSubscription {
+	workspaceID := s.repo.WorkspaceID()
+	sub := &TodoSubscription{
+		sub: s.broker.SubscribeFiltered(buffer, func(event *model.TodoEvent) bool {
+			return event.WorkspaceID == workspaceID
+		}),
+		events: make(chan *model.TodoEvent),
+		done:   make(chan struct{}),
+	}
+	go s.forward(ctx, sub)
+	return sub
+}
+
+// TodoSubscription receives the events of one user's todos, see Subscribe
+type TodoSubscription struct {
+	sub    *events.Subscription // every event of the workspace
+	events chan *model.TodoEvent
+	done   chan struct{} // closed by Cancel
+	cancel sync.Once
+}
+
+// Events returns the channel the events arrive on; it is closed when the
+// subscription is cancelled, falls behind or the broker is closed
+func (s *TodoSubscription) Events() <-chan *model.TodoEvent {
+	return s.events
+}
+
+// Cancel stops the subscription; it is safe to call more than once
+func (s *TodoSubscription) Cancel() {
+	s.cancel.Do(func() { close(s.done) })
+	s.sub.Cancel()
+}
+
+// forward passes on the events of the workspace that concern the user of s
+// until sub is cancelled or ctx is done
+func (s *TodoService) forward(ctx context.Context, sub *TodoSubscription) {
+	defer close(sub.events)
+	defer sub.sub.Cancel()
+
+	for event := range sub.sub.Events() {
+		if event.UserID != s.userID && !s.joined(ctx, event.Todo.ListID) &&
+			(event.FromListID == 0 || !s.joined(ctx, event.FromListID)) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		case <-sub.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// joined reports whether the user of s owns or joined a list other than the inbox
+func (s *TodoService) joined(ctx context.Context, listID int) bool {
+	list, err := s.repo.GetList(ctx, listID)
+	if err != nil || list.Inbox {
+		return false
+	}
+	role, err := listRole(ctx, s.repo, list, s.userID)
+	return err == nil && role != ""
+}
+
+// GetEvents returns at most limit change log events of the user's todos and
+// of the todos in the lists they own or joined after the event with ID
+// afterID, oldest first
+func (s *TodoService) GetEvents(ctx context.Context, afterID int64, limit int) ([]*model.TodoEvent, error) {
+	return s.repo.GetEvents(ctx, s.userID, afterID, limit)
+}
+
+// write runs fn and appends the event it returns to the change log in one
+// transaction, so the log (and the webhook outbox filled from it) holds
+// exactly the writes that happened. The event is published once committed;
+// the writes of one workspace are made one at a time so that its events are
+// published in the order of their IDs.
+func (s *TodoService) write(ctx context.Context, fn func(tx repository.TxRepository) (*model.TodoEvent, error)) error {
+	lock := s.writeLocks.get(s.repo.WorkspaceID())
+	lock.Lock()
+	defer lock.Unlock()
+
+	var event *model.TodoEvent
+	err := s.repo.Atomically(ctx, func(tx repository.TxRepository) error {
+		var err error
+		if event, err = fn(tx); err != nil {
+			return err
+		}
+
+		todo := *event.Todo // Callers may keep changing their copy
+		event.Todo = &todo
+		event.UserID = todo.UserID
+		event.Origin = s.origin
+		_, err = tx.AppendEvent(ctx, event)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).DebugContext(ctx, "Todo changed", "type", event.Type, "todo_id", event.Todo.ID, "event_id", event.ID)
+	s.broker.Publish(event)
+	return nil
 }
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+)
+
+var (
+	// ErrWebhookNotFound is returned when the requested webhook does not exist
+	ErrWebhookNotFound = repository.ErrWebhookNotFound
+
+	// ErrInvalidWebhookURL is returned when a webhook URL is not an absolute http or https URL
+	ErrInvalidWebhookURL = errors.New("url must be an absolute http or https URL")
+
+	// ErrInvalidEventType is returned when a webhook subscribes to an unknown event type
+	ErrInvalidEventType = errors.New("event_types must be created, updated or deleted")
+)
+
+// webhookEventTypes are the event types a webhook can subscribe to, in the
+// order they are listed
+var webhookEventTypes = []model.TodoEventType{model.TodoCreated, model.TodoUpdated, model.TodoDeleted}
+
+// WebhookService handles business logic for webhooks. Every operation acts
+// on the webhooks of one user, see ForUser.
+type WebhookService struct {
+	repo   repository.TodoRepository
+	userID int
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(repo repository.TodoRepository) *WebhookService {
+	return &WebhookService{
+		repo: repo,
+	}
+}
+
+// ForWorkspace returns a service that works with repo, the storage of one workspace
+func (s *WebhookService) ForWorkspace(repo repository.TodoRepository) *WebhookService {
+	c := *s
+	c.repo = repo
+	return &c
+}
+
+// ForUser returns a service that only sees and changes the webhooks of
+// userID, which are sent the changes to that user's todos
+func (s *WebhookService) ForUser(userID int) *WebhookService {
+	c := *s
+	c.userID = userID
+	return &c
+}
+
+// CreateWebhook registers a webhook, generating its secret when none is
+// given. The returned webhook is the only one showing the secret. Workspaces
+// may turn webhooks off, which keeps the existing ones.
+func (s *WebhookService) CreateWebhook(ctx context.Context, input model.WebhookInput) (*model.Webhook, error) {
+	webhook, err := webhookFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.repo.GetWorkspaceSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.Webhooks {
+		return nil, ErrWebhooksDisabled
+	}
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+	webhook.UserID = s.userID
+	return s.repo.CreateWebhook(ctx, webhook)
+}
+
+// GetWebhooks returns every webhook without its secret
+func (s *WebhookService) GetWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	webhooks, err := s.repo.GetWebhooks(ctx, s.userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+	return webhooks, nil
+}
+
+// GetWebhook returns a single webhook by ID without its secret
+func (s *WebhookService) GetWebhook(ctx context.Context, id int) (*model.Webhook, error) {
+	webhook, err := s.getWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// getWebhook returns a webhook of the user of s, secret included, or ErrWebhookNotFound
+func (s *WebhookService) getWebhook(ctx context.Context, id int) (*model.Webhook, error) {
+	webhook, err := s.repo.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.UserID != s.userID {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// ReplaceWebhook changes the URL and event types of a webhook, and its secret
+// when one is given
+func (s *WebhookService) ReplaceWebhook(ctx context.Context, id int, input model.WebhookInput) (*model.Webhook, error) {
+	webhook, err := webhookFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	webhook.ID = id
+	current, err := s.getWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.Secret == "" {
+		webhook.Secret = current.Secret
+	}
+
+	webhook, err = s.repo.UpdateWebhook(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// DeleteWebhook removes a webhook and its delivery history
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	if _, err := s.getWebhook(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteWebhook(ctx, id)
+}
+
+// GetDeliveries returns the latest limit deliveries of a webhook with their
+// attempts, newest first
+func (s *WebhookService) GetDeliveries(ctx context.Context, id int, limit int) ([]*model.WebhookDelivery, error) {
+	if _, err := s.getWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetDeliveries(ctx, id, limit)
+}
+
+// webhookFromInput validates input. No event types subscribes to all of them.
+func webhookFromInput(input model.WebhookInput) (*model.Webhook, error) {
+	u, err := url.Parse(strings.TrimSpace(input.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+
+	types := webhookEventTypes
+	if len(input.EventTypes) > 0 {
+		types = nil
+		for _, t := range webhookEventTypes {
+			if slices.Contains(input.EventTypes, string(t)) {
+				types = append(types, t)
+			}
+		}
+		for _, t := range input.EventTypes {
+			if !slices.Contains(webhookEventTypes, model.TodoEventType(t)) {
+				return nil, fmt.Errorf("%w: got %q", ErrInvalidEventType, t)
+			}
+		}
+	}
+
+	return &model.Webhook{
+		URL:        u.String(),
+		Secret:     input.Secret,
+		EventTypes: slices.Clone(types),
+	}, nil
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+)
+
+// maxWorkspaceNameLength is the longest workspace name accepted, in characters
+const maxWorkspaceNameLength = 100
+
+var (
+	// ErrInvalidWorkspaceSlug is returned when creating a workspace with a slug of the wrong length or characters
+	ErrInvalidWorkspaceSlug = errors.New("workspace slug must be 3 to 32 characters: letters, digits or '-'")
+
+	// ErrInvalidWorkspaceName is returned when a workspace name is empty or too long
+	ErrInvalidWorkspaceName = errors.New("workspace name must be 1 to 100 characters")
+
+	// ErrSharingDisabled is returned when inviting someone to a list in a workspace that turned sharing off
+	ErrSharingDisabled = errors.New("sharing lists is turned off in this workspace")
+
+	// ErrWebhooksDisabled is returned when creating a webhook in a workspace that turned webhooks off
+	ErrWebhooksDisabled = errors.New("webhooks are turned off in this workspace")
+
+	// ErrWorkspaceNotFound is returned when the requested workspace does not exist
+	ErrWorkspaceNotFound = repository.ErrWorkspaceNotFound
+
+	// ErrWorkspaceExists is returned when creating a workspace with a slug already in use
+	ErrWorkspaceExists = repository.ErrWorkspaceExists
+)
+
+// WorkspaceService handles workspaces, their settings and finding the
+// storage of each one
+type WorkspaceService struct {
+	repo       repository.TodoRepository // the directory of workspaces and users
+	workspaces repository.Workspaces
+}
+
+// NewWorkspaceService creates a new workspace service over the directory
+// repo, opening the storage of each workspace with workspaces
+func NewWorkspaceService(repo repository.TodoRepository, workspaces repository.Workspaces) *WorkspaceService {
+	return &WorkspaceService{
+		repo:       repo,
+		workspaces: workspaces,
+	}
+}
+
+// CreateWorkspace creates a workspace with the default settings and prepares its storage.
+// Slugs are case-insensitive and stored in lower case.
+func (s *WorkspaceService) CreateWorkspace(ctx context.Context, input model.WorkspaceInput) (*model.Workspace, error) {
+	slug := strings.ToLower(strings.TrimSpace(input.Slug))
+	if !validWorkspaceSlug(slug) {
+		return nil, ErrInvalidWorkspaceSlug
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxWorkspaceNameLength {
+		return nil, ErrInvalidWorkspaceName
+	}
+
+	workspace, err := s.repo.CreateWorkspace(ctx, &model.Workspace{Slug: slug, Name: name})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.workspaces.Open(ctx, workspace.ID); err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// GetWorkspaces returns every workspace with its settings, by ID
+func (s *WorkspaceService) GetWorkspaces(ctx context.Context) ([]*model.Workspace, error) {
+	workspaces, err := s.repo.GetWorkspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, workspace := range workspaces {
+		if err := s.loadSettings(ctx, workspace); err != nil {
+			return nil, err
+		}
+	}
+	return workspaces, nil
+}
+
+// GetWorkspace returns one workspace with its settings
+func (s *WorkspaceService) GetWorkspace(ctx context.Context, id int) (*model.Workspace, error) {
+	workspace, err := s.repo.GetWorkspace(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return workspace, s.loadSettings(ctx, workspace)
+}
+
+// GetWorkspaceBySlug returns one workspace with its settings
+func (s *WorkspaceService) GetWorkspaceBySlug(ctx context.Context, slug string) (*model.Workspace, error) {
+	workspace, err := s.repo.GetWorkspaceBySlug(ctx, strings.ToLower(strings.TrimSpace(slug)))
+	if err != nil {
+		return nil, err
+	}
+	return workspace, s.loadSettings(ctx, workspace)
+}
+
+// UpdateSettings changes the settings of a workspace set in patch and
+// returns the workspace
+func (s *WorkspaceService) UpdateSettings(ctx context.Context, id int, patch model.WorkspaceSettingsPatch) (*model.Workspace, error) {
+	workspace, err := s.GetWorkspace(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Sharing != nil {
+		workspace.Settings.Sharing = *patch.Sharing
+	}
+	if patch.Webhooks != nil {
+		workspace.Settings.Webhooks = *patch.Webhooks
+	}
+	if patch.Registration != nil {
+		workspace.Settings.Registration = *patch.Registration
+	}
+
+	repo, err := s.workspaces.Open(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.UpdateWorkspaceSettings(ctx, workspace.Settings); err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// AssignOwner gives the user with username everything in their workspace
+// made before accounts existed, which nobody owns until an operator names
+// its owner, and returns the user
+func (s *WorkspaceService) AssignOwner(ctx context.Context, username string) (*model.User, error) {
+	user, err := s.repo.GetUserByUsername(ctx, strings.ToLower(strings.TrimSpace(username)))
+	if err != nil {
+		return nil, err
+	}
+	repo, err := s.workspaces.Open(ctx, user.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return user, repo.AssignOwner(ctx, user.ID)
+}
+
+// Open returns the storage of a workspace
+func (s *WorkspaceService) Open(ctx context.Context, id int) (repository.TodoRepository, error) {
+	return s.workspaces.Open(ctx, id)
+}
+
+// OpenAll returns the storage of every workspace, by ID
+func (s *WorkspaceService) OpenAll(ctx context.Context) ([]repository.TodoRepository, error) {
+	workspaces, err := s.repo.GetWorkspaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	repos := make([]repository.TodoRepository, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		repo, err := s.workspaces.Open(ctx, workspace.ID)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+// CountTodos counts the todos of every workspace, and those not completed
+func (s *WorkspaceService) CountTodos(ctx context.Context) (model.TodoCounts, error) {
+	repos, err := s.OpenAll(ctx)
+	if err != nil {
+		return model.TodoCounts{}, err
+	}
+
+	var total model.TodoCounts
+	for _, repo := range repos {
+		counts, err := repo.CountTodos(ctx)
+		if err != nil {
+			return model.TodoCounts{}, err
+		}
+		total.Total += counts.Total
+		total.Open += counts.Open
+	}
+	return total, nil
+}
+
+// loadSettings fills in the settings of workspace, which live with its data
+func (s *WorkspaceService) loadSettings(ctx context.Context, workspace *model.Workspace) error {
+	repo, err := s.workspaces.Open(ctx, workspace.ID)
+	if err != nil {
+		return err
+	}
+	workspace.Settings, err = repo.GetWorkspaceSettings(ctx)
+	return err
+}
+
+// validWorkspaceSlug reports whether a lower-cased slug is 3 to 32 of a-z, 0-9 and '-'
+func validWorkspaceSlug(slug string) bool {
+	if len(slug) < 3 || len(slug) > 32 {
+		return false
+	}
+	for _, c := range slug {
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
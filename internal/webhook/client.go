@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is the error of a delivery to an address webhooks may not reach
+var ErrForbiddenAddress = errors.New("webhooks may not be sent to loopback, link-local or private addresses")
+
+// sharedAddressSpace is the carrier-grade NAT range, private like the RFC 1918 ones
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// NewClient returns the HTTP client deliveries are sent with. Anyone who can
+// create a webhook picks its URL, so the client follows no redirects, which
+// fail the attempt with their 3xx status, and only connects to public
+// addresses, or to those in allowed: otherwise a webhook could post to the
+// server itself, its network or a cloud metadata service. The address is checked when connecting, after the name is
+// resolved, so DNS cannot point a public name at a private address.
+func NewClient(allowed []netip.Prefix) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := addrPort.Addr().Unmap(); private(ip) && !contains(allowed, ip) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// ParseAllowedNetworks reads a comma-separated list of addresses and CIDR
+// prefixes, such as "10.0.0.0/8,127.0.0.1", that webhooks may be sent to
+// although they are private
+func ParseAllowedNetworks(s string) ([]netip.Prefix, error) {
+	var allowed []netip.Prefix
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !strings.Contains(field, "/") {
+			addr, err := netip.ParseAddr(field)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an address or CIDR prefix", field)
+			}
+			allowed = append(allowed, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(field)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an address or CIDR prefix", field)
+		}
+		allowed = append(allowed, prefix.Masked())
+	}
+	return allowed, nil
+}
+
+// private reports whether ip is one webhooks may not reach by default
+func private(ip netip.Addr) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsMulticast() ||
+		ip.IsUnspecified() || sharedAddressSpace.Contains(ip)
+}
+
+func contains(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
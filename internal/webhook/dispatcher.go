@@ -0,0 +1,219 @@
+// Package webhook delivers the queued webhook deliveries to their endpoints
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+)
+
+// Request headers sent with every delivery
+const (
+	SignatureHeader = "X-Todo-Signature-256" // "sha256=" and the hex HMAC-SHA256 of the body keyed by the webhook secret
+	EventHeader     = "X-Todo-Event"         // the event type
+	DeliveryHeader  = "X-Todo-Delivery"      // the delivery ID, the same on every retry
+)
+
+// Store is the part of the repository the dispatcher works with
+type Store interface {
+	GetWebhook(ctx context.Context, id int) (*model.Webhook, error)
+	ClaimDueDeliveries(ctx context.Context, now, until time.Time, limit int) ([]*model.WebhookDelivery, error)
+	RecordDeliveryAttempt(ctx context.Context, delivery *model.WebhookDelivery, attempt *model.DeliveryAttempt) error
+}
+
+// Dispatcher sends due deliveries and schedules retries of the failed ones.
+// Attempt n waits BaseDelay << (n-1), at most MaxDelay, before the next one;
+// a delivery is failed after MaxAttempts.
+//
+// Each delivery is claimed before it is sent, so the dispatchers of several
+// backends sharing a database send it once. A delivery whose dispatcher
+// stopped before recording its attempt is due again after Lease.
+type Dispatcher struct {
+	Store        Store
+	Stores       func(ctx context.Context) ([]Store, error) // when set, Run polls every store it returns instead of Store
+	Client       *http.Client
+	PollInterval time.Duration // how often Run looks for due deliveries
+	BatchSize    int           // most deliveries sent per poll
+	Lease        time.Duration // how long a claimed delivery is kept from other dispatchers; longer than Client.Timeout
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	MaxAttempts  int
+}
+
+// NewDispatcher creates a dispatcher with the default timings: attempts
+// retried after 10s, 20s, 40s ... up to an hour, 8 attempts in all. Its
+// client reaches public addresses only; see NewClient.
+func NewDispatcher(store Store) *Dispatcher {
+	return &Dispatcher{
+		Store:        store,
+		Client:       NewClient(nil),
+		PollInterval: time.Second,
+		BatchSize:    50,
+		Lease:        time.Minute,
+		BaseDelay:    10 * time.Second,
+		MaxDelay:     time.Hour,
+		MaxAttempts:  8,
+	}
+}
+
+// Sign returns the signature header value of body for secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run delivers due deliveries every PollInterval until ctx is done
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.deliverAll(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Webhook delivery failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue makes one attempt at every delivery of Store that is due now, up to BatchSize
+func (d *Dispatcher) DeliverDue(ctx context.Context) error {
+	return d.deliverDue(ctx, d.Store)
+}
+
+// deliverAll delivers what is due in every store of Stores, or in Store
+// without it. A failing store does not hold up the others.
+func (d *Dispatcher) deliverAll(ctx context.Context) error {
+	if d.Stores == nil {
+		return d.DeliverDue(ctx)
+	}
+
+	stores, err := d.Stores(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, store := range stores {
+		if err := d.deliverDue(ctx, store); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deliverDue makes one attempt at every delivery of store that is due now,
+// up to BatchSize. Deliveries are claimed one at a time, each just before it
+// is sent, so the lease only has to outlast one attempt.
+func (d *Dispatcher) deliverDue(ctx context.Context, store Store) error {
+	for range d.BatchSize {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		now := time.Now()
+		claimed, err := store.ClaimDueDeliveries(ctx, now, now.Add(d.Lease), 1)
+		if err != nil || len(claimed) == 0 {
+			return err
+		}
+		if err := d.deliver(ctx, store, claimed[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliver makes one attempt at delivery, kept in store, and records it
+func (d *Dispatcher) deliver(ctx context.Context, store Store, delivery *model.WebhookDelivery) error {
+	webhook, err := store.GetWebhook(ctx, delivery.WebhookID)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		return nil // Deleted since the delivery was read, with its deliveries
+	}
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	statusCode, sendErr := d.send(ctx, webhook, delivery)
+	if sendErr != nil && ctx.Err() != nil {
+		return ctx.Err() // Shutting down; not the endpoint's fault, so no attempt is recorded
+	}
+	attempt := &model.DeliveryAttempt{
+		AttemptedAt: start.UTC(),
+		StatusCode:  statusCode,
+		DurationMS:  time.Since(start).Milliseconds(),
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+
+	attempts := len(delivery.Attempts) + 1
+	switch {
+	case sendErr == nil:
+		delivery.Status, delivery.NextAttemptAt = model.DeliveryDelivered, nil
+	case attempts >= d.MaxAttempts:
+		delivery.Status, delivery.NextAttemptAt = model.DeliveryFailed, nil
+	default:
+		next := time.Now().Add(d.backoff(attempts)).UTC()
+		delivery.NextAttemptAt = &next
+	}
+
+	// The attempt was made, so it is recorded even when shutdown starts meanwhile
+	err = store.RecordDeliveryAttempt(context.WithoutCancel(ctx), delivery, attempt)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		return nil
+	}
+	return err
+}
+
+// send posts the payload of delivery, returning the response status, if any,
+// and an error unless it was 2xx
+func (d *Dispatcher) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo-app-webhooks")
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, delivery.Payload))
+	req.Header.Set(EventHeader, string(delivery.EventType))
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // Lets the connection be reused
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns how long to wait after the given number of failed attempts
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.BaseDelay
+	for i := 1; i < attempts && delay < d.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, d.MaxDelay)
+}
@@ -117,7 +117,7 @@ sleep 30
 # Health check
 echo "🏥 Running health checks..."
 for i in {1..10}; do
-    if curl -f http://localhost/api/todos > /dev/null 2>&1; then
+    if curl -f http://localhost:8080/readyz > /dev/null 2>&1; then
         echo "✅ Application is healthy!"
         break
     else
@@ -169,7 +169,7 @@ echo ""
 
 # Health check
 echo "🏥 Health Check:"
-if curl -f http://localhost/api/todos > /dev/null 2>&1; then
+if curl -f http://localhost:8080/readyz > /dev/null 2>&1; then
     echo "✅ Application is healthy"
 else
     echo "❌ Application is not responding"
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"todo-app/internal/handler"
+	"todo-app/internal/model"
 	"todo-app/internal/repository"
 	"todo-app/internal/service"
 
@@ -17,13 +18,15 @@ import (
 
 // TestAPI_ResponseStructure tests the API response structure compliance
 func TestAPI_ResponseStructure(t *testing.T) {
+	ctx := t.Context()
 	tests := []struct {
 		name           string
 		method         string
 		path           string
 		body           interface{}
 		expectedStatus int
-		expectedFields []string
+		expectedFields []string // of the response object
+		expectedArray  string   // where the listed todos are: the "data" field, or "" for a bare array
 	}{
 		{
 			name:           "POST /api/todos - creates todo with correct structure",
@@ -31,15 +34,24 @@ func TestAPI_ResponseStructure(t *testing.T) {
 			path:           "/api/todos",
 			body:           map[string]string{"text": "test todo"},
 			expectedStatus: http.StatusCreated,
-			expectedFields: []string{"id", "text", "created_at", "updated_at"},
+			expectedFields: []string{"id", "text", "list_id", "completed", "completed_at", "priority", "due_at", "due_all_day", "tags", "created_at", "updated_at"},
 		},
 		{
-			name:           "GET /api/todos - returns array structure",
+			name:           "GET /api/todos - returns page envelope",
 			method:         "GET",
 			path:           "/api/todos",
 			body:           nil,
 			expectedStatus: http.StatusOK,
-			expectedFields: []string{}, // Array response
+			expectedFields: []string{"data"},
+			expectedArray:  "data",
+		},
+		{
+			name:           "GET /api/todos?legacy=true - returns bare array for the web client",
+			method:         "GET",
+			path:           "/api/todos?legacy=true",
+			body:           nil,
+			expectedStatus: http.StatusOK,
+			expectedArray:  "",
 		},
 	}
 
@@ -50,11 +62,13 @@ func TestAPI_ResponseStructure(t *testing.T) {
 			require.NoError(t, err)
 			defer repo.Close()
 
-			err = repo.Truncate() // Clean state for each test
+			err = repo.Truncate(ctx) // Clean state for each test
 			require.NoError(t, err)
 
 			svc := service.NewTodoService(repo)
 			h := handler.NewTodoHandler(svc)
+			_, err = svc.CreateTodo(ctx, model.TodoInput{Text: "existing todo"})
+			require.NoError(t, err)
 
 			// Prepare request
 			var reqBody *bytes.Buffer
@@ -82,7 +96,7 @@ func TestAPI_ResponseStructure(t *testing.T) {
 			// Verify
 			assert.Equal(t, tt.expectedStatus, rec.Code)
 
-			if len(tt.expectedFields) > 0 {
+			if tt.method == "POST" {
 				var response map[string]interface{}
 				err := json.Unmarshal(rec.Body.Bytes(), &response)
 				require.NoError(t, err)
@@ -90,18 +104,38 @@ func TestAPI_ResponseStructure(t *testing.T) {
 				for _, field := range tt.expectedFields {
 					assert.Contains(t, response, field, "Field %s should be present", field)
 				}
+				return
+			}
+
+			var listed interface{}
+			if tt.expectedArray == "" {
+				err = json.Unmarshal(rec.Body.Bytes(), &listed)
+				require.NoError(t, err)
+			} else {
+				var response map[string]interface{}
+				err = json.Unmarshal(rec.Body.Bytes(), &response)
+				require.NoError(t, err, "The response should be an object, not a bare array")
+				for _, field := range tt.expectedFields {
+					assert.Contains(t, response, field, "Field %s should be present", field)
+				}
+				listed = response[tt.expectedArray]
 			}
+			todos, ok := listed.([]interface{})
+			require.True(t, ok, "The todos should be an array, got %T", listed)
+			require.Len(t, todos, 1)
+			assert.Equal(t, "existing todo", todos[0].(map[string]interface{})["text"])
 		})
 	}
 }
 
 // TestAPI_ContentTypeHeaders tests correct content type handling
 func TestAPI_ContentTypeHeaders(t *testing.T) {
+	ctx := t.Context()
 	repo, err := repository.NewSQLiteTodoRepository("test.db")
 	require.NoError(t, err)
 	defer repo.Close()
 
-	err = repo.Truncate() // Clean state
+	err = repo.Truncate(ctx) // Clean state
 	require.NoError(t, err)
 
 	svc := service.NewTodoService(repo)
@@ -149,11 +183,12 @@ func TestAPI_ContentTypeHeaders(t *testing.T) {
 
 // TestAPI_CORS tests CORS headers (if implemented)
 func TestAPI_CORSHeaders(t *testing.T) {
+	ctx := t.Context()
 	repo, err := repository.NewSQLiteTodoRepository("test.db")
 	require.NoError(t, err)
 	defer repo.Close()
 
-	err = repo.Truncate() // Clean state
+	err = repo.Truncate(ctx) // Clean state
 	require.NoError(t, err)
 
 	svc := service.NewTodoService(repo)
@@ -0,0 +1,107 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPI_APITokenEndpoints tests the /api/tokens contract and Bearer authentication
+func TestAPI_APITokenEndpoints(t *testing.T) {
+	repo := repository.NewInMemoryTodoRepository()
+	workspaces := service.NewWorkspaceService(repo, repository.SharedWorkspaces(repo))
+	auth := handler.NewAuthHandler(service.NewAuthService(repo, workspaces), workspaces, false)
+	tokens := handler.NewAPITokenHandler(service.NewAPITokenService(repo))
+	todos := handler.NewTodoHandler(service.NewTodoService(repo))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/register", auth.Register)
+	mux.HandleFunc("POST /api/tokens", tokens.CreateAPIToken)
+	mux.HandleFunc("GET /api/tokens", tokens.GetAPITokens)
+	mux.HandleFunc("DELETE /api/tokens/{id}", tokens.RevokeAPIToken)
+	mux.Handle("GET /api/todos", handler.Scoped(model.ScopeTodosRead, todos.GetAllTodos))
+	mux.Handle("POST /api/todos", handler.Scoped(model.ScopeTodosWrite, todos.CreateTodo))
+	server := auth.RequireAuth(mux)
+
+	serve := func(method, path, body string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("POST", "/api/auth/register", `{"username":"alice","password":"correct horse battery"}`, nil)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	session := http.Header{"Cookie": {rec.Result().Cookies()[0].String()}}
+
+	// Creating a token returns its value once
+	rec = serve("POST", "/api/tokens", `{"name":"CI","scopes":["todos:read"]}`, session)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var created map[string]interface{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&created))
+	for _, field := range []string{"id", "name", "scopes", "token", "created_at", "last_used_at"} {
+		assert.Contains(t, created, field)
+	}
+	assert.NotContains(t, created, "token_hash")
+	bearer := http.Header{"Authorization": {"Bearer " + created["token"].(string)}}
+
+	// Listing leaves it out
+	rec = serve("GET", "/api/tokens", "", session)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var listed []map[string]interface{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&listed))
+	require.Len(t, listed, 1)
+	assert.NotContains(t, listed[0], "token")
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		header         http.Header
+		expectedStatus int
+		expectedAuth   string // WWW-Authenticate
+	}{
+		{name: "GET /api/todos - token with the scope returns todos", method: "GET", path: "/api/todos", header: bearer, expectedStatus: http.StatusOK},
+		{name: "GET /api/todos - lower-case scheme is accepted", method: "GET", path: "/api/todos", header: http.Header{"Authorization": {"bearer " + created["token"].(string)}}, expectedStatus: http.StatusOK},
+		{name: "POST /api/todos - token without the scope returns forbidden", method: "POST", path: "/api/todos", body: `{"text":"Ship it"}`, header: bearer, expectedStatus: http.StatusForbidden, expectedAuth: `Bearer error="insufficient_scope", scope="todos:write"`},
+		{name: "GET /api/tokens - tokens cannot manage tokens", method: "GET", path: "/api/tokens", header: bearer, expectedStatus: http.StatusForbidden},
+		{name: "GET /api/todos - unknown token returns unauthorized", method: "GET", path: "/api/todos", header: http.Header{"Authorization": {"Bearer forged"}}, expectedStatus: http.StatusUnauthorized, expectedAuth: `Bearer error="invalid_token"`},
+		{name: "GET /api/todos - other scheme returns unauthorized", method: "GET", path: "/api/todos", header: http.Header{"Authorization": {"Basic YWxpY2U6c2VjcmV0"}}, expectedStatus: http.StatusUnauthorized, expectedAuth: "Bearer"},
+		{name: "POST /api/tokens - unknown scope returns bad request", method: "POST", path: "/api/tokens", body: `{"name":"CI","scopes":["admin"]}`, header: session, expectedStatus: http.StatusBadRequest},
+		{name: "POST /api/tokens - without a session returns unauthorized", method: "POST", path: "/api/tokens", body: `{"name":"CI","scopes":["todos:read"]}`, expectedStatus: http.StatusUnauthorized},
+		{name: "DELETE /api/tokens/{id} - invalid ID returns bad request", method: "DELETE", path: "/api/tokens/abc", header: session, expectedStatus: http.StatusBadRequest},
+		{name: "DELETE /api/tokens/{id} - revokes the token", method: "DELETE", path: fmt.Sprintf("/api/tokens/%v", created["id"]), header: session, expectedStatus: http.StatusNoContent},
+		{name: "DELETE /api/tokens/{id} - revoked token returns not found", method: "DELETE", path: fmt.Sprintf("/api/tokens/%v", created["id"]), header: session, expectedStatus: http.StatusNotFound},
+		{name: "GET /api/todos - revoked token returns unauthorized", method: "GET", path: "/api/todos", header: bearer, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.method, tt.path, tt.body, tt.header)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code, rec.Body.String())
+			if tt.expectedAuth != "" {
+				assert.Equal(t, tt.expectedAuth, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
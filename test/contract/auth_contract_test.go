@@ -0,0 +1,108 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPI_AuthEndpoints tests the /api/auth contract and the session cookie
+func TestAPI_AuthEndpoints(t *testing.T) {
+	repo := repository.NewInMemoryTodoRepository()
+	workspaces := service.NewWorkspaceService(repo, repository.SharedWorkspaces(repo))
+	auth := handler.NewAuthHandler(service.NewAuthService(repo, workspaces), workspaces, true)
+	todos := handler.NewTodoHandler(service.NewTodoService(repo))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/register", auth.Register)
+	mux.HandleFunc("POST /api/auth/login", auth.Login)
+	mux.HandleFunc("POST /api/auth/logout", auth.Logout)
+	mux.HandleFunc("GET /api/auth/me", auth.Me)
+	mux.HandleFunc("GET /api/todos", todos.GetAllTodos)
+	server := auth.RequireAuth(mux)
+
+	serve := func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Registering returns the user without its password hash and sets the session cookie
+	rec := serve("POST", "/api/auth/register", `{"username":"alice","password":"correct horse battery"}`, nil)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var user map[string]interface{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&user))
+	for _, field := range []string{"id", "username", "created_at"} {
+		assert.Contains(t, user, field)
+	}
+	assert.NotContains(t, user, "password_hash")
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	session := cookies[0]
+	assert.Equal(t, handler.SessionCookie, session.Name)
+	assert.NotEmpty(t, session.Value)
+	assert.Equal(t, "/", session.Path)
+	assert.True(t, session.HttpOnly)
+	assert.True(t, session.Secure)
+	assert.Equal(t, http.SameSiteLaxMode, session.SameSite)
+	assert.Equal(t, int(service.SessionLifetime.Seconds()), session.MaxAge)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		cookie         *http.Cookie
+		expectedStatus int
+		expectedCookie bool
+	}{
+		{name: "GET /api/todos - without a session returns unauthorized", method: "GET", path: "/api/todos", expectedStatus: http.StatusUnauthorized},
+		{name: "GET /api/todos - unknown session returns unauthorized", method: "GET", path: "/api/todos", cookie: &http.Cookie{Name: handler.SessionCookie, Value: "forged"}, expectedStatus: http.StatusUnauthorized},
+		{name: "GET /api/todos - with a session returns todos", method: "GET", path: "/api/todos", cookie: session, expectedStatus: http.StatusOK},
+		{name: "GET /api/auth/me - returns the signed-in user", method: "GET", path: "/api/auth/me", cookie: session, expectedStatus: http.StatusOK},
+		{name: "POST /api/auth/register - taken username returns conflict", method: "POST", path: "/api/auth/register", body: `{"username":"alice","password":"correct horse battery"}`, expectedStatus: http.StatusConflict},
+		{name: "POST /api/auth/register - short password returns bad request", method: "POST", path: "/api/auth/register", body: `{"username":"bob","password":"short"}`, expectedStatus: http.StatusBadRequest},
+		{name: "POST /api/auth/register - invalid JSON returns bad request", method: "POST", path: "/api/auth/register", body: `{"username":`, expectedStatus: http.StatusBadRequest},
+		{name: "POST /api/auth/login - wrong password returns unauthorized", method: "POST", path: "/api/auth/login", body: `{"username":"alice","password":"wrong horse battery"}`, expectedStatus: http.StatusUnauthorized},
+		{name: "POST /api/auth/login - sets the session cookie", method: "POST", path: "/api/auth/login", body: `{"username":"alice","password":"correct horse battery"}`, expectedStatus: http.StatusOK, expectedCookie: true},
+		{name: "POST /api/auth/logout - clears the session cookie", method: "POST", path: "/api/auth/logout", cookie: session, expectedStatus: http.StatusNoContent, expectedCookie: true},
+		{name: "GET /api/auth/me - after logout returns unauthorized", method: "GET", path: "/api/auth/me", cookie: session, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.method, tt.path, tt.body, tt.cookie)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code, rec.Body.String())
+			cookies := rec.Result().Cookies()
+			if !tt.expectedCookie {
+				assert.Empty(t, cookies)
+				return
+			}
+			require.Len(t, cookies, 1)
+			assert.True(t, cookies[0].HttpOnly)
+			if tt.path == "/api/auth/logout" {
+				assert.Empty(t, cookies[0].Value)
+				assert.Negative(t, cookies[0].MaxAge)
+			}
+		})
+	}
+}
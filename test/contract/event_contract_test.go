@@ -0,0 +1,95 @@
+package contract
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPI_Events tests the GET /api/todos/events contract
+func TestAPI_Events(t *testing.T) {
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewEventHandler(svc)
+
+	for _, text := range []string{"Buy milk", "Buy bread"} {
+		_, err := svc.CreateTodo(ctx, model.TodoInput{Text: text})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name           string
+		header         string
+		query          string
+		expectedStatus int
+		expectedBody   []string
+		unexpectedBody []string
+	}{
+		{
+			name:           "without Last-Event-ID - live events only",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"retry: 3000\n\n"},
+			unexpectedBody: []string{"id: "},
+		},
+		{
+			name:           "Last-Event-ID header - replays later events",
+			header:         "1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"id: 2\nevent: created\ndata: {\"id\":2,\"type\":\"created\",\"todo_id\":2,\"todo\":{\"id\":2,\"text\":\"Buy bread\""},
+			unexpectedBody: []string{"id: 1\n"},
+		},
+		{
+			name:           "last_event_id parameter - replays the whole log from 0",
+			query:          "?last_event_id=0",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"id: 1\nevent: created\n", "id: 2\nevent: created\n"},
+		},
+		{
+			name:           "invalid Last-Event-ID",
+			header:         "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "negative last_event_id",
+			query:          "?last_event_id=-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A cancelled request ends the stream right after the replay
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			req := httptest.NewRequest("GET", "/api/todos/events"+tt.query, nil).WithContext(ctx)
+			if tt.header != "" {
+				req.Header.Set("Last-Event-ID", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			h.StreamEvents(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+			assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+			for _, expected := range tt.expectedBody {
+				assert.Contains(t, rec.Body.String(), expected)
+			}
+			for _, unexpected := range tt.unexpectedBody {
+				assert.NotContains(t, rec.Body.String(), unexpected)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package contract
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPI_HealthEndpoints tests the probe and build info contract, which needs no account
+func TestAPI_HealthEndpoints(t *testing.T) {
+	repo, err := repository.NewSQLiteTodoRepository(":memory:")
+	require.NoError(t, err)
+	defer repo.Close()
+	workspaces := service.NewWorkspaceService(repo, repository.SharedWorkspaces(repo))
+	auth := handler.NewAuthHandler(service.NewAuthService(repo, workspaces), workspaces, false)
+	healthSvc := service.NewHealthService(repo)
+	health := handler.NewHealthHandler(healthSvc)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", health.Healthz)
+	mux.HandleFunc("GET /readyz", health.Readyz)
+	mux.HandleFunc("GET /version", health.Version)
+	server := auth.RequireAuth(mux)
+
+	serve := func(method, path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		var body map[string]interface{}
+		if method != "HEAD" {
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		}
+		return rec, body
+	}
+
+	// The process is alive
+	rec, body := serve("GET", "/healthz")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", body["status"])
+
+	// Container healthchecks may use HEAD
+	rec, _ = serve("HEAD", "/readyz")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// The database answers and is fully migrated
+	rec, body = serve("GET", "/readyz")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	assert.Equal(t, "ready", body["status"])
+	assert.Equal(t, map[string]interface{}{"shutdown": "ok", "database": "ok", "migrations": "ok"}, body["checks"])
+	assert.EqualValues(t, repo.LatestSchemaVersion(), body["schema_version"])
+
+	// The build details
+	rec, body = serve("GET", "/version")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	for _, field := range []string{"commit", "build_time", "go_version"} {
+		assert.NotEmpty(t, body[field], field)
+	}
+	assert.EqualValues(t, repo.LatestSchemaVersion(), body["schema_version"])
+
+	// While draining for shutdown the server is alive but not ready
+	healthSvc.Drain()
+	rec, body = serve("GET", "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "unavailable", body["status"])
+	assert.NotEqual(t, "ok", body["checks"].(map[string]interface{})["shutdown"])
+
+	rec, _ = serve("GET", "/healthz")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
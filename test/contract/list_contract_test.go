@@ -0,0 +1,143 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPI_ListEndpoints tests the /api/lists contract
+func TestAPI_ListEndpoints(t *testing.T) {
+	ctx := t.Context()
+	listFields := []string{"id", "name", "inbox", "role", "todo_count", "created_at", "updated_at"}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedFields []string
+	}{
+		{
+			name:           "POST /api/lists - returns created list structure",
+			method:         "POST",
+			path:           "/api/lists",
+			body:           `{"name":"Groceries"}`,
+			expectedStatus: http.StatusCreated,
+			expectedFields: listFields,
+		},
+		{
+			name:           "POST /api/lists - empty name returns bad request",
+			method:         "POST",
+			path:           "/api/lists",
+			body:           `{"name":"  "}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "GET /api/lists - returns lists",
+			method:         "GET",
+			path:           "/api/lists",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET /api/lists/{id} - returns list structure",
+			method:         "GET",
+			path:           "/api/lists/2",
+			expectedStatus: http.StatusOK,
+			expectedFields: listFields,
+		},
+		{
+			name:           "GET /api/lists/{id} - unknown ID returns 404",
+			method:         "GET",
+			path:           "/api/lists/999",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "PUT /api/lists/{id} - returns renamed list structure",
+			method:         "PUT",
+			path:           "/api/lists/2",
+			body:           `{"name":"Office"}`,
+			expectedStatus: http.StatusOK,
+			expectedFields: listFields,
+		},
+		{
+			name:           "DELETE /api/lists/{id} - returns no content",
+			method:         "DELETE",
+			path:           "/api/lists/2",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "DELETE /api/lists/{id} - inbox returns conflict",
+			method:         "DELETE",
+			path:           "/api/lists/1",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "GET /api/lists/{id}/todos - returns todos",
+			method:         "GET",
+			path:           "/api/lists/2/todos",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET /api/lists/{id}/todos - unknown ID returns 404",
+			method:         "GET",
+			path:           "/api/lists/999/todos",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "GET /api/lists/{id}/todos - invalid ID returns bad request",
+			method:         "GET",
+			path:           "/api/lists/abc/todos",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewInMemoryTodoRepository()
+			svc := service.NewTodoService(repo)
+			listService := service.NewListService(repo)
+			lists := handler.NewListHandler(listService, svc)
+
+			// List 1 is the inbox, list 2 is "Work"
+			_, err := listService.CreateList(ctx, model.ListInput{Name: "Work"})
+			require.NoError(t, err)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /api/lists", lists.CreateList)
+			mux.HandleFunc("GET /api/lists", lists.GetLists)
+			mux.HandleFunc("GET /api/lists/{id}", lists.GetList)
+			mux.HandleFunc("PUT /api/lists/{id}", lists.UpdateList)
+			mux.HandleFunc("DELETE /api/lists/{id}", lists.DeleteList)
+			mux.HandleFunc("GET /api/lists/{id}/todos", lists.GetListTodos)
+
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if len(tt.expectedFields) > 0 {
+				var response map[string]interface{}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				for _, field := range tt.expectedFields {
+					assert.Contains(t, response, field, "Field %s should be present", field)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPI_ListMemberEndpoints tests the /api/lists/{id}/members and /api/invitations contract
+func TestAPI_ListMemberEndpoints(t *testing.T) {
+	repo := repository.NewInMemoryTodoRepository()
+	workspaces := service.NewWorkspaceService(repo, repository.SharedWorkspaces(repo))
+	auth := handler.NewAuthHandler(service.NewAuthService(repo, workspaces), workspaces, false)
+	todoService := service.NewTodoService(repo)
+	lists := handler.NewListHandler(service.NewListService(repo), todoService)
+	todos := handler.NewTodoHandler(todoService)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/register", auth.Register)
+	mux.HandleFunc("POST /api/lists", lists.CreateList)
+	mux.HandleFunc("GET /api/lists/{id}", lists.GetList)
+	mux.HandleFunc("PUT /api/lists/{id}", lists.UpdateList)
+	mux.HandleFunc("GET /api/lists/{id}/members", lists.GetMembers)
+	mux.HandleFunc("POST /api/lists/{id}/members", lists.InviteMember)
+	mux.HandleFunc("PATCH /api/lists/{id}/members/{userID}", lists.UpdateMember)
+	mux.HandleFunc("DELETE /api/lists/{id}/members/{userID}", lists.RemoveMember)
+	mux.HandleFunc("GET /api/invitations", lists.GetInvitations)
+	mux.HandleFunc("POST /api/invitations/{id}/accept", lists.AcceptInvitation)
+	mux.HandleFunc("POST /api/invitations/{id}/decline", lists.DeclineInvitation)
+	mux.HandleFunc("POST /api/todos", todos.CreateTodo)
+	mux.HandleFunc("DELETE /api/todos/{id}", todos.DeleteTodo)
+	server := auth.RequireAuth(mux)
+
+	serve := func(method, path, body string, session http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for name, values := range session {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+	register := func(username string) (http.Header, int) {
+		rec := serve("POST", "/api/auth/register", fmt.Sprintf(`{"username":%q,"password":"correct horse battery"}`, username), nil)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		var user map[string]interface{}
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&user))
+		return http.Header{"Cookie": {rec.Result().Cookies()[0].String()}}, int(user["id"].(float64))
+	}
+	alice, _ := register("alice")
+	bob, bobID := register("bob")
+	carol, carolID := register("carol")
+	dave, _ := register("dave")
+
+	// List 2 is Alice's "Work" with her todo 1
+	rec := serve("POST", "/api/lists", `{"name":"Work"}`, alice)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	rec = serve("POST", "/api/todos", `{"text":"Write the report","list_id":2}`, alice)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	// Inviting returns the pending membership
+	rec = serve("POST", "/api/lists/2/members", `{"username":"bob","role":"viewer"}`, alice)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var member map[string]interface{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&member))
+	for _, field := range []string{"list_id", "list_name", "user_id", "username", "role", "accepted", "created_at"} {
+		assert.Contains(t, member, field)
+	}
+	assert.Equal(t, false, member["accepted"])
+	rec = serve("POST", "/api/lists/2/members", `{"username":"carol","role":"editor"}`, alice)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	// Invitations are listed for the invited user
+	rec = serve("GET", "/api/invitations", "", bob)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var invitations []map[string]interface{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&invitations))
+	require.Len(t, invitations, 1)
+	assert.Equal(t, "Work", invitations[0]["list_name"])
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		session        http.Header
+		expectedStatus int
+	}{
+		{name: "GET /api/lists/{id} - before accepting returns 404", method: "GET", path: "/api/lists/2", session: bob, expectedStatus: http.StatusNotFound},
+		{name: "POST /api/invitations/{id}/accept - returns the list", method: "POST", path: "/api/invitations/2/accept", session: bob, expectedStatus: http.StatusOK},
+		{name: "POST /api/invitations/{id}/accept - twice returns 404", method: "POST", path: "/api/invitations/2/accept", session: bob, expectedStatus: http.StatusNotFound},
+		{name: "POST /api/invitations/{id}/accept - invalid ID returns bad request", method: "POST", path: "/api/invitations/abc/accept", session: bob, expectedStatus: http.StatusBadRequest},
+		{name: "GET /api/lists/{id} - after accepting returns the list", method: "GET", path: "/api/lists/2", session: bob, expectedStatus: http.StatusOK},
+		{name: "GET /api/lists/{id}/members - returns members", method: "GET", path: "/api/lists/2/members", session: bob, expectedStatus: http.StatusOK},
+		{name: "GET /api/lists/{id}/members - stranger returns 404", method: "GET", path: "/api/lists/2/members", session: dave, expectedStatus: http.StatusNotFound},
+		{name: "POST /api/lists/{id}/members - unknown role returns bad request", method: "POST", path: "/api/lists/2/members", body: `{"username":"dave","role":"admin"}`, session: alice, expectedStatus: http.StatusBadRequest},
+		{name: "POST /api/lists/{id}/members - unknown user returns 404", method: "POST", path: "/api/lists/2/members", body: `{"username":"erin","role":"viewer"}`, session: alice, expectedStatus: http.StatusNotFound},
+		{name: "POST /api/lists/{id}/members - member again returns conflict", method: "POST", path: "/api/lists/2/members", body: `{"username":"bob","role":"editor"}`, session: alice, expectedStatus: http.StatusConflict},
+		{name: "POST /api/lists/{id}/members - inbox returns bad request", method: "POST", path: "/api/lists/1/members", body: `{"username":"dave","role":"viewer"}`, session: alice, expectedStatus: http.StatusBadRequest},
+		{name: "POST /api/lists/{id}/members - member returns forbidden", method: "POST", path: "/api/lists/2/members", body: `{"username":"dave","role":"viewer"}`, session: bob, expectedStatus: http.StatusForbidden},
+		{name: "POST /api/lists/{id}/members - without JSON returns bad request", method: "POST", path: "/api/lists/2/members", session: alice, expectedStatus: http.StatusBadRequest},
+		{name: "PUT /api/lists/{id} - member returns forbidden", method: "PUT", path: "/api/lists/2", body: `{"name":"Mine"}`, session: bob, expectedStatus: http.StatusForbidden},
+		{name: "POST /api/todos - viewer returns forbidden", method: "POST", path: "/api/todos", body: `{"text":"Sneak in","list_id":2}`, session: bob, expectedStatus: http.StatusForbidden},
+		{name: "DELETE /api/todos/{id} - viewer returns forbidden", method: "DELETE", path: "/api/todos/1", session: bob, expectedStatus: http.StatusForbidden},
+		{name: "DELETE /api/todos/{id} - stranger returns 404", method: "DELETE", path: "/api/todos/1", session: dave, expectedStatus: http.StatusNotFound},
+		{name: "PATCH /api/lists/{id}/members/{userID} - returns the member", method: "PATCH", path: fmt.Sprintf("/api/lists/2/members/%d", bobID), body: `{"role":"editor"}`, session: alice, expectedStatus: http.StatusOK},
+		{name: "PATCH /api/lists/{id}/members/{userID} - invalid user ID returns bad request", method: "PATCH", path: "/api/lists/2/members/abc", body: `{"role":"editor"}`, session: alice, expectedStatus: http.StatusBadRequest},
+		{name: "PATCH /api/lists/{id}/members/{userID} - unknown member returns 404", method: "PATCH", path: "/api/lists/2/members/999", body: `{"role":"editor"}`, session: alice, expectedStatus: http.StatusNotFound},
+		{name: "DELETE /api/todos/{id} - editor returns no content", method: "DELETE", path: "/api/todos/1", session: bob, expectedStatus: http.StatusNoContent},
+		{name: "POST /api/invitations/{id}/decline - returns no content", method: "POST", path: "/api/invitations/2/decline", session: carol, expectedStatus: http.StatusNoContent},
+		{name: "DELETE /api/lists/{id}/members/{userID} - declined returns 404", method: "DELETE", path: fmt.Sprintf("/api/lists/2/members/%d", carolID), session: alice, expectedStatus: http.StatusNotFound},
+		{name: "DELETE /api/lists/{id}/members/{userID} - leaving returns no content", method: "DELETE", path: fmt.Sprintf("/api/lists/2/members/%d", bobID), session: bob, expectedStatus: http.StatusNoContent},
+		{name: "GET /api/lists/{id} - after leaving returns 404", method: "GET", path: "/api/lists/2", session: bob, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.method, tt.path, tt.body, tt.session)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package contract
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/logging"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPI_RequestLogging tests the X-Request-ID header and the logs of a request
+func TestAPI_RequestLogging(t *testing.T) {
+	store, err := repository.NewSQLiteTodoRepository(":memory:")
+	require.NoError(t, err)
+	defer store.Close()
+
+	var logs bytes.Buffer
+	logger, err := logging.New(&logs, "json", "info")
+	require.NoError(t, err)
+	workspaces := service.NewWorkspaceService(store, repository.SharedWorkspaces(store))
+	auth := handler.NewAuthHandler(service.NewAuthService(store, workspaces), workspaces, false)
+	todos := handler.NewTodoHandler(service.NewTodoService(store))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/register", auth.Register)
+	mux.Handle("GET /api/todos", handler.Scoped(model.ScopeTodosRead, todos.GetAllTodos))
+	server := logging.Middleware(logger, auth.RequireAuth(mux))
+
+	serve := func(method, path, body, requestID string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if requestID != "" {
+			req.Header.Set(logging.RequestIDHeader, requestID)
+		}
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Every response tells the ID of its request
+	rec := serve("POST", "/api/auth/register", `{"username":"alice","password":"correct horse battery"}`, "nginx-1", nil)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "nginx-1", rec.Header().Get(logging.RequestIDHeader))
+	session := rec.Result().Cookies()[0]
+
+	rec = serve("GET", "/api/todos", "", "", session)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, rec.Header().Get(logging.RequestIDHeader), 32)
+
+	// Unexpected errors are logged with the ID of their request, but not told the client
+	require.NoError(t, store.Close())
+	logs.Reset()
+	rec = serve("GET", "/api/todos", "", "nginx-2", session)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "closed")
+
+	var lines []map[string]any
+	scanner := bufio.NewScanner(&logs)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.NotEmpty(t, lines)
+	failure := lines[len(lines)-2]
+	assert.Equal(t, "ERROR", failure["level"])
+	assert.Equal(t, "nginx-2", failure["request_id"])
+	assert.Contains(t, failure["error"], "closed")
+
+	access := lines[len(lines)-1]
+	assert.Equal(t, "Request served", access["msg"])
+	assert.Equal(t, "nginx-2", access["request_id"])
+	assert.EqualValues(t, http.StatusInternalServerError, access["status"])
+}
@@ -0,0 +1,86 @@
+package contract
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/metrics"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPI_MetricsEndpoint tests the GET /metrics contract, which needs no account
+func TestAPI_MetricsEndpoint(t *testing.T) {
+	store, err := repository.NewSQLiteTodoRepository(":memory:")
+	require.NoError(t, err)
+	defer store.Close()
+
+	m := metrics.New()
+	repo := repository.WithCallOptions(store, repository.CallOptions{Observe: m.ObserveQuery})
+	m.WatchPool(store.PoolStats)
+	workspaces := service.NewWorkspaceService(repo, repository.SharedWorkspaces(repo))
+	m.WatchTodos(workspaces.CountTodos)
+	auth := handler.NewAuthHandler(service.NewAuthService(repo, workspaces), workspaces, false)
+	todos := handler.NewTodoHandler(service.NewTodoService(repo))
+	metricsHandler := handler.NewMetricsHandler(m)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/register", auth.Register)
+	mux.HandleFunc("POST /api/todos", todos.CreateTodo)
+	mux.HandleFunc("GET /api/todos/{id}", todos.GetTodo)
+	mux.HandleFunc("GET /metrics", metricsHandler.Metrics)
+	server := m.InstrumentHTTP(mux, auth.RequireAuth(mux))
+
+	serve := func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Given: A user with a todo, and a request refused for want of a session
+	rec := serve("POST", "/api/auth/register", `{"username":"alice","password":"correct horse battery"}`, nil)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	session := rec.Result().Cookies()[0]
+	require.Equal(t, http.StatusCreated, serve("POST", "/api/todos", `{"text":"Buy milk"}`, session).Code)
+	require.Equal(t, http.StatusOK, serve("GET", "/api/todos/1", "", session).Code)
+	require.Equal(t, http.StatusUnauthorized, serve("GET", "/api/todos/1", "", nil).Code)
+
+	// When: Scraping the metrics
+	rec = serve("GET", "/metrics", "", nil)
+
+	// Then: They are in the Prometheus text format
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	body := rec.Body.String()
+
+	// And: Requests are counted by route pattern and status, refused ones included
+	assert.Contains(t, body, `todoapp_http_requests_total{method="POST",route="/api/todos",status="201"} 1`)
+	assert.Contains(t, body, `todoapp_http_requests_total{method="GET",route="/api/todos/{id}",status="200"} 1`)
+	assert.Contains(t, body, `todoapp_http_requests_total{method="GET",route="/api/todos/{id}",status="401"} 1`)
+	assert.Contains(t, body, `# TYPE todoapp_http_request_duration_seconds histogram`)
+	assert.Contains(t, body, `todoapp_http_request_duration_seconds_bucket{method="POST",route="/api/todos",status="201",le="+Inf"} 1`)
+
+	// And: Repository calls are timed by method, transactions as a whole
+	assert.Contains(t, body, `todoapp_repository_query_duration_seconds_count{method="CreateUser"} 1`)
+	assert.Contains(t, body, `todoapp_repository_query_duration_seconds_count{method="Atomically"} 1`)
+	assert.Contains(t, body, `todoapp_repository_query_duration_seconds_count{method="GetByID"} 1`)
+
+	// And: The connection pool and the todos are reported
+	assert.Contains(t, body, "go_sql_open_connections ")
+	assert.Contains(t, body, "go_sql_wait_count_total ")
+	assert.Contains(t, body, "todoapp_todos 1\n")
+	assert.Contains(t, body, "todoapp_todos_open 1\n")
+}
@@ -0,0 +1,71 @@
+package contract
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPI_CanceledAndTimedOutRequests tests the answers to requests whose
+// client went away or whose database calls ran out of time
+func TestAPI_CanceledAndTimedOutRequests(t *testing.T) {
+	store, err := repository.NewSQLiteTodoRepository(":memory:")
+	require.NoError(t, err)
+	defer store.Close()
+
+	newServer := func(repo repository.TodoRepository) http.Handler {
+		workspaces := service.NewWorkspaceService(repo, repository.SharedWorkspaces(repo))
+		auth := handler.NewAuthHandler(service.NewAuthService(repo, workspaces), workspaces, false)
+		todos := handler.NewTodoHandler(service.NewTodoService(repo))
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /api/auth/register", auth.Register)
+		mux.Handle("GET /api/todos", handler.Scoped(model.ScopeTodosRead, todos.GetAllTodos))
+		mux.Handle("POST /api/todos", handler.Scoped(model.ScopeTodosWrite, todos.CreateTodo))
+		return auth.RequireAuth(mux)
+	}
+	serve := func(server http.Handler, req *http.Request, cookie *http.Cookie) *httptest.ResponseRecorder {
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	server := newServer(repository.WithCallOptions(store, repository.CallOptions{Timeout: time.Minute}))
+	req := httptest.NewRequest("POST", "/api/auth/register", bytes.NewBufferString(`{"username":"alice","password":"correct horse battery"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := serve(server, req, nil)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	session := rec.Result().Cookies()[0]
+
+	// A request the client gave up on is answered 499, like nginx does, and changes nothing
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req = httptest.NewRequestWithContext(ctx, "POST", "/api/todos", bytes.NewBufferString(`{"text":"Buy milk"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = serve(server, req, session)
+	assert.Equal(t, 499, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Request canceled")
+
+	rec = serve(server, httptest.NewRequest("GET", "/api/todos", nil), session)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data":[]}`, rec.Body.String())
+
+	// A request whose database calls run out of time is answered 503, so it can be retried
+	slow := newServer(repository.WithCallOptions(store, repository.CallOptions{Timeout: time.Nanosecond}))
+	rec = serve(slow, httptest.NewRequest("GET", "/api/todos", nil), session)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "did not answer in time")
+}
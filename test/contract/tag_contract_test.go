@@ -0,0 +1,131 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPI_TagEndpoints tests the /api/tags contract
+func TestAPI_TagEndpoints(t *testing.T) {
+	ctx := t.Context()
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedFields []string
+	}{
+		{
+			name:           "GET /api/tags - returns tags with counts",
+			method:         "GET",
+			path:           "/api/tags",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "PATCH /api/tags/{id} - returns renamed tag",
+			method:         "PATCH",
+			path:           "/api/tags/1",
+			body:           `{"name":"Office"}`,
+			expectedStatus: http.StatusOK,
+			expectedFields: []string{"id", "name", "todo_count"},
+		},
+		{
+			name:           "PATCH /api/tags/{id} - name in use returns conflict",
+			method:         "PATCH",
+			path:           "/api/tags/1",
+			body:           `{"name":"home"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "PATCH /api/tags/{id} - empty name returns bad request",
+			method:         "PATCH",
+			path:           "/api/tags/1",
+			body:           `{"name":" "}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "PATCH /api/tags/{id} - unknown ID returns 404",
+			method:         "PATCH",
+			path:           "/api/tags/999",
+			body:           `{"name":"office"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "POST /api/tags/{id}/merge - returns the kept tag",
+			method:         "POST",
+			path:           "/api/tags/1/merge",
+			body:           `{"into":2}`,
+			expectedStatus: http.StatusOK,
+			expectedFields: []string{"id", "name", "todo_count"},
+		},
+		{
+			name:           "POST /api/tags/{id}/merge - into itself returns bad request",
+			method:         "POST",
+			path:           "/api/tags/1/merge",
+			body:           `{"into":1}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "POST /api/tags/{id}/merge - missing into returns bad request",
+			method:         "POST",
+			path:           "/api/tags/1/merge",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "POST /api/tags/{id}/merge - invalid ID returns bad request",
+			method:         "POST",
+			path:           "/api/tags/abc/merge",
+			body:           `{"into":2}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewInMemoryTodoRepository()
+			svc := service.NewTodoService(repo)
+			tags := handler.NewTagHandler(svc)
+
+			// Tag 1 is "work", tag 2 is "home"
+			_, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo", Tags: []string{"work"}})
+			require.NoError(t, err)
+			_, err = svc.CreateTodo(ctx, model.TodoInput{Text: "todo", Tags: []string{"home"}})
+			require.NoError(t, err)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/tags", tags.GetTags)
+			mux.HandleFunc("PATCH /api/tags/{id}", tags.RenameTag)
+			mux.HandleFunc("POST /api/tags/{id}/merge", tags.MergeTag)
+
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if len(tt.expectedFields) > 0 {
+				var response map[string]interface{}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				for _, field := range tt.expectedFields {
+					assert.Contains(t, response, field, "Field %s should be present", field)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPI_TestSupportEndpoints tests the /api/test contract and its shared secret
+func TestAPI_TestSupportEndpoints(t *testing.T) {
+	repo := repository.NewInMemoryTodoRepository()
+	workspaces := service.NewWorkspaceService(repo, repository.SharedWorkspaces(repo))
+	authSvc := service.NewAuthService(repo, workspaces)
+	todoSvc := service.NewTodoService(repo)
+	auth := handler.NewAuthHandler(authSvc, workspaces, false)
+	todos := handler.NewTodoHandler(todoSvc)
+	testSupport := handler.NewTestSupportHandler(service.NewTestSupportService(repo, authSvc, workspaces, service.NewListService(repo), todoSvc), "s3cret")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/todos", todos.GetAllTodos)
+	mux.HandleFunc("POST /api/test/truncate", testSupport.Truncate)
+	mux.HandleFunc("POST /api/test/seed", testSupport.Seed)
+	mux.HandleFunc("GET /api/test/clock", testSupport.GetClock)
+	mux.HandleFunc("PUT /api/test/clock", testSupport.FreezeClock)
+	mux.HandleFunc("DELETE /api/test/clock", testSupport.UnfreezeClock)
+	server := auth.RequireAuth(mux)
+
+	serve := func(method, path, body, secret string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if secret != "" {
+			req.Header.Set(handler.TestSecretHeader, secret)
+		}
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Seeding returns the users with a session, the lists and the todos
+	rec := serve("POST", "/api/test/seed", `{
+		"users": [{"username": "alice", "password": "correct horse battery"}],
+		"lists": [{"owner": "alice", "name": "Work"}],
+		"todos": [{"owner": "alice", "list": "Work", "text": "Write the report", "tags": ["work"]}, {"owner": "alice", "text": "Buy milk"}]
+	}`, "s3cret", nil)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var seeded struct {
+		Users []map[string]interface{} `json:"users"`
+		Lists []map[string]interface{} `json:"lists"`
+		Todos []map[string]interface{} `json:"todos"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&seeded))
+	require.Len(t, seeded.Users, 1)
+	for _, field := range []string{"id", "username", "session"} {
+		assert.Contains(t, seeded.Users[0], field)
+	}
+	require.Len(t, seeded.Lists, 1)
+	require.Len(t, seeded.Todos, 2)
+	assert.Equal(t, seeded.Lists[0]["id"], seeded.Todos[0]["list_id"])
+
+	// The seeded session signs in as the seeded user
+	session := &http.Cookie{Name: handler.SessionCookie, Value: seeded.Users[0]["session"].(string)}
+	rec = serve("GET", "/api/todos?legacy=true", "", "", session)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var listed []map[string]interface{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&listed))
+	assert.Len(t, listed, 2)
+
+	// Freezing the clock reports the frozen time
+	rec = serve("PUT", "/api/test/clock", `{"now":"2026-03-02T09:00:00Z"}`, "s3cret", nil)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var clock map[string]interface{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&clock))
+	assert.Equal(t, map[string]interface{}{"now": "2026-03-02T09:00:00Z", "frozen": true}, clock)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		secret         string
+		expectedStatus int
+	}{
+		{name: "POST /api/test/truncate - without the secret returns forbidden", method: "POST", path: "/api/test/truncate", expectedStatus: http.StatusForbidden},
+		{name: "POST /api/test/truncate - wrong secret returns forbidden", method: "POST", path: "/api/test/truncate", secret: "guess", expectedStatus: http.StatusForbidden},
+		{name: "POST /api/test/seed - without the secret returns forbidden", method: "POST", path: "/api/test/seed", body: `{}`, expectedStatus: http.StatusForbidden},
+		{name: "PUT /api/test/clock - without the secret returns forbidden", method: "PUT", path: "/api/test/clock", body: `{"now":"2026-03-02T09:00:00Z"}`, expectedStatus: http.StatusForbidden},
+		{name: "POST /api/test/seed - unknown owner returns bad request", method: "POST", path: "/api/test/seed", body: `{"todos":[{"owner":"bob","text":"Walk the dog"}]}`, secret: "s3cret", expectedStatus: http.StatusBadRequest},
+		{name: "POST /api/test/seed - unknown list returns bad request", method: "POST", path: "/api/test/seed", body: `{"todos":[{"owner":"alice","list":"Home","text":"Walk the dog"}]}`, secret: "s3cret", expectedStatus: http.StatusBadRequest},
+		{name: "POST /api/test/seed - taken username returns conflict", method: "POST", path: "/api/test/seed", body: `{"users":[{"username":"alice","password":"correct horse battery"}]}`, secret: "s3cret", expectedStatus: http.StatusConflict},
+		{name: "PUT /api/test/clock - missing time returns bad request", method: "PUT", path: "/api/test/clock", body: `{}`, secret: "s3cret", expectedStatus: http.StatusBadRequest},
+		{name: "GET /api/test/clock - returns the clock", method: "GET", path: "/api/test/clock", secret: "s3cret", expectedStatus: http.StatusOK},
+		{name: "DELETE /api/test/clock - unfreezes the clock", method: "DELETE", path: "/api/test/clock", secret: "s3cret", expectedStatus: http.StatusNoContent},
+		{name: "POST /api/test/truncate - with the secret truncates", method: "POST", path: "/api/test/truncate", secret: "s3cret", expectedStatus: http.StatusNoContent},
+		{name: "GET /api/todos - seeded session is gone after truncating", method: "GET", path: "/api/todos", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cookie *http.Cookie
+			if tt.path == "/api/todos" {
+				cookie = session
+			}
+			rec := serve(tt.method, tt.path, tt.body, tt.secret, cookie)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code, rec.Body.String())
+		})
+	}
+
+	// Without a secret configured every request is refused
+	locked := handler.NewTestSupportHandler(service.NewTestSupportService(repo, authSvc, workspaces, service.NewListService(repo), todoSvc), "")
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/api/test/truncate", nil)
+	req.Header.Set(handler.TestSecretHeader, "")
+	locked.Truncate(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
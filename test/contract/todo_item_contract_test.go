@@ -0,0 +1,485 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"todo-app/internal/filterlang"
+	"todo-app/internal/handler"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPI_TodoItemEndpoints tests the /api/todos/{id} contract
+func TestAPI_TodoItemEndpoints(t *testing.T) {
+	ctx := t.Context()
+	tests := []struct {
+		name           string
+		method         string
+		id             string
+		action         string
+		body           string
+		expectedStatus int
+		expectedFields []string
+	}{
+		{
+			name:           "GET /api/todos/{id} - returns todo structure",
+			method:         "GET",
+			id:             "1",
+			expectedStatus: http.StatusOK,
+			expectedFields: []string{"id", "text", "list_id", "completed", "completed_at", "priority", "due_at", "due_all_day", "tags", "created_at", "updated_at"},
+		},
+		{
+			name:           "PUT /api/todos/{id} - returns updated todo structure",
+			method:         "PUT",
+			id:             "1",
+			body:           `{"text":"updated todo"}`,
+			expectedStatus: http.StatusOK,
+			expectedFields: []string{"id", "text", "list_id", "completed", "completed_at", "priority", "due_at", "due_all_day", "tags", "created_at", "updated_at"},
+		},
+		{
+			name:           "PATCH /api/todos/{id} - returns updated todo structure",
+			method:         "PATCH",
+			id:             "1",
+			body:           `{"text":"patched todo"}`,
+			expectedStatus: http.StatusOK,
+			expectedFields: []string{"id", "text", "list_id", "completed", "completed_at", "priority", "due_at", "due_all_day", "tags", "created_at", "updated_at"},
+		},
+		{
+			name:           "DELETE /api/todos/{id} - returns no content",
+			method:         "DELETE",
+			id:             "1",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "POST /api/todos/{id}/complete - returns completed todo structure",
+			method:         "POST",
+			id:             "1",
+			action:         "/complete",
+			expectedStatus: http.StatusOK,
+			expectedFields: []string{"id", "text", "list_id", "completed", "completed_at", "priority", "due_at", "due_all_day", "tags", "created_at", "updated_at"},
+		},
+		{
+			name:           "POST /api/todos/{id}/reopen - returns reopened todo structure",
+			method:         "POST",
+			id:             "1",
+			action:         "/reopen",
+			expectedStatus: http.StatusOK,
+			expectedFields: []string{"id", "text", "list_id", "completed", "completed_at", "priority", "due_at", "due_all_day", "tags", "created_at", "updated_at"},
+		},
+		{
+			name:           "POST /api/todos/{id}/complete - unknown ID returns 404",
+			method:         "POST",
+			id:             "999",
+			action:         "/complete",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "POST /api/todos/{id}/reopen - malformed ID returns 400",
+			method:         "POST",
+			id:             "abc",
+			action:         "/reopen",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "GET /api/todos/{id} - unknown ID returns 404",
+			method:         "GET",
+			id:             "999",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "PUT /api/todos/{id} - unknown ID returns 404",
+			method:         "PUT",
+			id:             "999",
+			body:           `{"text":"updated todo"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "PATCH /api/todos/{id} - unknown ID returns 404",
+			method:         "PATCH",
+			id:             "999",
+			body:           `{"text":"patched todo"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "DELETE /api/todos/{id} - unknown ID returns 404",
+			method:         "DELETE",
+			id:             "999",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "GET /api/todos/{id} - malformed ID returns 400",
+			method:         "GET",
+			id:             "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "PUT /api/todos/{id} - malformed ID returns 400",
+			method:         "PUT",
+			id:             "abc",
+			body:           `{"text":"updated todo"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "DELETE /api/todos/{id} - malformed ID returns 400",
+			method:         "DELETE",
+			id:             "-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "PUT /api/todos/{id} - empty text returns 400",
+			method:         "PUT",
+			id:             "1",
+			body:           `{"text":""}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			repo, err := repository.NewSQLiteTodoRepository(":memory:")
+			require.NoError(t, err)
+			defer repo.Close()
+
+			svc := service.NewTodoService(repo)
+			h := handler.NewTodoHandler(svc)
+
+			_, err = svc.CreateTodo(ctx, model.TodoInput{Text: "test todo"})
+			require.NoError(t, err)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/todos/{id}", h.GetTodo)
+			mux.HandleFunc("PUT /api/todos/{id}", h.UpdateTodo)
+			mux.HandleFunc("PATCH /api/todos/{id}", h.PatchTodo)
+			mux.HandleFunc("DELETE /api/todos/{id}", h.DeleteTodo)
+			mux.HandleFunc("POST /api/todos/{id}/complete", h.CompleteTodo)
+			mux.HandleFunc("POST /api/todos/{id}/reopen", h.ReopenTodo)
+
+			// Prepare request
+			req := httptest.NewRequest(tt.method, "/api/todos/"+tt.id+tt.action, bytes.NewBufferString(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+
+			// Execute
+			mux.ServeHTTP(rec, req)
+
+			// Verify
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if len(tt.expectedFields) > 0 {
+				assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+				var response map[string]interface{}
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				require.NoError(t, err)
+
+				for _, field := range tt.expectedFields {
+					assert.Contains(t, response, field, "Field %s should be present", field)
+				}
+			}
+		})
+	}
+}
+
+// TestAPI_CompletedFilter tests the ?completed= query parameter contract
+func TestAPI_CompletedFilter(t *testing.T) {
+	ctx := t.Context()
+	repo, err := repository.NewSQLiteTodoRepository(":memory:")
+	require.NoError(t, err)
+	defer repo.Close()
+
+	svc := service.NewTodoService(repo)
+	h := handler.NewTodoHandler(svc)
+
+	done, err := svc.CreateTodo(ctx, model.TodoInput{Text: "done todo"})
+	require.NoError(t, err)
+	_, err = svc.CompleteTodo(ctx, done.ID)
+	require.NoError(t, err)
+	_, err = svc.CreateTodo(ctx, model.TodoInput{Text: "open todo"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		query          string
+		expectedStatus int
+		expectedTexts  []string
+	}{
+		{query: "", expectedStatus: http.StatusOK, expectedTexts: []string{"open todo", "done todo"}},
+		{query: "?completed=true", expectedStatus: http.StatusOK, expectedTexts: []string{"done todo"}},
+		{query: "?completed=false", expectedStatus: http.StatusOK, expectedTexts: []string{"open todo"}},
+		{query: "?completed=yes", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run("GET /api/todos"+tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/todos"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			h.GetAllTodos(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var page struct {
+				Data []map[string]interface{} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+			todos := page.Data
+			texts := make([]string, len(todos))
+			for i, todo := range todos {
+				texts[i] = todo["text"].(string)
+			}
+			assert.Equal(t, tt.expectedTexts, texts)
+		})
+	}
+}
+
+// TestAPI_Sort tests the ?sort= query parameter contract
+func TestAPI_Sort(t *testing.T) {
+	ctx := t.Context()
+	repo, err := repository.NewSQLiteTodoRepository(":memory:")
+	require.NoError(t, err)
+	defer repo.Close()
+
+	svc := service.NewTodoService(repo)
+	h := handler.NewTodoHandler(svc)
+
+	for _, input := range []model.TodoInput{
+		{Text: "buy milk", Priority: "low"},
+		{Text: "Answer email", Priority: "urgent", DueAt: "2026-11-02"},
+		{Text: "call mom", Priority: "high", DueAt: "2026-11-01"},
+	} {
+		_, err := svc.CreateTodo(ctx, input)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		query          string
+		expectedStatus int
+		expectedTexts  []string
+	}{
+		{query: "?sort=priority:desc", expectedStatus: http.StatusOK, expectedTexts: []string{"Answer email", "call mom", "buy milk"}},
+		{query: "?sort=due_at", expectedStatus: http.StatusOK, expectedTexts: []string{"call mom", "Answer email", "buy milk"}},
+		{query: "?sort=text:asc", expectedStatus: http.StatusOK, expectedTexts: []string{"Answer email", "buy milk", "call mom"}},
+		{query: "?sort=created_at:asc,priority", expectedStatus: http.StatusOK, expectedTexts: []string{"buy milk", "Answer email", "call mom"}},
+		{query: "?sort=size", expectedStatus: http.StatusBadRequest},
+		{query: "?sort=priority:up", expectedStatus: http.StatusBadRequest},
+		{query: "?sort=text,text", expectedStatus: http.StatusBadRequest},
+		{query: "?sort=", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run("GET /api/todos"+tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/todos"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			h.GetAllTodos(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				assert.Contains(t, rec.Body.String(), "invalid sort")
+				return
+			}
+
+			var page struct {
+				Data []map[string]interface{} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+			todos := page.Data
+			texts := make([]string, len(todos))
+			for i, todo := range todos {
+				texts[i] = todo["text"].(string)
+			}
+			assert.Equal(t, tt.expectedTexts, texts)
+		})
+	}
+}
+
+// TestAPI_Pagination tests the paging contract of GET /api/todos
+func TestAPI_Pagination(t *testing.T) {
+	ctx := t.Context()
+	repo, err := repository.NewSQLiteTodoRepository(":memory:")
+	require.NoError(t, err)
+	defer repo.Close()
+
+	svc := service.NewTodoService(repo)
+	h := handler.NewTodoHandler(svc)
+
+	for _, text := range []string{"todo 1", "todo 2", "todo 3"} {
+		_, err := svc.CreateTodo(ctx, model.TodoInput{Text: text})
+		require.NoError(t, err)
+	}
+	sortedByText := model.NewCursor(&model.Todo{ID: 1, Text: "todo 1"}, []model.SortKey{{Field: model.SortText}}, false).Encode()
+
+	tests := []struct {
+		query          string
+		expectedStatus int
+		expectedFields []string
+		expectedLink   string
+	}{
+		{query: "", expectedStatus: http.StatusOK, expectedFields: []string{"data"}},
+		{query: "?limit=2", expectedStatus: http.StatusOK, expectedFields: []string{"data", "next_cursor"}, expectedLink: `rel="next"`},
+		{query: "?limit=0", expectedStatus: http.StatusBadRequest},
+		{query: "?limit=501", expectedStatus: http.StatusBadRequest},
+		{query: "?limit=ten", expectedStatus: http.StatusBadRequest},
+		{query: "?cursor=not-a-cursor", expectedStatus: http.StatusBadRequest},
+		{query: "?cursor=" + sortedByText, expectedStatus: http.StatusBadRequest},
+		{query: "?sort=text&cursor=" + sortedByText, expectedStatus: http.StatusOK, expectedFields: []string{"data", "prev_cursor"}, expectedLink: `rel="prev"`},
+		{query: "?legacy=true&limit=2", expectedStatus: http.StatusBadRequest},
+		{query: "?legacy=maybe", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run("GET /api/todos"+tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/todos"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			h.GetAllTodos(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			for _, field := range tt.expectedFields {
+				assert.Contains(t, response, field, "Field %s should be present", field)
+			}
+			assert.Contains(t, rec.Header().Get("Link"), tt.expectedLink)
+		})
+	}
+
+	t.Run("GET /api/todos?legacy=true - returns a bare array", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/todos?legacy=true", nil)
+		rec := httptest.NewRecorder()
+
+		h.GetAllTodos(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var todos []map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &todos))
+		assert.Len(t, todos, 3)
+		assert.Empty(t, rec.Header().Get("Link"))
+	})
+}
+
+// TestAPI_Search tests the contract of GET /api/todos/search
+func TestAPI_Search(t *testing.T) {
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	for _, text := range []string{"Buy milk", "Buy bread"} {
+		_, err := svc.CreateTodo(ctx, model.TodoInput{Text: text})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		query          string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{query: "?q=milk", expectedStatus: http.StatusOK, expectedCount: 1},
+		{query: "?q=buy&limit=1", expectedStatus: http.StatusOK, expectedCount: 1},
+		{query: "?q=eggs", expectedStatus: http.StatusOK, expectedCount: 0},
+		{query: "", expectedStatus: http.StatusBadRequest},
+		{query: "?q=%20", expectedStatus: http.StatusBadRequest},
+		{query: "?q=milk%20AND", expectedStatus: http.StatusBadRequest},
+		{query: "?q=milk&limit=0", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run("GET /api/todos/search"+tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/todos/search"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			h.SearchTodos(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data []map[string]interface{} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			require.Len(t, response.Data, tt.expectedCount)
+			for _, result := range response.Data {
+				for _, field := range []string{"id", "text", "tags", "snippet", "rank"} {
+					assert.Contains(t, result, field, "Field %s should be present", field)
+				}
+			}
+		})
+	}
+}
+
+// TestAPI_Filter tests the ?filter= contract of GET /api/todos
+func TestAPI_Filter(t *testing.T) {
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	_, err := svc.CreateTodo(ctx, model.TodoInput{Text: "report", Tags: []string{"work"}, Priority: "high"})
+	require.NoError(t, err)
+	_, err = svc.CreateTodo(ctx, model.TodoInput{Text: "groceries", Tags: []string{"home"}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		filter         string
+		expectedStatus int
+		expectedTexts  []string
+		expectedError  string
+	}{
+		{filter: "tag:work AND priority>=high", expectedStatus: http.StatusOK, expectedTexts: []string{"report"}},
+		{filter: "NOT tag:work", expectedStatus: http.StatusOK, expectedTexts: []string{"groceries"}},
+		{filter: "", expectedStatus: http.StatusBadRequest, expectedError: "invalid filter: filter is empty"},
+		{filter: "tag:work AND", expectedStatus: http.StatusBadRequest, expectedError: "at position 13"},
+		{filter: "priority>=hgh", expectedStatus: http.StatusBadRequest, expectedError: "at position 11"},
+	}
+
+	for _, tt := range tests {
+		t.Run("GET /api/todos?filter="+tt.filter, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/todos?filter="+url.QueryEscape(tt.filter), nil)
+			rec := httptest.NewRecorder()
+
+			h.GetAllTodos(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				assert.Contains(t, rec.Body.String(), tt.expectedError)
+				return
+			}
+
+			var page model.TodoPage
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+			texts := make([]string, len(page.Todos))
+			for i, todo := range page.Todos {
+				texts[i] = todo.Text
+			}
+			assert.Equal(t, tt.expectedTexts, texts)
+		})
+	}
+
+	// A filter too large for the database is a client error too
+	huge := strings.Repeat("tag:work OR ", 1100) + "tag:home"
+	rec := httptest.NewRecorder()
+	h.GetAllTodos(rec, httptest.NewRequest("GET", "/api/todos?filter="+url.QueryEscape(huge), nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), fmt.Sprintf("filter has more than %d comparisons", filterlang.MaxComparisons))
+}
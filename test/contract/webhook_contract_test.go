@@ -0,0 +1,168 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPI_WebhookEndpoints tests the /api/webhooks contract
+func TestAPI_WebhookEndpoints(t *testing.T) {
+	ctx := t.Context()
+	webhookFields := []string{"id", "url", "event_types", "created_at", "updated_at"}
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		body             string
+		expectedStatus   int
+		expectedFields   []string
+		unexpectedFields []string
+	}{
+		{
+			name:           "POST /api/webhooks - returns created webhook with its secret",
+			method:         "POST",
+			path:           "/api/webhooks",
+			body:           `{"url":"https://example.com/hook","event_types":["created"]}`,
+			expectedStatus: http.StatusCreated,
+			expectedFields: append([]string{"secret"}, webhookFields...),
+		},
+		{
+			name:           "POST /api/webhooks - invalid URL returns bad request",
+			method:         "POST",
+			path:           "/api/webhooks",
+			body:           `{"url":"not a url"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "POST /api/webhooks - unknown event type returns bad request",
+			method:         "POST",
+			path:           "/api/webhooks",
+			body:           `{"url":"https://example.com","event_types":["archived"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "GET /api/webhooks - returns webhooks",
+			method:         "GET",
+			path:           "/api/webhooks",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:             "GET /api/webhooks/{id} - returns webhook structure without the secret",
+			method:           "GET",
+			path:             "/api/webhooks/1",
+			expectedStatus:   http.StatusOK,
+			expectedFields:   webhookFields,
+			unexpectedFields: []string{"secret"},
+		},
+		{
+			name:           "GET /api/webhooks/{id} - unknown ID returns 404",
+			method:         "GET",
+			path:           "/api/webhooks/999",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "GET /api/webhooks/{id} - invalid ID returns bad request",
+			method:         "GET",
+			path:           "/api/webhooks/abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:             "PUT /api/webhooks/{id} - returns replaced webhook structure",
+			method:           "PUT",
+			path:             "/api/webhooks/1",
+			body:             `{"url":"https://example.com/v2"}`,
+			expectedStatus:   http.StatusOK,
+			expectedFields:   webhookFields,
+			unexpectedFields: []string{"secret"},
+		},
+		{
+			name:           "PUT /api/webhooks/{id} - unknown ID returns 404",
+			method:         "PUT",
+			path:           "/api/webhooks/999",
+			body:           `{"url":"https://example.com"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "DELETE /api/webhooks/{id} - returns no content",
+			method:         "DELETE",
+			path:           "/api/webhooks/1",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "DELETE /api/webhooks/{id} - unknown ID returns 404",
+			method:         "DELETE",
+			path:           "/api/webhooks/999",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "GET /api/webhooks/{id}/deliveries - returns deliveries",
+			method:         "GET",
+			path:           "/api/webhooks/1/deliveries?limit=10",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "GET /api/webhooks/{id}/deliveries - invalid limit returns bad request",
+			method:         "GET",
+			path:           "/api/webhooks/1/deliveries?limit=0",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "GET /api/webhooks/{id}/deliveries - unknown ID returns 404",
+			method:         "GET",
+			path:           "/api/webhooks/999/deliveries",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewInMemoryTodoRepository()
+			svc := service.NewWebhookService(repo)
+			webhooks := handler.NewWebhookHandler(svc)
+
+			// Webhook 1 listens to every change
+			_, err := svc.CreateWebhook(ctx, model.WebhookInput{URL: "https://example.com"})
+			require.NoError(t, err)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("POST /api/webhooks", webhooks.CreateWebhook)
+			mux.HandleFunc("GET /api/webhooks", webhooks.GetWebhooks)
+			mux.HandleFunc("GET /api/webhooks/{id}", webhooks.GetWebhook)
+			mux.HandleFunc("PUT /api/webhooks/{id}", webhooks.UpdateWebhook)
+			mux.HandleFunc("DELETE /api/webhooks/{id}", webhooks.DeleteWebhook)
+			mux.HandleFunc("GET /api/webhooks/{id}/deliveries", webhooks.GetDeliveries)
+
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if len(tt.expectedFields) > 0 {
+				var response map[string]interface{}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				for _, field := range tt.expectedFields {
+					assert.Contains(t, response, field, "Field %s should be present", field)
+				}
+				for _, field := range tt.unexpectedFields {
+					assert.NotContains(t, response, field, "Field %s should not be present", field)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package contract
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAPI_WorkspaceEndpoints tests GET /api/workspace and registering into a workspace
+func TestAPI_WorkspaceEndpoints(t *testing.T) {
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
+	workspaces := service.NewWorkspaceService(repo, repository.SharedWorkspaces(repo))
+	acme, err := workspaces.CreateWorkspace(ctx, model.WorkspaceInput{Slug: "acme", Name: "Acme Corp"})
+	require.NoError(t, err)
+	on := true
+	_, err = workspaces.UpdateSettings(ctx, acme.ID, model.WorkspaceSettingsPatch{Registration: &on})
+	require.NoError(t, err)
+	_, err = workspaces.CreateWorkspace(ctx, model.WorkspaceInput{Slug: "initech", Name: "Initech"})
+	require.NoError(t, err)
+	auth := handler.NewAuthHandler(service.NewAuthService(repo, workspaces), workspaces, false)
+	workspace := handler.NewWorkspaceHandler(workspaces)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/register", auth.Register)
+	mux.Handle("GET /api/workspace", handler.Scoped(model.ScopeTodosRead, workspace.GetWorkspace))
+	server := auth.RequireAuth(mux)
+
+	serve := func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Registering into a workspace by its slug returns the user with the workspace ID
+	rec := serve("POST", "/api/auth/register", `{"username":"alice","password":"correct horse battery","workspace":"acme"}`, nil)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var user map[string]interface{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&user))
+	assert.Contains(t, user, "workspace_id")
+	session := rec.Result().Cookies()[0]
+
+	// The workspace of the signed-in user comes with its settings
+	rec = serve("GET", "/api/workspace", "", session)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	for _, field := range []string{"id", "slug", "name", "settings", "created_at", "updated_at"} {
+		assert.Contains(t, body, field)
+	}
+	assert.Equal(t, user["workspace_id"], body["id"])
+	assert.Equal(t, "acme", body["slug"])
+	assert.Equal(t, map[string]interface{}{"sharing": true, "webhooks": true, "registration": true}, body["settings"])
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		cookie         *http.Cookie
+		expectedStatus int
+	}{
+		{name: "GET /api/workspace - without a session returns unauthorized", method: "GET", path: "/api/workspace", expectedStatus: http.StatusUnauthorized},
+		{name: "POST /api/auth/register - unknown workspace returns bad request", method: "POST", path: "/api/auth/register", body: `{"username":"bob","password":"correct horse battery","workspace":"globex"}`, expectedStatus: http.StatusBadRequest},
+		{name: "POST /api/auth/register - workspace closed to registration returns forbidden", method: "POST", path: "/api/auth/register", body: `{"username":"dave","password":"correct horse battery","workspace":"initech"}`, expectedStatus: http.StatusForbidden},
+		{name: "POST /api/auth/register - without a workspace joins the default one", method: "POST", path: "/api/auth/register", body: `{"username":"carol","password":"correct horse battery"}`, expectedStatus: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(tt.method, tt.path, tt.body, tt.cookie)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code, rec.Body.String())
+		})
+	}
+}
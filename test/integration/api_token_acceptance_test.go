@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPITokens_UserStory(t *testing.T) {
+	// Given: Alice, signed in in the browser, creates a token for the team's CI
+	server := setupTestServer(t)
+	t.Cleanup(server.Close)
+	alice := signUp(t, server, "alice")
+
+	resp, err := alice.Post(server.URL+"/api/tokens", "application/json", bytes.NewBufferString(`{"name":"CI","scopes":["todos:read","todos:write"]}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var ci map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&ci))
+	resp.Body.Close()
+
+	// And: One for a dashboard that only reads
+	resp, err = alice.Post(server.URL+"/api/tokens", "application/json", bytes.NewBufferString(`{"name":"Dashboard","scopes":["todos:read"]}`))
+	require.NoError(t, err)
+	var dashboard map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&dashboard))
+	resp.Body.Close()
+
+	// When: The CI script adds a todo with its token, as curl would
+	resp = callWithToken(t, ci["token"], "POST", server.URL+"/api/todos", `{"text":"Fix the flaky build"}`)
+	resp.Body.Close()
+
+	// Then: It is Alice's todo
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	todos := getTodos(t, alice, server.URL+"/api/todos")
+	require.Len(t, todos, 1)
+	assert.Equal(t, "Fix the flaky build", todos[0]["text"])
+
+	// And: The dashboard can read it but not change it
+	resp = callWithToken(t, dashboard["token"], "GET", server.URL+"/api/todos", "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = callWithToken(t, dashboard["token"], "DELETE", fmt.Sprintf("%s/api/todos/%v", server.URL, todos[0]["id"]), "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(server.URL, "http")+"/api/ws", &websocket.DialOptions{
+		HTTPHeader: http.Header{"Authorization": {"Bearer " + dashboard["token"].(string)}},
+	})
+	require.NoError(t, err)
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	require.NoError(t, wsjson.Write(ctx, conn, map[string]interface{}{"type": "create", "request_id": "1", "todo": map[string]string{"text": "Sneak in"}}))
+	var reply map[string]interface{}
+	require.NoError(t, wsjson.Read(ctx, conn, &reply))
+	assert.Equal(t, "error", reply["type"])
+	assert.Equal(t, float64(http.StatusForbidden), reply["status"])
+
+	// And: Alice sees when each token was last used
+	var tokens []map[string]interface{}
+	getJSON(t, alice, server.URL+"/api/tokens", &tokens)
+	require.Len(t, tokens, 2)
+	assert.NotNil(t, tokens[0]["last_used_at"])
+	assert.NotContains(t, tokens[0], "token")
+
+	// When: Alice revokes the CI token
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/tokens/%v", server.URL, ci["id"]), nil)
+	resp, err = alice.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Then: The script is locked out
+	resp = callWithToken(t, ci["token"], "GET", server.URL+"/api/todos", "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+// callWithToken sends a request authenticated with an API token
+func callWithToken(t *testing.T, token interface{}, method, url, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", token))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
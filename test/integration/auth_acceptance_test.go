@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccounts_UserStory(t *testing.T) {
+	// Given: Two people with their own accounts
+	server := setupTestServer(t)
+	defer server.Close()
+	alice := signUp(t, server, "alice")
+	bob := signUp(t, server, "bob")
+
+	// When: Alice adds a todo
+	resp, err := alice.Post(server.URL+"/api/todos", "application/json", bytes.NewBufferString(`{"text":"Plan the surprise party","tags":["party"]}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var todo map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&todo))
+	resp.Body.Close()
+	todoURL := fmt.Sprintf("%s/api/todos/%v", server.URL, todo["id"])
+
+	// Then: Only Alice sees it; to Bob it does not exist
+	assert.Len(t, getTodos(t, alice, server.URL+"/api/todos"), 1)
+	assert.Empty(t, getTodos(t, bob, server.URL+"/api/todos"))
+	var tags []map[string]interface{}
+	getJSON(t, bob, server.URL+"/api/tags", &tags)
+	assert.Empty(t, tags)
+
+	resp, err = bob.Get(todoURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	req, _ := http.NewRequest("DELETE", todoURL, nil)
+	resp, err = bob.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// And: Without signing in the API answers 401
+	resp, err = http.Get(server.URL + "/api/todos")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// When: Alice signs out
+	resp, err = alice.Post(server.URL+"/api/auth/logout", "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Then: Her session no longer works
+	resp, err = alice.Get(server.URL + "/api/todos")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// When: She signs in again on another device, mistyping her password first
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	device := &http.Client{Jar: jar}
+
+	resp, err = device.Post(server.URL+"/api/auth/login", "application/json", bytes.NewBufferString(`{"username":"alice","password":"wrong horse battery"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = device.Post(server.URL+"/api/auth/login", "application/json", bytes.NewBufferString(`{"username":"Alice","password":"correct horse battery"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Then: Her todo is waiting for her
+	var me map[string]interface{}
+	getJSON(t, device, server.URL+"/api/auth/me", &me)
+	assert.Equal(t, "alice", me["username"])
+	todos := getTodos(t, device, server.URL+"/api/todos")
+	require.Len(t, todos, 1)
+	assert.Equal(t, "Plan the surprise party", todos[0]["text"])
+}
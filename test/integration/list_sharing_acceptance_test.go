@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareList_UserStory(t *testing.T) {
+	// Given: Alice with a Trip list holding one todo, Bob and Carol
+	server := setupTestServer(t)
+	defer server.Close()
+	alice := signUp(t, server, "alice")
+	bob := signUp(t, server, "bob")
+	carol := signUp(t, server, "carol")
+
+	trip := postJSON(t, alice, server.URL+"/api/lists", `{"name":"Trip"}`, http.StatusCreated)
+	listURL := fmt.Sprintf("%s/api/lists/%v", server.URL, trip["id"])
+	tickets := postJSON(t, alice, server.URL+"/api/todos", fmt.Sprintf(`{"text":"Book tickets","list_id":%v}`, trip["id"]), http.StatusCreated)
+	postJSON(t, alice, server.URL+"/api/todos", `{"text":"Call mom"}`, http.StatusCreated)
+
+	// When: She shares it with Bob as an editor and Carol as a viewer
+	postJSON(t, alice, listURL+"/members", `{"username":"bob","role":"editor"}`, http.StatusCreated)
+	postJSON(t, alice, listURL+"/members", `{"username":"carol","role":"viewer"}`, http.StatusCreated)
+
+	// Then: Bob finds the invitation, and accepting it gives him the list
+	var invitations []map[string]interface{}
+	getJSON(t, bob, server.URL+"/api/invitations", &invitations)
+	require.Len(t, invitations, 1)
+	assert.Equal(t, "Trip", invitations[0]["list_name"])
+	assert.Equal(t, "editor", invitations[0]["role"])
+
+	joined := postJSON(t, bob, fmt.Sprintf("%s/api/invitations/%v/accept", server.URL, trip["id"]), "", http.StatusOK)
+	assert.Equal(t, "editor", joined["role"])
+	assert.Equal(t, float64(1), joined["todo_count"])
+	postJSON(t, carol, fmt.Sprintf("%s/api/invitations/%v/accept", server.URL, trip["id"]), "", http.StatusOK)
+
+	// And: He sees the list's todo, but not Alice's inbox
+	todos := getTodos(t, bob, server.URL+"/api/todos")
+	require.Len(t, todos, 1)
+	assert.Equal(t, "Book tickets", todos[0]["text"])
+
+	// When: Bob adds a todo and completes Alice's
+	postJSON(t, bob, server.URL+"/api/todos", fmt.Sprintf(`{"text":"Pack bags","list_id":%v}`, trip["id"]), http.StatusCreated)
+	postJSON(t, bob, fmt.Sprintf("%s/api/todos/%v/complete", server.URL, tickets["id"]), "", http.StatusOK)
+
+	// Then: Alice sees both changes
+	todos = getTodos(t, alice, listURL+"/todos")
+	require.Len(t, todos, 2)
+	for _, todo := range todos {
+		if todo["text"] == "Book tickets" {
+			assert.Equal(t, true, todo["completed"])
+		}
+	}
+
+	// And: Carol can read but not change the list
+	assert.Len(t, getTodos(t, carol, listURL+"/todos"), 2)
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/api/todos/%v", server.URL, tickets["id"]), nil)
+	resp, err := carol.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// And: Only Alice manages the list
+	req, _ = http.NewRequest("DELETE", listURL, nil)
+	resp, err = bob.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	var members []map[string]interface{}
+	getJSON(t, carol, listURL+"/members", &members)
+	require.Len(t, members, 3)
+	assert.Equal(t, "alice", members[0]["username"])
+	assert.Equal(t, "owner", members[0]["role"])
+
+	// When: Alice removes Carol
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("%s/members/%v", listURL, members[2]["user_id"]), nil)
+	resp, err = alice.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Then: To Carol the list no longer exists
+	resp, err = carol.Get(listURL + "/todos")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Empty(t, getTodos(t, carol, server.URL+"/api/todos"))
+}
+
+// postJSON posts body to url, checks the status and returns the decoded response, if any
+func postJSON(t *testing.T, client *http.Client, url, body string, expectedStatus int) map[string]interface{} {
+	t.Helper()
+	var resp *http.Response
+	var err error
+	if body == "" {
+		resp, err = client.Post(url, "", nil)
+	} else {
+		resp, err = client.Post(url, "application/json", bytes.NewBufferString(body))
+	}
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, expectedStatus, resp.StatusCode)
+
+	var v map[string]interface{}
+	if resp.StatusCode != http.StatusNoContent {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&v))
+	}
+	return v
+}
@@ -1,15 +1,30 @@
 package integration
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/http/cookiejar"
 	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"todo-app/internal/handler"
+	"todo-app/internal/logging"
+	"todo-app/internal/metrics"
+	"todo-app/internal/model"
 	"todo-app/internal/repository"
 	"todo-app/internal/service"
+	"todo-app/internal/webhook"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,6 +35,7 @@ func TestAddTodoItem_UserStory(t *testing.T) {
 	// Given: Empty todo list
 	server := setupTestServer(t)
 	defer server.Close()
+	client := signUp(t, server, "alice")
 
 	// When: User adds "süt al" todo
 	todoData := map[string]string{
@@ -27,32 +43,75 @@ func TestAddTodoItem_UserStory(t *testing.T) {
 	}
 	jsonData, _ := json.Marshal(todoData)
 
-	response, err := http.Post(server.URL+"/api/todos", "application/json", bytes.NewBuffer(jsonData))
+	response, err := client.Post(server.URL+"/api/todos", "application/json", bytes.NewBuffer(jsonData))
 
 	// Then: Todo should be created successfully
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusCreated, response.StatusCode)
 
 	// And: Todo should appear in the list
-	listResponse, err := http.Get(server.URL + "/api/todos")
+	listResponse, err := client.Get(server.URL + "/api/todos")
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, listResponse.StatusCode)
 
-	var todos []map[string]interface{}
-	json.NewDecoder(listResponse.Body).Decode(&todos)
+	todos := decodeTodoPage(t, listResponse)
 
 	assert.Len(t, todos, 1)
 	assert.Equal(t, "süt al", todos[0]["text"])
 }
 
+// AcceptanceTest: User can fix a typo and remove a todo
+func TestEditAndDeleteTodo_UserStory(t *testing.T) {
+	// Given: A todo with a typo
+	server := setupTestServer(t)
+	defer server.Close()
+	client := signUp(t, server, "alice")
+
+	jsonData, _ := json.Marshal(map[string]string{"text": "süt all"})
+	createResp, err := client.Post(server.URL+"/api/todos", "application/json", bytes.NewBuffer(jsonData))
+	require.NoError(t, err)
+	var created map[string]interface{}
+	json.NewDecoder(createResp.Body).Decode(&created)
+	todoURL := fmt.Sprintf("%s/api/todos/%v", server.URL, created["id"])
+
+	// When: User fixes the typo
+	req, _ := http.NewRequest("PATCH", todoURL, bytes.NewBufferString(`{"text":"süt al"}`))
+	req.Header.Set("Content-Type", "application/json")
+	patchResp, err := client.Do(req)
+
+	// Then: The todo should show the corrected text
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, patchResp.StatusCode)
+
+	getResp, err := client.Get(todoURL)
+	require.NoError(t, err)
+	var fetched map[string]interface{}
+	json.NewDecoder(getResp.Body).Decode(&fetched)
+	assert.Equal(t, "süt al", fetched["text"])
+
+	// When: User removes the todo
+	req, _ = http.NewRequest("DELETE", todoURL, nil)
+	deleteResp, err := client.Do(req)
+
+	// Then: It should be gone from the list
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, deleteResp.StatusCode)
+
+	listResponse, err := client.Get(server.URL + "/api/todos")
+	require.NoError(t, err)
+	todos := decodeTodoPage(t, listResponse)
+	assert.Len(t, todos, 0)
+}
+
 // AcceptanceTest: Error handling for non-existent todos
 func TestErrorHandling_NotFound(t *testing.T) {
 	// Given: Empty server
 	server := setupTestServer(t)
 	defer server.Close()
+	client := signUp(t, server, "alice")
 
 	// When: User tries to get non-existent todo
-	getResp, err := http.Get(server.URL + "/api/todos/999")
+	getResp, err := client.Get(server.URL + "/api/todos/999")
 
 	// Then: Should return 404
 	require.NoError(t, err)
@@ -62,7 +121,6 @@ func TestErrorHandling_NotFound(t *testing.T) {
 	updateData := map[string]string{"text": "updated"}
 	updateJSON, _ := json.Marshal(updateData)
 
-	client := &http.Client{}
 	req, _ := http.NewRequest("PUT", server.URL+"/api/todos/999", bytes.NewBuffer(updateJSON))
 	req.Header.Set("Content-Type", "application/json")
 	updateResp, err := client.Do(req)
@@ -85,16 +143,16 @@ func TestEmptyState_UserStory(t *testing.T) {
 	// Given: Empty server
 	server := setupTestServer(t)
 	defer server.Close()
+	client := signUp(t, server, "alice")
 
 	// When: User gets all todos
-	listResponse, err := http.Get(server.URL + "/api/todos")
+	listResponse, err := client.Get(server.URL + "/api/todos")
 
-	// Then: Empty array should be returned
+	// Then: An empty page should be returned
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusOK, listResponse.StatusCode)
 
-	var todos []map[string]interface{}
-	json.NewDecoder(listResponse.Body).Decode(&todos)
+	todos := decodeTodoPage(t, listResponse)
 	assert.Len(t, todos, 0)
 }
 
@@ -103,22 +161,22 @@ func TestMultipleTodos_UserStory(t *testing.T) {
 	// Given: Server
 	server := setupTestServer(t)
 	defer server.Close()
+	client := signUp(t, server, "alice")
 
 	// When: User creates multiple todos
 	todos := []string{"todo 1", "todo 2", "todo 3"}
 	for _, todoText := range todos {
 		todoData := map[string]string{"text": todoText}
 		jsonData, _ := json.Marshal(todoData)
-		_, err := http.Post(server.URL+"/api/todos", "application/json", bytes.NewBuffer(jsonData))
+		_, err := client.Post(server.URL+"/api/todos", "application/json", bytes.NewBuffer(jsonData))
 		require.NoError(t, err)
 	}
 
 	// Then: All todos should be listed (newest first - DESC order)
-	listResponse, err := http.Get(server.URL + "/api/todos")
+	listResponse, err := client.Get(server.URL + "/api/todos")
 	require.NoError(t, err)
 
-	var fetchedTodos []map[string]interface{}
-	json.NewDecoder(listResponse.Body).Decode(&fetchedTodos)
+	fetchedTodos := decodeTodoPage(t, listResponse)
 
 	assert.Len(t, fetchedTodos, 3)
 	// Should be in reverse order (newest first)
@@ -127,18 +185,464 @@ func TestMultipleTodos_UserStory(t *testing.T) {
 	assert.Equal(t, "todo 1", fetchedTodos[2]["text"])
 }
 
+// AcceptanceTest: User can tag todos, filter by tag and clean up duplicate tags
+func TestTagTodos_UserStory(t *testing.T) {
+	// Given: Todos tagged by the user, with a misspelt duplicate tag
+	server := setupTestServer(t)
+	defer server.Close()
+	client := signUp(t, server, "alice")
+
+	for _, body := range []string{
+		`{"text":"write report","tags":["Work","urgent"]}`,
+		`{"text":"book flights","tags":["wrk"]}`,
+		`{"text":"water plants","tags":["home"]}`,
+	} {
+		resp, err := client.Post(server.URL+"/api/todos", "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	// When: User lists todos tagged work and urgent
+	todos := getTodos(t, client, server.URL+"/api/todos?tag=work&tag=urgent")
+
+	// Then: Only the todo with both tags matches, with its tags normalized
+	require.Len(t, todos, 1)
+	assert.Equal(t, "write report", todos[0]["text"])
+	assert.Equal(t, []interface{}{"urgent", "work"}, todos[0]["tags"])
+
+	// When: User merges the misspelt tag into work
+	var tags []map[string]interface{}
+	getJSON(t, client, server.URL+"/api/tags", &tags)
+	ids := make(map[string]interface{})
+	for _, tag := range tags {
+		ids[tag["name"].(string)] = tag["id"]
+	}
+
+	body := fmt.Sprintf(`{"into":%v}`, ids["work"])
+	resp, err := client.Post(fmt.Sprintf("%s/api/tags/%v/merge", server.URL, ids["wrk"]), "application/json", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Then: work is used by both todos and wrk is gone
+	getJSON(t, client, server.URL+"/api/tags", &tags)
+	counts := make(map[string]interface{})
+	for _, tag := range tags {
+		counts[tag["name"].(string)] = tag["todo_count"]
+	}
+	assert.Equal(t, map[string]interface{}{"home": 1.0, "urgent": 1.0, "work": 2.0}, counts)
+
+	// And: Either tag can be matched with tag_mode=any
+	todos = getTodos(t, client, server.URL+"/api/todos?tag=home&tag=urgent&tag_mode=any")
+	assert.Len(t, todos, 2)
+}
+
+// AcceptanceTest: User can keep work and personal todos in separate lists
+func TestListsKeepTodosApart_UserStory(t *testing.T) {
+	// Given: A work list and a todo in the inbox
+	server := setupTestServer(t)
+	defer server.Close()
+	client := signUp(t, server, "alice")
+
+	resp, err := client.Post(server.URL+"/api/lists", "application/json", bytes.NewBufferString(`{"name":"Work"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var work map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&work))
+
+	resp, err = client.Post(server.URL+"/api/todos", "application/json", bytes.NewBufferString(`{"text":"prepare slides"}`))
+	require.NoError(t, err)
+	var todo map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&todo))
+	assert.NotEqual(t, work["id"], todo["list_id"], "todos without a list go to the inbox")
+
+	// When: User moves the todo to the work list
+	body := fmt.Sprintf(`{"list_id":%v}`, work["id"])
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("%s/api/todos/%v", server.URL, todo["id"]), bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Then: The work list shows it and the inbox is empty
+	todos := getTodos(t, client, fmt.Sprintf("%s/api/lists/%v/todos", server.URL, work["id"]))
+	require.Len(t, todos, 1)
+	assert.Equal(t, "prepare slides", todos[0]["text"])
+
+	todos = getTodos(t, client, fmt.Sprintf("%s/api/lists/%v/todos", server.URL, todo["list_id"]))
+	assert.Empty(t, todos)
+
+	// When: User deletes the work list
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("%s/api/lists/%v", server.URL, work["id"]), nil)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Then: The todo is back in the inbox
+	todos = getTodos(t, client, fmt.Sprintf("%s/api/lists/%v/todos", server.URL, todo["list_id"]))
+	assert.Len(t, todos, 1)
+}
+
+// AcceptanceTest: A long list is read page by page by following the Link header
+func TestPaginateLongList_UserStory(t *testing.T) {
+	// Given: Seven todos
+	server := setupTestServer(t)
+	defer server.Close()
+	client := signUp(t, server, "alice")
+
+	for i := 1; i <= 7; i++ {
+		body := fmt.Sprintf(`{"text":"todo %d"}`, i)
+		resp, err := client.Post(server.URL+"/api/todos", "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	// When: Following rel="next" links three todos at a time
+	var seen []interface{}
+	next := "/api/todos?limit=3"
+	pages := 0
+	for next != "" {
+		resp, err := client.Get(server.URL + next)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		link := resp.Header.Get("Link")
+		for _, todo := range decodeTodoPage(t, resp) {
+			seen = append(seen, todo["text"])
+		}
+		pages++
+
+		next = ""
+		if match := regexp.MustCompile(`<([^>]*)>; rel="next"`).FindStringSubmatch(link); match != nil {
+			next = match[1]
+		}
+	}
+
+	// Then: Every todo is seen once, newest first, over three pages
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []interface{}{"todo 7", "todo 6", "todo 5", "todo 4", "todo 3", "todo 2", "todo 1"}, seen)
+
+	// And: The React client's legacy flag still returns everything as an array
+	resp, err := client.Get(server.URL + "/api/todos?legacy=true")
+	require.NoError(t, err)
+	var todos []map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&todos))
+	assert.Len(t, todos, 7)
+}
+
+func TestFilterExpression_UserStory(t *testing.T) {
+	// Given: Work and home todos with different priorities and due dates
+	server := setupTestServer(t)
+	defer server.Close()
+	client := signUp(t, server, "alice")
+
+	for _, body := range []string{
+		`{"text":"Quarterly report","tags":["work"],"priority":"high","due_at":"2026-10-30"}`,
+		`{"text":"Team offsite","tags":["work"],"priority":"high","due_at":"2026-12-10"}`,
+		`{"text":"Expense claims","tags":["work"],"priority":"low","due_at":"2026-10-15"}`,
+		`{"text":"Fix the fence","tags":["home"],"priority":"urgent","due_at":"2026-10-20"}`,
+		`{"text":"Book flights","tags":["work"],"priority":"urgent","due_at":"2026-10-01","completed":true}`,
+	} {
+		resp, err := client.Post(server.URL+"/api/todos", "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	// When: A power user lists open, important work due before November
+	filter := "tag:work AND priority>=high AND due<2026-11-01 AND NOT completed"
+	todos := getTodos(t, client, server.URL+"/api/todos?filter="+url.QueryEscape(filter))
+
+	// Then: Only the quarterly report matches
+	require.Len(t, todos, 1)
+	assert.Equal(t, "Quarterly report", todos[0]["text"])
+
+	// And: A typo is reported with its position
+	resp, err := client.Get(server.URL + "/api/todos?filter=" + url.QueryEscape("tag:work AND priorty>=high"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	message, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Contains(t, string(message), `unknown field "priorty"`)
+	assert.Contains(t, string(message), "at position 14")
+}
+
+func TestSearchTodos_UserStory(t *testing.T) {
+	// Given: A few todos, one of them an old one about the passport
+	server := setupTestServer(t)
+	defer server.Close()
+	client := signUp(t, server, "alice")
+
+	for _, text := range []string{"Renew passport before the trip", "Buy milk", "Book passport photos"} {
+		resp, err := client.Post(server.URL+"/api/todos", "application/json", bytes.NewBufferString(fmt.Sprintf(`{"text":%q}`, text)))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	// When: Searching for the renewal instead of scrolling the whole list
+	resp, err := client.Get(server.URL + "/api/todos/search?q=" + url.QueryEscape(`passport NOT photo*`))
+	require.NoError(t, err)
+	if resp.StatusCode == http.StatusNotImplemented {
+		t.Skip("full-text search is not available; build with -tags sqlite_fts5")
+	}
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Then: Only that todo is found, with the match highlighted
+	results := decodeTodoPage(t, resp)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Renew passport before the trip", results[0]["text"])
+	assert.Equal(t, "Renew <mark>passport</mark> before the trip", results[0]["snippet"])
+}
+
+func TestLiveUpdates_UserStory(t *testing.T) {
+	// Given: A teammate watching the todo stream
+	server := setupTestServer(t)
+	t.Cleanup(server.Close) // Runs after the streams are closed; Close waits for them
+	client := signUp(t, server, "alice")
+
+	stream := openEventStream(t, client, server.URL, "")
+
+	// When: A todo is added in another tab, completed and removed
+	resp, err := client.Post(server.URL+"/api/todos", "application/json", bytes.NewBufferString(`{"text":"Order team lunch"}`))
+	require.NoError(t, err)
+	var created map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	todoURL := fmt.Sprintf("%s/api/todos/%v", server.URL, created["id"])
+
+	resp, err = client.Post(todoURL+"/complete", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	req, _ := http.NewRequest("DELETE", todoURL, nil)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// Then: The watcher sees each change as it happens
+	first := stream.next(t)
+	assert.Equal(t, "created", first.name)
+	assert.Equal(t, "Order team lunch", first.data["todo"].(map[string]interface{})["text"])
+	second := stream.next(t)
+	assert.Equal(t, "updated", second.name)
+	assert.Equal(t, true, second.data["todo"].(map[string]interface{})["completed"])
+	third := stream.next(t)
+	assert.Equal(t, "deleted", third.name)
+	assert.Equal(t, created["id"], third.data["todo_id"])
+
+	// When: The watcher's connection drops after the first event and comes back
+	resumed := openEventStream(t, client, server.URL, first.id)
+
+	// Then: The missed changes are replayed in order
+	assert.Equal(t, second.id, resumed.next(t).id)
+	assert.Equal(t, third.id, resumed.next(t).id)
+}
+
+// eventStream reads Server-Sent Events from an open response
+type eventStream struct {
+	reader *bufio.Reader
+}
+
+// sseEvent is one event read from a stream
+type sseEvent struct {
+	id, name string
+	data     map[string]interface{}
+}
+
+// openEventStream connects to GET /api/todos/events, resuming after lastEventID when set
+func openEventStream(t *testing.T, client *http.Client, serverURL, lastEventID string) *eventStream {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", serverURL+"/api/todos/events", nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	return &eventStream{reader: bufio.NewReader(resp.Body)}
+}
+
+// next returns the next event, skipping comments and the retry hint
+func (s *eventStream) next(t *testing.T) sseEvent {
+	t.Helper()
+	var event sseEvent
+	for {
+		line, err := s.reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			event.id = value
+		case "event":
+			event.name = value
+		case "data":
+			require.NoError(t, json.Unmarshal([]byte(value), &event.data))
+		case "":
+			if event.id != "" {
+				return event
+			}
+		}
+	}
+}
+
+// getTodos fetches one page of a todo listing
+func getTodos(t *testing.T, client *http.Client, url string) []map[string]interface{} {
+	t.Helper()
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	return decodeTodoPage(t, resp)
+}
+
+// decodeTodoPage reads the todos of a paginated listing response
+func decodeTodoPage(t *testing.T, resp *http.Response) []map[string]interface{} {
+	t.Helper()
+	defer resp.Body.Close()
+	var page struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+	return page.Data
+}
+
+func getJSON(t *testing.T, client *http.Client, url string, v interface{}) {
+	t.Helper()
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+}
+
 // Setup test server with real handlers
 func setupTestServer(t *testing.T) *httptest.Server {
-	// Create dependencies
-	repo, err := repository.NewSQLiteTodoRepository(":memory:")
+	store, err := repository.NewSQLiteTodoRepository(":memory:")
 	require.NoError(t, err)
+	m := metrics.New()
+	repo := withServerCalls(store, m)
+	return setupWorkspaceServer(t, m, repo, repository.SharedWorkspaces(repo))
+}
+
+// withServerCalls returns store timing its calls for m and bounding them as the server does
+func withServerCalls(store repository.TodoRepository, m *metrics.Metrics) repository.TodoRepository {
+	return repository.WithCallOptions(store, repository.CallOptions{Timeout: repository.DefaultQueryTimeout, Observe: m.ObserveQuery})
+}
+
+// setupWorkspaceServer sets up the test server over repo, finding the
+// storage of each workspace with workspaces, and timing requests with m
+func setupWorkspaceServer(t *testing.T, m *metrics.Metrics, repo repository.TodoRepository, workspaces repository.Workspaces) *httptest.Server {
+
+	// Create dependencies
+	workspaceSvc := service.NewWorkspaceService(repo, workspaces)
 	svc := service.NewTodoService(repo)
 	h := handler.NewTodoHandler(svc)
+	tags := handler.NewTagHandler(svc)
+	listSvc := service.NewListService(repo)
+	lists := handler.NewListHandler(listSvc, svc)
+	events := handler.NewEventHandler(svc)
+	ws := handler.NewWebSocketHandler(listSvc, svc)
+	webhooks := handler.NewWebhookHandler(service.NewWebhookService(repo))
+	auth := handler.NewAuthHandler(service.NewAuthService(repo, workspaceSvc), workspaceSvc, false)
+	tokens := handler.NewAPITokenHandler(service.NewAPITokenService(repo))
+	workspace := handler.NewWorkspaceHandler(workspaceSvc)
+	health := handler.NewHealthHandler(service.NewHealthService(repo))
+	metricsHandler := handler.NewMetricsHandler(m)
+
+	// Deliver webhooks quickly so the tests need not wait for retries,
+	// and let it reach the test receivers on 127.0.0.1
+	dispatcher := webhook.NewDispatcher(repo)
+	dispatcher.Client = webhook.NewClient([]netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")})
+	dispatcher.Stores = func(ctx context.Context) ([]webhook.Store, error) {
+		repos, err := workspaceSvc.OpenAll(ctx)
+		stores := make([]webhook.Store, len(repos))
+		for i, repo := range repos {
+			stores[i] = repo
+		}
+		return stores, err
+	}
+	dispatcher.PollInterval = 10 * time.Millisecond
+	dispatcher.BaseDelay = 50 * time.Millisecond
+	dispatcher.MaxAttempts = 3
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go dispatcher.Run(ctx)
 
 	// Setup routes
 	mux := http.NewServeMux()
-	mux.HandleFunc("POST /api/todos", h.CreateTodo)
-	mux.HandleFunc("GET /api/todos", h.GetAllTodos)
+	mux.HandleFunc("GET /healthz", health.Healthz)
+	mux.HandleFunc("GET /readyz", health.Readyz)
+	mux.HandleFunc("GET /version", health.Version)
+	mux.HandleFunc("GET /metrics", metricsHandler.Metrics)
+	mux.HandleFunc("POST /api/auth/register", auth.Register)
+	mux.HandleFunc("POST /api/auth/login", auth.Login)
+	mux.HandleFunc("POST /api/auth/logout", auth.Logout)
+	mux.HandleFunc("GET /api/auth/me", auth.Me)
+	mux.HandleFunc("POST /api/tokens", tokens.CreateAPIToken)
+	mux.HandleFunc("GET /api/tokens", tokens.GetAPITokens)
+	mux.HandleFunc("DELETE /api/tokens/{id}", tokens.RevokeAPIToken)
+	mux.Handle("GET /api/workspace", handler.Scoped(model.ScopeTodosRead, workspace.GetWorkspace))
+	mux.Handle("POST /api/todos", handler.Scoped(model.ScopeTodosWrite, h.CreateTodo))
+	mux.Handle("GET /api/todos", handler.Scoped(model.ScopeTodosRead, h.GetAllTodos))
+	mux.Handle("GET /api/todos/search", handler.Scoped(model.ScopeTodosRead, h.SearchTodos))
+	mux.Handle("GET /api/todos/events", handler.Scoped(model.ScopeTodosRead, events.StreamEvents))
+	mux.Handle("GET /api/todos/{id}", handler.Scoped(model.ScopeTodosRead, h.GetTodo))
+	mux.Handle("PUT /api/todos/{id}", handler.Scoped(model.ScopeTodosWrite, h.UpdateTodo))
+	mux.Handle("PATCH /api/todos/{id}", handler.Scoped(model.ScopeTodosWrite, h.PatchTodo))
+	mux.Handle("DELETE /api/todos/{id}", handler.Scoped(model.ScopeTodosWrite, h.DeleteTodo))
+	mux.Handle("POST /api/todos/{id}/complete", handler.Scoped(model.ScopeTodosWrite, h.CompleteTodo))
+	mux.Handle("POST /api/todos/{id}/reopen", handler.Scoped(model.ScopeTodosWrite, h.ReopenTodo))
+	mux.Handle("GET /api/tags", handler.Scoped(model.ScopeTodosRead, tags.GetTags))
+	mux.Handle("PATCH /api/tags/{id}", handler.Scoped(model.ScopeTodosWrite, tags.RenameTag))
+	mux.Handle("POST /api/tags/{id}/merge", handler.Scoped(model.ScopeTodosWrite, tags.MergeTag))
+	mux.Handle("POST /api/lists", handler.Scoped(model.ScopeTodosWrite, lists.CreateList))
+	mux.Handle("GET /api/lists", handler.Scoped(model.ScopeTodosRead, lists.GetLists))
+	mux.Handle("GET /api/lists/{id}", handler.Scoped(model.ScopeTodosRead, lists.GetList))
+	mux.Handle("PUT /api/lists/{id}", handler.Scoped(model.ScopeTodosWrite, lists.UpdateList))
+	mux.Handle("DELETE /api/lists/{id}", handler.Scoped(model.ScopeTodosWrite, lists.DeleteList))
+	mux.Handle("GET /api/lists/{id}/todos", handler.Scoped(model.ScopeTodosRead, lists.GetListTodos))
+	mux.Handle("GET /api/lists/{id}/members", handler.Scoped(model.ScopeTodosRead, lists.GetMembers))
+	mux.Handle("POST /api/lists/{id}/members", handler.Scoped(model.ScopeTodosWrite, lists.InviteMember))
+	mux.Handle("PATCH /api/lists/{id}/members/{userID}", handler.Scoped(model.ScopeTodosWrite, lists.UpdateMember))
+	mux.Handle("DELETE /api/lists/{id}/members/{userID}", handler.Scoped(model.ScopeTodosWrite, lists.RemoveMember))
+	mux.Handle("GET /api/invitations", handler.Scoped(model.ScopeTodosRead, lists.GetInvitations))
+	mux.Handle("POST /api/invitations/{id}/accept", handler.Scoped(model.ScopeTodosWrite, lists.AcceptInvitation))
+	mux.Handle("POST /api/invitations/{id}/decline", handler.Scoped(model.ScopeTodosWrite, lists.DeclineInvitation))
+	mux.Handle("GET /api/ws", handler.Scoped(model.ScopeTodosRead, ws.Serve))
+	mux.Handle("POST /api/webhooks", handler.Scoped(model.ScopeWebhooksWrite, webhooks.CreateWebhook))
+	mux.Handle("GET /api/webhooks", handler.Scoped(model.ScopeWebhooksRead, webhooks.GetWebhooks))
+	mux.Handle("GET /api/webhooks/{id}", handler.Scoped(model.ScopeWebhooksRead, webhooks.GetWebhook))
+	mux.Handle("PUT /api/webhooks/{id}", handler.Scoped(model.ScopeWebhooksWrite, webhooks.UpdateWebhook))
+	mux.Handle("DELETE /api/webhooks/{id}", handler.Scoped(model.ScopeWebhooksWrite, webhooks.DeleteWebhook))
+	mux.Handle("GET /api/webhooks/{id}/deliveries", handler.Scoped(model.ScopeWebhooksRead, webhooks.GetDeliveries))
 
-	return httptest.NewServer(mux)
+	return httptest.NewServer(logging.Middleware(slog.New(slog.DiscardHandler), m.InstrumentHTTP(mux, auth.RequireAuth(mux))))
+}
+
+// signUp registers username on the test server and returns a client that
+// sends its session cookie
+func signUp(t *testing.T, server *httptest.Server, username string) *http.Client {
+	t.Helper()
+	return signUpIn(t, server, username, "")
+}
+
+// signUpIn registers username in the workspace with the given slug, the
+// default one when empty, and returns a client that sends its session cookie
+func signUpIn(t *testing.T, server *httptest.Server, username, workspace string) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	client := &http.Client{Jar: jar}
+
+	body := fmt.Sprintf(`{"username":%q,"password":"correct horse battery","workspace":%q}`, username, workspace)
+	resp, err := client.Post(server.URL+"/api/auth/register", "application/json", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	return client
 }
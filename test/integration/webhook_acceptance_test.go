@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"todo-app/internal/webhook"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks_UserStory(t *testing.T) {
+	// Given: A chat integration listening for new and deleted todos, which is
+	// down for its first request
+	type hook struct {
+		signature string
+		event     string
+		body      []byte
+	}
+	received := make(chan hook, 10)
+	failures := 1
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received <- hook{signature: r.Header.Get(webhook.SignatureHeader), event: r.Header.Get(webhook.EventHeader), body: body}
+	}))
+	t.Cleanup(receiver.Close)
+
+	server := setupTestServer(t)
+	t.Cleanup(server.Close)
+	client := signUp(t, server, "alice")
+
+	resp, err := client.Post(server.URL+"/api/webhooks", "application/json",
+		bytes.NewBufferString(fmt.Sprintf(`{"url":%q,"event_types":["created","deleted"]}`, receiver.URL)))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var registered struct {
+		ID     int    `json:"id"`
+		Secret string `json:"secret"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&registered))
+	resp.Body.Close()
+	require.NotEmpty(t, registered.Secret)
+
+	// When: A todo is added and then completed
+	resp, err = client.Post(server.URL+"/api/todos", "application/json", bytes.NewBufferString(`{"text":"Review the PR"}`))
+	require.NoError(t, err)
+	var todo struct {
+		ID int `json:"id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&todo))
+	resp.Body.Close()
+	resp, err = client.Post(fmt.Sprintf("%s/api/todos/%d/complete", server.URL, todo.ID), "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// Then: After a retry the integration hears about the new todo, signed with the secret
+	var got hook
+	select {
+	case got = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+	assert.Equal(t, "created", got.event)
+	assert.Equal(t, webhook.Sign(registered.Secret, got.body), got.signature)
+	assert.Contains(t, string(got.body), `"text":"Review the PR"`)
+
+	// And: The delivery history shows the failed and the successful attempt;
+	// the completion was not sent
+	var deliveries []struct {
+		EventType string `json:"event_type"`
+		Status    string `json:"status"`
+		Attempts  []struct {
+			StatusCode int `json:"status_code"`
+		} `json:"attempts"`
+	}
+	require.Eventually(t, func() bool {
+		resp, err := client.Get(fmt.Sprintf("%s/api/webhooks/%d/deliveries", server.URL, registered.ID))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&deliveries))
+		return len(deliveries) == 1 && deliveries[0].Status == "delivered"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "created", deliveries[0].EventType)
+	require.Len(t, deliveries[0].Attempts, 2)
+	assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].Attempts[0].StatusCode)
+	assert.Equal(t, http.StatusOK, deliveries[0].Attempts[1].StatusCode)
+
+	// When: The todo is deleted
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/todos/%d", server.URL, todo.ID), nil)
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// Then: The integration hears about that too
+	select {
+	case got = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+	assert.Equal(t, "deleted", got.event)
+}
@@ -0,0 +1,177 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebSocketCollaboration_UserStory(t *testing.T) {
+	// Given: Two teammates signed in to the team account, connected to the inbox
+	server := setupTestServer(t)
+	t.Cleanup(server.Close) // Runs after the connections are closed
+	team := signUp(t, server, "team")
+
+	alice := dialWebSocket(t, team, server.URL)
+	bob := dialWebSocket(t, team, server.URL)
+	alice.request(t, map[string]interface{}{"type": "subscribe", "request_id": "a1", "list_id": 1})
+	bob.request(t, map[string]interface{}{"type": "subscribe", "request_id": "b1", "list_id": 1})
+
+	// When: Alice adds a todo
+	ack := alice.request(t, map[string]interface{}{"type": "create", "request_id": "a2", "todo": map[string]string{"text": "Book the venue"}})
+
+	// Then: Her command is acknowledged with the new todo and Bob sees it appear
+	todo := ack["todo"].(map[string]interface{})
+	assert.Equal(t, "Book the venue", todo["text"])
+	event := bob.event(t)
+	assert.Equal(t, "created", event["type"])
+	assert.Equal(t, todo["id"], event["todo_id"])
+
+	// When: Bob completes it
+	ack = bob.request(t, map[string]interface{}{"type": "update", "request_id": "b2", "id": todo["id"], "patch": map[string]bool{"completed": true}})
+	assert.Equal(t, true, ack["todo"].(map[string]interface{})["completed"])
+
+	// Then: Alice gets Bob's change, not an echo of her own
+	event = alice.event(t)
+	assert.Equal(t, "updated", event["type"])
+	assert.Equal(t, true, event["todo"].(map[string]interface{})["completed"])
+
+	// When: Alice deletes it
+	alice.request(t, map[string]interface{}{"type": "delete", "request_id": "a3", "id": todo["id"]})
+
+	// Then: Bob sees it go
+	event = bob.event(t)
+	assert.Equal(t, "deleted", event["type"])
+	assert.Equal(t, todo["id"], event["todo_id"])
+}
+
+func TestWebSocketListSubscriptions_UserStory(t *testing.T) {
+	// Given: A teammate who only follows the Work list
+	server := setupTestServer(t)
+	t.Cleanup(server.Close)
+	client := signUp(t, server, "alice")
+
+	resp, err := client.Post(server.URL+"/api/lists", "application/json", bytes.NewBufferString(`{"name":"Work"}`))
+	require.NoError(t, err)
+	var work map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&work))
+	resp.Body.Close()
+
+	follower := dialWebSocket(t, client, server.URL)
+	follower.request(t, map[string]interface{}{"type": "subscribe", "request_id": "1", "list_id": work["id"]})
+
+	// When: Someone adds a todo to the inbox and then one to Work
+	writer := dialWebSocket(t, client, server.URL)
+	writer.request(t, map[string]interface{}{"type": "create", "request_id": "1", "todo": map[string]interface{}{"text": "Buy milk"}})
+	ack := writer.request(t, map[string]interface{}{"type": "create", "request_id": "2", "todo": map[string]interface{}{"text": "Ship release", "list_id": work["id"]}})
+	shipID := ack["todo"].(map[string]interface{})["id"]
+
+	// Then: The follower only hears about Work
+	event := follower.event(t)
+	assert.Equal(t, "Ship release", event["todo"].(map[string]interface{})["text"])
+
+	// When: The todo is moved out of Work
+	writer.request(t, map[string]interface{}{"type": "update", "request_id": "3", "id": shipID, "patch": map[string]interface{}{"list_id": 1}})
+
+	// Then: The follower learns it left
+	event = follower.event(t)
+	assert.Equal(t, "updated", event["type"])
+	assert.Equal(t, float64(1), event["todo"].(map[string]interface{})["list_id"])
+
+	// When: The follower unsubscribes and Work changes again
+	follower.request(t, map[string]interface{}{"type": "unsubscribe", "request_id": "2", "list_id": work["id"]})
+	writer.request(t, map[string]interface{}{"type": "create", "request_id": "4", "todo": map[string]interface{}{"text": "Write notes", "list_id": work["id"]}})
+
+	// Then: Nothing more arrives; the next message answers the follower's own command
+	reply := follower.request(t, map[string]interface{}{"type": "subscribe", "request_id": "3", "list_id": 1})
+	assert.Equal(t, "ack", reply["type"])
+}
+
+func TestWebSocketErrors(t *testing.T) {
+	server := setupTestServer(t)
+	t.Cleanup(server.Close)
+	ws := dialWebSocket(t, signUp(t, server, "alice"), server.URL)
+
+	tests := []struct {
+		name           string
+		command        string
+		expectedStatus float64
+		expectedError  string
+	}{
+		{name: "invalid JSON", command: `{"type":`, expectedStatus: http.StatusBadRequest, expectedError: "Invalid JSON"},
+		{name: "unknown type", command: `{"type":"archive","request_id":"1"}`, expectedStatus: http.StatusBadRequest, expectedError: `Unknown command type "archive"`},
+		{name: "empty text", command: `{"type":"create","request_id":"2","todo":{"text":" "}}`, expectedStatus: http.StatusBadRequest, expectedError: "Text cannot be empty"},
+		{name: "missing todo", command: `{"type":"update","request_id":"3","id":999,"patch":{"completed":true}}`, expectedStatus: http.StatusNotFound, expectedError: "Todo not found"},
+		{name: "empty patch", command: `{"type":"update","request_id":"4","id":1,"patch":{}}`, expectedStatus: http.StatusBadRequest, expectedError: "No fields to update"},
+		{name: "unknown list", command: `{"type":"subscribe","request_id":"5","list_id":999}`, expectedStatus: http.StatusNotFound, expectedError: "List not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, ws.conn.Write(ws.ctx, websocket.MessageText, []byte(tt.command)))
+
+			reply := ws.receive(t)
+
+			assert.Equal(t, "error", reply["type"])
+			assert.Equal(t, tt.expectedStatus, reply["status"])
+			assert.Equal(t, tt.expectedError, reply["error"])
+			if strings.Contains(tt.command, "request_id") {
+				assert.NotEmpty(t, reply["request_id"])
+			}
+		})
+	}
+}
+
+// wsTestClient is a WebSocket connection to the test server
+type wsTestClient struct {
+	conn *websocket.Conn
+	ctx  context.Context
+}
+
+// dialWebSocket connects to GET /api/ws with the session of client
+func dialWebSocket(t *testing.T, client *http.Client, serverURL string) *wsTestClient {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(serverURL, "http")+"/api/ws", &websocket.DialOptions{HTTPClient: client})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close(websocket.StatusNormalClosure, "") })
+	return &wsTestClient{conn: conn, ctx: ctx}
+}
+
+// request sends a command and returns its ack
+func (c *wsTestClient) request(t *testing.T, command map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	require.NoError(t, wsjson.Write(c.ctx, c.conn, command))
+
+	reply := c.receive(t)
+	require.Equal(t, "ack", reply["type"], "reply: %v", reply)
+	require.Equal(t, command["request_id"], reply["request_id"])
+	return reply
+}
+
+// event returns the next message, which must be an event
+func (c *wsTestClient) event(t *testing.T) map[string]interface{} {
+	t.Helper()
+	message := c.receive(t)
+	require.Equal(t, "event", message["type"], "message: %v", message)
+	return message["event"].(map[string]interface{})
+}
+
+// receive returns the next message
+func (c *wsTestClient) receive(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var message map[string]interface{}
+	require.NoError(t, wsjson.Read(c.ctx, c.conn, &message))
+	return message
+}
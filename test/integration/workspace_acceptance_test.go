@@ -0,0 +1,109 @@
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"todo-app/internal/metrics"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaces_UserStory(t *testing.T) {
+	// Given: A deployment keeping each workspace in its own SQLite file, with
+	// an Acme workspace that turned sharing off
+	ctx := t.Context()
+	store, err := repository.NewSQLiteTodoRepository(filepath.Join(t.TempDir(), "todos.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	m := metrics.New()
+	repo := withServerCalls(store, m)
+	files, err := repository.NewSQLiteWorkspaceFiles(repo, t.TempDir())
+	require.NoError(t, err)
+	defer files.Close()
+
+	workspaces := service.NewWorkspaceService(repo, files)
+	acme, err := workspaces.CreateWorkspace(ctx, model.WorkspaceInput{Slug: "acme", Name: "Acme Corp"})
+	require.NoError(t, err)
+	off := false
+	_, err = workspaces.UpdateSettings(ctx, acme.ID, model.WorkspaceSettingsPatch{Sharing: &off})
+	require.NoError(t, err)
+
+	server := setupWorkspaceServer(t, m, repo, files)
+	defer server.Close()
+
+	// When: Someone registers into Acme before it takes registrations
+	status := post(t, http.DefaultClient, server.URL+"/api/auth/register", `{"username":"mallory","password":"correct horse battery","workspace":"acme"}`)
+
+	// Then: They are refused
+	assert.Equal(t, http.StatusForbidden, status)
+
+	// When: Acme's administrator opens registration, saved in Acme's own file
+	on := true
+	_, err = workspaces.UpdateSettings(ctx, acme.ID, model.WorkspaceSettingsPatch{Registration: &on})
+	require.NoError(t, err)
+
+	// And: Alice joins the default workspace and Bob and Carol join Acme
+	alice := signUp(t, server, "alice")
+	bob := signUpIn(t, server, "bob", "acme")
+	carol := signUpIn(t, server, "carol", "acme")
+
+	// Then: Each sees their own workspace
+	var workspace map[string]interface{}
+	getJSON(t, bob, server.URL+"/api/workspace", &workspace)
+	assert.Equal(t, "Acme Corp", workspace["name"])
+	assert.Equal(t, map[string]interface{}{"sharing": false, "webhooks": true, "registration": true}, workspace["settings"])
+	getJSON(t, alice, server.URL+"/api/workspace", &workspace)
+	assert.Equal(t, "default", workspace["slug"])
+
+	// When: Both Alice and Bob add a todo, which get the same ID in their own files
+	aliceTodo := postJSON(t, alice, server.URL+"/api/todos", `{"text":"Write the report","tags":["work"]}`, http.StatusCreated)
+	bobTodo := postJSON(t, bob, server.URL+"/api/todos", `{"text":"Plan the launch","tags":["work"]}`, http.StatusCreated)
+	assert.Equal(t, aliceTodo["id"], bobTodo["id"])
+
+	// Then: Each only sees their own
+	todos := getTodos(t, alice, server.URL+"/api/todos")
+	require.Len(t, todos, 1)
+	assert.Equal(t, "Write the report", todos[0]["text"])
+	todos = getTodos(t, bob, server.URL+"/api/todos")
+	require.Len(t, todos, 1)
+	assert.Equal(t, "Plan the launch", todos[0]["text"])
+	assert.Empty(t, getTodos(t, carol, server.URL+"/api/todos"))
+
+	// And: Bob's list lives in Acme's file, out of reach of Alice
+	launch := postJSON(t, bob, server.URL+"/api/lists", `{"name":"Launch"}`, http.StatusCreated)
+	assert.FileExists(t, files.Path(acme.ID))
+	resp, err := alice.Get(fmt.Sprintf("%s/api/lists/%v", server.URL, launch["id"]))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// When: Bob shares the list in a workspace without sharing
+	status = post(t, bob, fmt.Sprintf("%s/api/lists/%v/members", server.URL, launch["id"]), `{"username":"carol","role":"editor"}`)
+
+	// Then: It is forbidden
+	assert.Equal(t, http.StatusForbidden, status)
+
+	// When: Alice shares a list with Bob across workspaces
+	report := postJSON(t, alice, server.URL+"/api/lists", `{"name":"Report"}`, http.StatusCreated)
+	status = post(t, alice, fmt.Sprintf("%s/api/lists/%v/members", server.URL, report["id"]), `{"username":"bob","role":"editor"}`)
+
+	// Then: To her Bob does not exist
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+// post sends a JSON body to url and returns the response status
+func post(t *testing.T, client *http.Client, url, body string) int {
+	t.Helper()
+	resp, err := client.Post(url, "application/json", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPITokenService_CreateAndRevoke(t *testing.T) {
+	// Given: Two users
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
+	authSvc := newAuthService(repo)
+	alice, _, err := authSvc.Register(ctx, model.Credentials{Username: "alice", Password: "correct horse battery"})
+	require.NoError(t, err)
+	bob, _, err := authSvc.Register(ctx, model.Credentials{Username: "bob", Password: "correct horse battery"})
+	require.NoError(t, err)
+	tokens := service.NewAPITokenService(repo)
+
+	// When: Alice creates a token, naming a scope twice
+	created, err := tokens.ForUser(alice.ID).CreateAPIToken(ctx, model.APITokenInput{
+		Name:   " CI ",
+		Scopes: []string{model.ScopeTodosWrite, model.ScopeTodosRead, model.ScopeTodosWrite},
+	})
+
+	// Then: Its value is shown once; the scopes are kept in order without repeats
+	require.NoError(t, err)
+	assert.Equal(t, "CI", created.Name)
+	assert.NotEmpty(t, created.Token)
+	assert.Equal(t, []string{model.ScopeTodosRead, model.ScopeTodosWrite}, created.Scopes)
+
+	listed, err := tokens.ForUser(alice.ID).GetAPITokens(ctx)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Empty(t, listed[0].Token)
+	assert.NotContains(t, listed[0].TokenHash, created.Token)
+
+	// And: It authenticates as Alice and records its use
+	user, token, err := authSvc.AuthenticateAPIToken(ctx, created.Token)
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, user.ID)
+	assert.True(t, token.HasScope(model.ScopeTodosWrite))
+	assert.False(t, token.HasScope(model.ScopeWebhooksRead))
+	stored, err := repo.GetAPIToken(ctx, created.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, stored.LastUsedAt)
+
+	// When: Bob tries to revoke it
+	err = tokens.ForUser(bob.ID).RevokeAPIToken(ctx, created.ID)
+
+	// Then: To him it does not exist
+	assert.ErrorIs(t, err, service.ErrAPITokenNotFound)
+
+	// When: Alice revokes it
+	require.NoError(t, tokens.ForUser(alice.ID).RevokeAPIToken(ctx, created.ID))
+
+	// Then: It no longer authenticates
+	_, _, err = authSvc.AuthenticateAPIToken(ctx, created.Token)
+	assert.ErrorIs(t, err, service.ErrAPITokenNotFound)
+}
+
+func TestAPITokenService_Errors(t *testing.T) {
+	ctx := t.Context()
+	tokens := service.NewAPITokenService(repository.NewInMemoryTodoRepository()).ForUser(1)
+
+	tests := []struct {
+		name     string
+		input    model.APITokenInput
+		expected error
+	}{
+		{name: "empty name", input: model.APITokenInput{Name: " ", Scopes: []string{model.ScopeTodosRead}}, expected: service.ErrInvalidTokenName},
+		{name: "long name", input: model.APITokenInput{Name: strings.Repeat("a", 101), Scopes: []string{model.ScopeTodosRead}}, expected: service.ErrInvalidTokenName},
+		{name: "no scopes", input: model.APITokenInput{Name: "CI"}, expected: service.ErrInvalidScope},
+		{name: "unknown scope", input: model.APITokenInput{Name: "CI", Scopes: []string{"admin"}}, expected: service.ErrInvalidScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tokens.CreateAPIToken(ctx, tt.input)
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
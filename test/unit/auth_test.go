@@ -0,0 +1,202 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/internal/auth"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAuthService creates an auth service over repo, keeping every workspace in it
+func newAuthService(repo repository.TodoRepository) *service.AuthService {
+	return service.NewAuthService(repo, service.NewWorkspaceService(repo, repository.SharedWorkspaces(repo)))
+}
+
+func TestHashPassword(t *testing.T) {
+	// Given: A password hashed twice
+	first, err := auth.HashPassword("correct horse battery")
+	require.NoError(t, err)
+	second, err := auth.HashPassword("correct horse battery")
+	require.NoError(t, err)
+
+	// Then: The hashes are argon2id with their parameters and different salts
+	assert.True(t, strings.HasPrefix(first, "$argon2id$v=19$m=19456,t=2,p=1$"), first)
+	assert.NotEqual(t, first, second)
+
+	// And: Only the right password verifies
+	ok, err := auth.VerifyPassword("correct horse battery", first)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = auth.VerifyPassword("correct horse battery!", first)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// And: Hashes it did not make are rejected
+	for _, hash := range []string{"", "plain", "$2a$10$abcdefghijklmnopqrstuv", "$argon2id$v=19$m=19456,t=0,p=1$c2FsdA$a2V5"} {
+		_, err := auth.VerifyPassword("correct horse battery", hash)
+		assert.ErrorIs(t, err, auth.ErrMalformedHash, hash)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	first, err := auth.NewToken()
+	require.NoError(t, err)
+	second, err := auth.NewToken()
+	require.NoError(t, err)
+
+	assert.Len(t, first, 43) // 32 bytes, base64url without padding
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, auth.HashToken(first), auth.HashToken(first))
+	assert.NotEqual(t, auth.HashToken(first), auth.HashToken(second))
+	assert.NotContains(t, auth.HashToken(first), first)
+}
+
+func TestAuthService_RegisterAndLogin(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
+	svc := newAuthService(repo)
+
+	// When: Registering
+	user, token, err := svc.Register(ctx, model.Credentials{Username: " Alice ", Password: "correct horse battery"})
+
+	// Then: The username is normalized, the password hashed and a session started
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+	assert.NotContains(t, user.PasswordHash, "correct horse battery")
+	signedIn, err := svc.Authenticate(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, signedIn.ID)
+
+	// And: Only the hash of the token is stored
+	_, err = repo.GetSession(ctx, token)
+	assert.ErrorIs(t, err, repository.ErrSessionNotFound)
+
+	// When: Signing in again
+	_, second, err := svc.Login(ctx, model.Credentials{Username: "ALICE", Password: "correct horse battery"})
+
+	// Then: A second session is started
+	require.NoError(t, err)
+	assert.NotEqual(t, token, second)
+
+	// When: Signing out of the first one
+	require.NoError(t, svc.Logout(ctx, token))
+
+	// Then: Only the second one still works
+	_, err = svc.Authenticate(ctx, token)
+	assert.ErrorIs(t, err, service.ErrSessionNotFound)
+	_, err = svc.Authenticate(ctx, second)
+	assert.NoError(t, err)
+}
+
+func TestAuthService_Errors(t *testing.T) {
+	ctx := t.Context()
+	svc := newAuthService(repository.NewInMemoryTodoRepository())
+	_, _, err := svc.Register(ctx, model.Credentials{Username: "alice", Password: "correct horse battery"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		register    bool
+		credentials model.Credentials
+		expected    error
+	}{
+		{name: "short username", register: true, credentials: model.Credentials{Username: "al", Password: "correct horse battery"}, expected: service.ErrInvalidUsername},
+		{name: "username with spaces", register: true, credentials: model.Credentials{Username: "al ice", Password: "correct horse battery"}, expected: service.ErrInvalidUsername},
+		{name: "short password", register: true, credentials: model.Credentials{Username: "bob", Password: "1234567"}, expected: service.ErrInvalidPassword},
+		{name: "long password", register: true, credentials: model.Credentials{Username: "bob", Password: strings.Repeat("a", 1025)}, expected: service.ErrInvalidPassword},
+		{name: "taken username", register: true, credentials: model.Credentials{Username: "Alice", Password: "correct horse battery"}, expected: service.ErrUsernameTaken},
+		{name: "wrong password", credentials: model.Credentials{Username: "alice", Password: "wrong horse battery"}, expected: service.ErrInvalidCredentials},
+		{name: "unknown username", credentials: model.Credentials{Username: "bob", Password: "correct horse battery"}, expected: service.ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.register {
+				_, _, err = svc.Register(ctx, tt.credentials)
+			} else {
+				_, _, err = svc.Login(ctx, tt.credentials)
+			}
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+func TestAuthService_ExpiredSession(t *testing.T) {
+	// Given: A session that has run out
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
+	svc := newAuthService(repo)
+	user, _, err := svc.Register(ctx, model.Credentials{Username: "alice", Password: "correct horse battery"})
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateSession(ctx, &model.Session{
+		TokenHash: auth.HashToken("old token"),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(-time.Second),
+	}))
+
+	// When: It is used
+	_, err = svc.Authenticate(ctx, "old token")
+
+	// Then: It is refused and removed
+	assert.ErrorIs(t, err, service.ErrSessionNotFound)
+	_, err = repo.GetSession(ctx, auth.HashToken("old token"))
+	assert.ErrorIs(t, err, repository.ErrSessionNotFound)
+}
+
+func TestTodoService_ForUser(t *testing.T) {
+	// Given: Two users of one service
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	alice, bob := svc.ForUser(1), svc.ForUser(2)
+	bobEvents := bob.Subscribe(ctx, 10)
+	defer bobEvents.Cancel()
+
+	// When: Alice creates a todo
+	todo, err := alice.CreateTodo(ctx, model.TodoInput{Text: "Buy milk", Tags: []string{"errands"}})
+	require.NoError(t, err)
+
+	// Then: It is hers alone
+	todos, err := alice.GetAllTodos(ctx, model.TodoFilter{})
+	require.NoError(t, err)
+	assert.Len(t, todos, 1)
+	todos, err = bob.GetAllTodos(ctx, model.TodoFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, todos)
+
+	// And: To Bob it does not exist
+	_, err = bob.GetTodo(ctx, todo.ID)
+	assert.ErrorIs(t, err, service.ErrTodoNotFound)
+	_, err = bob.CompleteTodo(ctx, todo.ID)
+	assert.ErrorIs(t, err, service.ErrTodoNotFound)
+	assert.ErrorIs(t, bob.DeleteTodo(ctx, todo.ID), service.ErrTodoNotFound)
+
+	tags, err := alice.GetTags(ctx)
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	_, err = bob.RenameTag(ctx, tags[0].ID, "shopping")
+	assert.ErrorIs(t, err, service.ErrTagNotFound)
+
+	events, err := bob.GetEvents(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	// And: Bob hears nothing of it, but does hear about his own todos
+	_, err = bob.CreateTodo(ctx, model.TodoInput{Text: "Walk the dog"})
+	require.NoError(t, err)
+	event := <-bobEvents.Events()
+	assert.Equal(t, "Walk the dog", event.Todo.Text)
+
+	// And: Alice's todo is untouched
+	stored, err := alice.GetTodo(ctx, todo.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.Completed)
+}
@@ -0,0 +1,51 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoService_FrozenClock(t *testing.T) {
+	// Given: A todo due on 1 March and the clock frozen the day after
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	alice := svc.ForUser(1)
+	todo, err := alice.CreateTodo(ctx, model.TodoInput{Text: "Pay the bill", DueAt: "2026-03-01T09:00:00Z"})
+	require.NoError(t, err)
+	frozen := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	svc.Clock().Freeze(frozen)
+
+	// Then: Every service made from it tells the frozen time
+	now, ok := alice.Clock().Frozen()
+	require.True(t, ok)
+	assert.Equal(t, frozen, now)
+	assert.Equal(t, frozen, alice.Clock().Now())
+
+	// And: The todo is overdue by it
+	overdue, err := alice.GetAllTodos(ctx, model.TodoFilter{Overdue: true})
+	require.NoError(t, err)
+	assert.Len(t, overdue, 1)
+
+	// When: Completing it
+	completed, err := alice.CompleteTodo(ctx, todo.ID)
+
+	// Then: It is completed at the frozen time
+	require.NoError(t, err)
+	require.NotNil(t, completed.CompletedAt)
+	assert.True(t, frozen.Equal(*completed.CompletedAt))
+
+	// When: Unfreezing the clock
+	svc.Clock().Unfreeze()
+
+	// Then: It follows the real time again
+	_, ok = svc.Clock().Frozen()
+	assert.False(t, ok)
+	assert.WithinDuration(t, time.Now(), svc.Clock().Now(), time.Second)
+}
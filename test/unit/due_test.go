@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"todo-app/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDue(t *testing.T) {
+	tests := []struct {
+		input      string
+		expected   time.Time
+		allDay     bool
+		shouldFail bool
+	}{
+		{input: "2026-11-01T09:00:00Z", expected: time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC)},
+		{input: "2026-11-01T12:00:00+03:00", expected: time.Date(2026, 11, 1, 9, 0, 0, 0, time.UTC)},
+		{input: "2026-11-01", expected: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), allDay: true},
+		{input: "2026-11-01 09:00", shouldFail: true},
+		{input: "tomorrow", shouldFail: true},
+		{input: "2026-13-01", shouldFail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			dueAt, allDay, err := model.ParseDue(tt.input)
+
+			if tt.shouldFail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.expected.Equal(dueAt), "got %s", dueAt)
+			assert.Equal(t, time.UTC, dueAt.Location())
+			assert.Equal(t, tt.allDay, allDay)
+		})
+	}
+}
+
+func TestTodoPatch_DueAtNullVersusAbsent(t *testing.T) {
+	var absent, null, set model.TodoPatch
+
+	require.NoError(t, json.Unmarshal([]byte(`{"text":"x"}`), &absent))
+	require.NoError(t, json.Unmarshal([]byte(`{"due_at":null}`), &null))
+	require.NoError(t, json.Unmarshal([]byte(`{"due_at":"2026-11-01"}`), &set))
+
+	assert.False(t, absent.DueAt.Set)
+	assert.True(t, null.DueAt.Set)
+	assert.Nil(t, null.DueAt.Value)
+	assert.True(t, set.DueAt.Set)
+	assert.Equal(t, "2026-11-01", *set.DueAt.Value)
+}
+
+func TestTodo_IsOverdue(t *testing.T) {
+	now := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	today := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+
+	assert.True(t, (&model.Todo{DueAt: &past}).IsOverdue(now))
+	assert.False(t, (&model.Todo{DueAt: &past, Completed: true}).IsOverdue(now))
+	assert.False(t, (&model.Todo{DueAt: &today, DueAllDay: true}).IsOverdue(now))
+	assert.True(t, (&model.Todo{DueAt: &yesterday, DueAllDay: true}).IsOverdue(now))
+	assert.False(t, (&model.Todo{}).IsOverdue(now))
+}
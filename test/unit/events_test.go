@@ -0,0 +1,163 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"todo-app/internal/events"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker_PublishesToEverySubscriber(t *testing.T) {
+	// Given: Two subscribers
+	broker := events.NewBroker()
+	first := broker.Subscribe(1)
+	second := broker.Subscribe(1)
+
+	// When: An event is published
+	event := &model.TodoEvent{ID: 1, Type: model.TodoCreated}
+	broker.Publish(event)
+
+	// Then: Both receive it
+	assert.Same(t, event, <-first.Events())
+	assert.Same(t, event, <-second.Events())
+
+	// And: A cancelled subscription is closed and no longer counted
+	first.Cancel()
+	first.Cancel()
+	_, ok := <-first.Events()
+	assert.False(t, ok)
+	assert.Equal(t, 1, broker.Subscribers())
+}
+
+func TestBroker_DropsSubscriberThatFallsBehind(t *testing.T) {
+	// Given: A subscriber with room for one event
+	broker := events.NewBroker()
+	sub := broker.Subscribe(1)
+
+	// When: Two events are published before it reads any
+	broker.Publish(&model.TodoEvent{ID: 1})
+	broker.Publish(&model.TodoEvent{ID: 2})
+
+	// Then: It gets the first one and then a closed channel
+	event, ok := <-sub.Events()
+	require.True(t, ok)
+	assert.Equal(t, int64(1), event.ID)
+	_, ok = <-sub.Events()
+	assert.False(t, ok)
+	assert.Equal(t, 0, broker.Subscribers())
+}
+
+func TestTodoService_RecordsEvents(t *testing.T) {
+	// Given: A subscriber to a service
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	sub := svc.Subscribe(ctx, 10)
+	defer sub.Cancel()
+
+	// When: A todo is created, completed and deleted
+	todo, err := svc.CreateTodo(ctx, model.TodoInput{Text: "Buy milk"})
+	require.NoError(t, err)
+	_, err = svc.CompleteTodo(ctx, todo.ID)
+	require.NoError(t, err)
+	require.NoError(t, svc.DeleteTodo(ctx, todo.ID))
+
+	// Then: The subscriber sees the three changes in order
+	var types []model.TodoEventType
+	for range 3 {
+		event := <-sub.Events()
+		assert.Equal(t, todo.ID, event.TodoID)
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []model.TodoEventType{model.TodoCreated, model.TodoUpdated, model.TodoDeleted}, types)
+
+	// And: The change log holds them for clients that reconnect
+	logged, err := svc.GetEvents(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, logged, 3)
+	assert.True(t, logged[1].Todo.Completed)
+
+	// And: Failed writes record nothing
+	_, err = svc.CreateTodo(ctx, model.TodoInput{Text: " "})
+	require.ErrorIs(t, err, service.ErrEmptyText)
+	require.ErrorIs(t, svc.DeleteTodo(ctx, todo.ID), service.ErrTodoNotFound)
+	logged, err = svc.GetEvents(ctx, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, logged, 3)
+}
+
+func TestBroker_Close(t *testing.T) {
+	// Given: A subscriber
+	broker := events.NewBroker()
+	sub := broker.Subscribe(1)
+
+	// When: The broker is closed, as on shutdown
+	broker.Close()
+
+	// Then: The subscription ends, and so do those made later
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+	_, ok = <-broker.Subscribe(1).Events()
+	assert.False(t, ok)
+	assert.True(t, broker.Closed())
+	assert.Equal(t, 0, broker.Subscribers())
+
+	// And: Publishing and cancelling are harmless
+	broker.Publish(&model.TodoEvent{Type: model.TodoCreated})
+	sub.Cancel()
+}
+
+// stalledWrites is a repository whose transactions wait for release once begun
+type stalledWrites struct {
+	repository.TodoRepository
+	begun   chan struct{}
+	release chan struct{}
+}
+
+func (r *stalledWrites) Atomically(ctx context.Context, fn func(tx repository.TxRepository) error) error {
+	close(r.begun)
+	<-r.release
+	return r.TodoRepository.Atomically(ctx, fn)
+}
+
+func TestTodoService_WorkspacesWriteIndependently(t *testing.T) {
+	// Given: A service for two workspaces, one of which is in the middle of a write
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
+	acme, err := repo.CreateWorkspace(ctx, &model.Workspace{Slug: "acme", Name: "Acme"})
+	require.NoError(t, err)
+	stalled := &stalledWrites{TodoRepository: repo.ForWorkspace(acme.ID), begun: make(chan struct{}), release: make(chan struct{})}
+	svc := service.NewTodoService(repo)
+	acmeWritten := make(chan error, 1)
+	go func() {
+		_, err := svc.ForWorkspace(stalled).CreateTodo(ctx, model.TodoInput{Text: "Ship the order"})
+		acmeWritten <- err
+	}()
+	<-stalled.begun
+
+	// When: The other workspace writes meanwhile
+	written := make(chan error, 1)
+	go func() {
+		_, err := svc.CreateTodo(ctx, model.TodoInput{Text: "Buy milk"})
+		written <- err
+	}()
+
+	// Then: Its write does not wait for the first one
+	select {
+	case err := <-written:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		close(stalled.release)
+		t.Fatal("a write waited for one in another workspace")
+	}
+
+	// And: The first one completes once it may go on
+	close(stalled.release)
+	require.NoError(t, <-acmeWritten)
+}
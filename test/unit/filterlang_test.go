@@ -0,0 +1,149 @@
+package unit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"todo-app/internal/filterlang"
+	"todo-app/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterlangParse(t *testing.T) {
+	compare := func(field string, cmp model.FilterCmp) *model.FilterExpr {
+		return &model.FilterExpr{Op: model.FilterCompare, Field: field, Cmp: cmp}
+	}
+	tag := func(name string) *model.FilterExpr {
+		expr := compare(model.FieldTag, model.CmpEq)
+		expr.Text = name
+		return expr
+	}
+	at := func(field string, cmp model.FilterCmp, value string) *model.FilterExpr {
+		expr := compare(field, cmp)
+		tm, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		expr.Time = &tm
+		return expr
+	}
+	completed := compare(model.FieldCompleted, model.CmpEq)
+	completed.Bool = true
+	high := compare(model.FieldPriority, model.CmpGe)
+	high.Int = int(model.PriorityHigh)
+	not := func(operand *model.FilterExpr) *model.FilterExpr {
+		return &model.FilterExpr{Op: model.FilterNot, Left: operand}
+	}
+	and := func(left, right *model.FilterExpr) *model.FilterExpr {
+		return &model.FilterExpr{Op: model.FilterAnd, Left: left, Right: right}
+	}
+	or := func(left, right *model.FilterExpr) *model.FilterExpr {
+		return &model.FilterExpr{Op: model.FilterOr, Left: left, Right: right}
+	}
+
+	tests := []struct {
+		input    string
+		expected *model.FilterExpr
+	}{
+		{input: "tag:Work", expected: tag("work")},
+		{input: "tag != work", expected: not(tag("work"))},
+		{input: "completed", expected: completed},
+		{input: "priority>=high", expected: high},
+		{input: "due<2026-11-01", expected: at(model.FieldDue, model.CmpLt, "2026-11-01T00:00:00Z")},
+		{input: "due<=2026-11-01", expected: at(model.FieldDue, model.CmpLt, "2026-11-02T00:00:00Z")},
+		{input: "due>2026-11-01", expected: at(model.FieldDue, model.CmpGe, "2026-11-02T00:00:00Z")},
+		{input: "created:2026-11-01", expected: and(at(model.FieldCreated, model.CmpGe, "2026-11-01T00:00:00Z"), at(model.FieldCreated, model.CmpLt, "2026-11-02T00:00:00Z"))},
+		{input: "updated>2026-11-01T10:00:00+02:00", expected: at(model.FieldUpdated, model.CmpGt, "2026-11-01T08:00:00Z")},
+		{input: "due:none", expected: compare(model.FieldDue, model.CmpEq)},
+		{
+			input:    "tag:work AND priority>=high AND due<2026-11-01 AND NOT completed",
+			expected: and(and(tag("work"), high), and(at(model.FieldDue, model.CmpLt, "2026-11-01T00:00:00Z"), not(completed))),
+		},
+		{input: "tag:a or tag:b and tag:c", expected: or(tag("a"), and(tag("b"), tag("c")))},
+		{input: "tag:a OR tag:b OR tag:c", expected: or(tag("a"), or(tag("b"), tag("c")))},
+		{input: "(tag:a OR tag:b) AND completed", expected: and(or(tag("a"), tag("b")), completed)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := filterlang.Parse(tt.input)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, expr)
+		})
+	}
+
+	t.Run(`text:"oat milk"`, func(t *testing.T) {
+		expr, err := filterlang.Parse(`text:"oat \"milk\"" AND list:2`)
+
+		require.NoError(t, err)
+		assert.Equal(t, `oat "milk"`, expr.Left.Text)
+		assert.Equal(t, 2, expr.Right.Int)
+	})
+}
+
+func TestFilterlangParse_Balanced(t *testing.T) {
+	// Given: The longest chain of ORs a filter may hold
+	input := strings.Repeat("tag:a OR ", filterlang.MaxComparisons-1) + "tag:b"
+
+	// When
+	expr, err := filterlang.Parse(input)
+
+	// Then: It parses into a tree of logarithmic depth, not one as deep as it is long
+	require.NoError(t, err)
+	var depth func(*model.FilterExpr) int
+	depth = func(expr *model.FilterExpr) int {
+		if expr == nil {
+			return 0
+		}
+		return 1 + max(depth(expr.Left), depth(expr.Right))
+	}
+	assert.Equal(t, 8, depth(expr)) // ceil(log2(100)) ORs above the comparisons
+}
+
+func TestFilterlangParse_Errors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: " ", expected: "filter is empty at position 2"},
+		{input: "tag:work AND", expected: "expected a field, NOT or (, got the end of the filter at position 13"},
+		{input: "tag:work completed", expected: `expected AND, OR or the end of the filter, got "completed" at position 10`},
+		{input: "colour:red", expected: `unknown field "colour"`},
+		{input: "priority high", expected: `expected an operator after priority, got "high" at position 10`},
+		{input: "tag<work", expected: "tag only supports :, = and != at position 4"},
+		{input: "priority>=", expected: "expected a value after priority>=, got the end of the filter at position 11"},
+		{input: "priority>=hgh", expected: `unknown priority "hgh"`},
+		{input: "due<tomorrow", expected: "at position 5"},
+		{input: "due<none", expected: "due:none only supports :, = and != at position 5"},
+		{input: "completed:yes", expected: `completed must be true or false, got "yes" at position 11`},
+		{input: "list:0", expected: "list must be a positive integer"},
+		{input: "(tag:a OR tag:b", expected: "unclosed ( at position 1"},
+		{input: "tag:a)", expected: `expected AND, OR or the end of the filter, got ")" at position 6`},
+		{input: `text:"milk`, expected: "unterminated string at position 6"},
+		{
+			input:    strings.Repeat("tag:a OR ", filterlang.MaxComparisons) + "tag:b",
+			expected: fmt.Sprintf("filter has more than %d comparisons at position %d", filterlang.MaxComparisons, 9*filterlang.MaxComparisons+1),
+		},
+		{
+			input:    strings.Repeat("(", filterlang.MaxDepth+1) + "tag:a" + strings.Repeat(")", filterlang.MaxDepth+1),
+			expected: fmt.Sprintf("more than %d deep at position %d", filterlang.MaxDepth, filterlang.MaxDepth+1),
+		},
+		{
+			input:    strings.Repeat("NOT ", filterlang.MaxDepth+1) + "tag:a",
+			expected: fmt.Sprintf("more than %d deep at position %d", filterlang.MaxDepth, 4*filterlang.MaxDepth+1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := filterlang.Parse(tt.input)
+
+			var syntaxErr *filterlang.SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
 	"todo-app/internal/handler"
 	"todo-app/internal/repository"
 	"todo-app/internal/service"
@@ -16,9 +15,7 @@ import (
 
 func TestTodoHandler_CreateTodo(t *testing.T) {
 	// Given
-	repo, err := repository.NewSQLiteTodoRepository(":memory:")
-	require.NoError(t, err)
-	svc := service.NewTodoService(repo)
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
 	h := handler.NewTodoHandler(svc)
 
 	requestBody := map[string]string{"text": "test todo"}
@@ -32,7 +29,7 @@ func TestTodoHandler_CreateTodo(t *testing.T) {
 
 	// Then
 	assert.Equal(t, http.StatusCreated, rec.Code)
-	
+
 	var todo map[string]interface{}
 	json.NewDecoder(rec.Body).Decode(&todo)
 	assert.Equal(t, "test todo", todo["text"])
@@ -41,9 +38,7 @@ func TestTodoHandler_CreateTodo(t *testing.T) {
 
 func TestTodoHandler_CreateTodo_EmptyText(t *testing.T) {
 	// Given
-	repo, err := repository.NewSQLiteTodoRepository(":memory:")
-	require.NoError(t, err)
-	svc := service.NewTodoService(repo)
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
 	h := handler.NewTodoHandler(svc)
 
 	requestBody := map[string]string{"text": ""}
@@ -61,9 +56,7 @@ func TestTodoHandler_CreateTodo_EmptyText(t *testing.T) {
 
 func TestTodoHandler_CreateTodo_InvalidJSON(t *testing.T) {
 	// Given
-	repo, err := repository.NewSQLiteTodoRepository(":memory:")
-	require.NoError(t, err)
-	svc := service.NewTodoService(repo)
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
 	h := handler.NewTodoHandler(svc)
 
 	req := httptest.NewRequest("POST", "/api/todos", bytes.NewBuffer([]byte("invalid json")))
@@ -75,4 +68,41 @@ func TestTodoHandler_CreateTodo_InvalidJSON(t *testing.T) {
 
 	// Then
 	assert.Equal(t, http.StatusBadRequest, rec.Code)
-} 
\ No newline at end of file
+}
+func TestTodoHandler_CreateTodo_DueAt(t *testing.T) {
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	tests := []struct {
+		name           string
+		dueAt          string
+		expectedStatus int
+		expectedDueAt  interface{}
+		expectedAllDay bool
+	}{
+		{name: "RFC3339 with offset is stored in UTC", dueAt: "2026-11-01T12:00:00+03:00", expectedStatus: http.StatusCreated, expectedDueAt: "2026-11-01T09:00:00Z"},
+		{name: "Date only is all-day", dueAt: "2026-11-01", expectedStatus: http.StatusCreated, expectedDueAt: "2026-11-01T00:00:00Z", expectedAllDay: true},
+		{name: "Omitted is null", dueAt: "", expectedStatus: http.StatusCreated, expectedDueAt: nil},
+		{name: "Invalid format", dueAt: "next friday", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonBody, _ := json.Marshal(map[string]string{"text": "pay rent", "due_at": tt.dueAt})
+			req := httptest.NewRequest("POST", "/api/todos", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			h.CreateTodo(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+			var todo map[string]interface{}
+			json.NewDecoder(rec.Body).Decode(&todo)
+			assert.Equal(t, tt.expectedDueAt, todo["due_at"])
+			assert.Equal(t, tt.expectedAllDay, todo["due_all_day"])
+		})
+	}
+}
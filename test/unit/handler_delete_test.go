@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoHandler_DeleteTodo(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	_, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("DELETE", "/api/todos/1", nil)
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	// When
+	h.DeleteTodo(rec, req)
+
+	// Then
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	_, err = svc.GetTodo(ctx, 1)
+	assert.ErrorIs(t, err, service.ErrTodoNotFound)
+}
+
+func TestTodoHandler_DeleteTodo_NotFound(t *testing.T) {
+	// Given
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	req := httptest.NewRequest("DELETE", "/api/todos/999", nil)
+	req.SetPathValue("id", "999")
+	rec := httptest.NewRecorder()
+
+	// When
+	h.DeleteTodo(rec, req)
+
+	// Then
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestTodoHandler_DeleteTodo_InvalidID(t *testing.T) {
+	// Given
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	req := httptest.NewRequest("DELETE", "/api/todos/abc", nil)
+	req.SetPathValue("id", "abc")
+	rec := httptest.NewRecorder()
+
+	// When
+	h.DeleteTodo(rec, req)
+
+	// Then
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
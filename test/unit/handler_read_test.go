@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"todo-app/internal/handler"
+	"todo-app/internal/model"
 	"todo-app/internal/repository"
 	"todo-app/internal/service"
 
@@ -16,15 +17,14 @@ import (
 
 func TestTodoHandler_GetAllTodos(t *testing.T) {
 	// Given
-	repo, err := repository.NewSQLiteTodoRepository(":memory:")
-	require.NoError(t, err)
-	svc := service.NewTodoService(repo)
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
 	h := handler.NewTodoHandler(svc)
 
 	// Create some todos
-	_, err = svc.CreateTodo("todo 1")
+	_, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1"})
 	require.NoError(t, err)
-	_, err = svc.CreateTodo("todo 2")
+	_, err = svc.CreateTodo(ctx, model.TodoInput{Text: "todo 2"})
 	require.NoError(t, err)
 
 	req := httptest.NewRequest("GET", "/api/todos", nil)
@@ -36,8 +36,7 @@ func TestTodoHandler_GetAllTodos(t *testing.T) {
 	// Then
 	assert.Equal(t, http.StatusOK, rec.Code)
 
-	var todos []map[string]interface{}
-	json.NewDecoder(rec.Body).Decode(&todos)
+	todos := decodeTodoPage(t, rec)
 	assert.Len(t, todos, 2)
 
 	// Check that both todos exist (order may vary)
@@ -51,9 +50,7 @@ func TestTodoHandler_GetAllTodos(t *testing.T) {
 
 func TestTodoHandler_GetAllTodos_Empty(t *testing.T) {
 	// Given
-	repo, err := repository.NewSQLiteTodoRepository(":memory:")
-	require.NoError(t, err)
-	svc := service.NewTodoService(repo)
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
 	h := handler.NewTodoHandler(svc)
 
 	req := httptest.NewRequest("GET", "/api/todos", nil)
@@ -65,7 +62,123 @@ func TestTodoHandler_GetAllTodos_Empty(t *testing.T) {
 	// Then
 	assert.Equal(t, http.StatusOK, rec.Code)
 
-	var todos []map[string]interface{}
-	json.NewDecoder(rec.Body).Decode(&todos)
+	todos := decodeTodoPage(t, rec)
 	assert.Len(t, todos, 0)
 }
+
+func TestTodoHandler_GetTodo(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	created, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/api/todos/1", nil)
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	// When
+	h.GetTodo(rec, req)
+
+	// Then
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var todo map[string]interface{}
+	json.NewDecoder(rec.Body).Decode(&todo)
+	assert.Equal(t, float64(created.ID), todo["id"])
+	assert.Equal(t, "todo 1", todo["text"])
+}
+
+func TestTodoHandler_GetTodo_NotFound(t *testing.T) {
+	// Given
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	req := httptest.NewRequest("GET", "/api/todos/999", nil)
+	req.SetPathValue("id", "999")
+	rec := httptest.NewRecorder()
+
+	// When
+	h.GetTodo(rec, req)
+
+	// Then
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestTodoHandler_GetTodo_InvalidID(t *testing.T) {
+	// Given
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	for _, id := range []string{"abc", "0", "-1", "1.5"} {
+		req := httptest.NewRequest("GET", "/api/todos/"+id, nil)
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+
+		// When
+		h.GetTodo(rec, req)
+
+		// Then
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "id %q", id)
+	}
+}
+
+func TestTodoHandler_GetAllTodos_DueFilters(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	_, err := svc.CreateTodo(ctx, model.TodoInput{Text: "long overdue", DueAt: "2000-01-01"})
+	require.NoError(t, err)
+	_, err = svc.CreateTodo(ctx, model.TodoInput{Text: "far future", DueAt: "2999-01-01T10:00:00Z"})
+	require.NoError(t, err)
+	_, err = svc.CreateTodo(ctx, model.TodoInput{Text: "no due date"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		query          string
+		expectedStatus int
+		expectedTexts  []string
+	}{
+		{query: "?overdue=true", expectedStatus: http.StatusOK, expectedTexts: []string{"long overdue"}},
+		{query: "?due_before=2500-01-01", expectedStatus: http.StatusOK, expectedTexts: []string{"long overdue"}},
+		{query: "?due_after=2500-01-01T00:00:00Z", expectedStatus: http.StatusOK, expectedTexts: []string{"far future"}},
+		{query: "?due_before=soon", expectedStatus: http.StatusBadRequest},
+		{query: "?overdue=1", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/todos"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			// When
+			h.GetAllTodos(rec, req)
+
+			// Then
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			todos := decodeTodoPage(t, rec)
+			texts := make([]string, len(todos))
+			for i, todo := range todos {
+				texts[i] = todo["text"].(string)
+			}
+			assert.Equal(t, tt.expectedTexts, texts)
+		})
+	}
+}
+
+// decodeTodoPage reads the todos of a paginated listing response
+func decodeTodoPage(t *testing.T, rec *httptest.ResponseRecorder) []map[string]interface{} {
+	t.Helper()
+	var page struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&page))
+	return page.Data
+}
@@ -0,0 +1,187 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoHandler_UpdateTodo(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	_, err := svc.CreateTodo(ctx, model.TodoInput{Text: "süt al"})
+	require.NoError(t, err)
+
+	jsonBody, _ := json.Marshal(map[string]string{"text": "organik süt al"})
+	req := httptest.NewRequest("PUT", "/api/todos/1", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	// When
+	h.UpdateTodo(rec, req)
+
+	// Then
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var todo map[string]interface{}
+	json.NewDecoder(rec.Body).Decode(&todo)
+	assert.Equal(t, "organik süt al", todo["text"])
+
+	stored, err := svc.GetTodo(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "organik süt al", stored.Text)
+}
+
+func TestTodoHandler_UpdateTodo_EmptyText(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	_, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1"})
+	require.NoError(t, err)
+
+	jsonBody, _ := json.Marshal(map[string]string{"text": "  "})
+	req := httptest.NewRequest("PUT", "/api/todos/1", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	// When
+	h.UpdateTodo(rec, req)
+
+	// Then
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestTodoHandler_UpdateTodo_NotFound(t *testing.T) {
+	// Given
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	jsonBody, _ := json.Marshal(map[string]string{"text": "updated"})
+	req := httptest.NewRequest("PUT", "/api/todos/999", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetPathValue("id", "999")
+	rec := httptest.NewRecorder()
+
+	// When
+	h.UpdateTodo(rec, req)
+
+	// Then
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestTodoHandler_PatchTodo(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	_, err := svc.CreateTodo(ctx, model.TodoInput{Text: "ekmek al"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("PATCH", "/api/todos/1", bytes.NewBufferString(`{"text":"tam buğday ekmek al"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	// When
+	h.PatchTodo(rec, req)
+
+	// Then
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var todo map[string]interface{}
+	json.NewDecoder(rec.Body).Decode(&todo)
+	assert.Equal(t, "tam buğday ekmek al", todo["text"])
+}
+
+func TestTodoHandler_PatchTodo_NoFields(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	_, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("PATCH", "/api/todos/1", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	// When
+	h.PatchTodo(rec, req)
+
+	// Then
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestTodoHandler_CompleteTodo(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	_, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1"})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/api/todos/1/complete", nil)
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	// When
+	h.CompleteTodo(rec, req)
+
+	// Then
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var todo map[string]interface{}
+	json.NewDecoder(rec.Body).Decode(&todo)
+	assert.Equal(t, true, todo["completed"])
+	assert.NotNil(t, todo["completed_at"])
+}
+
+func TestTodoHandler_PatchTodo_Completed(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	h := handler.NewTodoHandler(svc)
+
+	created, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1"})
+	require.NoError(t, err)
+	_, err = svc.CompleteTodo(ctx, created.ID)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("PATCH", "/api/todos/1", bytes.NewBufferString(`{"completed":false}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetPathValue("id", "1")
+	rec := httptest.NewRecorder()
+
+	// When
+	h.PatchTodo(rec, req)
+
+	// Then
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var todo map[string]interface{}
+	json.NewDecoder(rec.Body).Decode(&todo)
+	assert.Equal(t, false, todo["completed"])
+	assert.Nil(t, todo["completed_at"])
+	assert.Equal(t, "todo 1", todo["text"])
+}
@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthService_Readiness(t *testing.T) {
+	// Given: A migrated SQLite database
+	dbFile := filepath.Join(t.TempDir(), "todos.db")
+	repo, err := repository.NewSQLiteTodoRepository(dbFile)
+	require.NoError(t, err)
+	defer repo.Close()
+	svc := service.NewHealthService(repo)
+
+	// Then: The server is ready, at the latest schema version
+	readiness := svc.Readiness(context.Background())
+	assert.Equal(t, model.ReadinessReady, readiness.Status, readiness.Checks)
+	assert.Equal(t, map[string]string{service.CheckShutdown: "ok", service.CheckDatabase: "ok", service.CheckMigrations: "ok"}, readiness.Checks)
+	assert.Equal(t, repo.LatestSchemaVersion(), readiness.SchemaVersion)
+	assert.Equal(t, repo.LatestSchemaVersion(), svc.BuildInfo().SchemaVersion)
+	assert.NotEmpty(t, svc.BuildInfo().GoVersion)
+
+	// When: Another process holds the write lock
+	other, err := repository.OpenSQLite(dbFile)
+	require.NoError(t, err)
+	defer other.Close()
+	conn, err := other.Conn(context.Background())
+	require.NoError(t, err)
+	_, err = conn.ExecContext(context.Background(), "BEGIN IMMEDIATE")
+	require.NoError(t, err)
+
+	// Then: The database is reported locked well before the busy timeout
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, repo.Ping(ctx), repository.ErrDatabaseBusy)
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	readiness = svc.Readiness(context.Background())
+	assert.Equal(t, model.ReadinessUnavailable, readiness.Status)
+	assert.Contains(t, readiness.Checks[service.CheckDatabase], repository.ErrDatabaseBusy.Error())
+	assert.NotEqual(t, "ok", readiness.Checks[service.CheckMigrations])
+
+	// When: The lock is released
+	_, err = conn.ExecContext(context.Background(), "ROLLBACK")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	// Then: The server is ready again, and writes go through
+	assert.Equal(t, model.ReadinessReady, svc.Readiness(context.Background()).Status)
+	_, err = repo.Create(context.Background(), &model.Todo{Text: "Buy milk"})
+	assert.NoError(t, err)
+}
+
+func TestHealthService_PendingMigrations(t *testing.T) {
+	// Given: A database whose last migration was reverted behind the server's back
+	dbFile := filepath.Join(t.TempDir(), "todos.db")
+	repo, err := repository.NewSQLiteTodoRepository(dbFile)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	db, err := repository.OpenSQLite(dbFile)
+	require.NoError(t, err)
+	defer db.Close()
+	migrator, err := repository.NewSQLiteMigrator(db)
+	require.NoError(t, err)
+	_, err = migrator.Down(1)
+	require.NoError(t, err)
+
+	// When: Checking readiness
+	readiness := service.NewHealthService(repo).Readiness(context.Background())
+
+	// Then: The server is not ready until the migration is applied again
+	assert.Equal(t, model.ReadinessUnavailable, readiness.Status)
+	assert.Equal(t, "ok", readiness.Checks[service.CheckDatabase])
+	assert.Contains(t, readiness.Checks[service.CheckMigrations], "pending")
+	assert.Equal(t, repo.LatestSchemaVersion()-1, readiness.SchemaVersion)
+
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	assert.Equal(t, model.ReadinessReady, service.NewHealthService(repo).Readiness(context.Background()).Status)
+}
+
+func TestSQLiteRepository_SchemaVersionOnlyReads(t *testing.T) {
+	// Given: A database whose schema_migrations table was dropped behind the server's back
+	dbFile := filepath.Join(t.TempDir(), "todos.db")
+	repo, err := repository.NewSQLiteTodoRepository(dbFile)
+	require.NoError(t, err)
+	defer repo.Close()
+	db, err := repository.OpenSQLite(dbFile)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(`DROP TABLE schema_migrations`)
+	require.NoError(t, err)
+
+	// When: Checking its schema version
+	_, err = repo.SchemaVersion(context.Background())
+
+	// Then: The check fails without creating the table again
+	assert.Error(t, err)
+	var tables int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'schema_migrations'`).Scan(&tables))
+	assert.Zero(t, tables)
+
+	// And: A check whose context is done is not made at all
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = repo.SchemaVersion(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestHealthService_Drain(t *testing.T) {
+	// Given: A server without a database
+	svc := service.NewHealthService(repository.NewInMemoryTodoRepository())
+	require.Equal(t, model.ReadinessReady, svc.Readiness(context.Background()).Status)
+
+	// When: It starts shutting down
+	svc.Drain()
+
+	// Then: It is no longer ready
+	readiness := svc.Readiness(context.Background())
+	assert.Equal(t, model.ReadinessUnavailable, readiness.Status)
+	assert.NotEqual(t, "ok", readiness.Checks[service.CheckShutdown])
+	assert.Equal(t, "ok", readiness.Checks[service.CheckDatabase])
+	assert.Zero(t, svc.BuildInfo().SchemaVersion)
+}
@@ -0,0 +1,73 @@
+package unit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"todo-app/internal/httputil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseRecorder(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle func(w http.ResponseWriter)
+		status int
+		bytes  int64
+	}{
+		{
+			name:   "nothing written",
+			handle: func(w http.ResponseWriter) {},
+			status: http.StatusOK,
+		},
+		{
+			name:   "body without a status",
+			handle: func(w http.ResponseWriter) { io.WriteString(w, "hello") },
+			status: http.StatusOK,
+			bytes:  5,
+		},
+		{
+			name: "informational status before the real one",
+			handle: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusEarlyHints)
+				w.WriteHeader(http.StatusNotFound)
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "switching protocols",
+			handle: func(w http.ResponseWriter) { w.WriteHeader(http.StatusSwitchingProtocols) },
+			status: http.StatusSwitchingProtocols,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given: A recorder around a response
+			rec := httputil.NewResponseRecorder(httptest.NewRecorder())
+
+			// When: A handler answers through it
+			tt.handle(rec)
+
+			// Then: It remembers the status and counts the body
+			assert.Equal(t, tt.status, rec.Status())
+			assert.Equal(t, tt.bytes, rec.Bytes())
+		})
+	}
+}
+
+func TestResponseRecorder_Unwrap(t *testing.T) {
+	// Given: A recorder around a response that can flush
+	underlying := httptest.NewRecorder()
+	rec := httputil.NewResponseRecorder(underlying)
+
+	// When: A handler flushes through it
+	err := http.NewResponseController(rec).Flush()
+
+	// Then: The writer underneath is flushed
+	assert.NoError(t, err)
+	assert.True(t, underlying.Flushed)
+}
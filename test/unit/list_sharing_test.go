@@ -0,0 +1,318 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sharingFixture is a list of Alice's shared with Bob as an editor and Carol as a viewer
+type sharingFixture struct {
+	repo               repository.TodoRepository
+	alice, bob, carol  *model.User
+	lists              *service.ListService
+	todos              *service.TodoService
+	work               *model.List
+	aliceTodo, bobTodo *model.Todo
+}
+
+func newSharingFixture(t *testing.T) *sharingFixture {
+	t.Helper()
+	ctx := t.Context()
+	f := &sharingFixture{repo: repository.NewInMemoryTodoRepository()}
+	f.lists = service.NewListService(f.repo)
+	f.todos = service.NewTodoService(f.repo)
+	f.alice = createUser(t, f.repo, "alice")
+	f.bob = createUser(t, f.repo, "bob")
+	f.carol = createUser(t, f.repo, "carol")
+
+	var err error
+	f.work, err = f.lists.ForUser(f.alice.ID).CreateList(ctx, model.ListInput{Name: "Work"})
+	require.NoError(t, err)
+	for username, role := range map[string]string{"bob": "editor", "carol": "viewer"} {
+		_, err := f.lists.ForUser(f.alice.ID).InviteMember(ctx, f.work.ID, model.ListMemberInput{Username: username, Role: role})
+		require.NoError(t, err)
+	}
+	for _, user := range []*model.User{f.bob, f.carol} {
+		_, err := f.lists.ForUser(user.ID).AcceptInvitation(ctx, f.work.ID)
+		require.NoError(t, err)
+	}
+
+	f.aliceTodo, err = f.todos.ForUser(f.alice.ID).CreateTodo(ctx, model.TodoInput{Text: "Write the report", ListID: f.work.ID})
+	require.NoError(t, err)
+	f.bobTodo, err = f.todos.ForUser(f.bob.ID).CreateTodo(ctx, model.TodoInput{Text: "Review the report", ListID: f.work.ID})
+	require.NoError(t, err)
+	return f
+}
+
+func createUser(t *testing.T, repo repository.TodoRepository, username string) *model.User {
+	t.Helper()
+	ctx := t.Context()
+	user, err := repo.CreateUser(ctx, &model.User{Username: username, PasswordHash: "hash of " + username})
+	require.NoError(t, err)
+	return user
+}
+
+func TestListService_Invitations(t *testing.T) {
+	// Given: Alice's list and Bob
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
+	lists := service.NewListService(repo)
+	alice := createUser(t, repo, "alice")
+	bob := createUser(t, repo, "bob")
+	work, err := lists.ForUser(alice.ID).CreateList(ctx, model.ListInput{Name: "Work"})
+	require.NoError(t, err)
+	assert.Equal(t, model.ListOwner, work.Role)
+
+	// When: She invites him, spelling his name her own way
+	member, err := lists.ForUser(alice.ID).InviteMember(ctx, work.ID, model.ListMemberInput{Username: " Bob ", Role: "Viewer"})
+
+	// Then: He is invited as a viewer, and does not see the list yet
+	require.NoError(t, err)
+	assert.Equal(t, bob.ID, member.UserID)
+	assert.Equal(t, model.ListViewer, member.Role)
+	assert.False(t, member.Accepted)
+	_, err = lists.ForUser(bob.ID).GetList(ctx, work.ID)
+	assert.ErrorIs(t, err, service.ErrListNotFound)
+	invitations, err := lists.ForUser(bob.ID).GetInvitations(ctx)
+	require.NoError(t, err)
+	require.Len(t, invitations, 1)
+	assert.Equal(t, "Work", invitations[0].ListName)
+
+	// When: He accepts
+	joined, err := lists.ForUser(bob.ID).AcceptInvitation(ctx, work.ID)
+
+	// Then: He sees the list as a viewer, and the invitation is gone
+	require.NoError(t, err)
+	assert.Equal(t, model.ListViewer, joined.Role)
+	invitations, err = lists.ForUser(bob.ID).GetInvitations(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, invitations)
+	_, err = lists.ForUser(bob.ID).AcceptInvitation(ctx, work.ID)
+	assert.ErrorIs(t, err, service.ErrInvitationNotFound)
+
+	// And: The members list the owner first
+	members, err := lists.ForUser(bob.ID).GetMembers(ctx, work.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, "alice", members[0].Username)
+	assert.Equal(t, model.ListOwner, members[0].Role)
+	assert.Equal(t, "bob", members[1].Username)
+
+	// When: He leaves
+	require.NoError(t, lists.ForUser(bob.ID).RemoveMember(ctx, work.ID, bob.ID))
+
+	// Then: The list is gone for him
+	_, err = lists.ForUser(bob.ID).GetList(ctx, work.ID)
+	assert.ErrorIs(t, err, service.ErrListNotFound)
+}
+
+func TestListService_SharingErrors(t *testing.T) {
+	ctx := t.Context()
+	f := newSharingFixture(t)
+	owner, editor := f.lists.ForUser(f.alice.ID), f.lists.ForUser(f.bob.ID)
+	inbox, err := owner.GetLists(ctx)
+	require.NoError(t, err)
+	stranger := createUser(t, f.repo, "dave")
+
+	tests := []struct {
+		name     string
+		run      func() error
+		expected error
+	}{
+		{name: "invite with an unknown role", run: func() error {
+			_, err := owner.InviteMember(ctx, f.work.ID, model.ListMemberInput{Username: "dave", Role: "owner"})
+			return err
+		}, expected: service.ErrInvalidRole},
+		{name: "invite an unknown user", run: func() error {
+			_, err := owner.InviteMember(ctx, f.work.ID, model.ListMemberInput{Username: "erin", Role: "viewer"})
+			return err
+		}, expected: service.ErrUserNotFound},
+		{name: "invite a member again", run: func() error {
+			_, err := owner.InviteMember(ctx, f.work.ID, model.ListMemberInput{Username: "bob", Role: "viewer"})
+			return err
+		}, expected: service.ErrMemberExists},
+		{name: "invite the owner", run: func() error {
+			_, err := owner.InviteMember(ctx, f.work.ID, model.ListMemberInput{Username: "alice", Role: "viewer"})
+			return err
+		}, expected: service.ErrMemberExists},
+		{name: "share the inbox", run: func() error {
+			_, err := owner.InviteMember(ctx, inbox[0].ID, model.ListMemberInput{Username: "dave", Role: "viewer"})
+			return err
+		}, expected: service.ErrShareInbox},
+		{name: "editor invites", run: func() error {
+			_, err := editor.InviteMember(ctx, f.work.ID, model.ListMemberInput{Username: "dave", Role: "viewer"})
+			return err
+		}, expected: service.ErrNotListOwner},
+		{name: "editor changes a role", run: func() error {
+			_, err := editor.UpdateMember(ctx, f.work.ID, f.carol.ID, model.ListMemberInput{Role: "editor"})
+			return err
+		}, expected: service.ErrNotListOwner},
+		{name: "editor removes another member", run: func() error {
+			return editor.RemoveMember(ctx, f.work.ID, f.carol.ID)
+		}, expected: service.ErrNotListOwner},
+		{name: "editor renames", run: func() error {
+			_, err := editor.RenameList(ctx, f.work.ID, model.ListInput{Name: "Mine"})
+			return err
+		}, expected: service.ErrNotListOwner},
+		{name: "editor deletes", run: func() error {
+			return editor.DeleteList(ctx, f.work.ID)
+		}, expected: service.ErrNotListOwner},
+		{name: "stranger reads the members", run: func() error {
+			_, err := f.lists.ForUser(stranger.ID).GetMembers(ctx, f.work.ID)
+			return err
+		}, expected: service.ErrListNotFound},
+		{name: "stranger declines", run: func() error {
+			return f.lists.ForUser(stranger.ID).DeclineInvitation(ctx, f.work.ID)
+		}, expected: service.ErrInvitationNotFound},
+		{name: "owner removes a stranger", run: func() error {
+			return owner.RemoveMember(ctx, f.work.ID, stranger.ID)
+		}, expected: service.ErrMemberNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.run(), tt.expected)
+		})
+	}
+}
+
+func TestTodoService_ListRoles(t *testing.T) {
+	// Given: A shared list with a todo of Alice's and one of Bob's
+	ctx := t.Context()
+	f := newSharingFixture(t)
+	alice, bob, carol := f.todos.ForUser(f.alice.ID), f.todos.ForUser(f.bob.ID), f.todos.ForUser(f.carol.ID)
+	aliceInbox, err := alice.CreateTodo(ctx, model.TodoInput{Text: "Buy milk"})
+	require.NoError(t, err)
+
+	// Then: Every member sees both todos, but nobody else's inbox
+	for _, svc := range []*service.TodoService{alice, bob, carol} {
+		todos, err := svc.GetAllTodos(ctx, model.TodoFilter{ListID: f.work.ID})
+		require.NoError(t, err)
+		assert.Len(t, todos, 2)
+	}
+	_, err = bob.GetTodo(ctx, aliceInbox.ID)
+	assert.ErrorIs(t, err, service.ErrTodoNotFound)
+
+	// And: The editor changes the owner's todo
+	updated, err := bob.CompleteTodo(ctx, f.aliceTodo.ID)
+	require.NoError(t, err)
+	assert.True(t, updated.Completed)
+	assert.Equal(t, f.alice.ID, updated.UserID)
+
+	// And: The viewer changes nothing
+	_, err = carol.CompleteTodo(ctx, f.bobTodo.ID)
+	assert.ErrorIs(t, err, service.ErrReadOnlyList)
+	assert.ErrorIs(t, carol.DeleteTodo(ctx, f.bobTodo.ID), service.ErrReadOnlyList)
+	_, err = carol.CreateTodo(ctx, model.TodoInput{Text: "Sneak in", ListID: f.work.ID})
+	assert.ErrorIs(t, err, service.ErrReadOnlyList)
+
+	// And: Nobody moves a todo into a list they have not joined
+	stranger := f.todos.ForUser(createUser(t, f.repo, "dave").ID)
+	_, err = stranger.CreateTodo(ctx, model.TodoInput{Text: "Sneak in", ListID: f.work.ID})
+	assert.ErrorIs(t, err, service.ErrUnknownList)
+
+	// When: The viewer is made an editor
+	_, err = f.lists.ForUser(f.alice.ID).UpdateMember(ctx, f.work.ID, f.carol.ID, model.ListMemberInput{Role: "editor"})
+	require.NoError(t, err)
+
+	// Then: She may delete
+	assert.NoError(t, carol.DeleteTodo(ctx, f.bobTodo.ID))
+}
+
+func TestTodoService_SharedListEvents(t *testing.T) {
+	// Given: A member of a shared list listening for changes
+	ctx := t.Context()
+	f := newSharingFixture(t)
+	carolEvents := f.todos.ForUser(f.carol.ID).Subscribe(ctx, 10)
+	defer carolEvents.Cancel()
+	alice := f.todos.ForUser(f.alice.ID)
+
+	// When: Alice adds a todo to her inbox and then one to the shared list
+	_, err := alice.CreateTodo(ctx, model.TodoInput{Text: "Buy milk"})
+	require.NoError(t, err)
+	notes, err := alice.CreateTodo(ctx, model.TodoInput{Text: "Write notes", ListID: f.work.ID})
+	require.NoError(t, err)
+
+	// Then: Carol only hears about the shared one
+	event := <-carolEvents.Events()
+	assert.Equal(t, notes.ID, event.TodoID)
+
+	// When: Alice moves it to her inbox
+	inbox := 1
+	_, err = alice.PatchTodo(ctx, notes.ID, model.TodoPatch{ListID: &inbox})
+	require.NoError(t, err)
+
+	// Then: Carol learns it left
+	event = <-carolEvents.Events()
+	assert.Equal(t, model.TodoUpdated, event.Type)
+	assert.Equal(t, inbox, event.Todo.ListID)
+
+	// And: The change log of the shared list is hers to read, the move out of it included
+	events, err := f.todos.ForUser(f.carol.ID).GetEvents(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 4) // The two fixture todos, the notes and their move
+	assert.Equal(t, model.TodoUpdated, events[3].Type)
+	assert.Equal(t, notes.ID, events[3].TodoID)
+	assert.Equal(t, f.work.ID, events[3].FromListID)
+}
+
+// stalledLists is a repository whose GetList waits for release when called
+// with a context marked by stallKey
+type stalledLists struct {
+	repository.TodoRepository
+	release chan struct{}
+}
+
+type stallKey struct{}
+
+func (r *stalledLists) GetList(ctx context.Context, id int) (*model.List, error) {
+	if ctx.Value(stallKey{}) != nil {
+		<-r.release
+	}
+	return r.TodoRepository.GetList(ctx, id)
+}
+
+func TestTodoService_SubscribersDoNotHoldUpWrites(t *testing.T) {
+	// Given: A member of a shared list listening for changes, whose lookup of the lists she joined hangs
+	ctx := t.Context()
+	f := newSharingFixture(t)
+	repo := &stalledLists{TodoRepository: f.repo, release: make(chan struct{})}
+	svc := service.NewTodoService(repo)
+	carolEvents := svc.ForUser(f.carol.ID).Subscribe(context.WithValue(ctx, stallKey{}, true), 10)
+	defer carolEvents.Cancel()
+
+	// When: Alice adds todos to the shared list
+	written := make(chan error)
+	go func() {
+		var err error
+		for range 3 {
+			if _, err = svc.ForUser(f.alice.ID).CreateTodo(ctx, model.TodoInput{Text: "Write notes", ListID: f.work.ID}); err != nil {
+				break
+			}
+		}
+		written <- err
+	}()
+
+	// Then: Her writes go through meanwhile
+	select {
+	case err := <-written:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Writes waited on the subscriber's lookups")
+	}
+
+	// And: Carol hears about them once the lookup answers
+	close(repo.release)
+	for range 3 {
+		event := <-carolEvents.Events()
+		assert.Equal(t, f.work.ID, event.Todo.ListID)
+	}
+}
@@ -0,0 +1,184 @@
+package unit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todo-app/internal/logging"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logLines decodes the JSON log records written to buf
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line), scanner.Text())
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestLogging_New(t *testing.T) {
+	// Given: A JSON logger at warn level
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", "WARN")
+	require.NoError(t, err)
+
+	// When: Logging below and at that level
+	logger.Info("dropped")
+	logger.Warn("kept", "todo_id", 7)
+
+	// Then: Only the warning is written, as a JSON line
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "WARN", lines[0]["level"])
+	assert.Equal(t, "kept", lines[0]["msg"])
+	assert.EqualValues(t, 7, lines[0]["todo_id"])
+
+	// And: The text format writes key=value pairs
+	buf.Reset()
+	logger, err = logging.New(&buf, "text", "debug")
+	require.NoError(t, err)
+	logger.Debug("kept", "todo_id", 7)
+	assert.Contains(t, buf.String(), `level=DEBUG msg=kept todo_id=7`)
+
+	// And: Unknown formats and levels are refused
+	_, err = logging.New(&buf, "xml", "info")
+	assert.ErrorContains(t, err, "invalid log format")
+	_, err = logging.New(&buf, "json", "loud")
+	assert.ErrorContains(t, err, "invalid log level")
+}
+
+func TestLogging_Middleware(t *testing.T) {
+	// Given: A handler logging with the logger of its request
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", "info")
+	require.NoError(t, err)
+	server := logging.Middleware(logger, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logging.FromContext(r.Context()).Info("Handling", "seen_id", logging.RequestIDFromContext(r.Context()))
+		if r.URL.Path == "/fail" {
+			http.Error(w, "Broken", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		expectedID string // "" for a made up one
+		status     int
+		level      string
+	}{
+		{name: "the ID from nginx is kept", path: "/ok", header: "4f1c2e0a9b7d4c3e8f6a5b4c3d2e1f0a", expectedID: "4f1c2e0a9b7d4c3e8f6a5b4c3d2e1f0a", status: http.StatusOK, level: "INFO"},
+		{name: "without an ID one is made up", path: "/ok", status: http.StatusOK, level: "INFO"},
+		{name: "an ID that could forge log lines is replaced", path: "/ok", header: "abc\" injected=\"1", status: http.StatusOK, level: "INFO"},
+		{name: "an overlong ID is replaced", path: "/ok", header: strings.Repeat("a", 129), status: http.StatusOK, level: "INFO"},
+		{name: "server errors are logged as warnings", path: "/fail", header: "req-1", expectedID: "req-1", status: http.StatusInternalServerError, level: "WARN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(logging.RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			// When
+			server.ServeHTTP(rec, req)
+
+			// Then: The response tells the ID
+			id := rec.Header().Get(logging.RequestIDHeader)
+			if tt.expectedID != "" {
+				assert.Equal(t, tt.expectedID, id)
+			} else {
+				assert.Regexp(t, `^[0-9a-f]{32}$`, id)
+			}
+
+			// And: The handler's record and the access log both carry it
+			lines := logLines(t, &buf)
+			require.Len(t, lines, 2)
+			assert.Equal(t, "Handling", lines[0]["msg"])
+			assert.Equal(t, id, lines[0]["request_id"])
+			assert.Equal(t, id, lines[0]["seen_id"])
+
+			access := lines[1]
+			assert.Equal(t, "Request served", access["msg"])
+			assert.Equal(t, tt.level, access["level"])
+			assert.Equal(t, id, access["request_id"])
+			assert.Equal(t, "GET", access["method"])
+			assert.Equal(t, tt.path, access["path"])
+			assert.EqualValues(t, tt.status, access["status"])
+			assert.EqualValues(t, rec.Body.Len(), access["bytes"])
+			assert.Contains(t, access, "duration_ms")
+			assert.Contains(t, access, "remote_addr")
+		})
+	}
+}
+
+func TestLogging_ServiceAndRepositoryCalls(t *testing.T) {
+	// Given: A service over a SQLite repository, and the context of a request carrying its logger
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", "debug")
+	require.NoError(t, err)
+	repo, err := repository.NewSQLiteTodoRepository(":memory:")
+	require.NoError(t, err)
+	defer repo.Close()
+	svc := service.NewTodoService(repo)
+	ctx := logging.WithLogger(t.Context(), logger.With("request_id", "req-1"))
+
+	// When: Creating a todo
+	todo, err := svc.CreateTodo(ctx, model.TodoInput{Text: "Buy milk"})
+	require.NoError(t, err)
+
+	// Then: The repository call and the change are logged with the request's ID
+	lines := logLines(t, &buf)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "Repository call", lines[0]["msg"])
+	assert.Equal(t, "Atomically", lines[0]["method"])
+	assert.Equal(t, "req-1", lines[0]["request_id"])
+	assert.Equal(t, "Todo changed", lines[1]["msg"])
+	assert.Equal(t, "created", lines[1]["type"])
+	assert.EqualValues(t, todo.ID, lines[1]["todo_id"])
+	assert.Equal(t, "req-1", lines[1]["request_id"])
+
+	// And: So do the calls of services made for other workspaces
+	buf.Reset()
+	_, err = svc.ForWorkspace(repo.ForWorkspace(2)).GetAllTodos(ctx, model.TodoFilter{})
+	require.NoError(t, err)
+	lines = logLines(t, &buf)
+	require.NotEmpty(t, lines)
+	assert.Equal(t, "req-1", lines[0]["request_id"])
+	assert.EqualValues(t, 2, lines[0]["workspace_id"])
+
+	// And: Nothing is logged for them below debug level
+	buf.Reset()
+	quiet, err := logging.New(&buf, "json", "info")
+	require.NoError(t, err)
+	_, err = svc.GetAllTodos(logging.WithLogger(t.Context(), quiet), model.TodoFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, buf.String())
+}
+
+func TestLogging_FromContextDefault(t *testing.T) {
+	// A context without a logger falls back to the default one
+	req := httptest.NewRequest("GET", "/", nil)
+	assert.Same(t, slog.Default(), logging.FromContext(req.Context()))
+	assert.Empty(t, logging.RequestIDFromContext(req.Context()))
+}
@@ -0,0 +1,161 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"todo-app/internal/metrics"
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_TextFormat(t *testing.T) {
+	// Given: A counter, a histogram and gauges
+	registry := metrics.NewRegistry()
+	requests := registry.NewCounterVec("requests_total", "Requests served.", "route", "status")
+	latency := registry.NewHistogramVec("latency_seconds", "Time taken.", []float64{0.1, 1}, "route")
+	gauge := registry.NewGauge("todos", "Todos.")
+	registry.NewGaugeFunc("answer", "The answer,\nwith a newline.", func() float64 { return 42 })
+
+	// When: Recording
+	requests.Inc("/api/todos", "200")
+	requests.Inc("/api/todos", "200")
+	requests.Add(3, `/a"b\c`, "500")
+	latency.Observe(0.05, "/api/todos")
+	latency.Observe(0.1, "/api/todos")
+	latency.Observe(5, "/api/todos")
+	gauge.Set(7)
+
+	var out strings.Builder
+	n, err := registry.WriteTo(&out)
+
+	// Then: They are written in the text exposition format, in the order they were made
+	require.NoError(t, err)
+	assert.EqualValues(t, out.Len(), n)
+	assert.Equal(t, `# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{route="/a\"b\\c",status="500"} 3
+requests_total{route="/api/todos",status="200"} 2
+# HELP latency_seconds Time taken.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/api/todos",le="0.1"} 2
+latency_seconds_bucket{route="/api/todos",le="1"} 2
+latency_seconds_bucket{route="/api/todos",le="+Inf"} 3
+latency_seconds_sum{route="/api/todos"} 5.15
+latency_seconds_count{route="/api/todos"} 3
+# HELP todos Todos.
+# TYPE todos gauge
+todos 7
+# HELP answer The answer,\nwith a newline.
+# TYPE answer gauge
+answer 42
+`, out.String())
+
+	// And: The wrong number of label values is a programming error
+	assert.Panics(t, func() { requests.Inc("/api/todos") })
+}
+
+func TestMetrics_InstrumentHTTP(t *testing.T) {
+	// Given: Routes behind the instrumentation
+	m := metrics.New()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/todos/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "404" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("{}"))
+	})
+	mux.HandleFunc("POST /api/todos", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("GET /stream", func(w http.ResponseWriter, r *http.Request) {
+		// Handlers still reach the writer underneath
+		assert.NoError(t, http.NewResponseController(w).Flush())
+	})
+	server := m.InstrumentHTTP(mux, mux)
+
+	// When: Serving requests to several todos, routes and methods
+	for _, req := range []struct{ method, path string }{
+		{"GET", "/api/todos/1"}, {"GET", "/api/todos/2"}, {"GET", "/api/todos/404"},
+		{"POST", "/api/todos"}, {"GET", "/stream"}, {"GET", "/nowhere"}, {"BREW", "/api/todos"},
+	} {
+		server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	// Then: Requests are counted by route pattern rather than path
+	out := scrapeMetrics(t, m)
+	assert.Contains(t, out, `todoapp_http_requests_total{method="GET",route="/api/todos/{id}",status="200"} 2`)
+	assert.Contains(t, out, `todoapp_http_requests_total{method="GET",route="/api/todos/{id}",status="404"} 1`)
+	assert.Contains(t, out, `todoapp_http_requests_total{method="POST",route="/api/todos",status="201"} 1`)
+	assert.Contains(t, out, `todoapp_http_requests_total{method="GET",route="/stream",status="200"} 1`)
+	assert.Contains(t, out, `todoapp_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, out, `todoapp_http_requests_total{method="OTHER",route="unmatched",status="405"} 1`)
+	assert.Contains(t, out, `todoapp_http_request_duration_seconds_count{method="GET",route="/api/todos/{id}",status="200"} 2`)
+	assert.NotContains(t, out, "/api/todos/1")
+}
+
+func TestMetrics_RepositoryQueries(t *testing.T) {
+	// Given: A SQLite repository reporting its calls
+	ctx := t.Context()
+	m := metrics.New()
+	store, err := repository.NewSQLiteTodoRepository(":memory:")
+	require.NoError(t, err)
+	defer store.Close()
+	repo := repository.WithCallOptions(store, repository.CallOptions{Observe: m.ObserveQuery})
+
+	// When: Calling it, in the default workspace and another one, and failing once
+	_, err = repo.Create(ctx, &model.Todo{Text: "Buy milk"})
+	require.NoError(t, err)
+	_, err = repo.GetByID(ctx, 1<<30)
+	assert.ErrorIs(t, err, repository.ErrTodoNotFound)
+	workspace, err := repo.CreateWorkspace(ctx, &model.Workspace{Slug: "acme", Name: "Acme", Settings: model.DefaultWorkspaceSettings(model.DefaultWorkspaceID)})
+	require.NoError(t, err)
+	_, err = repo.ForWorkspace(workspace.ID).GetAll(ctx, model.TodoFilter{})
+	require.NoError(t, err)
+	m.WatchTodos(repo.CountTodos)
+
+	// And: In the file of a workspace kept apart
+	files, err := repository.NewSQLiteWorkspaceFiles(repo, t.TempDir())
+	require.NoError(t, err)
+	defer files.Close()
+	file, err := files.Open(ctx, workspace.ID)
+	require.NoError(t, err)
+	_, err = file.GetTags(ctx, 0)
+	require.NoError(t, err)
+
+	// Then: Each call is timed by method
+	out := scrapeMetrics(t, m)
+	for _, method := range []string{"Create", "GetByID", "CreateWorkspace", "GetAll", "CountTodos", "GetTags"} {
+		assert.Contains(t, out, `todoapp_repository_query_duration_seconds_count{method="`+method+`"} 1`, method)
+	}
+	assert.Contains(t, out, "todoapp_todos 1\n")
+	assert.Contains(t, out, "todoapp_todos_open 1\n")
+}
+
+func TestMetrics_WatchPool(t *testing.T) {
+	repo, err := repository.NewSQLiteTodoRepository(":memory:")
+	require.NoError(t, err)
+	defer repo.Close()
+	m := metrics.New()
+	m.WatchPool(repo.PoolStats)
+
+	out := scrapeMetrics(t, m)
+
+	assert.Contains(t, out, "# TYPE go_sql_open_connections gauge\ngo_sql_open_connections 1\n")
+	assert.Contains(t, out, "go_sql_max_open_connections 1\n")
+	assert.Contains(t, out, "# TYPE go_sql_wait_duration_seconds_total counter\n")
+}
+
+func scrapeMetrics(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	var out strings.Builder
+	_, err := m.WriteTo(&out)
+	require.NoError(t, err)
+	return out.String()
+}
@@ -0,0 +1,168 @@
+package unit
+
+import (
+	"database/sql"
+	"testing"
+	"testing/fstest"
+
+	"todo-app/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"migrations/0001_create_items.up.sql":   {Data: []byte(`CREATE TABLE items (id INTEGER PRIMARY KEY);`)},
+		"migrations/0001_create_items.down.sql": {Data: []byte(`DROP TABLE items;`)},
+		"migrations/0002_add_name.up.sql":       {Data: []byte(`ALTER TABLE items ADD COLUMN name TEXT;`)},
+		"migrations/0002_add_name.down.sql":     {Data: []byte(`ALTER TABLE items DROP COLUMN name;`)},
+		"migrations/README.md":                  {Data: []byte(`ignored`)},
+	}
+}
+
+func openMigratorDB(t *testing.T) *sql.DB {
+	db, err := repository.OpenSQLite(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrator_UpAndDown(t *testing.T) {
+	// Given: An empty database and two migrations
+	db := openMigratorDB(t)
+	migrator, err := repository.NewMigrator(db, testMigrations(), "migrations")
+	require.NoError(t, err)
+
+	// When: Applying all migrations
+	applied, err := migrator.Up()
+
+	// Then: Both should run in version order
+	require.NoError(t, err)
+	require.Len(t, applied, 2)
+	assert.Equal(t, 1, applied[0].Version)
+	assert.Equal(t, "add_name", applied[1].Name)
+
+	_, err = db.Exec(`INSERT INTO items (name) VALUES ('x')`)
+	require.NoError(t, err)
+
+	version, err := migrator.Version()
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	// And: Running up again should be a no-op
+	applied, err = migrator.Up()
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	// When: Reverting one step
+	reverted, err := migrator.Down(1)
+
+	// Then: Only the newest migration should be undone
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, 2, reverted[0].Version)
+
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+}
+
+func TestMigrator_FailedMigrationRollsBack(t *testing.T) {
+	// Given: A migration whose second statement fails
+	db := openMigratorDB(t)
+	fsys := fstest.MapFS{
+		"migrations/0001_broken.up.sql": {Data: []byte(`CREATE TABLE items (id INTEGER); INSERT INTO missing VALUES (1);`)},
+	}
+	migrator, err := repository.NewMigrator(db, fsys, "migrations")
+	require.NoError(t, err)
+
+	// When: Applying it
+	_, err = migrator.Up()
+
+	// Then: Neither the table nor the version should be recorded
+	require.Error(t, err)
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'items'`).Scan(&count))
+	assert.Equal(t, 0, count)
+
+	version, err := migrator.Version()
+	require.NoError(t, err)
+	assert.Equal(t, 0, version)
+}
+
+func TestMigrator_DetectsEditedMigration(t *testing.T) {
+	// Given: A database with the first migration applied
+	db := openMigratorDB(t)
+	fsys := testMigrations()
+	migrator, err := repository.NewMigrator(db, fsys, "migrations")
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	// When: The applied file is edited afterwards
+	fsys["migrations/0001_create_items.up.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE items (id INTEGER PRIMARY KEY, extra TEXT);`)}
+	edited, err := repository.NewMigrator(db, fsys, "migrations")
+	require.NoError(t, err)
+
+	// Then: Verification and further migrations should refuse to run
+	assert.ErrorIs(t, edited.Verify(), repository.ErrChecksumMismatch)
+	_, err = edited.Up()
+	assert.ErrorIs(t, err, repository.ErrChecksumMismatch)
+}
+
+func TestSQLiteMigrator_AdoptsSchemaSQLDatabase(t *testing.T) {
+	// Given: A database created by the old schema.sql, without schema_migrations
+	db := openMigratorDB(t)
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS todos (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			text TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO todos (text) VALUES ('legacy todo');
+	`)
+	require.NoError(t, err)
+
+	// When: Opening it with the SQLite migrator and applying migrations
+	migrator, err := repository.NewSQLiteMigrator(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	// Then: The baseline should be recorded and the data kept
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.True(t, status.Applied, "migration %d should be applied", status.Version)
+	}
+
+	var completed bool
+	require.NoError(t, db.QueryRow(`SELECT completed FROM todos WHERE text = 'legacy todo'`).Scan(&completed))
+	assert.False(t, completed)
+}
+
+func TestSQLiteMigrator_EmbeddedMigrationsRevert(t *testing.T) {
+	// Given: A database with every embedded migration applied
+	db := openMigratorDB(t)
+	migrator, err := repository.NewSQLiteMigrator(db)
+	require.NoError(t, err)
+	applied, err := migrator.Up()
+	require.NoError(t, err)
+
+	// When: Reverting all of them and applying them again
+	reverted, err := migrator.Down(len(applied))
+	require.NoError(t, err)
+	reapplied, err := migrator.Up()
+
+	// Then: Every down file undoes its up file
+	require.NoError(t, err)
+	assert.Len(t, reverted, len(applied))
+	assert.Len(t, reapplied, len(applied))
+
+	var inboxes int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM lists WHERE is_inbox`).Scan(&inboxes))
+	assert.Equal(t, 1, inboxes)
+}
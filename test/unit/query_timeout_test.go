@@ -0,0 +1,75 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteRepository_CanceledContext(t *testing.T) {
+	// Given: A SQLite repository and the context of a request its client gave up on
+	repo, err := repository.NewSQLiteTodoRepository(":memory:")
+	require.NoError(t, err)
+	defer repo.Close()
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	// When: Reading and writing with it
+	_, readErr := repo.GetAll(ctx, model.TodoFilter{})
+	_, writeErr := repo.Create(ctx, &model.Todo{Text: "Buy milk"})
+
+	// Then: Both fail with the cancellation, and nothing is written
+	assert.ErrorIs(t, readErr, context.Canceled)
+	assert.ErrorIs(t, writeErr, context.Canceled)
+	todos, err := repo.GetAll(t.Context(), model.TodoFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, todos)
+}
+
+func TestWithCallOptions_Timeout(t *testing.T) {
+	// Given: A SQLite repository
+	store, err := repository.NewSQLiteTodoRepository(":memory:")
+	require.NoError(t, err)
+	defer store.Close()
+	_, err = store.CreateWorkspace(t.Context(), &model.Workspace{Slug: "acme", Name: "Acme"})
+	require.NoError(t, err)
+
+	// When: Its calls may take as long as they need to
+	repo := repository.WithCallOptions(store, repository.CallOptions{Timeout: time.Minute})
+
+	// Then: They go through, inside Atomically too, and so do the calls they make themselves
+	todo, err := repo.Create(t.Context(), &model.Todo{Text: "Buy milk"})
+	require.NoError(t, err)
+	todo.Text = "Buy oat milk"
+	_, err = repo.Update(t.Context(), todo)
+	require.NoError(t, err)
+	err = repo.Atomically(t.Context(), func(tx repository.TxRepository) error {
+		_, err := tx.GetByID(t.Context(), todo.ID)
+		return err
+	})
+	assert.NoError(t, err)
+
+	// When: They run out of time at once
+	repo = repository.WithCallOptions(store, repository.CallOptions{Timeout: time.Nanosecond})
+
+	// Then: They fail with the deadline, in other workspaces too
+	_, err = repo.GetAll(t.Context(), model.TodoFilter{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = repo.ForWorkspace(2).GetAll(t.Context(), model.TodoFilter{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	err = repo.Atomically(t.Context(), func(tx repository.TxRepository) error {
+		_, err := tx.GetByID(t.Context(), todo.ID)
+		return err
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// And: So do the workspaces opened through it
+	_, err = repository.SharedWorkspaces(repo).Open(t.Context(), 2)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
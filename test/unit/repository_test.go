@@ -7,17 +7,16 @@ import (
 	"todo-app/internal/repository"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
 )
 
 func TestInMemoryTodoRepository_Create(t *testing.T) {
 	// Given
-	repo, err := repository.NewSQLiteTodoRepository(":memory:")
-	require.NoError(t, err)
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
 	todo := &model.Todo{Text: "test todo"}
 
 	// When
-	result, err := repo.Create(todo)
+	result, err := repo.Create(ctx, todo)
 
 	// Then
 	assert.NoError(t, err)
@@ -29,15 +28,15 @@ func TestInMemoryTodoRepository_Create(t *testing.T) {
 
 func TestInMemoryTodoRepository_GetAll(t *testing.T) {
 	// Given
-	repo, err := repository.NewSQLiteTodoRepository(":memory:")
-	require.NoError(t, err)
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
 	todo1 := &model.Todo{Text: "todo 1"}
 	todo2 := &model.Todo{Text: "todo 2"}
-	repo.Create(todo1)
-	repo.Create(todo2)
+	repo.Create(ctx, todo1)
+	repo.Create(ctx, todo2)
 
 	// When
-	todos, err := repo.GetAll()
+	todos, err := repo.GetAll(ctx, model.TodoFilter{})
 
 	// Then
 	assert.NoError(t, err)
@@ -0,0 +1,69 @@
+package unit
+
+import (
+	"testing"
+
+	"todo-app/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearch(t *testing.T) {
+	term := func(prefix bool, words ...string) *model.SearchExpr {
+		return &model.SearchExpr{Op: model.SearchMatch, Words: words, Prefix: prefix}
+	}
+	op := func(op model.SearchOp, left, right *model.SearchExpr) *model.SearchExpr {
+		return &model.SearchExpr{Op: op, Left: left, Right: right}
+	}
+
+	tests := []struct {
+		input    string
+		expected *model.SearchExpr
+	}{
+		{input: "Milk", expected: term(false, "milk")},
+		{input: "milk*", expected: term(true, "milk")},
+		{input: `"oat milk"`, expected: term(false, "oat", "milk")},
+		{input: `"oat mi"*`, expected: term(true, "oat", "mi")},
+		{input: "e-mail", expected: term(false, "e", "mail")},
+		{input: "buy milk", expected: op(model.SearchAnd, term(false, "buy"), term(false, "milk"))},
+		{input: "buy AND milk OR bread", expected: op(model.SearchOr, op(model.SearchAnd, term(false, "buy"), term(false, "milk")), term(false, "bread"))},
+		{input: "buy milk NOT oat", expected: op(model.SearchAnd, term(false, "buy"), op(model.SearchNot, term(false, "milk"), term(false, "oat")))},
+		{input: "buy (milk OR bread)", expected: op(model.SearchAnd, term(false, "buy"), op(model.SearchOr, term(false, "milk"), term(false, "bread")))},
+		{input: "milk or bread", expected: op(model.SearchAnd, op(model.SearchAnd, term(false, "milk"), term(false, "or")), term(false, "bread"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			expr, err := model.ParseSearch(tt.input)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, expr)
+		})
+	}
+}
+
+func TestParseSearch_Errors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "  ", expected: "query is empty"},
+		{input: "milk AND", expected: "expected a term at position 9"},
+		{input: "OR milk", expected: "expected a term, got OR at position 1"},
+		{input: "(milk OR bread", expected: "unclosed ( at position 1"},
+		{input: "milk)", expected: "unexpected ) at position 5"},
+		{input: `buy "oat milk`, expected: "unterminated phrase at position 5"},
+		{input: "mi*lk", expected: "* must end a term at position 1"},
+		{input: "milk ---", expected: `"---" has no words to search for at position 6`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := model.ParseSearch(tt.input)
+
+			require.ErrorIs(t, err, model.ErrInvalidSearch)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"todo-app/internal/handler"
+	"todo-app/internal/repository"
+	"todo-app/internal/server"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startServer serves handler on a free port until ctx is done; the returned
+// channel receives what Serve returned
+func startServer(t *testing.T, ctx context.Context, srv *server.Server) (string, <-chan error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(ctx, ln)
+	}()
+	return "http://" + ln.Addr().String(), done
+}
+
+func TestServer_InFlightRequestsComplete(t *testing.T) {
+	// Given: A request in flight that finishes only when released
+	started, release := make(chan struct{}), make(chan struct{})
+	cfg := server.DefaultConfig("")
+	cfg.ReadinessDelay = 50 * time.Millisecond
+	srv := server.New(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "saved")
+	}))
+
+	var mu sync.Mutex
+	var shutdown []string
+	record := func(step string) {
+		mu.Lock()
+		defer mu.Unlock()
+		shutdown = append(shutdown, step)
+	}
+	srv.OnDrain(func() { record("drain") })
+	srv.OnStop(func() { record("not ready") })
+	srv.OnShutdown(func() error { record("close repository"); return nil })
+	srv.OnShutdown(func() error { record("stop dispatcher"); return nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	url, done := startServer(t, ctx, srv)
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{status: resp.StatusCode, body: string(body), err: err}
+	}()
+	<-started
+
+	// When: The server is told to stop
+	cancel()
+
+	// Then: It stops accepting connections but waits for the request
+	require.Eventually(t, func() bool {
+		conn, err := net.DialTimeout("tcp", strings.TrimPrefix(url, "http://"), 100*time.Millisecond)
+		if err == nil {
+			conn.Close()
+		}
+		return err != nil
+	}, time.Second, 10*time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("Serve returned before the request finished: %v", err)
+	default:
+	}
+
+	// When: The request finishes
+	close(release)
+
+	// Then: Its response arrives whole and the server shuts down cleanly
+	response := <-responses
+	require.NoError(t, response.err)
+	assert.Equal(t, http.StatusOK, response.status)
+	assert.Equal(t, "saved", response.body)
+	require.NoError(t, <-done)
+
+	// And: Readiness failed first, then the streams were told, then the
+	// resources closed last registered first
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"not ready", "drain", "stop dispatcher", "close repository"}, shutdown)
+}
+
+func TestServer_ReadinessFailsBeforeConnectionsAreRefused(t *testing.T) {
+	// Given: A server whose readiness probe fails once it is draining
+	repo, err := repository.NewSQLiteTodoRepository(":memory:")
+	require.NoError(t, err)
+	defer repo.Close()
+	healthSvc := service.NewHealthService(repo)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /readyz", handler.NewHealthHandler(healthSvc).Readyz)
+
+	cfg := server.DefaultConfig("")
+	cfg.ReadinessDelay = time.Second
+	srv := server.New(cfg, mux)
+	srv.OnStop(healthSvc.Drain)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	url, done := startServer(t, ctx, srv)
+	readyz := func() (int, error) {
+		resp, err := http.Get(url + "/readyz")
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+	status, err := readyz()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	// When: The server is told to stop
+	stopped := time.Now()
+	cancel()
+
+	// Then: Probes still connect during the readiness delay, and see it is not ready
+	require.Eventually(t, func() bool {
+		status, err := readyz()
+		return err == nil && status == http.StatusServiceUnavailable
+	}, cfg.ReadinessDelay/2, 10*time.Millisecond)
+
+	// And: Only after the delay are connections refused, and it shuts down cleanly
+	require.NoError(t, <-done)
+	assert.GreaterOrEqual(t, time.Since(stopped), cfg.ReadinessDelay)
+	_, err = readyz()
+	assert.Error(t, err)
+}
+
+func TestServer_DrainTimeout(t *testing.T) {
+	// Given: A request that outlives the drain period
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	cfg := server.DefaultConfig("")
+	cfg.DrainTimeout = 50 * time.Millisecond
+	srv := server.New(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	closed := false
+	srv.OnShutdown(func() error { closed = true; return nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	url, done := startServer(t, ctx, srv)
+	failed := make(chan error, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+		}
+		failed <- err
+	}()
+	<-started
+
+	// When: The server is told to stop
+	cancel()
+
+	// Then: The request is cut off, the error reported and the resources still closed
+	assert.ErrorIs(t, <-done, context.DeadlineExceeded)
+	assert.Error(t, <-failed)
+	assert.True(t, closed)
+}
+
+func TestServer_Limits(t *testing.T) {
+	// Given: A server with the default limits
+	cfg := server.DefaultConfig("")
+	assert.Positive(t, cfg.ReadHeaderTimeout)
+	assert.Positive(t, cfg.ReadTimeout)
+	assert.Positive(t, cfg.WriteTimeout)
+	assert.Positive(t, cfg.IdleTimeout)
+
+	srv := server.New(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ctx, cancel := context.WithCancel(context.Background())
+	url, done := startServer(t, ctx, srv)
+
+	// When: A request's headers are larger than allowed
+	req, err := http.NewRequest("GET", url, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Padding", strings.Repeat("a", 2*cfg.MaxHeaderBytes))
+	resp, err := http.DefaultClient.Do(req)
+
+	// Then: It is refused
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestHeaderFieldsTooLarge, resp.StatusCode)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestServer_RunReportsListenErrors(t *testing.T) {
+	// Given: An address already in use
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	srv := server.New(server.DefaultConfig(ln.Addr().String()), http.NotFoundHandler())
+	closed := false
+	srv.OnShutdown(func() error { closed = true; return nil })
+
+	// When: Running on it
+	err = srv.Run(context.Background())
+
+	// Then: It fails, still closing the resources
+	assert.Error(t, err)
+	assert.True(t, closed)
+}
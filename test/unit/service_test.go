@@ -1,8 +1,11 @@
 package unit
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
+	"todo-app/internal/model"
 	"todo-app/internal/repository"
 	"todo-app/internal/service"
 
@@ -12,12 +15,11 @@ import (
 
 func TestTodoService_CreateTodo(t *testing.T) {
 	// Given
-	repo, err := repository.NewSQLiteTodoRepository(":memory:")
-	require.NoError(t, err)
-	svc := service.NewTodoService(repo)
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
 
 	// When
-	todo, err := svc.CreateTodo("test todo")
+	todo, err := svc.CreateTodo(ctx, model.TodoInput{Text: "test todo"})
 
 	// Then
 	assert.NoError(t, err)
@@ -27,14 +29,13 @@ func TestTodoService_CreateTodo(t *testing.T) {
 
 func TestTodoService_GetAllTodos(t *testing.T) {
 	// Given
-	repo, err := repository.NewSQLiteTodoRepository(":memory:")
-	require.NoError(t, err)
-	svc := service.NewTodoService(repo)
-	svc.CreateTodo("todo 1")
-	svc.CreateTodo("todo 2")
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1"})
+	svc.CreateTodo(ctx, model.TodoInput{Text: "todo 2"})
 
 	// When
-	todos, err := svc.GetAllTodos()
+	todos, err := svc.GetAllTodos(ctx, model.TodoFilter{})
 
 	// Then
 	assert.NoError(t, err)
@@ -43,3 +44,229 @@ func TestTodoService_GetAllTodos(t *testing.T) {
 	assert.Equal(t, "todo 2", todos[0].Text)
 	assert.Equal(t, "todo 1", todos[1].Text)
 }
+
+func TestTodoService_UpdateTodo(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	created, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1"})
+	require.NoError(t, err)
+
+	// When
+	todo, err := svc.UpdateTodo(ctx, created.ID, model.TodoInput{Text: "todo 1 (edited)"})
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, "todo 1 (edited)", todo.Text)
+	assert.Equal(t, created.ID, todo.ID)
+}
+
+func TestTodoService_UpdateTodo_Validation(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	created, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1"})
+	require.NoError(t, err)
+
+	// When / Then
+	_, err = svc.UpdateTodo(ctx, created.ID, model.TodoInput{Text: "   "})
+	assert.ErrorIs(t, err, service.ErrEmptyText)
+
+	_, err = svc.UpdateTodo(ctx, 999, model.TodoInput{Text: "text"})
+	assert.ErrorIs(t, err, service.ErrTodoNotFound)
+}
+
+func TestTodoService_DeleteTodo(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	created, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1"})
+	require.NoError(t, err)
+
+	// When
+	err = svc.DeleteTodo(ctx, created.ID)
+
+	// Then
+	assert.NoError(t, err)
+	_, err = svc.GetTodo(ctx, created.ID)
+	assert.ErrorIs(t, err, service.ErrTodoNotFound)
+}
+
+func TestTodoService_CompleteAndReopenTodo(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	created, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1"})
+	require.NoError(t, err)
+
+	// When
+	completed, err := svc.CompleteTodo(ctx, created.ID)
+
+	// Then
+	require.NoError(t, err)
+	assert.True(t, completed.Completed)
+	require.NotNil(t, completed.CompletedAt)
+
+	// When: completing again keeps the original timestamp
+	again, err := svc.CompleteTodo(ctx, created.ID)
+	require.NoError(t, err)
+	require.NotNil(t, again.CompletedAt)
+	assert.True(t, completed.CompletedAt.Equal(*again.CompletedAt))
+
+	// When: reopening clears completion
+	reopened, err := svc.ReopenTodo(ctx, created.ID)
+	require.NoError(t, err)
+	assert.False(t, reopened.Completed)
+	assert.Nil(t, reopened.CompletedAt)
+}
+
+func TestTodoService_PatchTodo_DueAt(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	created, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1", DueAt: "2026-11-01"})
+	require.NoError(t, err)
+	require.NotNil(t, created.DueAt)
+
+	// When: changing to a timed due date
+	due := "2026-11-02T15:00:00+01:00"
+	todo, err := svc.PatchTodo(ctx, created.ID, model.TodoPatch{DueAt: model.Optional[string]{Set: true, Value: &due}})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, "2026-11-02T14:00:00Z", todo.DueAt.Format(time.RFC3339))
+	assert.False(t, todo.DueAllDay)
+
+	// When: an invalid due date is rejected
+	bad := "02/11/2026"
+	_, err = svc.PatchTodo(ctx, created.ID, model.TodoPatch{DueAt: model.Optional[string]{Set: true, Value: &bad}})
+	assert.ErrorIs(t, err, service.ErrInvalidDueDate)
+
+	// When: clearing it with null
+	todo, err = svc.PatchTodo(ctx, created.ID, model.TodoPatch{DueAt: model.Optional[string]{Set: true}})
+	require.NoError(t, err)
+	assert.Nil(t, todo.DueAt)
+	assert.Equal(t, "todo 1", todo.Text)
+}
+
+func TestTodoService_Priority(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	created, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1", Priority: "high"})
+	require.NoError(t, err)
+	assert.Equal(t, model.PriorityHigh, created.Priority)
+
+	// When: an unknown priority is rejected
+	bad := "critical"
+	_, err = svc.PatchTodo(ctx, created.ID, model.TodoPatch{Priority: &bad})
+	assert.ErrorIs(t, err, service.ErrInvalidPriority)
+
+	// When: a PUT without priority resets it to none
+	todo, err := svc.UpdateTodo(ctx, created.ID, model.TodoInput{Text: "todo 1"})
+	require.NoError(t, err)
+	assert.Equal(t, model.PriorityNone, todo.Priority)
+}
+
+func TestTodoService_Tags(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+
+	// When: tags are normalized and deduplicated
+	created, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1", Tags: []string{" Work ", "work", "Home"}})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"home", "work"}, created.Tags)
+
+	// When: an empty tag is rejected
+	_, err = svc.PatchTodo(ctx, created.ID, model.TodoPatch{Tags: &[]string{" "}})
+	assert.ErrorIs(t, err, service.ErrInvalidTag)
+
+	// When: renaming to a name in use or merging a tag into itself
+	tags, err := svc.GetTags(ctx)
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	_, err = svc.RenameTag(ctx, tags[0].ID, "WORK")
+	assert.ErrorIs(t, err, service.ErrTagExists)
+	_, err = svc.MergeTags(ctx, tags[0].ID, tags[0].ID)
+	assert.ErrorIs(t, err, service.ErrMergeSameTag)
+}
+
+func TestTodoService_Lists(t *testing.T) {
+	// Given
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
+	svc := service.NewTodoService(repo)
+	work, err := service.NewListService(repo).CreateList(ctx, model.ListInput{Name: " Work "})
+	require.NoError(t, err)
+	assert.Equal(t, "Work", work.Name)
+
+	// When: a todo is created in an unknown list
+	_, err = svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1", ListID: 999})
+	assert.ErrorIs(t, err, service.ErrUnknownList)
+
+	// When: a PUT without list_id keeps the todo in its list
+	created, err := svc.CreateTodo(ctx, model.TodoInput{Text: "todo 1", ListID: work.ID})
+	require.NoError(t, err)
+	todo, err := svc.UpdateTodo(ctx, created.ID, model.TodoInput{Text: "todo 2"})
+	require.NoError(t, err)
+	assert.Equal(t, work.ID, todo.ListID)
+
+	// When: moving it to an unknown list
+	missing := 999
+	_, err = svc.PatchTodo(ctx, created.ID, model.TodoPatch{ListID: &missing})
+	assert.ErrorIs(t, err, service.ErrUnknownList)
+}
+
+func TestTodoService_ListTodos(t *testing.T) {
+	// Given: Five todos
+	ctx := t.Context()
+	svc := service.NewTodoService(repository.NewInMemoryTodoRepository())
+	for i := 1; i <= 5; i++ {
+		_, err := svc.CreateTodo(ctx, model.TodoInput{Text: fmt.Sprintf("todo %d", i)})
+		require.NoError(t, err)
+	}
+
+	// When: Reading the first page
+	first, err := svc.ListTodos(ctx, model.TodoFilter{}, 2)
+
+	// Then: Only a next cursor is offered
+	require.NoError(t, err)
+	assert.Len(t, first.Todos, 2)
+	assert.NotEmpty(t, first.NextCursor)
+	assert.Empty(t, first.PrevCursor)
+
+	// When: Following it to the last page
+	cursor, err := model.DecodeCursor(first.NextCursor)
+	require.NoError(t, err)
+	second, err := svc.ListTodos(ctx, model.TodoFilter{Cursor: cursor}, 2)
+	require.NoError(t, err)
+	cursor, err = model.DecodeCursor(second.NextCursor)
+	require.NoError(t, err)
+	last, err := svc.ListTodos(ctx, model.TodoFilter{Cursor: cursor}, 2)
+
+	// Then: The last page only points back
+	require.NoError(t, err)
+	require.Len(t, last.Todos, 1)
+	assert.Equal(t, "todo 1", last.Todos[0].Text)
+	assert.Empty(t, last.NextCursor)
+	assert.NotEmpty(t, last.PrevCursor)
+
+	// When: Paging back from the second page
+	cursor, err = model.DecodeCursor(second.PrevCursor)
+	require.NoError(t, err)
+	back, err := svc.ListTodos(ctx, model.TodoFilter{Cursor: cursor}, 2)
+
+	// Then: The first page comes back without a previous page
+	require.NoError(t, err)
+	assert.Equal(t, first.Todos[0].ID, back.Todos[0].ID)
+	assert.Len(t, back.Todos, 2)
+	assert.Empty(t, back.PrevCursor)
+	assert.NotEmpty(t, back.NextCursor)
+
+	// When: Reusing the cursor with another sort order
+	_, err = svc.ListTodos(ctx, model.TodoFilter{Cursor: cursor, Sort: []model.SortKey{{Field: model.SortText}}}, 2)
+	assert.ErrorIs(t, err, service.ErrCursorMismatch)
+}
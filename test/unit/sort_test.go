@@ -0,0 +1,52 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"todo-app/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		input      string
+		expected   []model.SortKey
+		shouldFail bool
+	}{
+		{input: "priority", expected: []model.SortKey{{Field: "priority"}}},
+		{input: "priority:desc,due_at:asc", expected: []model.SortKey{{Field: "priority", Desc: true}, {Field: "due_at"}}},
+		{input: "text, updated_at:desc", expected: []model.SortKey{{Field: "text"}, {Field: "updated_at", Desc: true}}},
+		{input: "", shouldFail: true},
+		{input: "priority,", shouldFail: true},
+		{input: "id", shouldFail: true},
+		{input: "priority:down", shouldFail: true},
+		{input: "created_at,created_at:desc", shouldFail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			keys, err := model.ParseSort(tt.input)
+
+			if tt.shouldFail {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, keys)
+		})
+	}
+}
+
+func TestPriority_JSON(t *testing.T) {
+	data, err := json.Marshal(model.Todo{Priority: model.PriorityUrgent})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"priority":"urgent"`)
+
+	var priority model.Priority
+	require.NoError(t, json.Unmarshal([]byte(`"medium"`), &priority))
+	assert.Equal(t, model.PriorityMedium, priority)
+	assert.Error(t, json.Unmarshal([]byte(`"critical"`), &priority))
+}
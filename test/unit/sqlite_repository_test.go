@@ -1,6 +1,8 @@
 package unit
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"testing"
@@ -15,13 +17,14 @@ import (
 
 func TestSQLiteTodoRepository_Create(t *testing.T) {
 	// Given: Clean test database
+	ctx := t.Context()
 	repo, cleanup := setupTestDB(t)
 	defer cleanup()
 
 	todo := &model.Todo{Text: "test todo"}
 
 	// When: Create todo
-	result, err := repo.Create(todo)
+	result, err := repo.Create(ctx, todo)
 
 	// Then: Todo should be created successfully
 	require.NoError(t, err)
@@ -33,16 +36,17 @@ func TestSQLiteTodoRepository_Create(t *testing.T) {
 
 func TestSQLiteTodoRepository_GetAll(t *testing.T) {
 	// Given: Test database with todos
+	ctx := t.Context()
 	repo, cleanup := setupTestDB(t)
 	defer cleanup()
 
 	todo1 := &model.Todo{Text: "todo 1"}
 	todo2 := &model.Todo{Text: "todo 2"}
-	repo.Create(todo1)
-	repo.Create(todo2)
+	repo.Create(ctx, todo1)
+	repo.Create(ctx, todo2)
 
 	// When: Get all todos
-	todos, err := repo.GetAll()
+	todos, err := repo.GetAll(ctx, model.TodoFilter{})
 
 	// Then: All todos should be returned
 	require.NoError(t, err)
@@ -57,11 +61,12 @@ func TestSQLiteTodoRepository_GetAll(t *testing.T) {
 
 func TestSQLiteTodoRepository_DatabasePersistence(t *testing.T) {
 	// Given: Database with a todo
+	ctx := t.Context()
 	repo, cleanup := setupTestDB(t)
 	defer cleanup()
 
 	originalTodo := &model.Todo{Text: "persistent todo"}
-	created, err := repo.Create(originalTodo)
+	created, err := repo.Create(ctx, originalTodo)
 	require.NoError(t, err)
 
 	// When: Create new repository instance (simulates server restart)
@@ -69,13 +74,188 @@ func TestSQLiteTodoRepository_DatabasePersistence(t *testing.T) {
 	defer cleanup2()
 
 	// Then: Todo should still exist
-	todos, err := repo2.GetAll()
+	todos, err := repo2.GetAll(ctx, model.TodoFilter{})
 	require.NoError(t, err)
 	assert.Len(t, todos, 1)
 	assert.Equal(t, created.ID, todos[0].ID)
 	assert.Equal(t, "persistent todo", todos[0].Text)
 }
 
+func TestSQLiteTodoRepository_GetByID(t *testing.T) {
+	// Given: Database with a todo
+	ctx := t.Context()
+	repo, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	created, err := repo.Create(ctx, &model.Todo{Text: "find me"})
+	require.NoError(t, err)
+
+	// When: Get the todo by ID
+	todo, err := repo.GetByID(ctx, created.ID)
+
+	// Then: The same todo should be returned
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, todo.ID)
+	assert.Equal(t, "find me", todo.Text)
+
+	// And: Unknown IDs should report ErrTodoNotFound
+	_, err = repo.GetByID(ctx, 999)
+	assert.ErrorIs(t, err, repository.ErrTodoNotFound)
+}
+
+func TestSQLiteTodoRepository_Update(t *testing.T) {
+	// Given: Database with a todo
+	ctx := t.Context()
+	repo, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	created, err := repo.Create(ctx, &model.Todo{Text: "typo todoo"})
+	require.NoError(t, err)
+
+	// When: Update its text
+	updated, err := repo.Update(ctx, &model.Todo{ID: created.ID, Text: "typo todo"})
+
+	// Then: The change should be persisted and updated_at bumped
+	require.NoError(t, err)
+	assert.Equal(t, "typo todo", updated.Text)
+	assert.False(t, updated.UpdatedAt.Before(created.UpdatedAt))
+
+	todo, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "typo todo", todo.Text)
+
+	// And: Updating an unknown ID should report ErrTodoNotFound
+	_, err = repo.Update(ctx, &model.Todo{ID: 999, Text: "ghost"})
+	assert.ErrorIs(t, err, repository.ErrTodoNotFound)
+}
+
+func TestSQLiteTodoRepository_Delete(t *testing.T) {
+	// Given: Database with two todos
+	ctx := t.Context()
+	repo, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	keep, err := repo.Create(ctx, &model.Todo{Text: "keep"})
+	require.NoError(t, err)
+	remove, err := repo.Create(ctx, &model.Todo{Text: "remove"})
+	require.NoError(t, err)
+
+	// When: Delete one of them
+	err = repo.Delete(ctx, remove.ID)
+
+	// Then: Only the other todo should remain
+	require.NoError(t, err)
+	todos, err := repo.GetAll(ctx, model.TodoFilter{})
+	require.NoError(t, err)
+	assert.Len(t, todos, 1)
+	assert.Equal(t, keep.ID, todos[0].ID)
+
+	// And: Deleting it again should report ErrTodoNotFound
+	err = repo.Delete(ctx, remove.ID)
+	assert.ErrorIs(t, err, repository.ErrTodoNotFound)
+}
+
+func TestSQLiteTodoRepository_Completion(t *testing.T) {
+	// Given: Database with an open and a completed todo
+	ctx := t.Context()
+	repo, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	open, err := repo.Create(ctx, &model.Todo{Text: "open"})
+	require.NoError(t, err)
+	done, err := repo.Create(ctx, &model.Todo{Text: "done"})
+	require.NoError(t, err)
+
+	completedAt := time.Now().UTC().Truncate(time.Second)
+	done.Completed = true
+	done.CompletedAt = &completedAt
+	_, err = repo.Update(ctx, done)
+	require.NoError(t, err)
+
+	// When: Filter by completion state
+	completed := true
+	doneTodos, err := repo.GetAll(ctx, model.TodoFilter{Completed: &completed})
+	require.NoError(t, err)
+	completed = false
+	openTodos, err := repo.GetAll(ctx, model.TodoFilter{Completed: &completed})
+	require.NoError(t, err)
+
+	// Then: Each filter should return only matching todos
+	require.Len(t, doneTodos, 1)
+	assert.Equal(t, done.ID, doneTodos[0].ID)
+	assert.True(t, doneTodos[0].Completed)
+	require.NotNil(t, doneTodos[0].CompletedAt)
+	assert.True(t, completedAt.Equal(*doneTodos[0].CompletedAt))
+
+	require.Len(t, openTodos, 1)
+	assert.Equal(t, open.ID, openTodos[0].ID)
+	assert.False(t, openTodos[0].Completed)
+	assert.Nil(t, openTodos[0].CompletedAt)
+}
+
+func TestSQLiteTodoRepository_MigratesLegacySchema(t *testing.T) {
+	// Given: A database created before completion tracking existed
+	ctx := t.Context()
+	dbFile := fmt.Sprintf("test_legacy_%d.db", time.Now().UnixNano())
+	defer os.Remove(dbFile)
+
+	legacy, err := sql.Open("sqlite3", dbFile)
+	require.NoError(t, err)
+	_, err = legacy.Exec(`
+		CREATE TABLE todos (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			text TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO todos (text) VALUES ('legacy todo');
+	`)
+	require.NoError(t, err)
+	require.NoError(t, legacy.Close())
+
+	// When: The repository opens it
+	repo, err := repository.NewSQLiteTodoRepository(dbFile)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	// Then: Existing todos should be readable as not completed
+	todos, err := repo.GetAll(ctx, model.TodoFilter{})
+	require.NoError(t, err)
+	require.Len(t, todos, 1)
+	assert.Equal(t, "legacy todo", todos[0].Text)
+	assert.False(t, todos[0].Completed)
+	assert.Nil(t, todos[0].CompletedAt)
+}
+
+func TestSQLiteTodoRepository_IndexesExistingTodosForSearch(t *testing.T) {
+	// Given: A database whose search triggers were dropped by a build without FTS5
+	ctx := t.Context()
+	repo, cleanup := setupTestDB(t)
+	defer cleanup()
+	_, err := repo.Create(ctx, &model.Todo{Text: "renew passport"})
+	require.NoError(t, err)
+
+	db, err := sql.Open("sqlite3", repo.DBPath())
+	require.NoError(t, err)
+	_, err = db.Exec(`DROP TRIGGER IF EXISTS todos_fts_insert; INSERT INTO todos (text, list_id) VALUES ('passport photos', 1)`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// When: The repository opens it again
+	reopened, cleanup2 := setupTestDBWithSameFile(t, repo.DBPath())
+	defer cleanup2()
+
+	// Then: The index is rebuilt, so todos written meanwhile are found too
+	query, err := model.ParseSearch("passport")
+	require.NoError(t, err)
+	results, err := reopened.SearchTodos(ctx, query, 0, 10)
+	if errors.Is(err, repository.ErrSearchUnavailable) {
+		t.Skip("full-text search is not available; build with -tags sqlite_fts5")
+	}
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+}
+
 // Test helper functions
 func setupTestDB(t *testing.T) (*repository.SQLiteTodoRepository, func()) {
 	// Set test environment
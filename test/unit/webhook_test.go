@@ -0,0 +1,399 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+	"todo-app/internal/webhook"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// receivedRequest is a webhook request seen by a test receiver
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newReceiver starts an endpoint answering each request with the next of
+// statuses, then 200, and records what it was sent
+func newReceiver(t *testing.T, statuses ...int) (*httptest.Server, <-chan receivedRequest) {
+	t.Helper()
+	received := make(chan receivedRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedRequest{header: r.Header.Clone(), body: body}
+		status := http.StatusOK
+		if len(statuses) > 0 {
+			status, statuses = statuses[0], statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+// loopback lets a dispatcher reach the test receivers, which listen on 127.0.0.1
+var loopback = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+
+// newDispatcher creates a dispatcher for store allowed to reach the test receivers
+func newDispatcher(store webhook.Store) *webhook.Dispatcher {
+	dispatcher := webhook.NewDispatcher(store)
+	dispatcher.Client = webhook.NewClient(loopback)
+	return dispatcher
+}
+
+// setupWebhook registers a webhook for url and creates a todo, queueing one delivery
+func setupWebhook(t *testing.T, url string) (repository.TodoRepository, *model.Webhook) {
+	t.Helper()
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
+	created, err := service.NewWebhookService(repo).CreateWebhook(ctx, model.WebhookInput{URL: url, Secret: "s3cret"})
+	require.NoError(t, err)
+	_, err = service.NewTodoService(repo).CreateTodo(ctx, model.TodoInput{Text: "Buy milk"})
+	require.NoError(t, err)
+	return repo, created
+}
+
+func TestDispatcher_DeliversSignedPayload(t *testing.T) {
+	// Given: A webhook with one queued delivery
+	ctx := t.Context()
+	receiver, received := newReceiver(t)
+	repo, created := setupWebhook(t, receiver.URL)
+
+	// When: The dispatcher runs
+	require.NoError(t, newDispatcher(repo).DeliverDue(context.Background()))
+
+	// Then: The endpoint gets the event signed with the secret
+	request := <-received
+	assert.Equal(t, webhook.Sign("s3cret", request.body), request.header.Get(webhook.SignatureHeader))
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, request.header.Get(webhook.SignatureHeader))
+	assert.Equal(t, "created", request.header.Get(webhook.EventHeader))
+	assert.Equal(t, "application/json", request.header.Get("Content-Type"))
+	assert.Contains(t, string(request.body), `"text":"Buy milk"`)
+
+	// And: The delivery is recorded as delivered, under the ID it was sent with
+	deliveries, err := repo.GetDeliveries(ctx, created.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, strconv.FormatInt(deliveries[0].ID, 10), request.header.Get(webhook.DeliveryHeader))
+	assert.Equal(t, model.DeliveryDelivered, deliveries[0].Status)
+	assert.Nil(t, deliveries[0].NextAttemptAt)
+	require.Len(t, deliveries[0].Attempts, 1)
+	assert.Equal(t, http.StatusOK, deliveries[0].Attempts[0].StatusCode)
+	assert.Empty(t, deliveries[0].Attempts[0].Error)
+
+	// And: It is not sent again
+	require.NoError(t, newDispatcher(repo).DeliverDue(context.Background()))
+	assert.Empty(t, received)
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	// Given: An endpoint that fails twice and a dispatcher doubling a 50ms delay up to 80ms
+	ctx := t.Context()
+	receiver, received := newReceiver(t, http.StatusInternalServerError, http.StatusServiceUnavailable)
+	repo, created := setupWebhook(t, receiver.URL)
+	dispatcher := newDispatcher(repo)
+	dispatcher.BaseDelay = 50 * time.Millisecond
+	dispatcher.MaxDelay = 80 * time.Millisecond
+
+	// When: The first attempt fails
+	require.NoError(t, dispatcher.DeliverDue(context.Background()))
+	<-received
+
+	// Then: The delivery stays pending, due again after the base delay
+	deliveries, err := repo.GetDeliveries(ctx, created.ID, 10)
+	require.NoError(t, err)
+	delivery := deliveries[0]
+	assert.Equal(t, model.DeliveryPending, delivery.Status)
+	assert.Equal(t, http.StatusInternalServerError, delivery.Attempts[0].StatusCode)
+	assert.Equal(t, "endpoint answered 500 Internal Server Error", delivery.Attempts[0].Error)
+	assert.InDelta(t, 50*time.Millisecond, delivery.NextAttemptAt.Sub(delivery.Attempts[0].AttemptedAt), float64(20*time.Millisecond))
+
+	// And: It is not sent again before then
+	require.NoError(t, dispatcher.DeliverDue(context.Background()))
+	assert.Empty(t, received)
+
+	// When: The delay passes and the second attempt fails
+	time.Sleep(time.Until(*delivery.NextAttemptAt))
+	require.NoError(t, dispatcher.DeliverDue(context.Background()))
+	<-received
+
+	// Then: The delay doubles, capped at the maximum
+	deliveries, err = repo.GetDeliveries(ctx, created.ID, 10)
+	require.NoError(t, err)
+	delivery = deliveries[0]
+	require.Len(t, delivery.Attempts, 2)
+	assert.InDelta(t, 80*time.Millisecond, delivery.NextAttemptAt.Sub(delivery.Attempts[1].AttemptedAt), float64(20*time.Millisecond))
+
+	// When: The third attempt is made
+	time.Sleep(time.Until(*delivery.NextAttemptAt))
+	require.NoError(t, dispatcher.DeliverDue(context.Background()))
+	<-received
+
+	// Then: It succeeds and the delivery is done
+	deliveries, err = repo.GetDeliveries(ctx, created.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryDelivered, deliveries[0].Status)
+	assert.Len(t, deliveries[0].Attempts, 3)
+}
+
+func TestDispatcher_FailsAfterMaxAttempts(t *testing.T) {
+	// Given: An endpoint that always fails and a dispatcher allowing two attempts
+	ctx := t.Context()
+	receiver, received := newReceiver(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	repo, created := setupWebhook(t, receiver.URL)
+	dispatcher := newDispatcher(repo)
+	dispatcher.BaseDelay = -time.Hour
+	dispatcher.MaxAttempts = 2
+
+	// When: The dispatcher runs three times
+	for range 3 {
+		require.NoError(t, dispatcher.DeliverDue(context.Background()))
+	}
+
+	// Then: Two attempts were made and the delivery failed
+	assert.Len(t, received, 2)
+	deliveries, err := repo.GetDeliveries(ctx, created.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryFailed, deliveries[0].Status)
+	assert.Nil(t, deliveries[0].NextAttemptAt)
+	assert.Len(t, deliveries[0].Attempts, 2)
+}
+
+func TestDispatcher_RecordsConnectionErrors(t *testing.T) {
+	// Given: A webhook whose endpoint is down
+	ctx := t.Context()
+	receiver, _ := newReceiver(t)
+	repo, created := setupWebhook(t, receiver.URL)
+	receiver.Close()
+
+	// When: The dispatcher runs
+	require.NoError(t, newDispatcher(repo).DeliverDue(context.Background()))
+
+	// Then: The attempt is recorded without a status code
+	deliveries, err := repo.GetDeliveries(ctx, created.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries[0].Attempts, 1)
+	assert.Zero(t, deliveries[0].Attempts[0].StatusCode)
+	assert.NotEmpty(t, deliveries[0].Attempts[0].Error)
+	assert.Equal(t, model.DeliveryPending, deliveries[0].Status)
+}
+
+func TestDispatcher_RefusesPrivateAddresses(t *testing.T) {
+	// Given: A webhook whose endpoint listens on 127.0.0.1, retried after 10ms
+	ctx := t.Context()
+	receiver, received := newReceiver(t)
+	repo, created := setupWebhook(t, receiver.URL)
+	dispatcher := webhook.NewDispatcher(repo)
+	dispatcher.BaseDelay = 10 * time.Millisecond
+
+	// When: The dispatcher runs with the default client
+	require.NoError(t, dispatcher.DeliverDue(context.Background()))
+
+	// Then: Nothing is sent and the attempt fails with the reason
+	assert.Empty(t, received)
+	deliveries, err := repo.GetDeliveries(ctx, created.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries[0].Attempts, 1)
+	assert.Zero(t, deliveries[0].Attempts[0].StatusCode)
+	assert.Contains(t, deliveries[0].Attempts[0].Error, webhook.ErrForbiddenAddress.Error())
+	assert.Equal(t, model.DeliveryPending, deliveries[0].Status)
+
+	// When: The operator allows loopback addresses and the retry is made
+	dispatcher.Client = webhook.NewClient(loopback)
+	time.Sleep(time.Until(*deliveries[0].NextAttemptAt))
+	require.NoError(t, dispatcher.DeliverDue(context.Background()))
+
+	// Then: The delivery is sent
+	<-received
+	deliveries, err = repo.GetDeliveries(ctx, created.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryDelivered, deliveries[0].Status)
+	assert.Len(t, deliveries[0].Attempts, 2)
+}
+
+func TestDispatcher_DoesNotFollowRedirects(t *testing.T) {
+	// Given: A webhook whose endpoint redirects to another one
+	ctx := t.Context()
+	target, received := newReceiver(t)
+	redirector := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer redirector.Close()
+	repo, created := setupWebhook(t, redirector.URL)
+
+	// When: The dispatcher runs
+	require.NoError(t, newDispatcher(repo).DeliverDue(context.Background()))
+
+	// Then: The redirect target is sent nothing and the attempt fails with the redirect status
+	assert.Empty(t, received)
+	deliveries, err := repo.GetDeliveries(ctx, created.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries[0].Attempts, 1)
+	assert.Equal(t, http.StatusTemporaryRedirect, deliveries[0].Attempts[0].StatusCode)
+	assert.Equal(t, "endpoint answered 307 Temporary Redirect", deliveries[0].Attempts[0].Error)
+	assert.Equal(t, model.DeliveryPending, deliveries[0].Status)
+}
+
+func TestParseAllowedNetworks(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []netip.Prefix
+		wantErr bool
+	}{
+		{name: "empty", input: ""},
+		{
+			name:  "prefixes and addresses",
+			input: "10.0.0.0/8, 192.168.1.7,fd00::/8",
+			want: []netip.Prefix{
+				netip.MustParsePrefix("10.0.0.0/8"),
+				netip.MustParsePrefix("192.168.1.7/32"),
+				netip.MustParsePrefix("fd00::/8"),
+			},
+		},
+		{name: "host bits masked", input: "10.1.2.3/8", want: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}},
+		{name: "not an address", input: "10.0.0.0/8,intranet", wantErr: true},
+		{name: "bad prefix length", input: "10.0.0.0/33", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When: The list is parsed
+			got, err := webhook.ParseAllowedNetworks(tt.input)
+
+			// Then: It gives the prefixes, or an error
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDispatcher_SharedDatabaseSendsOnce(t *testing.T) {
+	// Given: Two backends sharing one database, with twenty queued deliveries
+	ctx := t.Context()
+	var mu sync.Mutex
+	sent := make(map[string]int) // delivery ID -> times sent
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sent[r.Header.Get(webhook.DeliveryHeader)]++
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond) // Keeps both dispatchers busy at once
+	}))
+	defer receiver.Close()
+
+	dbFile := filepath.Join(t.TempDir(), "todos.db")
+	var stores [2]repository.TodoRepository
+	for i := range stores {
+		repo, err := repository.NewSQLiteTodoRepository(dbFile)
+		require.NoError(t, err)
+		defer repo.Close()
+		stores[i] = repo
+	}
+	created, err := service.NewWebhookService(stores[0]).CreateWebhook(ctx, model.WebhookInput{URL: receiver.URL, Secret: "s3cret"})
+	require.NoError(t, err)
+	for i := range 20 {
+		_, err := service.NewTodoService(stores[0]).CreateTodo(ctx, model.TodoInput{Text: fmt.Sprintf("Todo %d", i)})
+		require.NoError(t, err)
+	}
+
+	// When: The dispatcher of each backend runs at the same time
+	var wg sync.WaitGroup
+	errs := make([]error, len(stores))
+	for i, store := range stores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = newDispatcher(store).DeliverDue(context.Background())
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, errors.Join(errs...))
+
+	// Then: Every delivery was sent once and recorded with one attempt
+	deliveries, err := stores[0].GetDeliveries(ctx, created.ID, 50)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 20)
+	for _, delivery := range deliveries {
+		assert.Equal(t, 1, sent[strconv.FormatInt(delivery.ID, 10)], "delivery %d", delivery.ID)
+		assert.Equal(t, model.DeliveryDelivered, delivery.Status)
+		assert.Len(t, delivery.Attempts, 1)
+	}
+	assert.Len(t, sent, 20)
+}
+
+func TestWebhookService_Validation(t *testing.T) {
+	ctx := t.Context()
+	svc := service.NewWebhookService(repository.NewInMemoryTodoRepository())
+
+	tests := []struct {
+		name          string
+		input         model.WebhookInput
+		expectedErr   error
+		expectedTypes []model.TodoEventType
+	}{
+		{name: "every type by default", input: model.WebhookInput{URL: "https://example.com/hook"},
+			expectedTypes: []model.TodoEventType{model.TodoCreated, model.TodoUpdated, model.TodoDeleted}},
+		{name: "types in canonical order without duplicates", input: model.WebhookInput{URL: "http://localhost:9000", EventTypes: []string{"deleted", "created", "deleted"}},
+			expectedTypes: []model.TodoEventType{model.TodoCreated, model.TodoDeleted}},
+		{name: "unknown type", input: model.WebhookInput{URL: "https://example.com", EventTypes: []string{"archived"}}, expectedErr: service.ErrInvalidEventType},
+		{name: "relative URL", input: model.WebhookInput{URL: "/hook"}, expectedErr: service.ErrInvalidWebhookURL},
+		{name: "unsupported scheme", input: model.WebhookInput{URL: "ftp://example.com"}, expectedErr: service.ErrInvalidWebhookURL},
+		{name: "empty URL", input: model.WebhookInput{}, expectedErr: service.ErrInvalidWebhookURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, err := svc.CreateWebhook(ctx, tt.input)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedTypes, created.EventTypes)
+			assert.Len(t, created.Secret, 64, "a generated secret")
+		})
+	}
+}
+
+func TestWebhookService_HidesSecret(t *testing.T) {
+	// Given: A webhook with a secret
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
+	svc := service.NewWebhookService(repo)
+	created, err := svc.CreateWebhook(ctx, model.WebhookInput{URL: "https://example.com", Secret: "s3cret"})
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", created.Secret)
+
+	// When: It is read and replaced without a secret
+	found, err := svc.GetWebhook(ctx, created.ID)
+	require.NoError(t, err)
+	replaced, err := svc.ReplaceWebhook(ctx, created.ID, model.WebhookInput{URL: "https://example.com/v2"})
+	require.NoError(t, err)
+
+	// Then: The secret is not shown but is kept
+	assert.Empty(t, found.Secret)
+	assert.Empty(t, replaced.Secret)
+	stored, err := repo.GetWebhook(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", stored.Secret)
+	assert.Equal(t, "https://example.com/v2", stored.URL)
+}
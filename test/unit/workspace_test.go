@@ -0,0 +1,207 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+
+	"todo-app/internal/model"
+	"todo-app/internal/repository"
+	"todo-app/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceService_CreateWorkspace(t *testing.T) {
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
+	svc := service.NewWorkspaceService(repo, repository.SharedWorkspaces(repo))
+
+	// When: Creating a workspace
+	workspace, err := svc.CreateWorkspace(ctx, model.WorkspaceInput{Slug: " Acme ", Name: " Acme Corp "})
+
+	// Then: The slug is normalized and the settings are the defaults
+	require.NoError(t, err)
+	assert.Equal(t, "acme", workspace.Slug)
+	assert.Equal(t, "Acme Corp", workspace.Name)
+	assert.Equal(t, model.DefaultWorkspaceSettings(workspace.ID), workspace.Settings)
+	assert.False(t, workspace.Settings.Registration)
+
+	found, err := svc.GetWorkspaceBySlug(ctx, "ACME")
+	require.NoError(t, err)
+	assert.Equal(t, workspace.ID, found.ID)
+
+	tests := []struct {
+		name     string
+		input    model.WorkspaceInput
+		expected error
+	}{
+		{name: "short slug", input: model.WorkspaceInput{Slug: "ab", Name: "AB"}, expected: service.ErrInvalidWorkspaceSlug},
+		{name: "slug with spaces", input: model.WorkspaceInput{Slug: "acme corp", Name: "Acme"}, expected: service.ErrInvalidWorkspaceSlug},
+		{name: "empty name", input: model.WorkspaceInput{Slug: "globex", Name: " "}, expected: service.ErrInvalidWorkspaceName},
+		{name: "taken slug", input: model.WorkspaceInput{Slug: "ACME", Name: "Other"}, expected: service.ErrWorkspaceExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.CreateWorkspace(ctx, tt.input)
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+func TestWorkspaceService_SettingsAreEnforced(t *testing.T) {
+	// Given: Alice and Bob in a workspace that turned sharing and webhooks off
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
+	workspaces := service.NewWorkspaceService(repo, repository.SharedWorkspaces(repo))
+	acme, err := workspaces.CreateWorkspace(ctx, model.WorkspaceInput{Slug: "acme", Name: "Acme"})
+	require.NoError(t, err)
+	off, on := false, true
+	acme, err = workspaces.UpdateSettings(ctx, acme.ID, model.WorkspaceSettingsPatch{Sharing: &off, Webhooks: &off, Registration: &on})
+	require.NoError(t, err)
+	assert.Equal(t, model.WorkspaceSettings{Registration: true}, acme.Settings)
+
+	auth := newAuthService(repo)
+	alice, _, err := auth.Register(ctx, model.Credentials{Username: "alice", Password: "correct horse battery", Workspace: "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, acme.ID, alice.WorkspaceID)
+	_, _, err = auth.Register(ctx, model.Credentials{Username: "bob", Password: "correct horse battery", Workspace: "acme"})
+	require.NoError(t, err)
+
+	storage, err := workspaces.Open(ctx, acme.ID)
+	require.NoError(t, err)
+	lists := service.NewListService(repo).ForWorkspace(storage).ForUser(alice.ID)
+	work, err := lists.CreateList(ctx, model.ListInput{Name: "Work"})
+	require.NoError(t, err)
+
+	// When: Alice shares a list or adds a webhook
+	_, shareErr := lists.InviteMember(ctx, work.ID, model.ListMemberInput{Username: "bob", Role: "editor"})
+	_, webhookErr := service.NewWebhookService(repo).ForWorkspace(storage).ForUser(alice.ID).
+		CreateWebhook(ctx, model.WebhookInput{URL: "https://example.com/hook", EventTypes: []string{string(model.TodoCreated)}})
+
+	// Then: Neither is allowed
+	assert.ErrorIs(t, shareErr, service.ErrSharingDisabled)
+	assert.ErrorIs(t, webhookErr, service.ErrWebhooksDisabled)
+
+	// And: The default workspace is unaffected
+	settings, err := repo.GetWorkspaceSettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, model.DefaultWorkspaceSettings(model.DefaultWorkspaceID), settings)
+}
+
+func TestListService_InviteOnlyWithinWorkspace(t *testing.T) {
+	// Given: Alice in the default workspace and Bob in another one
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
+	acme, err := service.NewWorkspaceService(repo, repository.SharedWorkspaces(repo)).
+		CreateWorkspace(ctx, model.WorkspaceInput{Slug: "acme", Name: "Acme"})
+	require.NoError(t, err)
+	alice := createUser(t, repo, "alice")
+	_, err = repo.CreateUser(ctx, &model.User{WorkspaceID: acme.ID, Username: "bob", PasswordHash: "hash of bob"})
+	require.NoError(t, err)
+
+	lists := service.NewListService(repo).ForUser(alice.ID)
+	work, err := lists.CreateList(ctx, model.ListInput{Name: "Work"})
+	require.NoError(t, err)
+
+	// When: Alice invites Bob
+	_, err = lists.InviteMember(ctx, work.ID, model.ListMemberInput{Username: "bob", Role: "editor"})
+
+	// Then: To her he does not exist
+	assert.ErrorIs(t, err, service.ErrUserNotFound)
+}
+
+func TestAuthService_RegistrationIsOpenedPerWorkspace(t *testing.T) {
+	// Given: A new workspace nobody configured
+	ctx := t.Context()
+	repo := repository.NewInMemoryTodoRepository()
+	workspaces := service.NewWorkspaceService(repo, repository.SharedWorkspaces(repo))
+	acme, err := workspaces.CreateWorkspace(ctx, model.WorkspaceInput{Slug: "acme", Name: "Acme"})
+	require.NoError(t, err)
+	auth := service.NewAuthService(repo, workspaces)
+	register := func(username, workspace string) error {
+		_, _, err := auth.Register(ctx, model.Credentials{Username: username, Password: "correct horse battery", Workspace: workspace})
+		return err
+	}
+
+	// When: Someone registers into it by its slug
+	err = register("mallory", "acme")
+
+	// Then: They are refused and no account is made
+	assert.ErrorIs(t, err, service.ErrRegistrationClosed)
+	_, err = repo.GetUserByUsername(ctx, "mallory")
+	assert.ErrorIs(t, err, repository.ErrUserNotFound)
+
+	// And: The default workspace takes registrations
+	assert.NoError(t, register("alice", ""))
+
+	// When: Its administrator opens registration in Acme and closes it in the default workspace
+	on, off := true, false
+	_, err = workspaces.UpdateSettings(ctx, acme.ID, model.WorkspaceSettingsPatch{Registration: &on})
+	require.NoError(t, err)
+	_, err = workspaces.UpdateSettings(ctx, model.DefaultWorkspaceID, model.WorkspaceSettingsPatch{Registration: &off})
+	require.NoError(t, err)
+
+	// Then: Registering goes the other way round
+	assert.NoError(t, register("bob", "acme"))
+	assert.ErrorIs(t, register("carol", ""), service.ErrRegistrationClosed)
+}
+
+func TestAuthService_RegisterInUnknownWorkspace(t *testing.T) {
+	ctx := t.Context()
+	svc := newAuthService(repository.NewInMemoryTodoRepository())
+
+	_, _, err := svc.Register(ctx, model.Credentials{Username: "alice", Password: "correct horse battery", Workspace: "globex"})
+
+	assert.ErrorIs(t, err, service.ErrUnknownWorkspace)
+}
+
+func TestSQLiteWorkspaceFiles(t *testing.T) {
+	// Given: A directory database and a data directory for workspace files
+	ctx := t.Context()
+	dataDir := filepath.Join(t.TempDir(), "workspaces")
+	dir, err := repository.NewSQLiteTodoRepository(filepath.Join(t.TempDir(), "todos.db"))
+	require.NoError(t, err)
+	defer dir.Close()
+	files, err := repository.NewSQLiteWorkspaceFiles(dir, dataDir)
+	require.NoError(t, err)
+	defer files.Close()
+	svc := service.NewWorkspaceService(dir, files)
+
+	// When: Creating a workspace and adding a todo to it
+	acme, err := svc.CreateWorkspace(ctx, model.WorkspaceInput{Slug: "acme", Name: "Acme"})
+	require.NoError(t, err)
+	storage, err := svc.Open(ctx, acme.ID)
+	require.NoError(t, err)
+	_, err = service.NewTodoService(dir).ForWorkspace(storage).CreateTodo(ctx, model.TodoInput{Text: "Plan the launch"})
+	require.NoError(t, err)
+
+	// Then: The workspace has a file of its own holding the todo and its inbox
+	assert.FileExists(t, files.Path(acme.ID))
+	todos, err := storage.GetAll(ctx, model.TodoFilter{})
+	require.NoError(t, err)
+	require.Len(t, todos, 1)
+	lists, err := storage.GetLists(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, lists, 1)
+	assert.Equal(t, lists[0].ID, todos[0].ListID)
+
+	// And: The directory database does not
+	todos, err = dir.ForWorkspace(acme.ID).GetAll(ctx, model.TodoFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, todos)
+
+	// And: Opening it again gives the same storage; the default workspace stays in the directory
+	again, err := svc.Open(ctx, acme.ID)
+	require.NoError(t, err)
+	assert.Same(t, storage, again)
+	defaultStorage, err := svc.Open(ctx, model.DefaultWorkspaceID)
+	require.NoError(t, err)
+	assert.Equal(t, model.DefaultWorkspaceID, defaultStorage.WorkspaceID())
+	_, err = svc.Open(ctx, missingWorkspaceID)
+	assert.ErrorIs(t, err, service.ErrWorkspaceNotFound)
+}
+
+// missingWorkspaceID is the ID of a workspace no test creates
+const missingWorkspaceID = 999
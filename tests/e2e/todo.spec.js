@@ -4,14 +4,28 @@ const { test, expect } = require('@playwright/test');
 // Test environment URL
 const BASE_URL = process.env.TEST_UI_URL || 'http://localhost:3001';
 const API_URL = process.env.TEST_API_URL || 'http://localhost:8081';
+// docker-compose.test.yml içindeki TEST_SECRET ile aynı olmalı
+const TEST_SECRET = process.env.TEST_SECRET || 'e2e-test-secret';
+const TEST_HEADERS = { 'X-Test-Secret': TEST_SECRET };
 
 // Test veritabanını temizleme fonksiyonu
 async function clearTestDatabase(page) {
-	await page.request.post(`${API_URL}/api/test/truncate`);
+	const response = await page.request.post(`${API_URL}/api/test/truncate`, { headers: TEST_HEADERS });
+	expect(response.status()).toBe(204);
 	console.log('🧪 Test veritabanı temizlendi.');
 	await page.waitForTimeout(2000);
 }
 
+// Her test kendi hesabıyla başlar; oturum çerezi sayfanın tarayıcı bağlamına kaydedilir
+async function signIn(page) {
+	const username = `e2e-${Date.now()}-${Math.floor(Math.random() * 1000)}`;
+	const response = await page.request.post(`${API_URL}/api/auth/register`, {
+		data: { username, password: 'correct horse battery' },
+	});
+	expect(response.status()).toBe(201);
+	return username;
+}
+
 // Sayfanın yüklenmesini bekle
 async function waitForPageLoad(page) {
 	await page.waitForLoadState('networkidle');
@@ -28,6 +42,7 @@ test.describe('Todo App CRUD Tests', () => {
 	});
 
 	test.beforeEach(async ({ page }) => {
+		await signIn(page);
 		await page.goto(BASE_URL, { waitUntil: 'load' });
 		await waitForPageLoad(page);
 		console.log('🧪 Yeni CRUD testi başlıyor...');
@@ -63,6 +78,7 @@ test.describe('Todo App UI Tests', () => {
 	});
 
 	test.beforeEach(async ({ page }) => {
+		await signIn(page);
 		await page.goto(BASE_URL);
 		console.log('🧪 Yeni UI testi başlıyor...');
 	});
@@ -103,16 +119,23 @@ test.describe('Todo App API Tests', () => {
 	});
 
 	test.beforeEach(async ({ page }) => {
+		await signIn(page);
 		await page.goto(BASE_URL);
 		console.log('🧪 Yeni API testi başlıyor...');
 	});
 
-	test('API integration works correctly', async ({ page, request }) => {
-		// Test direct API call
+	test('API integration works correctly', async ({ page }) => {
+		// Test direct API call with the session of the signed-in user
+		const request = page.request;
 		const response = await request.get(`${API_URL}/api/todos`);
 		expect(response.status()).toBe(200);
 
-		const todos = await response.json();
+		const listing = await response.json();
+		expect(Array.isArray(listing.data)).toBe(true);
+
+		// The legacy flag used by the React client returns a bare array
+		const legacyResponse = await request.get(`${API_URL}/api/todos?legacy=true`);
+		const todos = await legacyResponse.json();
 		expect(Array.isArray(todos)).toBe(true);
 	});
 
@@ -128,6 +151,13 @@ test.describe('Todo App API Tests', () => {
 		// await expect(page.locator('[data-testid="error-message"]')).toBeVisible();
 	});
 
+	test('API requires signing in', async ({ playwright }) => {
+		const anonymous = await playwright.request.newContext();
+		const response = await anonymous.get(`${API_URL}/api/todos`);
+		expect(response.status()).toBe(401);
+		await anonymous.dispose();
+	});
+
 	test('Performance: App loads quickly', async ({ page }) => {
 		const startTime = Date.now();
 		await page.goto(BASE_URL);
@@ -137,4 +167,70 @@ test.describe('Todo App API Tests', () => {
 		// Should load within 3 seconds
 		expect(loadTime).toBeLessThan(3000);
 	});
-}); 
\ No newline at end of file
+}); 
+// Test fixture ve saat testleri
+test.describe('Todo App Fixture Tests', () => {
+	test.beforeAll(async ({ browser }) => {
+		console.log('🚀 Fixture testleri başlıyor...');
+		const page = await browser.newPage();
+		await clearTestDatabase(page);
+		await page.close();
+	});
+
+	test.afterEach(async ({ page }) => {
+		await page.request.delete(`${API_URL}/api/test/clock`, { headers: TEST_HEADERS });
+	});
+
+	test('Test routes require the shared secret', async ({ page }) => {
+		const response = await page.request.post(`${API_URL}/api/test/truncate`);
+		expect(response.status()).toBe(403);
+	});
+
+	test('Seeded todos are shown to their owner', async ({ page }) => {
+		// Given: A seeded user with a todo in a list
+		const username = `fixture-${Date.now()}`;
+		const response = await page.request.post(`${API_URL}/api/test/seed`, {
+			headers: TEST_HEADERS,
+			data: {
+				users: [{ username, password: 'correct horse battery' }],
+				lists: [{ owner: username, name: 'Groceries' }],
+				todos: [{ owner: username, list: 'Groceries', text: 'ekmek al', priority: 'high' }],
+			},
+		});
+		expect(response.status()).toBe(201);
+		const seeded = await response.json();
+
+		// When: The user opens the app with the seeded session
+		await page.context().addCookies([{ name: 'session', value: seeded.users[0].session, url: API_URL }]);
+		await page.goto(BASE_URL);
+		await waitForPageLoad(page);
+
+		// Then: The seeded todo is listed
+		await expect(page.locator('[data-testid="todo-item"]')).toContainText('ekmek al');
+	});
+
+	test('Overdue todos follow the frozen clock', async ({ page }) => {
+		// Given: A todo due on 1 March and the clock frozen on 2 March
+		await signIn(page);
+		await page.request.post(`${API_URL}/api/todos`, { data: { text: 'fatura öde', due_at: '2026-03-01T09:00:00Z' } });
+		const frozen = await page.request.put(`${API_URL}/api/test/clock`, {
+			headers: TEST_HEADERS,
+			data: { now: '2026-03-02T09:00:00Z' },
+		});
+		expect(frozen.status()).toBe(200);
+
+		// Then: It is overdue
+		let listing = await (await page.request.get(`${API_URL}/api/todos?overdue=true`)).json();
+		expect(listing.data.map(todo => todo.text)).toEqual(['fatura öde']);
+
+		// When: The clock is moved back to 28 February
+		await page.request.put(`${API_URL}/api/test/clock`, {
+			headers: TEST_HEADERS,
+			data: { now: '2026-02-28T09:00:00Z' },
+		});
+
+		// Then: It is not overdue yet
+		listing = await (await page.request.get(`${API_URL}/api/todos?overdue=true`)).json();
+		expect(listing.data).toEqual([]);
+	});
+});
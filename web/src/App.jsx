@@ -1,7 +1,43 @@
+import { useState, useEffect } from 'react'
+import axios from 'axios'
 import TodoApp from './TodoApp'
+import LoginForm from './components/LoginForm'
 
 function App() {
-  return <TodoApp />
+	const [user, setUser] = useState(undefined) // undefined until the session is checked
+
+	// The session cookie is HTTP-only, so ask the server who is signed in
+	useEffect(() => {
+		axios.get('/api/auth/me')
+			.then(response => setUser(response.data))
+			.catch(() => setUser(null))
+	}, [])
+
+	const logout = async () => {
+		try {
+			await axios.post('/api/auth/logout')
+		} finally {
+			setUser(null)
+		}
+	}
+
+	if (user === undefined) return null
+
+	if (!user) {
+		return (
+			<div className="todo-app">
+				<header className="todo-header">
+					<h1 data-testid="app-title">📝 ToDo Listesi</h1>
+					<p>Görevlerinizi görmek için giriş yapın</p>
+				</header>
+				<main className="todo-main">
+					<LoginForm onSignedIn={setUser} />
+				</main>
+			</div>
+		)
+	}
+
+	return <TodoApp user={user} onLogout={logout} />
 }
 
 export default App
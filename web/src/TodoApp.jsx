@@ -4,7 +4,7 @@ import TodoForm from './components/TodoForm'
 import TodoList from './components/TodoList'
 import './TodoApp.css'
 
-function TodoApp() {
+function TodoApp({ user, onLogout }) {
 	const [todos, setTodos] = useState([])
 	const [loading, setLoading] = useState(false)
 
@@ -13,10 +13,30 @@ function TodoApp() {
 		fetchTodos()
 	}, [])
 
+	// Apply changes made by other clients as they happen
+	useEffect(() => {
+		if (typeof EventSource === 'undefined') return
+
+		const source = new EventSource('/api/todos/events')
+		const applyChange = (message) => {
+			const { type, todo_id, todo } = JSON.parse(message.data)
+			setTodos(prevTodos => {
+				const others = prevTodos.filter(t => t.id !== todo_id)
+				if (type === 'deleted') return others
+				if (type === 'created' && others.length === prevTodos.length) return [todo, ...prevTodos]
+				return prevTodos.map(t => (t.id === todo_id ? todo : t))
+			})
+		}
+		for (const type of ['created', 'updated', 'deleted']) {
+			source.addEventListener(type, applyChange)
+		}
+		return () => source.close()
+	}, [])
+
 	const fetchTodos = async () => {
 		try {
 			setLoading(true)
-			const response = await axios.get('/api/todos')
+			const response = await axios.get('/api/todos', { params: { legacy: true } })
 			setTodos(response.data)
 		} catch (error) {
 			console.error('Error fetching todos:', error)
@@ -44,6 +64,14 @@ function TodoApp() {
 			<header className="todo-header">
 				<h1 data-testid="app-title">📝 ToDo Listesi</h1>
 				<p>Günlük görevlerinizi organize edin</p>
+				{user && (
+					<p className="todo-user">
+						<span data-testid="current-user">{user.username}</span>
+						<button type="button" className="link-btn" onClick={onLogout} data-testid="logout-button">
+							Çıkış yap
+						</button>
+					</p>
+				)}
 			</header>
 
 			<main className="todo-main">
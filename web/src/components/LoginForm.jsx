@@ -0,0 +1,69 @@
+import { useState } from 'react'
+import axios from 'axios'
+
+function LoginForm({ onSignedIn }) {
+	const [mode, setMode] = useState('login') // or 'register'
+	const [username, setUsername] = useState('')
+	const [password, setPassword] = useState('')
+	const [error, setError] = useState('')
+	const [submitting, setSubmitting] = useState(false)
+
+	const handleSubmit = async (e) => {
+		e.preventDefault()
+		try {
+			setSubmitting(true)
+			setError('')
+			// The server answers with the user and sets an HTTP-only session cookie
+			const response = await axios.post(`/api/auth/${mode}`, { username, password })
+			onSignedIn(response.data)
+		} catch (err) {
+			setError(err.response?.data?.trim() || 'Bir hata oluştu')
+		} finally {
+			setSubmitting(false)
+		}
+	}
+
+	return (
+		<section className="todo-input-section">
+			<form onSubmit={handleSubmit} className="login-form" data-testid="login-form">
+				<input
+					type="text"
+					value={username}
+					onChange={(e) => setUsername(e.target.value)}
+					placeholder="Kullanıcı adı"
+					autoComplete="username"
+					data-testid="username-input"
+					aria-label="Username"
+				/>
+				<input
+					type="password"
+					value={password}
+					onChange={(e) => setPassword(e.target.value)}
+					placeholder="Parola"
+					autoComplete={mode === 'login' ? 'current-password' : 'new-password'}
+					data-testid="password-input"
+					aria-label="Password"
+				/>
+				{error && <p className="login-error" data-testid="login-error">{error}</p>}
+				<button
+					type="submit"
+					className="add-btn"
+					disabled={submitting || !username || !password}
+					data-testid="login-button"
+				>
+					{mode === 'login' ? 'Giriş yap' : 'Kayıt ol'}
+				</button>
+				<button
+					type="button"
+					className="link-btn"
+					onClick={() => setMode(mode === 'login' ? 'register' : 'login')}
+					data-testid="toggle-mode"
+				>
+					{mode === 'login' ? 'Hesabınız yok mu? Kayıt olun' : 'Hesabınız var mı? Giriş yapın'}
+				</button>
+			</form>
+		</section>
+	)
+}
+
+export default LoginForm
@@ -11,7 +11,7 @@ export default defineConfig({
   },
   server: {
     proxy: {
-      '/api': 'http://localhost:8080'
+      '/api': { target: 'http://localhost:8080', ws: true }
     }
   }
 })